@@ -19,9 +19,27 @@ type ChildWorkflowInput struct {
 	Inputs       map[string]interface{} `json:"inputs"`
 	Timeout      time.Duration          `json:"timeout"`
 	ParentID     string                 `json:"parent_id"`
+
+	// AllowFailure returns the result of a child that failed or timed
+	// out instead of failing the step, so the parent can branch on
+	// its status.
+	AllowFailure bool `json:"allow_failure"`
 }
 
 // ChildWorkflowActivity executes a registered child workflow synchronously.
+//
+// The result stored under Step.Store is a map with these keys:
+//
+//   - execution_id: the child's execution ID
+//   - status: the child's final ExecutionStatus, such as "completed"
+//   - success: true when status is "completed"
+//   - outputs: the child's outputs, an empty map when it has none
+//   - duration: the child's run time in seconds, as a float
+//   - duration_ms: the child's run time in whole milliseconds
+//   - error: the child's error message, present only when it failed
+//
+// A child that fails the step leaves no stored result unless
+// AllowFailure is set.
 type ChildWorkflowActivity struct {
 	executor workflow.ChildWorkflowExecutor
 }
@@ -57,15 +75,80 @@ func (c *ChildWorkflowActivity) Execute(ctx workflow.Context, params ChildWorkfl
 	}
 
 	result, err := c.executor.ExecuteSync(ctx, spec)
-	if err != nil {
+	if err != nil && (result == nil || !params.AllowFailure) {
 		return nil, fmt.Errorf("child workflow execution failed: %w", err)
 	}
+	return childResult(result, err), nil
+}
 
-	return map[string]any{
-		"outputs":      result.Outputs,
-		"status":       string(result.Status),
+// childResult builds the stored result documented on
+// ChildWorkflowActivity.
+func childResult(result *workflow.ChildWorkflowResult, err error) map[string]any {
+	outputs := result.Outputs
+	if outputs == nil {
+		outputs = map[string]any{}
+	}
+	m := map[string]any{
 		"execution_id": result.ExecutionID,
-		"duration":     result.Duration.Seconds(),
+		"status":       string(result.Status),
 		"success":      result.Status == workflow.ExecutionStatusCompleted,
+		"outputs":      outputs,
+		"duration":     result.Duration.Seconds(),
+		"duration_ms":  result.Duration.Milliseconds(),
+	}
+	if err != nil {
+		m["error"] = err.Error()
+	}
+	return m
+}
+
+// ChildWorkflowCancelInput defines the input parameters for the child
+// workflow cancel activity.
+type ChildWorkflowCancelInput struct {
+	ExecutionID  string `json:"execution_id"`
+	WorkflowName string `json:"workflow_name"`
+}
+
+// ChildWorkflowCancelActivity cancels an async child workflow started
+// with ChildWorkflowExecutor.ExecuteAsync.
+type ChildWorkflowCancelActivity struct {
+	executor workflow.ChildWorkflowExecutor
+}
+
+// NewChildWorkflowCancelActivity creates a new ChildWorkflowCancelActivity
+// that cancels async child workflows tracked by executor
+func NewChildWorkflowCancelActivity(executor workflow.ChildWorkflowExecutor) workflow.Activity {
+	return workflow.NewTypedActivity(&ChildWorkflowCancelActivity{
+		executor: executor,
+	})
+}
+
+// Name returns the activity name
+func (c *ChildWorkflowCancelActivity) Name() string {
+	return "workflow.child.cancel"
+}
+
+// Execute cancels the child workflow and waits for it to stop
+func (c *ChildWorkflowCancelActivity) Execute(ctx workflow.Context, params ChildWorkflowCancelInput) (map[string]any, error) {
+	if params.ExecutionID == "" {
+		return nil, fmt.Errorf("child workflow cancel activity requires 'execution_id' parameter")
+	}
+
+	handle := &workflow.ChildWorkflowHandle{
+		ExecutionID:  params.ExecutionID,
+		WorkflowName: params.WorkflowName,
+	}
+	if err := c.executor.Cancel(ctx, handle); err != nil {
+		return nil, fmt.Errorf("child workflow cancel failed: %w", err)
+	}
+
+	result, err := c.executor.GetResult(ctx, handle)
+	if result == nil {
+		return nil, fmt.Errorf("child workflow cancel failed: %w", err)
+	}
+	return map[string]any{
+		"execution_id": result.ExecutionID,
+		"status":       string(result.Status),
+		"canceled":     result.Status == workflow.ExecutionStatusCanceled,
 	}, nil
 }
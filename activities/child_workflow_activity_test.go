@@ -1,7 +1,10 @@
 package activities
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/deepnoodle-ai/workflow"
 	"github.com/deepnoodle-ai/workflow/internal/require"
@@ -69,3 +72,142 @@ func TestChildWorkflowActivity(t *testing.T) {
 		require.Contains(t, err.Error(), "child workflow execution failed")
 	})
 }
+
+func TestChildWorkflowCancelActivity(t *testing.T) {
+	wf, err := workflow.New(workflow.Options{
+		Name: "slow",
+		Steps: []*workflow.Step{
+			{Name: "wait", Activity: "wait"},
+		},
+	})
+	require.NoError(t, err)
+
+	reg := workflow.NewMemoryWorkflowRegistry()
+	reg.Register(wf)
+
+	started := make(chan struct{})
+	waitAct := workflow.ActivityFunc("wait", func(ctx workflow.Context, params map[string]any) (any, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	executor, err := workflow.NewDefaultChildWorkflowExecutor(workflow.ChildWorkflowExecutorOptions{
+		WorkflowRegistry: reg,
+		Activities:       []workflow.Activity{waitAct},
+	})
+	require.NoError(t, err)
+
+	activity := NewChildWorkflowCancelActivity(executor)
+	require.Equal(t, "workflow.child.cancel", activity.Name())
+
+	t.Run("cancels running child", func(t *testing.T) {
+		handle, err := executor.ExecuteAsync(newTestContext(), &workflow.ChildWorkflowSpec{WorkflowName: "slow"})
+		require.NoError(t, err)
+		<-started
+
+		result, err := activity.Execute(newTestContext(), map[string]any{"execution_id": handle.ExecutionID})
+		require.NoError(t, err)
+		m := result.(map[string]any)
+		require.Equal(t, true, m["canceled"])
+		require.Equal(t, "canceled", m["status"])
+		require.Equal(t, handle.ExecutionID, m["execution_id"])
+	})
+
+	t.Run("missing execution_id", func(t *testing.T) {
+		_, err := activity.Execute(newTestContext(), map[string]any{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "execution_id")
+	})
+
+	t.Run("unknown execution", func(t *testing.T) {
+		_, err := activity.Execute(newTestContext(), map[string]any{"execution_id": "missing"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+	})
+}
+
+func TestChildWorkflowResultInParentState(t *testing.T) {
+	children := workflow.NewMemoryWorkflowRegistry()
+	for name, activity := range map[string]string{"ok": "nap", "broken": "boom"} {
+		wf, err := workflow.New(workflow.Options{
+			Name:  name,
+			Steps: []*workflow.Step{{Name: "run", Activity: activity}},
+		})
+		require.NoError(t, err)
+		children.Register(wf)
+	}
+	executor, err := workflow.NewDefaultChildWorkflowExecutor(workflow.ChildWorkflowExecutorOptions{
+		WorkflowRegistry: children,
+		Activities: []workflow.Activity{
+			workflow.ActivityFunc("nap", func(ctx workflow.Context, params map[string]any) (any, error) {
+				time.Sleep(5 * time.Millisecond)
+				return nil, nil
+			}),
+			workflow.ActivityFunc("boom", func(ctx workflow.Context, params map[string]any) (any, error) {
+				return nil, errors.New("boom")
+			}),
+		},
+	})
+	require.NoError(t, err)
+
+	parent, err := workflow.New(workflow.Options{
+		Name:   "parent",
+		Inputs: []*workflow.Input{{Name: "child", Type: "string"}},
+		Steps: []*workflow.Step{
+			{
+				Name:     "call",
+				Activity: "workflow.child",
+				Parameters: map[string]any{
+					"workflow_name": "${inputs.child}",
+					"allow_failure": true,
+				},
+				Store: "child",
+				Next: []*workflow.Edge{
+					{Step: "done", Condition: `state.child.status == "completed"`},
+					{Step: "recover", Else: true},
+				},
+			},
+			{
+				Name:     "done",
+				Activity: "report",
+				Parameters: map[string]any{
+					"status":      "${state.child.status}",
+					"duration_ms": "${state.child.duration_ms}",
+					"duration":    "${state.child.duration}",
+				},
+			},
+			{
+				Name:       "recover",
+				Activity:   "report",
+				Parameters: map[string]any{"status": "${state.child.status}", "error": "${state.child.error}"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	run := func(child string) map[string]any {
+		var reported map[string]any
+		reg := workflow.NewActivityRegistry()
+		reg.MustRegister(NewChildWorkflowActivity(executor))
+		reg.MustRegister(workflow.ActivityFunc("report", func(ctx workflow.Context, params map[string]any) (any, error) {
+			reported = params
+			return nil, nil
+		}))
+		exec, err := workflow.NewExecution(parent, reg, workflow.WithInputs(map[string]any{"child": child}))
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, workflow.ExecutionStatusCompleted, result.Status)
+		return reported
+	}
+
+	reported := run("ok")
+	require.Equal(t, "completed", reported["status"])
+	require.GreaterOrEqual(t, reported["duration_ms"], int64(5))
+	require.GreaterOrEqual(t, reported["duration"], 0.005)
+
+	reported = run("broken")
+	require.Equal(t, "failed", reported["status"])
+	require.Contains(t, reported["error"], "boom")
+}
@@ -0,0 +1,222 @@
+package activities
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/deepnoodle-ai/workflow"
+)
+
+// DefaultMaxDecompressedBytes caps the output of gunzip and unzstd
+// unless WithMaxDecompressedBytes sets another limit.
+const DefaultMaxDecompressedBytes = 64 << 20
+
+// ZstdCodec compresses and decompresses zstd frames. The standard
+// library has no zstd implementation, so the zstd and unzstd operations
+// of the compress activity need one supplied with WithZstd. With
+// github.com/klauspost/compress/zstd:
+//
+//	type zstdCodec struct{}
+//
+//	func (zstdCodec) Compress(data []byte, level int) ([]byte, error) {
+//		opts := []zstd.EOption{}
+//		if level != 0 {
+//			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+//		}
+//		enc, err := zstd.NewWriter(nil, opts...)
+//		if err != nil {
+//			return nil, err
+//		}
+//		defer enc.Close()
+//		return enc.EncodeAll(data, nil), nil
+//	}
+//
+//	func (zstdCodec) Decompress(data []byte) ([]byte, error) {
+//		dec, err := zstd.NewReader(nil)
+//		if err != nil {
+//			return nil, err
+//		}
+//		defer dec.Close()
+//		return dec.DecodeAll(data, nil)
+//	}
+type ZstdCodec interface {
+	// Compress encodes data at level, where 0 selects the codec's
+	// default.
+	Compress(data []byte, level int) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// CompressOption configures the compress activity.
+type CompressOption func(*CompressActivity)
+
+// WithZstd enables the zstd and unzstd operations.
+func WithZstd(codec ZstdCodec) CompressOption {
+	return func(a *CompressActivity) {
+		a.zstd = codec
+	}
+}
+
+// WithMaxDecompressedBytes caps the size of a decompressed payload;
+// larger results fail the step. Values <= 0 are ignored.
+func WithMaxDecompressedBytes(n int) CompressOption {
+	return func(a *CompressActivity) {
+		if n > 0 {
+			a.maxDecompressed = n
+		}
+	}
+}
+
+// CompressInput defines the input parameters for the compress activity.
+type CompressInput struct {
+	Operation string `json:"operation"` // gzip, gunzip, zstd, or unzstd
+	Data      string `json:"data"`
+	Encoding  string `json:"encoding"` // "text" or "base64"; see CompressActivity
+	Level     *int   `json:"level"`    // compression level; unset uses the default
+}
+
+// CompressActivity compresses and decompresses payloads with gzip, or
+// with zstd when a codec is configured.
+//
+// Data is read according to Encoding, which defaults to "text" for
+// gzip and zstd and to "base64" for gunzip and unzstd, so the output of
+// one operation feeds the input of its inverse. Byte slices reach the
+// activity base64-encoded, so pass them with encoding "base64".
+//
+// The result is a map with "data" (the output, base64-encoded) and
+// "size" (its length in bytes). Decompression also sets "text" when the
+// output is valid UTF-8.
+type CompressActivity struct {
+	zstd            ZstdCodec
+	maxDecompressed int
+}
+
+// NewCompressActivity returns the compress activity, registered as
+// "compress".
+func NewCompressActivity(opts ...CompressOption) workflow.Activity {
+	a := &CompressActivity{maxDecompressed: DefaultMaxDecompressedBytes}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return workflow.NewTypedActivity(a)
+}
+
+func (a *CompressActivity) Name() string {
+	return "compress"
+}
+
+// ParamSchema declares the parameters of CompressInput.
+func (a *CompressActivity) ParamSchema() map[string]workflow.ParamSpec {
+	return map[string]workflow.ParamSpec{
+		"operation": {Type: workflow.InputTypeString, Required: true, Description: "gzip, gunzip, zstd, or unzstd"},
+		"data":      {Type: workflow.InputTypeString, Required: true, Description: "Payload to transform"},
+		"encoding":  {Type: workflow.InputTypeString, Description: "How data is encoded: text or base64"},
+		"level":     {Type: workflow.InputTypeInt, Description: "Compression level"},
+	}
+}
+
+func (a *CompressActivity) Execute(ctx workflow.Context, params CompressInput) (map[string]any, error) {
+	decompress := false
+	switch params.Operation {
+	case "gzip", "zstd":
+	case "gunzip", "unzstd":
+		decompress = true
+	default:
+		return nil, fmt.Errorf("unknown operation %q: expected gzip, gunzip, zstd, or unzstd", params.Operation)
+	}
+	if (params.Operation == "zstd" || params.Operation == "unzstd") && a.zstd == nil {
+		return nil, fmt.Errorf("operation %q requires a zstd codec; configure one with WithZstd", params.Operation)
+	}
+
+	encoding := params.Encoding
+	if encoding == "" {
+		encoding = "text"
+		if decompress {
+			encoding = "base64"
+		}
+	}
+	var input []byte
+	switch encoding {
+	case "text":
+		input = []byte(params.Data)
+	case "base64":
+		decoded, err := base64.StdEncoding.DecodeString(params.Data)
+		if err != nil {
+			return nil, fmt.Errorf("data is not valid base64: %w", err)
+		}
+		input = decoded
+	default:
+		return nil, fmt.Errorf("unknown encoding %q: expected text or base64", params.Encoding)
+	}
+	level := 0
+	if params.Level != nil {
+		level = *params.Level
+	}
+
+	var output []byte
+	var err error
+	switch params.Operation {
+	case "gzip":
+		if params.Level == nil {
+			level = gzip.DefaultCompression
+		}
+		output, err = gzipBytes(input, level)
+	case "gunzip":
+		output, err = gunzipBytes(input, a.maxDecompressed)
+	case "zstd":
+		output, err = a.zstd.Compress(input, level)
+	case "unzstd":
+		output, err = a.zstd.Decompress(input)
+		if err == nil && len(output) > a.maxDecompressed {
+			err = fmt.Errorf("decompressed data exceeds %d bytes", a.maxDecompressed)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", params.Operation, err)
+	}
+
+	result := map[string]any{
+		"data": base64.StdEncoding.EncodeToString(output),
+		"size": len(output),
+	}
+	if decompress && utf8.Valid(output) {
+		result["text"] = string(output)
+	}
+	return result, nil
+}
+
+func gzipBytes(data []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gunzipBytes decompresses data, failing once the output would exceed
+// limit bytes.
+func gunzipBytes(data []byte, limit int) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	output, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(output) > limit {
+		return nil, fmt.Errorf("decompressed data exceeds %d bytes", limit)
+	}
+	return output, nil
+}
@@ -0,0 +1,93 @@
+package activities
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+// reverseCodec stands in for a zstd implementation.
+type reverseCodec struct{ level int }
+
+func (c *reverseCodec) Compress(data []byte, level int) ([]byte, error) {
+	c.level = level
+	out := bytes.Clone(data)
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
+
+func (c *reverseCodec) Decompress(data []byte) ([]byte, error) {
+	return c.Compress(data, 0)
+}
+
+func TestCompressActivity(t *testing.T) {
+	codec := &reverseCodec{}
+	activity := NewCompressActivity(WithZstd(codec), WithMaxDecompressedBytes(1024))
+	require.Equal(t, "compress", activity.Name())
+	run := func(params map[string]any) (map[string]any, error) {
+		result, err := activity.Execute(newTestContext(), params)
+		if err != nil {
+			return nil, err
+		}
+		return result.(map[string]any), nil
+	}
+
+	text := strings.Repeat("compress me ", 50)
+	t.Run("gzip round trip", func(t *testing.T) {
+		compressed, err := run(map[string]any{"operation": "gzip", "data": text, "level": 9})
+		require.NoError(t, err)
+		require.True(t, compressed["size"].(int) < len(text))
+
+		restored, err := run(map[string]any{"operation": "gunzip", "data": compressed["data"]})
+		require.NoError(t, err)
+		require.Equal(t, text, restored["text"])
+		require.Equal(t, len(text), restored["size"])
+		require.Equal(t, base64.StdEncoding.EncodeToString([]byte(text)), restored["data"])
+	})
+
+	t.Run("zstd uses the configured codec", func(t *testing.T) {
+		compressed, err := run(map[string]any{"operation": "zstd", "data": "abc", "level": 3})
+		require.NoError(t, err)
+		require.Equal(t, 3, codec.level)
+		require.Equal(t, base64.StdEncoding.EncodeToString([]byte("cba")), compressed["data"])
+
+		restored, err := run(map[string]any{"operation": "unzstd", "data": compressed["data"]})
+		require.NoError(t, err)
+		require.Equal(t, "abc", restored["text"])
+	})
+
+	t.Run("base64 input and binary output", func(t *testing.T) {
+		binary := base64.StdEncoding.EncodeToString([]byte{0xff, 0xfe, 0x00})
+		compressed, err := run(map[string]any{"operation": "gzip", "data": binary, "encoding": "base64"})
+		require.NoError(t, err)
+		restored, err := run(map[string]any{"operation": "gunzip", "data": compressed["data"]})
+		require.NoError(t, err)
+		require.Equal(t, binary, restored["data"])
+		_, hasText := restored["text"]
+		require.False(t, hasText)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := run(map[string]any{"operation": "brotli", "data": "x"})
+		require.Error(t, err)
+		_, err = run(map[string]any{"operation": "gunzip", "data": "not base64!"})
+		require.Error(t, err)
+		_, err = run(map[string]any{"operation": "gzip", "data": "x", "level": 42})
+		require.Error(t, err)
+
+		bomb, err := run(map[string]any{"operation": "gzip", "data": strings.Repeat("a", 4096)})
+		require.NoError(t, err)
+		_, err = run(map[string]any{"operation": "gunzip", "data": bomb["data"]})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "exceeds 1024 bytes")
+
+		_, err = NewCompressActivity().Execute(newTestContext(), map[string]any{"operation": "zstd", "data": "x"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "WithZstd")
+	})
+}
@@ -5,18 +5,22 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/deepnoodle-ai/workflow"
 )
 
 // FileInput defines the input parameters for the file activity
 type FileInput struct {
-	Operation   string `json:"operation"`   // read, write, append, delete, exists, mkdir, list
+	Operation   string `json:"operation"`   // read, write, append, delete, exists, mkdir, list, stat
 	Path        string `json:"path"`        // file or directory path
 	Content     string `json:"content"`     // content to write (for write/append operations)
 	Permissions string `json:"permissions"` // file permissions (e.g., "0644", "0755")
 	CreateDirs  bool   `json:"create_dirs"` // create parent directories if they don't exist
+	Glob        string `json:"glob"`        // only list entries whose name matches this pattern (for list)
+	Recursive   bool   `json:"recursive"`   // list subdirectories too (for list)
 }
 
 // FileActivity can be used to perform file operations
@@ -38,7 +42,18 @@ func (a *FileActivity) Execute(ctx workflow.Context, params FileInput) (any, err
 		params.Operation = "read"
 	}
 
-	switch strings.ToLower(params.Operation) {
+	operation := strings.ToLower(params.Operation)
+	if workflow.IsDryRun(ctx) && isMutatingFileOperation(operation) {
+		if logger := ctx.Logger(); logger != nil {
+			logger.Info("dry run: skipping file operation",
+				"operation", operation,
+				"path", params.Path,
+				"bytes", len(params.Content))
+		}
+		return true, nil
+	}
+
+	switch operation {
 	case "read":
 		content, err := os.ReadFile(params.Path)
 		if err != nil {
@@ -108,25 +123,84 @@ func (a *FileActivity) Execute(ctx workflow.Context, params FileInput) (any, err
 		return true, nil
 
 	case "list":
-		entries, err := os.ReadDir(params.Path)
+		return listFiles(params.Path, params.Glob, params.Recursive)
+
+	case "stat":
+		info, err := os.Stat(params.Path)
 		if err != nil {
 			return nil, err
 		}
-		files := make([]string, len(entries))
-		for i, entry := range entries {
-			if entry.IsDir() {
-				files[i] = entry.Name() + "/"
-			} else {
-				files[i] = entry.Name()
-			}
-		}
-		return files, nil
+		return map[string]any{
+			"name":     info.Name(),
+			"size":     info.Size(),
+			"mod_time": info.ModTime().UTC().Format(time.RFC3339Nano),
+			"is_dir":   info.IsDir(),
+			"mode":     info.Mode().String(),
+		}, nil
 
 	default:
 		return nil, fmt.Errorf("unsupported operation: %s", params.Operation)
 	}
 }
 
+// listFiles returns the entries under dir as sorted paths relative to
+// dir, with directories marked by a trailing slash. A non-empty glob
+// keeps only entries whose base name matches it, and recursive descends
+// into subdirectories.
+func listFiles(dir, glob string, recursive bool) ([]string, error) {
+	if glob != "" {
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", glob, err)
+		}
+	}
+	files := []string{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if glob != "" {
+			if ok, _ := filepath.Match(glob, entry.Name()); !ok {
+				if entry.IsDir() && !recursive {
+					return fs.SkipDir
+				}
+				return nil
+			}
+		}
+		rel = filepath.ToSlash(rel)
+		if entry.IsDir() {
+			files = append(files, rel+"/")
+			if !recursive {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// isMutatingFileOperation reports whether an operation changes the
+// filesystem and must be skipped in a dry run.
+func isMutatingFileOperation(operation string) bool {
+	switch operation {
+	case "write", "append", "delete", "mkdir":
+		return true
+	}
+	return false
+}
+
 // parsePermissions converts a string permission to fs.FileMode
 func parsePermissions(perm string) (fs.FileMode, error) {
 	// Handle octal permissions like "0644", "0755"
@@ -1,10 +1,12 @@
 package contrib
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/deepnoodle-ai/workflow"
 	"github.com/deepnoodle-ai/workflow/internal/require"
 )
 
@@ -151,6 +153,58 @@ func TestFileActivity(t *testing.T) {
 		require.Contains(t, files, "subdir/")
 	})
 
+	t.Run("list with glob and recursive", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "b.csv"), []byte("b"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "a.csv"), []byte("a"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("n"), 0644))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "2024", "q1"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "2024", "c.csv"), []byte("c"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "2024", "q1", "d.csv"), []byte("d"), 0644))
+		ctx := newTestContext()
+
+		result, err := activity.Execute(ctx, map[string]any{"operation": "list", "path": dir})
+		require.NoError(t, err)
+		require.Equal(t, []string{"2024/", "a.csv", "b.csv", "notes.txt"}, result)
+
+		result, err = activity.Execute(ctx, map[string]any{"operation": "list", "path": dir, "glob": "*.csv"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"a.csv", "b.csv"}, result)
+
+		result, err = activity.Execute(ctx, map[string]any{"operation": "list", "path": dir, "glob": "*.csv", "recursive": true})
+		require.NoError(t, err)
+		require.Equal(t, []string{"2024/c.csv", "2024/q1/d.csv", "a.csv", "b.csv"}, result)
+
+		result, err = activity.Execute(ctx, map[string]any{"operation": "list", "path": dir, "recursive": true})
+		require.NoError(t, err)
+		require.Equal(t, []string{"2024/", "2024/c.csv", "2024/q1/", "2024/q1/d.csv", "a.csv", "b.csv", "notes.txt"}, result)
+
+		_, err = activity.Execute(ctx, map[string]any{"operation": "list", "path": dir, "glob": "["})
+		require.Error(t, err)
+	})
+
+	t.Run("stat", func(t *testing.T) {
+		dir := t.TempDir()
+		fp := filepath.Join(dir, "data.bin")
+		require.NoError(t, os.WriteFile(fp, []byte("12345"), 0644))
+		ctx := newTestContext()
+
+		result, err := activity.Execute(ctx, map[string]any{"operation": "stat", "path": fp})
+		require.NoError(t, err)
+		info := result.(map[string]any)
+		require.Equal(t, "data.bin", info["name"])
+		require.Equal(t, int64(5), info["size"])
+		require.Equal(t, false, info["is_dir"])
+		require.NotEmpty(t, info["mod_time"])
+
+		result, err = activity.Execute(ctx, map[string]any{"operation": "stat", "path": dir})
+		require.NoError(t, err)
+		require.Equal(t, true, result.(map[string]any)["is_dir"])
+
+		_, err = activity.Execute(ctx, map[string]any{"operation": "stat", "path": filepath.Join(dir, "missing")})
+		require.Error(t, err)
+	})
+
 	t.Run("unsupported operation", func(t *testing.T) {
 		ctx := newTestContext()
 		_, err := activity.Execute(ctx, map[string]any{"operation": "unknown", "path": "/tmp/whatever"})
@@ -164,3 +218,31 @@ func TestFileActivity(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestFileActivityDryRun(t *testing.T) {
+	activity := NewFileActivity()
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.txt")
+	require.NoError(t, os.WriteFile(existing, []byte("keep"), 0644))
+
+	ctx := workflow.NewContext(context.Background(), workflow.ExecutionContextOptions{
+		BranchLocalState: workflow.NewBranchLocalState(map[string]any{}, map[string]any{}),
+		DryRun:           true,
+	})
+
+	result, err := activity.Execute(ctx, map[string]any{
+		"operation": "write", "path": filepath.Join(dir, "new.txt"), "content": "data",
+	})
+	require.NoError(t, err)
+	require.Equal(t, true, result)
+	_, err = os.Stat(filepath.Join(dir, "new.txt"))
+	require.True(t, os.IsNotExist(err))
+
+	_, err = activity.Execute(ctx, map[string]any{"operation": "delete", "path": existing})
+	require.NoError(t, err)
+
+	// Reads are not side-effecting and run normally.
+	result, err = activity.Execute(ctx, map[string]any{"operation": "read", "path": existing})
+	require.NoError(t, err)
+	require.Equal(t, "keep", result)
+}
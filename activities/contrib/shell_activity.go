@@ -1,6 +1,7 @@
 package contrib
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
@@ -12,16 +13,27 @@ import (
 	"github.com/deepnoodle-ai/workflow"
 )
 
-// ShellInput defines the input parameters for the shell activity
+// ShellInput defines the input parameters for the shell activity.
+// Cwd and Env are short forms of WorkingDir and Environment; Cwd wins
+// over WorkingDir, and Env entries override Environment entries with
+// the same name.
 type ShellInput struct {
-	Command     string            `json:"command"`
-	Args        []string          `json:"args"`
-	WorkingDir  string            `json:"working_dir"`
-	Environment map[string]string `json:"environment"`
-	Timeout     time.Duration     `json:"timeout"` // 0 means no timeout
+	Command      string            `json:"command"`
+	Args         []string          `json:"args"`
+	WorkingDir   string            `json:"working_dir"`
+	Cwd          string            `json:"cwd"`
+	Environment  map[string]string `json:"environment"`
+	Env          map[string]string `json:"env"`
+	Stdin        string            `json:"stdin"`         // written to the process's standard input
+	Timeout      time.Duration     `json:"timeout"`       // 0 means no timeout
+	AllowFailure bool              `json:"allow_failure"` // return a non-zero exit instead of failing
 }
 
-// ShellActivity can be used to execute shell commands
+// ShellActivity can be used to execute shell commands. The result holds
+// the command's trimmed "stdout" and "stderr" and its "exit_code". A
+// non-zero exit fails the step with ErrorTypeActivityFailed, whose cause
+// includes stderr and whose details hold the full result, unless
+// allow_failure is set.
 type ShellActivity struct{}
 
 func NewShellActivity() workflow.Activity {
@@ -37,6 +49,23 @@ func (a *ShellActivity) Execute(ctx workflow.Context, params ShellInput) (map[st
 		return nil, fmt.Errorf("command cannot be empty")
 	}
 
+	// Shell commands are opaque, so a dry run never executes them.
+	if workflow.IsDryRun(ctx) {
+		if logger := ctx.Logger(); logger != nil {
+			logger.Info("dry run: skipping shell command",
+				"command", params.Command,
+				"args", params.Args,
+				"working_dir", params.dir())
+		}
+		return map[string]any{
+			"stdout":    "",
+			"stderr":    "",
+			"exit_code": 0,
+			"success":   true,
+			"dry_run":   true,
+		}, nil
+	}
+
 	// Create command with context for timeout support
 	var cmd *exec.Cmd
 	if params.Timeout > 0 {
@@ -48,26 +77,34 @@ func (a *ShellActivity) Execute(ctx workflow.Context, params ShellInput) (map[st
 	}
 
 	// Set working directory if specified
-	if params.WorkingDir != "" {
-		cmd.Dir = params.WorkingDir
-	}
+	cmd.Dir = params.dir()
 
-	// Set environment variables
-	if len(params.Environment) > 0 {
+	// Set environment variables. Later entries win, so Env overrides
+	// Environment, which overrides the inherited environment.
+	if len(params.Environment) > 0 || len(params.Env) > 0 {
 		cmd.Env = os.Environ()
 		for key, value := range params.Environment {
 			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
 		}
+		for key, value := range params.Env {
+			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
+		}
 	}
 
-	// Execute command and capture output
-	stdout, err := cmd.Output()
-	var stderr []byte
-	var exitCode int
+	if params.Stdin != "" {
+		cmd.Stdin = strings.NewReader(params.Stdin)
+	}
 
-	if err != nil {
+	// Capture stdout and stderr separately. cmd.Output only retains
+	// stderr when the command fails, which loses warnings printed by
+	// commands that succeed.
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	var exitCode int
+	if err := cmd.Run(); err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
-			stderr = exitError.Stderr
 			if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
 				exitCode = status.ExitStatus()
 			}
@@ -76,10 +113,40 @@ func (a *ShellActivity) Execute(ctx workflow.Context, params ShellInput) (map[st
 		}
 	}
 
-	return map[string]any{
-		"stdout":    strings.TrimSpace(string(stdout)),
-		"stderr":    strings.TrimSpace(string(stderr)),
+	stdoutStr := strings.TrimSpace(stdout.String())
+	stderrStr := strings.TrimSpace(stderr.String())
+	if logger := ctx.Logger(); logger != nil {
+		logger.Debug("shell command finished",
+			"command", params.Command,
+			"exit_code", exitCode,
+			"stdout", stdoutStr,
+			"stderr", stderrStr)
+	}
+
+	result := map[string]any{
+		"stdout":    stdoutStr,
+		"stderr":    stderrStr,
 		"exit_code": exitCode,
 		"success":   exitCode == 0,
-	}, nil
+	}
+	if exitCode != 0 && !params.AllowFailure {
+		cause := fmt.Sprintf("command %q exited with code %d", params.Command, exitCode)
+		if stderrStr != "" {
+			cause += ": " + stderrStr
+		}
+		return nil, &workflow.WorkflowError{
+			Type:    workflow.ErrorTypeActivityFailed,
+			Cause:   cause,
+			Details: result,
+		}
+	}
+	return result, nil
+}
+
+// dir returns the working directory, preferring Cwd.
+func (p ShellInput) dir() string {
+	if p.Cwd != "" {
+		return p.Cwd
+	}
+	return p.WorkingDir
 }
@@ -1,10 +1,18 @@
 package contrib
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/deepnoodle-ai/workflow"
 	"github.com/deepnoodle-ai/workflow/internal/require"
 )
 
@@ -31,13 +39,76 @@ func TestShellActivity(t *testing.T) {
 
 	t.Run("non-zero exit code", func(t *testing.T) {
 		ctx := newTestContext()
-		result, err := activity.Execute(ctx, map[string]any{"command": "sh", "args": []string{"-c", "exit 1"}})
+		_, err := activity.Execute(ctx, map[string]any{"command": "sh", "args": []string{"-c", "exit 1"}})
+		require.Error(t, err)
+		var wfErr *workflow.WorkflowError
+		require.True(t, errors.As(err, &wfErr))
+		require.Equal(t, workflow.ErrorTypeActivityFailed, wfErr.Type)
+		require.Equal(t, `command "sh" exited with code 1`, wfErr.Cause)
+		require.Equal(t, 1, wfErr.Details.(map[string]any)["exit_code"])
+	})
+
+	t.Run("allow failure", func(t *testing.T) {
+		ctx := newTestContext()
+		result, err := activity.Execute(ctx, map[string]any{
+			"command": "sh", "args": []string{"-c", "exit 1"}, "allow_failure": true,
+		})
 		require.NoError(t, err)
 		m := result.(map[string]any)
 		require.Equal(t, 1, m["exit_code"])
 		require.Equal(t, false, m["success"])
 	})
 
+	t.Run("stdout and stderr are captured separately", func(t *testing.T) {
+		var logs bytes.Buffer
+		ctx := workflow.NewContext(context.Background(), workflow.ExecutionContextOptions{
+			BranchLocalState: workflow.NewBranchLocalState(map[string]any{}, map[string]any{}),
+			Logger:           slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		})
+		result, err := activity.Execute(ctx, map[string]any{
+			"command": "sh", "args": []string{"-c", "echo to-out; echo to-err >&2"},
+		})
+		require.NoError(t, err)
+		m := result.(map[string]any)
+		require.Equal(t, "to-out", m["stdout"])
+		require.Equal(t, "to-err", m["stderr"])
+		require.Equal(t, 0, m["exit_code"])
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+		require.Equal(t, "to-out", entry["stdout"])
+		require.Equal(t, "to-err", entry["stderr"])
+	})
+
+	t.Run("stderr on failure", func(t *testing.T) {
+		ctx := newTestContext()
+		_, err := activity.Execute(ctx, map[string]any{
+			"command": "sh", "args": []string{"-c", "echo partial; echo bad input >&2; exit 2"},
+		})
+		require.Error(t, err)
+		var wfErr *workflow.WorkflowError
+		require.True(t, errors.As(err, &wfErr))
+		require.Equal(t, `command "sh" exited with code 2: bad input`, wfErr.Cause)
+		m := wfErr.Details.(map[string]any)
+		require.Equal(t, "partial", m["stdout"])
+		require.Equal(t, "bad input", m["stderr"])
+		require.Equal(t, 2, m["exit_code"])
+	})
+
+	t.Run("dry run does not execute", func(t *testing.T) {
+		marker := filepath.Join(t.TempDir(), "ran")
+		ctx := workflow.NewContext(context.Background(), workflow.ExecutionContextOptions{
+			BranchLocalState: workflow.NewBranchLocalState(map[string]any{}, map[string]any{}),
+			DryRun:           true,
+		})
+		result, err := activity.Execute(ctx, map[string]any{"command": "touch", "args": []string{marker}})
+		require.NoError(t, err)
+		m := result.(map[string]any)
+		require.Equal(t, true, m["dry_run"])
+		_, err = os.Stat(marker)
+		require.True(t, os.IsNotExist(err))
+	})
+
 	t.Run("working directory", func(t *testing.T) {
 		dir := t.TempDir()
 		ctx := newTestContext()
@@ -58,6 +129,26 @@ func TestShellActivity(t *testing.T) {
 		require.Equal(t, "test_value", m["stdout"])
 	})
 
+	t.Run("cwd, env, and stdin", func(t *testing.T) {
+		dir := t.TempDir()
+		ctx := newTestContext()
+		result, err := activity.Execute(ctx, map[string]any{
+			"command":     "sh",
+			"args":        []string{"-c", "pwd; echo $GREETING $NAME; tr a-z A-Z"},
+			"cwd":         dir,
+			"working_dir": "/",
+			"environment": map[string]string{"GREETING": "hi", "NAME": "old"},
+			"env":         map[string]string{"NAME": "shell"},
+			"stdin":       "piped in",
+		})
+		require.NoError(t, err)
+		lines := strings.Split(result.(map[string]any)["stdout"].(string), "\n")
+		require.Len(t, lines, 3)
+		require.Contains(t, lines[0], filepath.Base(dir))
+		require.Equal(t, "hi shell", lines[1])
+		require.Equal(t, "PIPED IN", lines[2])
+	})
+
 	t.Run("with timeout", func(t *testing.T) {
 		ctx := newTestContext()
 		result, err := activity.Execute(ctx, map[string]any{"command": "echo", "args": []string{"fast"}, "timeout": 5 * time.Second})
@@ -0,0 +1,309 @@
+package activities
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/deepnoodle-ai/workflow"
+)
+
+// DefaultDiffContext is the number of unchanged lines shown around each
+// change in a text diff when the input sets no context.
+const DefaultDiffContext = 3
+
+// DiffInput defines the input parameters for the diff activity
+type DiffInput struct {
+	Old      any    `json:"old"`
+	New      any    `json:"new"`
+	Mode     string `json:"mode"`      // "text", "json", or empty to pick by type
+	Context  *int   `json:"context"`   // unchanged lines around a text change
+	OldLabel string `json:"old_label"` // header of the old side; default "old"
+	NewLabel string `json:"new_label"` // header of the new side; default "new"
+}
+
+// DiffActivity compares two values.
+//
+// In text mode, the values are strings and the result holds a unified
+// diff under "diff". In JSON mode, the values are compared structurally
+// and the result holds "added", "removed", and "changed" maps keyed by
+// the JSON Pointer of each difference; a changed entry is a map with
+// "old" and "new". Strings are parsed as JSON in this mode, so two
+// documents that differ only in formatting are equal.
+//
+// Without a mode, two strings are diffed as text and anything else as
+// JSON. Either way the result has "has_changes" (bool), for use in a
+// following edge condition.
+type DiffActivity struct{}
+
+func NewDiffActivity() workflow.Activity {
+	return workflow.NewTypedActivity(&DiffActivity{})
+}
+
+func (a *DiffActivity) Name() string {
+	return "diff"
+}
+
+// ParamSchema declares the parameters of DiffInput.
+func (a *DiffActivity) ParamSchema() map[string]workflow.ParamSpec {
+	return map[string]workflow.ParamSpec{
+		"old":       {Description: "Value before the change"},
+		"new":       {Description: "Value after the change"},
+		"mode":      {Type: workflow.InputTypeString, Description: "\"text\" or \"json\"; picked by type when empty"},
+		"context":   {Type: workflow.InputTypeInt, Description: "Unchanged lines around each text change"},
+		"old_label": {Type: workflow.InputTypeString, Description: "Header of the old side of a text diff"},
+		"new_label": {Type: workflow.InputTypeString, Description: "Header of the new side of a text diff"},
+	}
+}
+
+func (a *DiffActivity) Execute(ctx workflow.Context, params DiffInput) (map[string]any, error) {
+	mode := params.Mode
+	if mode == "" {
+		_, oldIsText := params.Old.(string)
+		_, newIsText := params.New.(string)
+		mode = "json"
+		if oldIsText && newIsText {
+			mode = "text"
+		}
+	}
+	switch mode {
+	case "text":
+		return diffText(params)
+	case "json":
+		return diffJSON(params)
+	}
+	return nil, fmt.Errorf("invalid mode %q: expected \"text\" or \"json\"", params.Mode)
+}
+
+func diffText(params DiffInput) (map[string]any, error) {
+	oldText, ok := params.Old.(string)
+	if !ok && params.Old != nil {
+		return nil, fmt.Errorf("old must be a string in text mode, got %T", params.Old)
+	}
+	newText, ok := params.New.(string)
+	if !ok && params.New != nil {
+		return nil, fmt.Errorf("new must be a string in text mode, got %T", params.New)
+	}
+	context := DefaultDiffContext
+	if params.Context != nil {
+		if *params.Context < 0 {
+			return nil, fmt.Errorf("context cannot be negative, got %d", *params.Context)
+		}
+		context = *params.Context
+	}
+	oldLabel, newLabel := params.OldLabel, params.NewLabel
+	if oldLabel == "" {
+		oldLabel = "old"
+	}
+	if newLabel == "" {
+		newLabel = "new"
+	}
+	diff := unifiedDiff(splitLines(oldText), splitLines(newText), context, oldLabel, newLabel)
+	return map[string]any{
+		"has_changes": diff != "",
+		"diff":        diff,
+	}, nil
+}
+
+func diffJSON(params DiffInput) (map[string]any, error) {
+	oldValue, err := parseDiffValue(params.Old)
+	if err != nil {
+		return nil, fmt.Errorf("invalid old value: %w", err)
+	}
+	newValue, err := parseDiffValue(params.New)
+	if err != nil {
+		return nil, fmt.Errorf("invalid new value: %w", err)
+	}
+	d := &jsonDiff{
+		added:   map[string]any{},
+		removed: map[string]any{},
+		changed: map[string]any{},
+	}
+	d.compare(oldValue, newValue, "")
+	return map[string]any{
+		"has_changes": len(d.added)+len(d.removed)+len(d.changed) > 0,
+		"added":       d.added,
+		"removed":     d.removed,
+		"changed":     d.changed,
+	}, nil
+}
+
+// parseDiffValue decodes a JSON string and normalizes anything else to
+// the types encoding/json produces, so values compare by content.
+func parseDiffValue(v any) (any, error) {
+	if s, ok := v.(string); ok {
+		var out any
+		if err := json.Unmarshal([]byte(s), &out); err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+	return toJSONValue(v)
+}
+
+type jsonDiff struct {
+	added, removed, changed map[string]any
+}
+
+func (d *jsonDiff) compare(oldValue, newValue any, path string) {
+	switch o := oldValue.(type) {
+	case map[string]any:
+		if n, ok := newValue.(map[string]any); ok {
+			for k, ov := range o {
+				childPath := path + "/" + escapeJSONPointer(k)
+				if nv, ok := n[k]; ok {
+					d.compare(ov, nv, childPath)
+				} else {
+					d.removed[childPath] = ov
+				}
+			}
+			for k, nv := range n {
+				if _, ok := o[k]; !ok {
+					d.added[path+"/"+escapeJSONPointer(k)] = nv
+				}
+			}
+			return
+		}
+	case []any:
+		if n, ok := newValue.([]any); ok {
+			for i := 0; i < len(o) || i < len(n); i++ {
+				childPath := path + "/" + strconv.Itoa(i)
+				switch {
+				case i >= len(n):
+					d.removed[childPath] = o[i]
+				case i >= len(o):
+					d.added[childPath] = n[i]
+				default:
+					d.compare(o[i], n[i], childPath)
+				}
+			}
+			return
+		}
+	}
+	if !reflect.DeepEqual(oldValue, newValue) {
+		if path == "" {
+			path = "/"
+		}
+		d.changed[path] = map[string]any{"old": oldValue, "new": newValue}
+	}
+}
+
+// splitLines splits text into lines without their newlines. A final
+// newline does not start another line.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffOp is one line of an edit script: ' ' kept, '-' removed, '+'
+// added.
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// diffLines returns the edit script turning a into b, computed from a
+// longest common subsequence of lines.
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	ops := make([]diffOp, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		default:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		}
+	}
+	return ops
+}
+
+// unifiedDiff renders the changes between a and b in unified format,
+// with context unchanged lines around each change. It returns "" when
+// the lines are equal.
+func unifiedDiff(a, b []string, context int, oldLabel, newLabel string) string {
+	ops := diffLines(a, b)
+
+	// Group the changed ops into hunks, merging changes whose context
+	// would overlap.
+	type hunk struct{ start, end int } // ops[start:end]
+	var hunks []hunk
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		start, end := max(i-context, 0), min(i+1+context, len(ops))
+		if n := len(hunks); n > 0 && start <= hunks[n-1].end {
+			hunks[n-1].end = end
+		} else {
+			hunks = append(hunks, hunk{start, end})
+		}
+	}
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldLabel, newLabel)
+	oldLine, newLine, pos := 0, 0, 0
+	for _, h := range hunks {
+		for ; pos < h.start; pos++ {
+			oldLine++
+			newLine++
+		}
+		oldCount, newCount := 0, 0
+		for _, op := range ops[h.start:h.end] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for _, op := range ops[h.start:h.end] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.text)
+			sb.WriteByte('\n')
+		}
+		oldLine += oldCount
+		newLine += newCount
+		pos = h.end
+	}
+	return sb.String()
+}
+
+// hunkRange formats the line range of one side of a hunk, where before
+// is the number of lines preceding it.
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if count == 1 {
+		return strconv.Itoa(before + 1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
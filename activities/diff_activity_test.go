@@ -0,0 +1,84 @@
+package activities
+
+import (
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestDiffActivityText(t *testing.T) {
+	activity := NewDiffActivity()
+	require.Equal(t, "diff", activity.Name())
+
+	oldText := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	newText := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	result, err := activity.Execute(newTestContext(), map[string]any{
+		"old":       oldText,
+		"new":       newText,
+		"context":   1,
+		"old_label": "app.conf",
+		"new_label": "app.conf (proposed)",
+	})
+	require.NoError(t, err)
+	out := result.(map[string]any)
+	require.Equal(t, true, out["has_changes"])
+	require.Equal(t, "--- app.conf\n+++ app.conf (proposed)\n"+
+		"@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"+
+		"@@ -10 +10,2 @@\n j\n+k\n", out["diff"])
+
+	result, err = activity.Execute(newTestContext(), map[string]any{"old": oldText, "new": oldText})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"has_changes": false, "diff": ""}, result)
+
+	result, err = activity.Execute(newTestContext(), map[string]any{"old": "", "new": "x\n"})
+	require.NoError(t, err)
+	require.Equal(t, "--- old\n+++ new\n@@ -0,0 +1 @@\n+x\n", result.(map[string]any)["diff"])
+}
+
+func TestDiffActivityJSON(t *testing.T) {
+	activity := NewDiffActivity()
+
+	result, err := activity.Execute(newTestContext(), map[string]any{
+		"old": map[string]any{
+			"replicas": 2,
+			"image":    "api:1.4",
+			"env":      map[string]any{"LOG": "info", "a/b": 1},
+			"ports":    []any{80, 443},
+		},
+		"new": map[string]any{
+			"replicas": 3,
+			"image":    "api:1.4",
+			"env":      map[string]any{"LOG": "info", "TRACE": true},
+			"ports":    []any{80},
+		},
+	})
+	require.NoError(t, err)
+	out := result.(map[string]any)
+	require.Equal(t, true, out["has_changes"])
+	require.Equal(t, map[string]any{"/env/TRACE": true}, out["added"])
+	require.Equal(t, map[string]any{"/env/a~1b": float64(1), "/ports/1": float64(443)}, out["removed"])
+	require.Equal(t, map[string]any{
+		"/replicas": map[string]any{"old": float64(2), "new": float64(3)},
+	}, out["changed"])
+
+	// JSON strings are compared by content, not formatting.
+	result, err = activity.Execute(newTestContext(), map[string]any{
+		"old":  `{"a": 1, "b": [1, 2]}`,
+		"new":  `{"b":[1,2],"a":1}`,
+		"mode": "json",
+	})
+	require.NoError(t, err)
+	require.Equal(t, false, result.(map[string]any)["has_changes"])
+
+	result, err = activity.Execute(newTestContext(), map[string]any{"old": 1, "new": "x"})
+	require.Error(t, err)
+
+	result, err = activity.Execute(newTestContext(), map[string]any{"old": 1, "new": 2})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"/": map[string]any{"old": float64(1), "new": float64(2)},
+	}, result.(map[string]any)["changed"])
+
+	_, err = activity.Execute(newTestContext(), map[string]any{"old": "a", "new": "b", "mode": "xml"})
+	require.Error(t, err)
+}
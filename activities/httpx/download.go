@@ -0,0 +1,143 @@
+package httpx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/deepnoodle-ai/workflow"
+)
+
+// partSuffix names the file a download is written to until it
+// completes. Its size is the offset a retry resumes from.
+const partSuffix = ".part"
+
+// download streams a GET response for req into params.DownloadTo. The
+// bytes land in DownloadTo+".part" first; when that file already holds
+// bytes from an interrupted attempt, the request asks for the rest with
+// a Range header and appends to it. A server that ignores the range
+// restarts the file from the beginning. The part file is renamed to
+// DownloadTo once the body has been read in full.
+//
+// An interrupted body keeps what was written and fails with
+// ErrorTypeTimeout so a retry config can pick the download up again.
+func (a *HTTPActivity) download(ctx workflow.Context, client *http.Client, req *http.Request, params HTTPInput) (HTTPOutput, error) {
+	partPath := params.DownloadTo + partSuffix
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return HTTPOutput{}, fmt.Errorf("failed to stat partial download: %w", err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := a.send(client, req, params)
+	if err != nil {
+		return HTTPOutput{}, err
+	}
+	defer resp.Body.Close()
+
+	output := HTTPOutput{
+		StatusCode:    resp.StatusCode,
+		Status:        resp.Status,
+		Success:       resp.StatusCode >= 200 && resp.StatusCode < 300,
+		ContentLength: resp.ContentLength,
+		Headers:       responseHeaders(resp),
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			return output, workflow.NewWorkflowError(workflow.ErrorTypeActivityFailed,
+				fmt.Sprintf("server resumed at an unexpected offset (Content-Range %q, want %d)",
+					resp.Header.Get("Content-Range"), offset))
+		}
+		flags |= os.O_APPEND
+		output.ResumedFrom = offset
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0 &&
+		resp.Header.Get("Content-Range") == fmt.Sprintf("bytes */%d", offset):
+		// The previous attempt read every byte but failed before
+		// renaming the part file.
+		output.StatusCode, output.Status, output.Success = http.StatusOK, "200 OK", true
+		output.ResumedFrom = offset
+		return a.finishDownload(output, partPath, params.DownloadTo, offset)
+	case output.Success:
+		flags |= os.O_TRUNC
+		offset = 0
+	default:
+		// Error responses are not written to the file.
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if err != nil {
+			return output, fmt.Errorf("failed to read response body: %w", err)
+		}
+		output.Body = string(body)
+		if a.statusErrors {
+			return output, a.statusError(output)
+		}
+		return output, nil
+	}
+
+	file, err := os.OpenFile(partPath, flags, 0o644)
+	if err != nil {
+		return output, fmt.Errorf("failed to open download file: %w", err)
+	}
+	var body io.Reader = resp.Body
+	if a.maxResponseBytes > 0 {
+		body = io.LimitReader(resp.Body, a.maxResponseBytes-offset+1)
+	}
+	written, copyErr := io.Copy(file, body)
+	if err := file.Close(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	total := offset + written
+	if a.maxResponseBytes > 0 && total > a.maxResponseBytes {
+		os.Remove(partPath)
+		return output, workflow.NewWorkflowError(workflow.ErrorTypeFatal,
+			fmt.Sprintf("response body exceeds %d bytes", a.maxResponseBytes))
+	}
+	if copyErr != nil {
+		if logger := ctx.Logger(); logger != nil {
+			logger.Warn("download interrupted", "url", params.URL, "path", params.DownloadTo, "bytes", total)
+		}
+		output.BytesWritten = total
+		return output, &workflow.WorkflowError{
+			Type:    workflow.ErrorTypeTimeout,
+			Cause:   fmt.Sprintf("download interrupted after %d bytes", total),
+			Details: map[string]any{"bytes_written": total},
+			Wrapped: copyErr,
+		}
+	}
+	return a.finishDownload(output, partPath, params.DownloadTo, total)
+}
+
+// finishDownload moves a complete part file into place.
+func (a *HTTPActivity) finishDownload(output HTTPOutput, partPath, path string, total int64) (HTTPOutput, error) {
+	if err := os.Rename(partPath, path); err != nil {
+		return output, fmt.Errorf("failed to move download into place: %w", err)
+	}
+	output.File = path
+	output.BytesWritten = total
+	return output, nil
+}
+
+// contentRangeStart returns the first byte position of a
+// "bytes start-end/total" Content-Range header.
+func contentRangeStart(header string) (int64, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	return n, err == nil
+}
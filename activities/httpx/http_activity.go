@@ -3,9 +3,12 @@ package httpx
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -19,8 +22,40 @@ type HTTPInput struct {
 	Headers         map[string]string `json:"headers"`
 	Body            string            `json:"body"`             // JSON string or plain text
 	JSONPayload     map[string]any    `json:"json_payload"`     // Alternative to body for JSON
-	Timeout         time.Duration     `json:"timeout"`          // 0 uses default of 30s
+	Timeout         time.Duration     `json:"timeout"`          // "10s" or nanoseconds; 0 uses the activity default
 	FollowRedirects bool              `json:"follow_redirects"` // default true
+
+	// DownloadTo streams the body of a GET to this file path instead
+	// of returning it, resuming an interrupted download with a Range
+	// request when the step is retried.
+	DownloadTo string `json:"download_to"`
+}
+
+// UnmarshalJSON accepts the timeout as a duration string such as "10s"
+// as well as a number of nanoseconds.
+func (in *HTTPInput) UnmarshalJSON(data []byte) error {
+	type plain HTTPInput
+	aux := struct {
+		*plain
+		Timeout any `json:"timeout"`
+	}{plain: (*plain)(in)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	switch v := aux.Timeout.(type) {
+	case nil:
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %q: %w", v, err)
+		}
+		in.Timeout = d
+	case float64:
+		in.Timeout = time.Duration(v)
+	default:
+		return fmt.Errorf("invalid timeout: expected a duration string or nanoseconds, got %T", v)
+	}
+	return nil
 }
 
 // HTTPOutput defines the output of the HTTP activity
@@ -32,19 +67,91 @@ type HTTPOutput struct {
 	JSONResponse  map[string]any    `json:"json_response,omitempty"`
 	Success       bool              `json:"success"`
 	ContentLength int64             `json:"content_length"`
+	DryRun        bool              `json:"dry_run,omitempty"` // true when the request was simulated
+
+	// Set in download mode: the file written, its total size, and the
+	// offset this attempt resumed from (0 for a fresh download).
+	File         string `json:"file,omitempty"`
+	BytesWritten int64  `json:"bytes_written,omitempty"`
+	ResumedFrom  int64  `json:"resumed_from,omitempty"`
+}
+
+// DefaultTimeout bounds a request when neither the input nor
+// WithTimeout sets a timeout.
+const DefaultTimeout = 30 * time.Second
+
+// Option configures the HTTP activity.
+type Option func(*HTTPActivity)
+
+// WithTimeout sets the timeout for requests whose input has no timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(a *HTTPActivity) { a.timeout = d }
+}
+
+// WithMaxResponseBytes caps the response body size. A larger body fails
+// the activity with workflow.ErrorTypeFatal rather than being read into
+// memory. Zero, the default, means no limit.
+func WithMaxResponseBytes(n int64) Option {
+	return func(a *HTTPActivity) { a.maxResponseBytes = n }
+}
+
+// WithStatusErrors makes responses outside the 2xx and 3xx ranges fail
+// the activity with a *workflow.WorkflowError, so retry and catch
+// configs can tell transient failures from permanent ones:
+//
+//   - retryable status codes map to workflow.ErrorTypeTimeout;
+//   - every other 4xx or 5xx code maps to workflow.ErrorTypeActivityFailed.
+//
+// When no codes are given, 408, 429, and all 5xx codes are retryable.
+// The error's Details is a map with "status_code", "status", and
+// "body". Without this option, every response is returned as an
+// HTTPOutput and callers inspect Success or StatusCode themselves.
+func WithStatusErrors(retryable ...int) Option {
+	return func(a *HTTPActivity) {
+		a.statusErrors = true
+		a.retryable = retryable
+	}
 }
 
 // HTTPActivity can be used to make HTTP requests
-type HTTPActivity struct{}
+type HTTPActivity struct {
+	timeout          time.Duration
+	maxResponseBytes int64
+	statusErrors     bool
+	retryable        []int
+}
 
-func NewHTTPActivity() workflow.Activity {
-	return workflow.NewTypedActivity(&HTTPActivity{})
+// NewHTTPActivity returns the HTTP activity, registered as "http".
+func NewHTTPActivity(opts ...Option) workflow.Activity {
+	a := &HTTPActivity{timeout: DefaultTimeout}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.timeout <= 0 {
+		a.timeout = DefaultTimeout
+	}
+	return workflow.NewTypedActivity(a)
 }
 
 func (a *HTTPActivity) Name() string {
 	return "http"
 }
 
+// ParamSchema declares the parameters of HTTPInput so that steps using
+// the activity are checked when an execution is created.
+func (a *HTTPActivity) ParamSchema() map[string]workflow.ParamSpec {
+	return map[string]workflow.ParamSpec{
+		"url":              {Type: workflow.InputTypeString, Required: true, Description: "Request URL"},
+		"method":           {Type: workflow.InputTypeString, Description: "HTTP method, default GET"},
+		"headers":          {Type: workflow.InputTypeObject, Description: "Request headers"},
+		"body":             {Type: workflow.InputTypeString, Description: "Raw request body"},
+		"json_payload":     {Type: workflow.InputTypeObject, Description: "Object sent as a JSON body instead of body"},
+		"timeout":          {Description: "Duration string such as \"10s\" or nanoseconds"},
+		"follow_redirects": {Type: workflow.InputTypeBool, Description: "Follow 3xx redirects"},
+		"download_to":      {Type: workflow.InputTypeString, Description: "File path to stream a GET response into"},
+	}
+}
+
 func (a *HTTPActivity) Execute(ctx workflow.Context, params HTTPInput) (HTTPOutput, error) {
 	if params.URL == "" {
 		return HTTPOutput{}, fmt.Errorf("URL cannot be empty")
@@ -55,7 +162,34 @@ func (a *HTTPActivity) Execute(ctx workflow.Context, params HTTPInput) (HTTPOutp
 		params.Method = "GET"
 	}
 	if params.Timeout <= 0 {
-		params.Timeout = 30 * time.Second
+		params.Timeout = a.timeout
+		if params.Timeout <= 0 {
+			params.Timeout = DefaultTimeout
+		}
+	}
+
+	method := strings.ToUpper(params.Method)
+	if params.DownloadTo != "" && method != http.MethodGet {
+		return HTTPOutput{}, fmt.Errorf("download_to requires method GET, got %s", method)
+	}
+
+	// In a dry run, only safe methods are sent. Anything that may change
+	// remote state, or a download that would write a file, is logged
+	// and answered with a simulated 200.
+	if workflow.IsDryRun(ctx) && (!isSafeMethod(method) || params.DownloadTo != "") {
+		if logger := ctx.Logger(); logger != nil {
+			logger.Info("dry run: skipping http request",
+				"method", method,
+				"url", params.URL,
+				"download_to", params.DownloadTo)
+		}
+		return HTTPOutput{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK (dry run)",
+			Headers:    map[string]string{},
+			Success:    true,
+			DryRun:     true,
+		}, nil
 	}
 
 	// Prepare request body
@@ -71,7 +205,7 @@ func (a *HTTPActivity) Execute(ctx workflow.Context, params HTTPInput) (HTTPOutp
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(params.Method), params.URL, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, params.URL, bodyReader)
 	if err != nil {
 		return HTTPOutput{}, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -100,18 +234,31 @@ func (a *HTTPActivity) Execute(ctx workflow.Context, params HTTPInput) (HTTPOutp
 		}
 	}
 
+	if params.DownloadTo != "" {
+		return a.download(ctx, client, req, params)
+	}
+
 	// Make the request
-	resp, err := client.Do(req)
+	resp, err := a.send(client, req, params)
 	if err != nil {
-		return HTTPOutput{}, fmt.Errorf("failed to make request: %w", err)
+		return HTTPOutput{}, err
 	}
 	defer resp.Body.Close()
 
-	// Read response body
-	respBody, err := io.ReadAll(resp.Body)
+	// Read response body, stopping one byte past the limit to detect
+	// oversized responses without buffering them.
+	var bodySource io.Reader = resp.Body
+	if a.maxResponseBytes > 0 {
+		bodySource = io.LimitReader(resp.Body, a.maxResponseBytes+1)
+	}
+	respBody, err := io.ReadAll(bodySource)
 	if err != nil {
 		return HTTPOutput{}, fmt.Errorf("failed to read response body: %w", err)
 	}
+	if a.maxResponseBytes > 0 && int64(len(respBody)) > a.maxResponseBytes {
+		return HTTPOutput{}, workflow.NewWorkflowError(workflow.ErrorTypeFatal,
+			fmt.Sprintf("response body exceeds %d bytes", a.maxResponseBytes))
+	}
 
 	// Prepare output
 	output := HTTPOutput{
@@ -120,14 +267,7 @@ func (a *HTTPActivity) Execute(ctx workflow.Context, params HTTPInput) (HTTPOutp
 		Body:          string(respBody),
 		Success:       resp.StatusCode >= 200 && resp.StatusCode < 300,
 		ContentLength: resp.ContentLength,
-		Headers:       make(map[string]string),
-	}
-
-	// Copy response headers
-	for key, values := range resp.Header {
-		if len(values) > 0 {
-			output.Headers[key] = values[0]
-		}
+		Headers:       responseHeaders(resp),
 	}
 
 	// Try to parse JSON response
@@ -138,5 +278,70 @@ func (a *HTTPActivity) Execute(ctx workflow.Context, params HTTPInput) (HTTPOutp
 		}
 	}
 
+	if a.statusErrors && resp.StatusCode >= 400 {
+		return output, a.statusError(output)
+	}
 	return output, nil
 }
+
+// send performs req, classifying a client timeout as
+// workflow.ErrorTypeTimeout.
+func (a *HTTPActivity) send(client *http.Client, req *http.Request, params HTTPInput) (*http.Response, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, &workflow.WorkflowError{
+				Type:    workflow.ErrorTypeTimeout,
+				Cause:   fmt.Sprintf("request timed out after %s", params.Timeout),
+				Wrapped: err,
+			}
+		}
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	return resp, nil
+}
+
+// responseHeaders returns the first value of each response header.
+func responseHeaders(resp *http.Response) map[string]string {
+	headers := make(map[string]string, len(resp.Header))
+	for key, values := range resp.Header {
+		if len(values) > 0 {
+			headers[key] = values[0]
+		}
+	}
+	return headers
+}
+
+// statusError classifies an error response for WithStatusErrors.
+func (a *HTTPActivity) statusError(output HTTPOutput) error {
+	errorType := workflow.ErrorTypeActivityFailed
+	if a.isRetryable(output.StatusCode) {
+		errorType = workflow.ErrorTypeTimeout
+	}
+	return &workflow.WorkflowError{
+		Type:  errorType,
+		Cause: fmt.Sprintf("http status %s", output.Status),
+		Details: map[string]any{
+			"status_code": output.StatusCode,
+			"status":      output.Status,
+			"body":        output.Body,
+		},
+	}
+}
+
+func (a *HTTPActivity) isRetryable(code int) bool {
+	if len(a.retryable) > 0 {
+		return slices.Contains(a.retryable, code)
+	}
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500
+}
+
+// isSafeMethod reports whether an HTTP method is read-only per RFC 9110.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
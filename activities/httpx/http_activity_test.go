@@ -1,10 +1,20 @@
 package httpx
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/deepnoodle-ai/workflow"
 	"github.com/deepnoodle-ai/workflow/internal/require"
 )
 
@@ -112,3 +122,210 @@ func TestHTTPActivity(t *testing.T) {
 		require.Equal(t, 404, output.StatusCode)
 	})
 }
+
+func TestHTTPActivityDryRun(t *testing.T) {
+	activity := NewHTTPActivity()
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method)
+		w.Write([]byte(`ok`))
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	ctx := workflow.NewContext(context.Background(), workflow.ExecutionContextOptions{
+		BranchLocalState: workflow.NewBranchLocalState(map[string]any{}, map[string]any{}),
+		Logger:           slog.New(slog.NewTextHandler(&logs, nil)),
+		DryRun:           true,
+	})
+
+	t.Run("POST is logged but not sent", func(t *testing.T) {
+		result, err := activity.Execute(ctx, map[string]any{
+			"url": server.URL, "method": "post", "json_payload": map[string]any{"key": "value"},
+		})
+		require.NoError(t, err)
+		output := result.(HTTPOutput)
+		require.True(t, output.DryRun)
+		require.True(t, output.Success)
+		require.Empty(t, requests)
+		require.Contains(t, logs.String(), "dry run: skipping http request")
+		require.Contains(t, logs.String(), "method=POST")
+	})
+
+	t.Run("GET still runs", func(t *testing.T) {
+		result, err := activity.Execute(ctx, map[string]any{"url": server.URL})
+		require.NoError(t, err)
+		output := result.(HTTPOutput)
+		require.False(t, output.DryRun)
+		require.Equal(t, "ok", output.Body)
+		require.Equal(t, []string{"GET"}, requests)
+	})
+}
+
+func TestHTTPActivityOptions(t *testing.T) {
+	statusServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, _ := strconv.Atoi(r.URL.Query().Get("code"))
+		w.WriteHeader(code)
+		w.Write([]byte(`detail`))
+	}))
+	defer statusServer.Close()
+
+	errorType := func(t *testing.T, err error) string {
+		t.Helper()
+		var wfErr *workflow.WorkflowError
+		require.True(t, errors.As(err, &wfErr), "error %v is not a WorkflowError", err)
+		return wfErr.Type
+	}
+
+	t.Run("status errors classify transient and permanent failures", func(t *testing.T) {
+		activity := NewHTTPActivity(WithStatusErrors())
+		for code, want := range map[int]string{
+			503: workflow.ErrorTypeTimeout,
+			500: workflow.ErrorTypeTimeout,
+			429: workflow.ErrorTypeTimeout,
+			404: workflow.ErrorTypeActivityFailed,
+			400: workflow.ErrorTypeActivityFailed,
+		} {
+			_, err := activity.Execute(newTestContext(), map[string]any{"url": fmt.Sprintf("%s?code=%d", statusServer.URL, code)})
+			require.Error(t, err)
+			require.Equal(t, want, errorType(t, err), "status %d", code)
+		}
+
+		_, err := activity.Execute(newTestContext(), map[string]any{"url": statusServer.URL + "?code=502"})
+		var wfErr *workflow.WorkflowError
+		require.True(t, errors.As(err, &wfErr))
+		details := wfErr.Details.(map[string]any)
+		require.Equal(t, 502, details["status_code"])
+		require.Equal(t, "detail", details["body"])
+
+		result, err := activity.Execute(newTestContext(), map[string]any{"url": statusServer.URL + "?code=204"})
+		require.NoError(t, err)
+		require.Equal(t, 204, result.(HTTPOutput).StatusCode)
+	})
+
+	t.Run("custom retryable codes", func(t *testing.T) {
+		activity := NewHTTPActivity(WithStatusErrors(409))
+		_, err := activity.Execute(newTestContext(), map[string]any{"url": statusServer.URL + "?code=409"})
+		require.Equal(t, workflow.ErrorTypeTimeout, errorType(t, err))
+		_, err = activity.Execute(newTestContext(), map[string]any{"url": statusServer.URL + "?code=503"})
+		require.Equal(t, workflow.ErrorTypeActivityFailed, errorType(t, err))
+	})
+
+	t.Run("max response bytes", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write(bytes.Repeat([]byte("x"), 100))
+		}))
+		defer server.Close()
+
+		_, err := NewHTTPActivity(WithMaxResponseBytes(10)).Execute(newTestContext(), map[string]any{"url": server.URL})
+		require.Error(t, err)
+		require.Equal(t, workflow.ErrorTypeFatal, errorType(t, err))
+
+		result, err := NewHTTPActivity(WithMaxResponseBytes(100)).Execute(newTestContext(), map[string]any{"url": server.URL})
+		require.NoError(t, err)
+		require.Len(t, result.(HTTPOutput).Body, 100)
+	})
+
+	t.Run("timeouts", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+
+		// Per-request timeout as a duration string.
+		_, err := NewHTTPActivity().Execute(newTestContext(), map[string]any{"url": server.URL, "timeout": "50ms"})
+		require.Equal(t, workflow.ErrorTypeTimeout, errorType(t, err))
+
+		// Activity-wide default.
+		_, err = NewHTTPActivity(WithTimeout(50*time.Millisecond)).Execute(newTestContext(), map[string]any{"url": server.URL})
+		require.Equal(t, workflow.ErrorTypeTimeout, errorType(t, err))
+
+		_, err = NewHTTPActivity().Execute(newTestContext(), map[string]any{"url": server.URL, "timeout": "soon"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `invalid timeout "soon"`)
+	})
+}
+
+func TestHTTPActivityParamSchema(t *testing.T) {
+	reg := workflow.NewActivityRegistry()
+	reg.MustRegister(NewHTTPActivity())
+
+	newWorkflow := func(params map[string]any) *workflow.Workflow {
+		wf, err := workflow.New(workflow.Options{
+			Name:  "fetch",
+			Steps: []*workflow.Step{{Name: "fetch", Activity: "http", Parameters: params}},
+		})
+		require.NoError(t, err)
+		return wf
+	}
+
+	_, err := workflow.NewExecution(newWorkflow(map[string]any{"method": "GET"}), reg)
+	require.ErrorIs(t, err, workflow.ErrInvalidParameter)
+	require.Contains(t, err.Error(), `requires parameter "url"`)
+
+	_, err = workflow.NewExecution(newWorkflow(map[string]any{"url": "https://example.com", "verb": "GET"}), reg)
+	require.ErrorIs(t, err, workflow.ErrInvalidParameter)
+	require.Contains(t, err.Error(), `does not accept parameter "verb"`)
+
+	_, err = workflow.NewExecution(newWorkflow(map[string]any{
+		"url":              "${inputs.url}",
+		"follow_redirects": "${inputs.follow}",
+		"timeout":          "10s",
+	}), reg)
+	require.NoError(t, err)
+}
+
+func TestHTTPActivityResumableDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	half := len(content) / 2
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Header.Get("Range"))
+		if len(requests) == 1 {
+			// Promise the full body, send half of it, then drop the connection.
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Write(content[:half])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "data.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "data.bin")
+	params := map[string]any{"url": server.URL, "download_to": path}
+	activity := NewHTTPActivity()
+
+	_, err := activity.Execute(newTestContext(), params)
+	var wfErr *workflow.WorkflowError
+	require.True(t, errors.As(err, &wfErr))
+	require.Equal(t, workflow.ErrorTypeTimeout, wfErr.Type)
+	info, err := os.Stat(path + ".part")
+	require.NoError(t, err)
+	require.Equal(t, int64(half), info.Size())
+
+	result, err := activity.Execute(newTestContext(), params)
+	require.NoError(t, err)
+	output := result.(HTTPOutput)
+	require.Equal(t, []string{"", fmt.Sprintf("bytes=%d-", half)}, requests)
+	require.Equal(t, http.StatusPartialContent, output.StatusCode)
+	require.Equal(t, int64(half), output.ResumedFrom)
+	require.Equal(t, int64(len(content)), output.BytesWritten)
+	require.Equal(t, path, output.File)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(content, data))
+	_, err = os.Stat(path + ".part")
+	require.True(t, errors.Is(err, os.ErrNotExist))
+
+	t.Run("requires GET", func(t *testing.T) {
+		_, err := activity.Execute(newTestContext(), map[string]any{"url": server.URL, "method": "POST", "download_to": path})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "download_to requires method GET")
+	})
+}
@@ -0,0 +1,456 @@
+package activities
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/deepnoodle-ai/workflow"
+)
+
+// ErrorTypeSchemaInvalid is the error type of the failure returned by
+// the json.validate activity when fail_on_invalid is set, for use in
+// Retry and Catch ErrorEquals.
+const ErrorTypeSchemaInvalid = "schema_invalid"
+
+// JSONSchemaInput defines the input parameters for the json.validate activity
+type JSONSchemaInput struct {
+	Schema        any  `json:"schema"`          // schema object, JSON string, or path to a schema file
+	Data          any  `json:"data"`            // value to validate
+	FailOnInvalid bool `json:"fail_on_invalid"` // fail the step when data is invalid
+}
+
+// JSONSchemaActivity validates a value against a JSON Schema.
+//
+// It implements the validation keywords of JSON Schema draft 2020-12
+// that apply to plain JSON data: type, enum, const, the numeric, string,
+// array, and object constraints, allOf, anyOf, oneOf, not, and local
+// $ref pointers ("#/$defs/..." or "#/definitions/..."). Annotations such
+// as format and title are ignored. Each error is prefixed with the JSON
+// Pointer of the offending value.
+//
+// The result is a map with "valid" (bool) and "errors" (a list of
+// strings, empty when valid).
+type JSONSchemaActivity struct{}
+
+func NewJSONSchemaActivity() workflow.Activity {
+	return workflow.NewTypedActivity(&JSONSchemaActivity{})
+}
+
+func (a *JSONSchemaActivity) Name() string {
+	return "json.validate"
+}
+
+func (a *JSONSchemaActivity) Execute(ctx workflow.Context, params JSONSchemaInput) (map[string]any, error) {
+	schema, err := loadJSONSchema(params.Schema)
+	if err != nil {
+		return nil, err
+	}
+	data, err := toJSONValue(params.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data: %w", err)
+	}
+
+	v := &schemaValidator{root: schema}
+	v.validate(schema, data, "")
+	if v.err != nil {
+		return nil, v.err
+	}
+	errors := make([]any, len(v.errors))
+	for i, e := range v.errors {
+		errors[i] = e
+	}
+	result := map[string]any{
+		"valid":  len(errors) == 0,
+		"errors": errors,
+	}
+	if len(errors) > 0 && params.FailOnInvalid {
+		wErr := workflow.NewWorkflowError(ErrorTypeSchemaInvalid,
+			fmt.Sprintf("data does not match schema: %s", strings.Join(v.errors, "; ")))
+		wErr.Details = v.errors
+		return nil, wErr
+	}
+	return result, nil
+}
+
+// loadJSONSchema accepts a schema as a decoded value, a JSON document,
+// or the path of a file containing one.
+func loadJSONSchema(schema any) (any, error) {
+	s, ok := schema.(string)
+	if !ok {
+		if schema == nil {
+			return nil, fmt.Errorf("schema is required")
+		}
+		return toJSONValue(schema)
+	}
+	data := []byte(s)
+	if trimmed := strings.TrimSpace(s); !strings.HasPrefix(trimmed, "{") && trimmed != "true" && trimmed != "false" {
+		content, err := os.ReadFile(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema file: %w", err)
+		}
+		data = content
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	return out, nil
+}
+
+// toJSONValue converts v to the generic form produced by decoding JSON:
+// maps, slices, strings, float64, bool, and nil.
+func toJSONValue(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type schemaValidator struct {
+	root   any
+	errors []string
+	err    error // a malformed schema, as opposed to invalid data
+	depth  int
+}
+
+func (v *schemaValidator) fail(path, format string, args ...any) {
+	if path == "" {
+		path = "/"
+	}
+	v.errors = append(v.errors, path+": "+fmt.Sprintf(format, args...))
+}
+
+// matches reports whether data is valid against schema without
+// recording errors, for anyOf, oneOf, and not.
+func (v *schemaValidator) matches(schema, data any, path string) bool {
+	sub := &schemaValidator{root: v.root, depth: v.depth}
+	sub.validate(schema, data, path)
+	if sub.err != nil && v.err == nil {
+		v.err = sub.err
+	}
+	return len(sub.errors) == 0
+}
+
+func (v *schemaValidator) validate(schema, data any, path string) {
+	if v.err != nil {
+		return
+	}
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			v.fail(path, "no value is allowed here")
+		}
+		return
+	case map[string]any:
+		v.validateObject(s, data, path)
+	default:
+		v.err = fmt.Errorf("invalid schema at %q: expected an object or boolean", path)
+	}
+}
+
+func (v *schemaValidator) validateObject(s map[string]any, data any, path string) {
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := resolveSchemaRef(v.root, ref)
+		if err != nil {
+			v.err = err
+			return
+		}
+		if v.depth++; v.depth > 100 {
+			v.err = fmt.Errorf("schema $ref %q nests too deeply", ref)
+			return
+		}
+		v.validate(target, data, path)
+		v.depth--
+	}
+
+	if t, ok := s["type"]; ok && !matchesSchemaType(t, data) {
+		v.fail(path, "expected %s, got %s", describeSchemaType(t), jsonTypeName(data))
+		return
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, data) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "value must be one of %s", compactJSON(enum))
+		}
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, data) {
+		v.fail(path, "value must be %s", compactJSON(c))
+	}
+
+	switch d := data.(type) {
+	case float64:
+		v.validateNumber(s, d, path)
+	case string:
+		v.validateString(s, d, path)
+	case []any:
+		v.validateArray(s, d, path)
+	case map[string]any:
+		v.validateProperties(s, d, path)
+	}
+
+	if all, ok := s["allOf"].([]any); ok {
+		for _, sub := range all {
+			v.validate(sub, data, path)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]any); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if v.matches(sub, data, path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "value does not match any schema in anyOf")
+		}
+	}
+	if oneOf, ok := s["oneOf"].([]any); ok {
+		count := 0
+		for _, sub := range oneOf {
+			if v.matches(sub, data, path) {
+				count++
+			}
+		}
+		if count != 1 {
+			v.fail(path, "value must match exactly one schema in oneOf, matched %d", count)
+		}
+	}
+	if not, ok := s["not"]; ok && v.matches(not, data, path) {
+		v.fail(path, "value must not match the schema in not")
+	}
+}
+
+func (v *schemaValidator) validateNumber(s map[string]any, n float64, path string) {
+	if min, ok := s["minimum"].(float64); ok && n < min {
+		v.fail(path, "must be >= %v", min)
+	}
+	if max, ok := s["maximum"].(float64); ok && n > max {
+		v.fail(path, "must be <= %v", max)
+	}
+	if min, ok := s["exclusiveMinimum"].(float64); ok && n <= min {
+		v.fail(path, "must be > %v", min)
+	}
+	if max, ok := s["exclusiveMaximum"].(float64); ok && n >= max {
+		v.fail(path, "must be < %v", max)
+	}
+	if m, ok := s["multipleOf"].(float64); ok && m > 0 {
+		if q := n / m; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(path, "must be a multiple of %v", m)
+		}
+	}
+}
+
+func (v *schemaValidator) validateString(s map[string]any, str string, path string) {
+	length := utf8.RuneCountInString(str)
+	if min, ok := s["minLength"].(float64); ok && float64(length) < min {
+		v.fail(path, "must be at least %v characters", min)
+	}
+	if max, ok := s["maxLength"].(float64); ok && float64(length) > max {
+		v.fail(path, "must be at most %v characters", max)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			v.err = fmt.Errorf("invalid schema pattern %q: %w", pattern, err)
+			return
+		}
+		if !re.MatchString(str) {
+			v.fail(path, "must match pattern %q", pattern)
+		}
+	}
+}
+
+func (v *schemaValidator) validateArray(s map[string]any, items []any, path string) {
+	if min, ok := s["minItems"].(float64); ok && float64(len(items)) < min {
+		v.fail(path, "must have at least %v items", min)
+	}
+	if max, ok := s["maxItems"].(float64); ok && float64(len(items)) > max {
+		v.fail(path, "must have at most %v items", max)
+	}
+	if unique, _ := s["uniqueItems"].(bool); unique {
+	outer:
+		for i := range items {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(items[i], items[j]) {
+					v.fail(path, "items %d and %d are equal", j, i)
+					break outer
+				}
+			}
+		}
+	}
+	start := 0
+	if prefix, ok := s["prefixItems"].([]any); ok {
+		for i, sub := range prefix {
+			if i < len(items) {
+				v.validate(sub, items[i], path+"/"+strconv.Itoa(i))
+			}
+		}
+		start = len(prefix)
+	}
+	if sub, ok := s["items"]; ok {
+		for i := start; i < len(items); i++ {
+			v.validate(sub, items[i], path+"/"+strconv.Itoa(i))
+		}
+	}
+	if sub, ok := s["contains"]; ok {
+		found := false
+		for i, item := range items {
+			if v.matches(sub, item, path+"/"+strconv.Itoa(i)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "no item matches the schema in contains")
+		}
+	}
+}
+
+func (v *schemaValidator) validateProperties(s map[string]any, obj map[string]any, path string) {
+	if min, ok := s["minProperties"].(float64); ok && float64(len(obj)) < min {
+		v.fail(path, "must have at least %v properties", min)
+	}
+	if max, ok := s["maxProperties"].(float64); ok && float64(len(obj)) > max {
+		v.fail(path, "must have at most %v properties", max)
+	}
+	if required, ok := s["required"].([]any); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				if _, present := obj[name]; !present {
+					v.fail(path, "missing required property %q", name)
+				}
+			}
+		}
+	}
+
+	properties, _ := s["properties"].(map[string]any)
+	additional, hasAdditional := s["additionalProperties"]
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		childPath := path + "/" + escapeJSONPointer(k)
+		if sub, ok := properties[k]; ok {
+			v.validate(sub, obj[k], childPath)
+			continue
+		}
+		if hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				v.fail(path, "property %q is not allowed", k)
+				continue
+			}
+			v.validate(additional, obj[k], childPath)
+		}
+	}
+}
+
+// resolveSchemaRef resolves a local JSON Pointer reference against the
+// root schema.
+func resolveSchemaRef(root any, ref string) (any, error) {
+	if ref == "#" {
+		return root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported schema $ref %q: only local references are supported", ref)
+	}
+	current := root
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("schema $ref %q not found", ref)
+		}
+		if current, ok = m[token]; !ok {
+			return nil, fmt.Errorf("schema $ref %q not found", ref)
+		}
+	}
+	return current, nil
+}
+
+func escapeJSONPointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+func matchesSchemaType(t, data any) bool {
+	switch t := t.(type) {
+	case string:
+		return matchesSingleType(t, data)
+	case []any:
+		for _, item := range t {
+			if name, ok := item.(string); ok && matchesSingleType(name, data) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesSingleType(name string, data any) bool {
+	switch name {
+	case "integer":
+		n, ok := data.(float64)
+		return ok && n == math.Trunc(n)
+	default:
+		return jsonTypeName(data) == name
+	}
+}
+
+func describeSchemaType(t any) string {
+	if list, ok := t.([]any); ok {
+		names := make([]string, len(list))
+		for i, item := range list {
+			names[i] = fmt.Sprint(item)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func jsonTypeName(data any) string {
+	switch data.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", data)
+}
+
+func compactJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
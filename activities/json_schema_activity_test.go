@@ -0,0 +1,173 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow"
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+var orderSchema = map[string]any{
+	"type":     "object",
+	"required": []any{"id", "items"},
+	"properties": map[string]any{
+		"id":     map[string]any{"type": "string", "pattern": "^ord-[0-9]+$"},
+		"status": map[string]any{"enum": []any{"open", "closed"}},
+		"items": map[string]any{
+			"type":     "array",
+			"minItems": 1,
+			"items":    map[string]any{"$ref": "#/$defs/item"},
+		},
+	},
+	"additionalProperties": false,
+	"$defs": map[string]any{
+		"item": map[string]any{
+			"type":     "object",
+			"required": []any{"sku", "qty"},
+			"properties": map[string]any{
+				"sku": map[string]any{"type": "string", "minLength": 1},
+				"qty": map[string]any{"type": "integer", "minimum": 1},
+			},
+		},
+	},
+}
+
+func TestJSONSchemaActivity(t *testing.T) {
+	activity := NewJSONSchemaActivity()
+	require.Equal(t, "json.validate", activity.Name())
+
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{"type": "number", "maximum": 10}`), 0644))
+
+	tests := []struct {
+		name       string
+		params     map[string]any
+		wantErrors []any
+		wantErr    string
+	}{
+		{
+			name: "valid",
+			params: map[string]any{"schema": orderSchema, "data": map[string]any{
+				"id": "ord-1", "status": "open", "items": []any{map[string]any{"sku": "a", "qty": 2}},
+			}},
+			wantErrors: []any{},
+		},
+		{
+			name: "invalid",
+			params: map[string]any{"schema": orderSchema, "data": map[string]any{
+				"id":    "order-1",
+				"extra": true,
+				"items": []any{map[string]any{"sku": "", "qty": 1.5}, map[string]any{"qty": 0}},
+			}},
+			wantErrors: []any{
+				`/: property "extra" is not allowed`,
+				`/id: must match pattern "^ord-[0-9]+$"`,
+				`/items/0/qty: expected integer, got number`,
+				`/items/0/sku: must be at least 1 characters`,
+				`/items/1: missing required property "sku"`,
+				`/items/1/qty: must be >= 1`,
+			},
+		},
+		{
+			name:       "missing required",
+			params:     map[string]any{"schema": orderSchema, "data": map[string]any{}},
+			wantErrors: []any{`/: missing required property "id"`, `/: missing required property "items"`},
+		},
+		{
+			name:       "schema as JSON string",
+			params:     map[string]any{"schema": `{"type": ["string", "null"]}`, "data": 5},
+			wantErrors: []any{`/: expected string or null, got number`},
+		},
+		{
+			name:       "schema file",
+			params:     map[string]any{"schema": schemaPath, "data": 11},
+			wantErrors: []any{`/: must be <= 10`},
+		},
+		{
+			name: "combinators",
+			params: map[string]any{
+				"schema": map[string]any{
+					"anyOf": []any{map[string]any{"type": "string"}, map[string]any{"type": "integer"}},
+					"not":   map[string]any{"const": "forbidden"},
+				},
+				"data": "forbidden",
+			},
+			wantErrors: []any{`/: value must not match the schema in not`},
+		},
+		{
+			name:    "missing schema",
+			params:  map[string]any{"data": 1},
+			wantErr: "schema is required",
+		},
+		{
+			name:    "unresolvable ref",
+			params:  map[string]any{"schema": map[string]any{"$ref": "#/$defs/missing"}, "data": 1},
+			wantErr: "not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := activity.Execute(newTestContext(), tt.params)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			out := result.(map[string]any)
+			require.Equal(t, tt.wantErrors, out["errors"])
+			require.Equal(t, len(tt.wantErrors) == 0, out["valid"])
+		})
+	}
+
+	t.Run("fail_on_invalid", func(t *testing.T) {
+		_, err := activity.Execute(newTestContext(), map[string]any{
+			"schema":          orderSchema,
+			"data":            map[string]any{"id": "ord-1"},
+			"fail_on_invalid": true,
+		})
+		var wErr *workflow.WorkflowError
+		require.True(t, errors.As(err, &wErr))
+		require.Equal(t, ErrorTypeSchemaInvalid, wErr.Type)
+		require.Equal(t, []string{`/: missing required property "items"`}, wErr.Details)
+	})
+}
+
+func TestJSONSchemaActivityCatch(t *testing.T) {
+	wf, err := workflow.New(workflow.Options{
+		Name:   "validate-payload",
+		Inputs: []*workflow.Input{{Name: "payload", Type: workflow.InputTypeObject}},
+		Steps: []*workflow.Step{
+			{
+				Name:     "validate",
+				Activity: "json.validate",
+				Parameters: map[string]any{
+					"schema":          orderSchema,
+					"data":            "${inputs.payload}",
+					"fail_on_invalid": true,
+				},
+				Catch: []*workflow.CatchConfig{
+					{ErrorEquals: []string{ErrorTypeSchemaInvalid}, Next: "reject", Store: "failure"},
+				},
+			},
+			{Name: "reject", Activity: "fail"},
+		},
+	})
+	require.NoError(t, err)
+
+	reg := workflow.NewActivityRegistry()
+	reg.MustRegister(NewJSONSchemaActivity())
+	reg.MustRegister(NewFailActivity())
+	exec, err := workflow.NewExecution(wf, reg,
+		workflow.WithInputs(map[string]any{"payload": map[string]any{"id": "nope"}}))
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, workflow.ExecutionStatusFailed, result.Status)
+	require.Contains(t, result.Error.Error(), "fail activity")
+}
@@ -0,0 +1,169 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/deepnoodle-ai/workflow"
+)
+
+// ErrLLMRateLimited signals that an LLM provider rejected a request
+// because of a rate limit or overload. An LLMClient returns an error
+// wrapping it, and the llm.chat activity turns that error into a
+// workflow.ErrorTypeTimeout so a RetryConfig can back off and retry it.
+var ErrLLMRateLimited = errors.New("llm: rate limited")
+
+// LLMMessage is one message of a chat conversation.
+type LLMMessage struct {
+	Role    string `json:"role"` // "system", "user", or "assistant"
+	Content string `json:"content"`
+}
+
+// LLMRequest is a chat completion request passed to an LLMClient.
+type LLMRequest struct {
+	Model       string
+	Messages    []LLMMessage
+	Temperature *float64 // nil leaves the provider default
+	MaxTokens   int      // 0 leaves the provider default
+}
+
+// LLMUsage reports the tokens a completion consumed.
+type LLMUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// LLMResponse is the completion returned by an LLMClient.
+type LLMResponse struct {
+	Text       string
+	Model      string // the model that answered, when the provider reports it
+	StopReason string
+	Usage      LLMUsage
+}
+
+// LLMClient sends a chat completion request to a provider. Chat must
+// give up when ctx is done, and should return an error wrapping
+// ErrLLMRateLimited when the provider asks the caller to slow down
+// (HTTP 429, or an overloaded response). Wrap a provider SDK with
+// LLMClientFunc:
+//
+//	activities.LLMClientFunc(func(ctx context.Context, req activities.LLMRequest) (activities.LLMResponse, error) {
+//		// Convert req to the SDK's request type and call it.
+//	})
+type LLMClient interface {
+	Chat(ctx context.Context, req LLMRequest) (LLMResponse, error)
+}
+
+// LLMClientFunc adapts a function to LLMClient.
+type LLMClientFunc func(ctx context.Context, req LLMRequest) (LLMResponse, error)
+
+// Chat calls f.
+func (f LLMClientFunc) Chat(ctx context.Context, req LLMRequest) (LLMResponse, error) {
+	return f(ctx, req)
+}
+
+// LLMInput defines the input parameters for the llm.chat activity.
+// Prompt is shorthand for a single user message; when Messages is also
+// set, Prompt is appended to it. System, when set, is sent as a leading
+// system message.
+type LLMInput struct {
+	Model       string       `json:"model"`
+	Messages    []LLMMessage `json:"messages"`
+	Prompt      string       `json:"prompt"`
+	System      string       `json:"system"`
+	Temperature *float64     `json:"temperature"`
+	MaxTokens   int          `json:"max_tokens"`
+}
+
+// LLMOutput holds a chat completion.
+type LLMOutput struct {
+	Text       string   `json:"text"`
+	Model      string   `json:"model"`
+	StopReason string   `json:"stop_reason,omitempty"`
+	Usage      LLMUsage `json:"usage"`
+}
+
+// LLMActivity sends a chat completion request through an LLMClient.
+type LLMActivity struct {
+	client LLMClient
+}
+
+// NewLLMActivity returns the chat completion activity, registered as
+// "llm.chat". The client decides which provider answers. A rate-limited
+// request fails with workflow.ErrorTypeTimeout, so it can be retried
+// like any other transient failure:
+//
+//	Retry: []*workflow.RetryConfig{workflow.ExponentialRetry(workflow.ErrorTypeTimeout, 5)}
+func NewLLMActivity(client LLMClient) workflow.Activity {
+	return workflow.NewTypedActivity(&LLMActivity{client: client})
+}
+
+func (a *LLMActivity) Name() string {
+	return "llm.chat"
+}
+
+// ParamSchema declares the parameters of LLMInput.
+func (a *LLMActivity) ParamSchema() map[string]workflow.ParamSpec {
+	return map[string]workflow.ParamSpec{
+		"model":       {Type: workflow.InputTypeString, Required: true, Description: "Model to use"},
+		"messages":    {Type: workflow.InputTypeArray, Description: "Conversation as a list of {role, content} messages"},
+		"prompt":      {Type: workflow.InputTypeString, Description: "User message appended to the conversation"},
+		"system":      {Type: workflow.InputTypeString, Description: "System prompt sent before the conversation"},
+		"temperature": {Type: workflow.InputTypeFloat, Description: "Sampling temperature; the provider default when unset"},
+		"max_tokens":  {Type: workflow.InputTypeInt, Description: "Maximum tokens to generate; the provider default when unset"},
+	}
+}
+
+func (a *LLMActivity) Execute(ctx workflow.Context, params LLMInput) (LLMOutput, error) {
+	if params.Model == "" {
+		return LLMOutput{}, fmt.Errorf("model cannot be empty")
+	}
+	if params.MaxTokens < 0 {
+		return LLMOutput{}, fmt.Errorf("max_tokens cannot be negative")
+	}
+	if params.Prompt == "" && len(params.Messages) == 0 {
+		return LLMOutput{}, fmt.Errorf("either messages or prompt is required")
+	}
+	for i, msg := range params.Messages {
+		if msg.Role == "" {
+			return LLMOutput{}, fmt.Errorf("message %d has no role", i)
+		}
+	}
+	var messages []LLMMessage
+	if params.System != "" {
+		messages = append(messages, LLMMessage{Role: "system", Content: params.System})
+	}
+	messages = append(messages, params.Messages...)
+	if params.Prompt != "" {
+		messages = append(messages, LLMMessage{Role: "user", Content: params.Prompt})
+	}
+
+	resp, err := a.client.Chat(ctx, LLMRequest{
+		Model:       params.Model,
+		Messages:    messages,
+		Temperature: params.Temperature,
+		MaxTokens:   params.MaxTokens,
+	})
+	if err != nil {
+		if errors.Is(err, ErrLLMRateLimited) {
+			return LLMOutput{}, &workflow.WorkflowError{
+				Type:    workflow.ErrorTypeTimeout,
+				Cause:   fmt.Sprintf("model %q is rate limited", params.Model),
+				Wrapped: err,
+			}
+		}
+		return LLMOutput{}, fmt.Errorf("chat completion with model %q failed: %w", params.Model, err)
+	}
+
+	model := resp.Model
+	if model == "" {
+		model = params.Model
+	}
+	return LLMOutput{
+		Text:       resp.Text,
+		Model:      model,
+		StopReason: resp.StopReason,
+		Usage:      resp.Usage,
+	}, nil
+}
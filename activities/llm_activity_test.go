@@ -0,0 +1,132 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow"
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestLLMActivity(t *testing.T) {
+	var got LLMRequest
+	client := LLMClientFunc(func(ctx context.Context, req LLMRequest) (LLMResponse, error) {
+		got = req
+		switch req.Model {
+		case "busy":
+			return LLMResponse{}, fmt.Errorf("status 429: %w", ErrLLMRateLimited)
+		case "broken":
+			return LLMResponse{}, errors.New("invalid api key")
+		}
+		return LLMResponse{
+			Text:       "Paris",
+			StopReason: "end_turn",
+			Usage:      LLMUsage{InputTokens: 12, OutputTokens: 1},
+		}, nil
+	})
+	activity := NewLLMActivity(client)
+	require.Equal(t, "llm.chat", activity.Name())
+
+	t.Run("prompt", func(t *testing.T) {
+		result, err := activity.Execute(newTestContext(), map[string]any{
+			"model":       "small",
+			"system":      "Answer in one word.",
+			"prompt":      "What is the capital of France?",
+			"temperature": 0,
+			"max_tokens":  16,
+		})
+		require.NoError(t, err)
+		require.Equal(t, LLMOutput{
+			Text:       "Paris",
+			Model:      "small",
+			StopReason: "end_turn",
+			Usage:      LLMUsage{InputTokens: 12, OutputTokens: 1},
+		}, result)
+		require.Equal(t, []LLMMessage{
+			{Role: "system", Content: "Answer in one word."},
+			{Role: "user", Content: "What is the capital of France?"},
+		}, got.Messages)
+		require.NotNil(t, got.Temperature)
+		require.Equal(t, 0.0, *got.Temperature)
+		require.Equal(t, 16, got.MaxTokens)
+	})
+
+	t.Run("messages", func(t *testing.T) {
+		_, err := activity.Execute(newTestContext(), map[string]any{
+			"model": "small",
+			"messages": []any{
+				map[string]any{"role": "user", "content": "Hi"},
+				map[string]any{"role": "assistant", "content": "Hello!"},
+			},
+			"prompt": "Bye",
+		})
+		require.NoError(t, err)
+		require.Len(t, got.Messages, 3)
+		require.Equal(t, LLMMessage{Role: "user", Content: "Bye"}, got.Messages[2])
+		require.Nil(t, got.Temperature)
+	})
+
+	t.Run("rate limits are retryable", func(t *testing.T) {
+		_, err := activity.Execute(newTestContext(), map[string]any{"model": "busy", "prompt": "hi"})
+		var wfErr *workflow.WorkflowError
+		require.True(t, errors.As(err, &wfErr))
+		require.Equal(t, workflow.ErrorTypeTimeout, wfErr.Type)
+		require.ErrorIs(t, err, ErrLLMRateLimited)
+
+		_, err = activity.Execute(newTestContext(), map[string]any{"model": "broken", "prompt": "hi"})
+		require.Error(t, err)
+		require.False(t, errors.As(err, &wfErr))
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		for _, params := range []map[string]any{
+			{"prompt": "hi"},
+			{"model": "small"},
+			{"model": "small", "messages": []any{map[string]any{"content": "hi"}}},
+			{"model": "small", "prompt": "hi", "max_tokens": -1},
+		} {
+			_, err := activity.Execute(newTestContext(), params)
+			require.Error(t, err, "params %v", params)
+		}
+	})
+}
+
+func TestLLMActivityRetriesRateLimits(t *testing.T) {
+	calls := 0
+	client := LLMClientFunc(func(ctx context.Context, req LLMRequest) (LLMResponse, error) {
+		calls++
+		if calls < 3 {
+			return LLMResponse{}, ErrLLMRateLimited
+		}
+		return LLMResponse{Text: "done"}, nil
+	})
+	wf, err := workflow.New(workflow.Options{
+		Name: "ask",
+		Steps: []*workflow.Step{{
+			Name:       "ask",
+			Activity:   "llm.chat",
+			Parameters: map[string]any{"model": "small", "prompt": "hi"},
+			Store:      "answer",
+			Retry: []*workflow.RetryConfig{{
+				ErrorEquals: []string{workflow.ErrorTypeTimeout},
+				MaxRetries:  3,
+				BaseDelay:   time.Millisecond,
+			}},
+		}},
+		Outputs: []*workflow.Output{{Name: "answer", Variable: "answer"}},
+	})
+	require.NoError(t, err)
+	reg := workflow.NewActivityRegistry()
+	reg.MustRegister(NewLLMActivity(client))
+
+	exec, err := workflow.NewExecution(wf, reg)
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, workflow.ExecutionStatusCompleted, result.Status)
+	require.Equal(t, 3, calls)
+	require.Equal(t, "done", result.Outputs["answer"].(LLMOutput).Text)
+}
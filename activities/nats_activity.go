@@ -0,0 +1,224 @@
+package activities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow"
+)
+
+// DefaultNATSTimeout bounds a nats.request call whose input sets no
+// timeout.
+const DefaultNATSTimeout = 5 * time.Second
+
+// NATSPublisher is the part of a NATS connection the nats.publish
+// activity uses. *nats.Conn satisfies it.
+type NATSPublisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// NATSRequester sends a request and returns the payload of the reply.
+// Request must give up when ctx is done. The NATS client returns a
+// *nats.Msg, so wrap the connection with NATSRequestFunc:
+//
+//	activities.NATSRequestFunc(func(ctx context.Context, subject string, data []byte) ([]byte, error) {
+//		msg, err := nc.RequestWithContext(ctx, subject, data)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return msg.Data, nil
+//	})
+type NATSRequester interface {
+	Request(ctx context.Context, subject string, data []byte) ([]byte, error)
+}
+
+// NATSRequestFunc adapts a function to NATSRequester.
+type NATSRequestFunc func(ctx context.Context, subject string, data []byte) ([]byte, error)
+
+// Request calls f.
+func (f NATSRequestFunc) Request(ctx context.Context, subject string, data []byte) ([]byte, error) {
+	return f(ctx, subject, data)
+}
+
+// NATSPublishInput defines the input parameters for the nats.publish
+// activity. A string Data is sent as is; any other value except nil is
+// serialized as JSON.
+type NATSPublishInput struct {
+	Subject string `json:"subject"`
+	Data    any    `json:"data"`
+}
+
+// NATSPublishOutput reports a published message.
+type NATSPublishOutput struct {
+	Subject string `json:"subject"`
+	Bytes   int    `json:"bytes"`
+	DryRun  bool   `json:"dry_run,omitempty"` // true when the publish was skipped
+}
+
+// NATSPublishActivity publishes a message on a NATS subject.
+type NATSPublishActivity struct {
+	conn NATSPublisher
+}
+
+// NewNATSPublishActivity returns the publish activity, registered as
+// "nats.publish". In a dry run nothing is published.
+func NewNATSPublishActivity(conn NATSPublisher) workflow.Activity {
+	return workflow.NewTypedActivity(&NATSPublishActivity{conn: conn})
+}
+
+func (a *NATSPublishActivity) Name() string {
+	return "nats.publish"
+}
+
+// ParamSchema declares the parameters of NATSPublishInput.
+func (a *NATSPublishActivity) ParamSchema() map[string]workflow.ParamSpec {
+	return map[string]workflow.ParamSpec{
+		"subject": {Type: workflow.InputTypeString, Required: true, Description: "Subject to publish on"},
+		"data":    {Description: "Payload; non-string values are sent as JSON"},
+	}
+}
+
+func (a *NATSPublishActivity) Execute(ctx workflow.Context, params NATSPublishInput) (NATSPublishOutput, error) {
+	if params.Subject == "" {
+		return NATSPublishOutput{}, fmt.Errorf("subject cannot be empty")
+	}
+	data, err := natsPayload(params.Data)
+	if err != nil {
+		return NATSPublishOutput{}, err
+	}
+	out := NATSPublishOutput{Subject: params.Subject, Bytes: len(data)}
+	if workflow.IsDryRun(ctx) {
+		if logger := ctx.Logger(); logger != nil {
+			logger.Info("dry run: skipping nats publish", "subject", params.Subject)
+		}
+		out.DryRun = true
+		return out, nil
+	}
+	if err := a.conn.Publish(params.Subject, data); err != nil {
+		return NATSPublishOutput{}, fmt.Errorf("failed to publish to %q: %w", params.Subject, err)
+	}
+	return out, nil
+}
+
+// NATSRequestInput defines the input parameters for the nats.request
+// activity. Data is encoded as for nats.publish.
+type NATSRequestInput struct {
+	Subject string        `json:"subject"`
+	Data    any           `json:"data"`
+	Timeout time.Duration `json:"timeout"` // "2s" or nanoseconds; 0 uses DefaultNATSTimeout
+}
+
+// UnmarshalJSON accepts the timeout as a duration string such as "2s"
+// as well as a number of nanoseconds.
+func (in *NATSRequestInput) UnmarshalJSON(data []byte) error {
+	type plain NATSRequestInput
+	aux := struct {
+		*plain
+		Timeout any `json:"timeout"`
+	}{plain: (*plain)(in)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	switch v := aux.Timeout.(type) {
+	case nil:
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %q: %w", v, err)
+		}
+		in.Timeout = d
+	case float64:
+		in.Timeout = time.Duration(v)
+	default:
+		return fmt.Errorf("invalid timeout: expected a duration string or nanoseconds, got %T", v)
+	}
+	return nil
+}
+
+// NATSRequestOutput holds the reply to a request.
+type NATSRequestOutput struct {
+	Data string `json:"data"`           // the reply payload
+	JSON any    `json:"json,omitempty"` // the payload decoded, when it is valid JSON
+}
+
+// NATSRequestActivity sends a request on a NATS subject and waits for
+// the reply.
+type NATSRequestActivity struct {
+	conn NATSRequester
+}
+
+// NewNATSRequestActivity returns the request activity, registered as
+// "nats.request". A request that gets no reply within the timeout fails
+// with workflow.ErrorTypeTimeout, so it can be retried like any other
+// timeout.
+func NewNATSRequestActivity(conn NATSRequester) workflow.Activity {
+	return workflow.NewTypedActivity(&NATSRequestActivity{conn: conn})
+}
+
+func (a *NATSRequestActivity) Name() string {
+	return "nats.request"
+}
+
+// ParamSchema declares the parameters of NATSRequestInput.
+func (a *NATSRequestActivity) ParamSchema() map[string]workflow.ParamSpec {
+	return map[string]workflow.ParamSpec{
+		"subject": {Type: workflow.InputTypeString, Required: true, Description: "Subject to send the request on"},
+		"data":    {Description: "Payload; non-string values are sent as JSON"},
+		"timeout": {Description: "Duration string such as \"2s\" or nanoseconds"},
+	}
+}
+
+func (a *NATSRequestActivity) Execute(ctx workflow.Context, params NATSRequestInput) (NATSRequestOutput, error) {
+	if params.Subject == "" {
+		return NATSRequestOutput{}, fmt.Errorf("subject cannot be empty")
+	}
+	if params.Timeout <= 0 {
+		params.Timeout = DefaultNATSTimeout
+	}
+	data, err := natsPayload(params.Data)
+	if err != nil {
+		return NATSRequestOutput{}, err
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, params.Timeout)
+	defer cancel()
+	reply, err := a.conn.Request(reqCtx, params.Subject, data)
+	if err != nil {
+		if ctx.Err() == nil && (errors.Is(err, context.DeadlineExceeded) || reqCtx.Err() != nil) {
+			return NATSRequestOutput{}, &workflow.WorkflowError{
+				Type:    workflow.ErrorTypeTimeout,
+				Cause:   fmt.Sprintf("no reply on %q within %s", params.Subject, params.Timeout),
+				Wrapped: err,
+			}
+		}
+		return NATSRequestOutput{}, fmt.Errorf("request to %q failed: %w", params.Subject, err)
+	}
+
+	out := NATSRequestOutput{Data: string(reply)}
+	var decoded any
+	if json.Unmarshal(reply, &decoded) == nil {
+		out.JSON = decoded
+	}
+	return out, nil
+}
+
+// natsPayload encodes a data parameter: strings and byte slices as is,
+// nil as an empty payload, and anything else as JSON.
+func natsPayload(data any) ([]byte, error) {
+	switch v := data.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode data as JSON: %w", err)
+	}
+	return encoded, nil
+}
@@ -0,0 +1,85 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow"
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+type recordingPublisher struct {
+	subject string
+	data    []byte
+}
+
+func (p *recordingPublisher) Publish(subject string, data []byte) error {
+	p.subject, p.data = subject, data
+	return nil
+}
+
+func TestNATSPublishActivity(t *testing.T) {
+	conn := &recordingPublisher{}
+	activity := NewNATSPublishActivity(conn)
+	require.Equal(t, "nats.publish", activity.Name())
+
+	result, err := activity.Execute(newTestContext(), map[string]any{
+		"subject": "orders.created",
+		"data":    map[string]any{"id": "ord-1", "qty": 2},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "orders.created", conn.subject)
+	require.Equal(t, `{"id":"ord-1","qty":2}`, string(conn.data))
+	require.Equal(t, NATSPublishOutput{Subject: "orders.created", Bytes: len(conn.data)}, result)
+
+	_, err = activity.Execute(newTestContext(), map[string]any{"subject": "raw", "data": "hello"})
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(conn.data))
+
+	_, err = activity.Execute(newTestContext(), map[string]any{"data": "hello"})
+	require.Error(t, err)
+}
+
+func TestNATSRequestActivity(t *testing.T) {
+	conn := NATSRequestFunc(func(ctx context.Context, subject string, data []byte) ([]byte, error) {
+		switch subject {
+		case "inventory.check":
+			return []byte(`{"sku":"a","available":3}`), nil
+		case "echo":
+			return data, nil
+		case "slow":
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return nil, errors.New("no responders available for request")
+	})
+	activity := NewNATSRequestActivity(conn)
+	require.Equal(t, "nats.request", activity.Name())
+
+	result, err := activity.Execute(newTestContext(), map[string]any{
+		"subject": "inventory.check",
+		"data":    map[string]any{"sku": "a"},
+	})
+	require.NoError(t, err)
+	out := result.(NATSRequestOutput)
+	require.Equal(t, `{"sku":"a","available":3}`, out.Data)
+	require.Equal(t, map[string]any{"sku": "a", "available": float64(3)}, out.JSON)
+
+	result, err = activity.Execute(newTestContext(), map[string]any{"subject": "echo", "data": "plain text"})
+	require.NoError(t, err)
+	require.Equal(t, NATSRequestOutput{Data: "plain text"}, result)
+
+	start := time.Now()
+	_, err = activity.Execute(newTestContext(), map[string]any{"subject": "slow", "timeout": "20ms"})
+	require.LessOrEqual(t, time.Since(start), time.Second)
+	var wErr *workflow.WorkflowError
+	require.True(t, errors.As(err, &wErr))
+	require.Equal(t, workflow.ErrorTypeTimeout, wErr.Type)
+
+	_, err = activity.Execute(newTestContext(), map[string]any{"subject": "nobody"})
+	require.Error(t, err)
+	require.False(t, errors.As(err, &wErr))
+	require.Contains(t, err.Error(), "no responders")
+}
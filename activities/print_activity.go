@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
 
 	"github.com/deepnoodle-ai/workflow"
 )
@@ -16,9 +17,12 @@ type PrintInput struct {
 
 // PrintActivity prints a formatted message to a configurable writer.
 // The default writer is os.Stdout; tests and embedded use cases can
-// pass a different writer via NewPrintActivityTo.
+// pass a different writer via NewPrintActivityTo. Writes are serialized,
+// so a writer that is not safe for concurrent use, such as a
+// bytes.Buffer, may back an activity shared across executions.
 type PrintActivity struct {
-	w io.Writer
+	mu sync.Mutex
+	w  io.Writer
 }
 
 // NewPrintActivity returns a print activity that writes to os.Stdout.
@@ -41,6 +45,8 @@ func (a *PrintActivity) Name() string {
 
 func (a *PrintActivity) Execute(ctx workflow.Context, params PrintInput) (string, error) {
 	message := fmt.Sprintf(params.Message, params.Args...)
+	a.mu.Lock()
 	fmt.Fprintln(a.w, message)
+	a.mu.Unlock()
 	return message, nil
 }
@@ -1,6 +1,9 @@
 package activities
 
 import (
+	"bytes"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/deepnoodle-ai/workflow/internal/require"
@@ -29,3 +32,20 @@ func TestPrintActivity(t *testing.T) {
 		require.Equal(t, "hello curtis, count is 30", result)
 	})
 }
+
+func TestPrintActivityConcurrentWrites(t *testing.T) {
+	var buf bytes.Buffer
+	activity := NewPrintActivityTo(&buf)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := activity.Execute(newTestContext(), map[string]any{"message": "line"})
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, strings.Repeat("line\n", 20), buf.String())
+}
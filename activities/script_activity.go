@@ -0,0 +1,157 @@
+package activities
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/deepnoodle-ai/workflow"
+	"github.com/deepnoodle-ai/workflow/script"
+)
+
+// ScriptInput defines the input parameters for the script activity.
+// Exactly one of Code or File must be set.
+type ScriptInput struct {
+	Code string `json:"code"` // Inline script source
+	File string `json:"file"` // Path to a script file
+}
+
+// ScriptActivity evaluates a script with the execution's configured
+// script.Compiler. The script sees two globals, "inputs" and "state";
+// state is a copy of the branch variables, and any keys the script
+// adds, changes, or removes are written back to the branch when it
+// returns. Whether a script can mutate state at all depends on the
+// compiler: the default expr compiler is expression-only, so consumers
+// who want imperative scripts plug in an engine such as Risor.
+//
+// Scripts loaded from a file are compiled once and cached by compiler
+// and path, so a ScriptActivity may be shared across concurrent
+// executions, including ones configured with different compilers.
+// Compilers whose dynamic type is not comparable are not cached and
+// recompile the file on every call.
+type ScriptActivity struct {
+	fsys  fs.FS
+	mu    sync.Mutex
+	cache map[scriptCacheKey]script.Script
+}
+
+// scriptCacheKey identifies a compiled script file.
+type scriptCacheKey struct {
+	compiler script.Compiler
+	path     string
+}
+
+// NewScriptActivity creates a script activity. File paths are resolved
+// against fsys, which allows scripts to be embedded with embed.FS. A
+// nil fsys reads files from the local filesystem.
+func NewScriptActivity(fsys fs.FS) workflow.Activity {
+	return workflow.NewTypedActivity(&ScriptActivity{
+		fsys:  fsys,
+		cache: map[scriptCacheKey]script.Script{},
+	})
+}
+
+// Name returns the activity name
+func (a *ScriptActivity) Name() string {
+	return "script"
+}
+
+// Execute compiles and evaluates the script, applies its state changes,
+// and returns the value the script evaluated to.
+func (a *ScriptActivity) Execute(ctx workflow.Context, params ScriptInput) (any, error) {
+	if params.Code != "" && params.File != "" {
+		return nil, fmt.Errorf("script activity accepts either 'code' or 'file', not both")
+	}
+	if params.Code == "" && params.File == "" {
+		return nil, fmt.Errorf("script activity requires 'code' or 'file' parameter")
+	}
+	compiler := ctx.Compiler()
+	if compiler == nil {
+		return nil, fmt.Errorf("script activity requires a script compiler")
+	}
+
+	var compiled script.Script
+	var err error
+	if params.File != "" {
+		compiled, err = a.compileFile(ctx, compiler, params.File)
+	} else {
+		compiled, err = compiler.Compile(ctx, params.Code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Normalize numbers the same way the engine does for conditions, so
+	// an int64 stored by one activity compares cleanly with an int
+	// literal. Normalized values that the script leaves alone compare
+	// equal below and are not written back.
+	original := make(map[string]any)
+	state := make(map[string]any)
+	for _, key := range ctx.Keys() {
+		value, _ := ctx.Get(key)
+		value = script.NormalizeValue(value)
+		original[key] = value
+		state[key] = value
+	}
+	globals := map[string]any{
+		"inputs": script.NormalizeMap(ctx.Inputs().ToMap()),
+		"state":  state,
+	}
+
+	result, err := compiled.Evaluate(ctx, globals)
+	if err != nil {
+		return nil, fmt.Errorf("script evaluation failed: %w", err)
+	}
+
+	for key, value := range state {
+		if prev, ok := original[key]; !ok || !reflect.DeepEqual(prev, value) {
+			ctx.Set(key, value)
+		}
+	}
+	for key := range original {
+		if _, ok := state[key]; !ok {
+			ctx.Delete(key)
+		}
+	}
+
+	if result == nil {
+		return nil, nil
+	}
+	return result.Value(), nil
+}
+
+// compileFile returns the cached compiled script for path, loading and
+// compiling it on first use.
+func (a *ScriptActivity) compileFile(ctx workflow.Context, compiler script.Compiler, path string) (script.Script, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// A non-comparable compiler would panic as a map key.
+	cacheable := reflect.ValueOf(compiler).Comparable()
+	key := scriptCacheKey{compiler: compiler, path: path}
+	if cacheable {
+		if compiled, ok := a.cache[key]; ok {
+			return compiled, nil
+		}
+	}
+	var source []byte
+	var err error
+	if a.fsys != nil {
+		source, err = fs.ReadFile(a.fsys, path)
+	} else {
+		source, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script file %q: %w", path, err)
+	}
+	compiled, err := compiler.Compile(ctx, string(source))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile script file %q: %w", path, err)
+	}
+	if cacheable {
+		a.cache[key] = compiled
+	}
+	return compiled, nil
+}
@@ -0,0 +1,225 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/deepnoodle-ai/workflow"
+	"github.com/deepnoodle-ai/workflow/internal/require"
+	"github.com/deepnoodle-ai/workflow/script"
+)
+
+// assignCompiler is a stand-in for a state-mutating engine. Each line of
+// a script is either "name = <int>", "name += <int>", or "delete name".
+// Evaluation returns the number of statements executed.
+type assignCompiler struct{ compiles *int }
+
+func (c assignCompiler) Compile(ctx context.Context, code string) (script.Script, error) {
+	if c.compiles != nil {
+		*c.compiles++
+	}
+	var stmts [][]string
+	for _, line := range strings.Split(code, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 && len(fields) != 3 {
+			return nil, fmt.Errorf("invalid statement %q", line)
+		}
+		stmts = append(stmts, fields)
+	}
+	return assignScript(stmts), nil
+}
+
+type assignScript [][]string
+
+func (s assignScript) Evaluate(ctx context.Context, globals map[string]any) (script.Value, error) {
+	state := globals["state"].(map[string]any)
+	for _, stmt := range s {
+		if stmt[0] == "delete" {
+			delete(state, stmt[1])
+			continue
+		}
+		n, err := strconv.Atoi(stmt[2])
+		if err != nil {
+			return nil, err
+		}
+		switch stmt[1] {
+		case "=":
+			state[stmt[0]] = n
+		case "+=":
+			current, _ := state[stmt[0]].(int)
+			state[stmt[0]] = current + n
+		default:
+			return nil, fmt.Errorf("unknown operator %q", stmt[1])
+		}
+	}
+	return assignValue(len(s)), nil
+}
+
+type assignValue int
+
+func (v assignValue) Value() any            { return int(v) }
+func (v assignValue) Items() ([]any, error) { return nil, fmt.Errorf("not iterable") }
+func (v assignValue) String() string        { return strconv.Itoa(int(v)) }
+func (v assignValue) IsTruthy() bool        { return v != 0 }
+
+func newScriptTestContext(compiler script.Compiler, vars map[string]any) workflow.Context {
+	return workflow.NewContext(context.Background(), workflow.ExecutionContextOptions{
+		BranchLocalState: workflow.NewBranchLocalState(map[string]any{}, vars),
+		Compiler:         compiler,
+	})
+}
+
+func TestScriptActivityFileMutatesState(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "update.script")
+	require.NoError(t, os.WriteFile(path, []byte("counter += 2\ntotal = 10\ndelete scratch\n"), 0o644))
+
+	compiles := 0
+	ctx := newScriptTestContext(assignCompiler{compiles: &compiles}, map[string]any{
+		"counter": 1,
+		"scratch": "tmp",
+	})
+	activity := NewScriptActivity(nil)
+	require.Equal(t, "script", activity.Name())
+
+	result, err := activity.Execute(ctx, map[string]any{"file": path})
+	require.NoError(t, err)
+	require.Equal(t, 3, result)
+
+	counter, _ := ctx.Get("counter")
+	require.Equal(t, 3, counter)
+	total, _ := ctx.Get("total")
+	require.Equal(t, 10, total)
+	_, exists := ctx.Get("scratch")
+	require.False(t, exists)
+
+	// A second run reuses the cached compiled script.
+	_, err = activity.Execute(ctx, map[string]any{"file": path})
+	require.NoError(t, err)
+	counter, _ = ctx.Get("counter")
+	require.Equal(t, 5, counter)
+	require.Equal(t, 1, compiles)
+}
+
+func TestScriptActivityEmbeddedFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"scripts/init.script": &fstest.MapFile{Data: []byte("ready = 1")},
+	}
+	ctx := newScriptTestContext(assignCompiler{}, map[string]any{})
+	activity := NewScriptActivity(fsys)
+
+	_, err := activity.Execute(ctx, map[string]any{"file": "scripts/init.script"})
+	require.NoError(t, err)
+	ready, _ := ctx.Get("ready")
+	require.Equal(t, 1, ready)
+
+	_, err = activity.Execute(ctx, map[string]any{"file": "scripts/missing.script"})
+	require.Error(t, err)
+}
+
+func TestScriptActivityInlineCode(t *testing.T) {
+	ctx := newScriptTestContext(assignCompiler{}, map[string]any{})
+	activity := NewScriptActivity(nil)
+
+	result, err := activity.Execute(ctx, map[string]any{"code": "x = 4"})
+	require.NoError(t, err)
+	require.Equal(t, 1, result)
+	x, _ := ctx.Get("x")
+	require.Equal(t, 4, x)
+}
+
+func TestScriptActivityParamValidation(t *testing.T) {
+	ctx := newScriptTestContext(assignCompiler{}, map[string]any{})
+	activity := NewScriptActivity(nil)
+
+	_, err := activity.Execute(ctx, map[string]any{"code": "x = 1", "file": "a.script"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not both")
+
+	_, err = activity.Execute(ctx, map[string]any{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "requires 'code' or 'file'")
+}
+
+func TestScriptActivityCachesPerCompiler(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "init.script")
+	require.NoError(t, os.WriteFile(path, []byte("ready = 1"), 0o644))
+
+	// One activity shared by executions configured with different compilers.
+	activity := NewScriptActivity(nil)
+	first, second := 0, 0
+	for range 3 {
+		for _, compiler := range []script.Compiler{assignCompiler{compiles: &first}, assignCompiler{compiles: &second}} {
+			_, err := activity.Execute(newScriptTestContext(compiler, map[string]any{}), map[string]any{"file": path})
+			require.NoError(t, err)
+		}
+	}
+	require.Equal(t, 1, first)
+	require.Equal(t, 1, second)
+}
+
+type variableRecorder struct {
+	workflow.BaseExecutionCallbacks
+	events []*workflow.VariableChangeEvent
+}
+
+func (r *variableRecorder) OnVariableChanged(ctx context.Context, event *workflow.VariableChangeEvent) {
+	r.events = append(r.events, event)
+}
+
+func TestScriptActivityReportsVariableChanges(t *testing.T) {
+	wf, err := workflow.New(workflow.Options{
+		Name:  "audited",
+		State: map[string]any{"count": 1, "old": 2, "kept": 3},
+		Steps: []*workflow.Step{{
+			Name:       "update",
+			Activity:   "script",
+			Parameters: map[string]any{"code": "count += 1\nlabel = 5\ndelete old"},
+			Store:      "statements",
+		}},
+	})
+	require.NoError(t, err)
+	reg := workflow.NewActivityRegistry()
+	reg.MustRegister(NewScriptActivity(nil))
+
+	recorder := &variableRecorder{}
+	exec, err := workflow.NewExecution(wf, reg,
+		workflow.WithScriptCompiler(assignCompiler{}),
+		workflow.WithExecutionCallbacks(recorder))
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, workflow.ExecutionStatusCompleted, result.Status)
+
+	// The script's changes come first, in no particular order, then
+	// the step's Store.
+	require.Len(t, recorder.events, 4)
+	changes := map[string]workflow.VariableChangeEvent{}
+	for _, event := range recorder.events[:3] {
+		require.Equal(t, "main", event.BranchID)
+		require.Equal(t, "update", event.StepName)
+		require.Equal(t, exec.ID(), event.ExecutionID)
+		changes[event.Variable] = *event
+	}
+	require.Equal(t, 1, changes["count"].OldValue)
+	require.Equal(t, 2, changes["count"].NewValue)
+	require.Nil(t, changes["label"].OldValue)
+	require.Equal(t, 5, changes["label"].NewValue)
+	require.True(t, changes["old"].Deleted)
+	require.Equal(t, 2, changes["old"].OldValue)
+
+	stored := recorder.events[3]
+	require.Equal(t, "statements", stored.Variable)
+	require.Equal(t, 3, stored.NewValue)
+	require.False(t, stored.Deleted)
+}
@@ -0,0 +1,77 @@
+package activities
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	texttemplate "text/template"
+
+	"github.com/deepnoodle-ai/workflow"
+)
+
+// TemplateInput defines the input parameters for the template activity
+type TemplateInput struct {
+	Template string         `json:"template"` // template source
+	File     string         `json:"file"`     // path to a template file, used when template is empty
+	Data     map[string]any `json:"data"`     // data passed to the template as "."
+	HTML     bool           `json:"html"`     // render with html/template for contextual escaping
+	Delims   []string       `json:"delims"`   // optional [left, right] action delimiters
+}
+
+// TemplateActivity renders Go text/template or html/template documents.
+// It complements ${...} parameter interpolation for multi-line content
+// such as emails and reports.
+type TemplateActivity struct{}
+
+func NewTemplateActivity() workflow.Activity {
+	return workflow.NewTypedActivity(&TemplateActivity{})
+}
+
+func (a *TemplateActivity) Name() string {
+	return "template"
+}
+
+func (a *TemplateActivity) Execute(ctx workflow.Context, params TemplateInput) (any, error) {
+	source := params.Template
+	name := "template"
+	if source == "" {
+		if params.File == "" {
+			return nil, fmt.Errorf("template or file must be set")
+		}
+		content, err := os.ReadFile(params.File)
+		if err != nil {
+			return nil, err
+		}
+		source = string(content)
+		name = params.File
+	}
+
+	var left, right string
+	if len(params.Delims) > 0 {
+		if len(params.Delims) != 2 || params.Delims[0] == "" || params.Delims[1] == "" {
+			return nil, fmt.Errorf("delims must be a pair of non-empty strings")
+		}
+		left, right = params.Delims[0], params.Delims[1]
+	}
+
+	var buf bytes.Buffer
+	if params.HTML {
+		tmpl, err := htmltemplate.New(name).Delims(left, right).Option("missingkey=error").Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template: %w", err)
+		}
+		if err := tmpl.Execute(&buf, params.Data); err != nil {
+			return nil, fmt.Errorf("failed to render template: %w", err)
+		}
+		return buf.String(), nil
+	}
+	tmpl, err := texttemplate.New(name).Delims(left, right).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	if err := tmpl.Execute(&buf, params.Data); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,84 @@
+package activities
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestTemplateActivity(t *testing.T) {
+	activity := NewTemplateActivity()
+	require.Equal(t, "template", activity.Name())
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "email.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte("Dear {{.name}},\n{{range .items}}- {{.}}\n{{end}}"), 0644))
+
+	tests := []struct {
+		name    string
+		params  map[string]any
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "text",
+			params: map[string]any{"template": "Hello {{.name}}!", "data": map[string]any{"name": "Ada"}},
+			want:   "Hello Ada!",
+		},
+		{
+			name:   "file",
+			params: map[string]any{"file": path, "data": map[string]any{"name": "Ada", "items": []any{"a", "b"}}},
+			want:   "Dear Ada,\n- a\n- b\n",
+		},
+		{
+			name:   "html escapes",
+			params: map[string]any{"template": "<p>{{.msg}}</p>", "html": true, "data": map[string]any{"msg": "<b>hi</b>"}},
+			want:   "<p>&lt;b&gt;hi&lt;/b&gt;</p>",
+		},
+		{
+			name:   "text does not escape",
+			params: map[string]any{"template": "<p>{{.msg}}</p>", "data": map[string]any{"msg": "<b>hi</b>"}},
+			want:   "<p><b>hi</b></p>",
+		},
+		{
+			name:   "custom delims",
+			params: map[string]any{"template": "[[.name]] {{literal}}", "delims": []any{"[[", "]]"}, "data": map[string]any{"name": "Ada"}},
+			want:   "Ada {{literal}}",
+		},
+		{
+			name:    "missing source",
+			params:  map[string]any{},
+			wantErr: "template or file must be set",
+		},
+		{
+			name:    "bad delims",
+			params:  map[string]any{"template": "x", "delims": []any{"[["}},
+			wantErr: "delims must be a pair",
+		},
+		{
+			name:    "missing key",
+			params:  map[string]any{"template": "{{.nope}}", "data": map[string]any{}},
+			wantErr: "failed to render template",
+		},
+		{
+			name:    "parse error",
+			params:  map[string]any{"template": "{{.name"},
+			wantErr: "failed to parse template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := activity.Execute(newTestContext(), tt.params)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, result)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package activities
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow"
+)
+
+// WaitInput defines the input parameters for the wait activity. Set
+// either Duration or Until.
+type WaitInput struct {
+	Duration time.Duration `json:"duration"` // "2s" or nanoseconds
+	Until    time.Time     `json:"until"`    // RFC3339 timestamp
+}
+
+// UnmarshalJSON accepts the duration as a duration string such as "2s"
+// as well as a number of nanoseconds.
+func (in *WaitInput) UnmarshalJSON(data []byte) error {
+	type plain WaitInput
+	aux := struct {
+		*plain
+		Duration any `json:"duration"`
+	}{plain: (*plain)(in)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	switch v := aux.Duration.(type) {
+	case nil:
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", v, err)
+		}
+		in.Duration = d
+	case float64:
+		in.Duration = time.Duration(v)
+	default:
+		return fmt.Errorf("invalid duration: expected a duration string or nanoseconds, got %T", v)
+	}
+	return nil
+}
+
+// WaitOutput reports a completed wait.
+type WaitOutput struct {
+	Waited time.Duration `json:"waited"`
+	Until  time.Time     `json:"until"`
+}
+
+// WaitActivity pauses the step for a duration or until a point in time.
+type WaitActivity struct{}
+
+// NewWaitActivity returns the wait activity, registered as "wait". If
+// the execution is cancelled or times out first, the wait stops at once
+// and fails with workflow.ErrorTypeTimeout.
+//
+// The wait holds the activity for its whole length and does not survive
+// a restart; use a step's Sleep for long, durable waits.
+func NewWaitActivity() workflow.Activity {
+	return workflow.NewTypedActivity(&WaitActivity{})
+}
+
+func (a *WaitActivity) Name() string {
+	return "wait"
+}
+
+// ParamSchema declares the parameters of WaitInput.
+func (a *WaitActivity) ParamSchema() map[string]workflow.ParamSpec {
+	return map[string]workflow.ParamSpec{
+		"duration": {Type: workflow.InputTypeDuration, Description: "Duration string such as \"2s\" or nanoseconds"},
+		"until":    {Type: workflow.InputTypeTimestamp, Description: "RFC3339 timestamp to wait until"},
+	}
+}
+
+func (a *WaitActivity) Execute(ctx workflow.Context, params WaitInput) (WaitOutput, error) {
+	if params.Duration != 0 && !params.Until.IsZero() {
+		return WaitOutput{}, fmt.Errorf("duration and until cannot both be set")
+	}
+	if params.Duration < 0 {
+		return WaitOutput{}, fmt.Errorf("duration cannot be negative, got %s", params.Duration)
+	}
+	start := time.Now()
+	until := params.Until
+	if until.IsZero() {
+		until = start.Add(params.Duration)
+	}
+	wait := until.Sub(start)
+	if wait <= 0 {
+		return WaitOutput{Until: until}, nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return WaitOutput{Waited: time.Since(start), Until: until}, nil
+	case <-ctx.Done():
+		return WaitOutput{}, &workflow.WorkflowError{
+			Type:    workflow.ErrorTypeTimeout,
+			Cause:   fmt.Sprintf("wait until %s interrupted after %s", until.Format(time.RFC3339), time.Since(start).Round(time.Millisecond)),
+			Wrapped: ctx.Err(),
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow"
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestWaitActivity(t *testing.T) {
+	activity := NewWaitActivity()
+	require.Equal(t, "wait", activity.Name())
+
+	t.Run("duration", func(t *testing.T) {
+		result, err := activity.Execute(newTestContext(), map[string]any{"duration": "20ms"})
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, result.(WaitOutput).Waited, 20*time.Millisecond)
+	})
+
+	t.Run("until", func(t *testing.T) {
+		until := time.Now().Add(20 * time.Millisecond)
+		result, err := activity.Execute(newTestContext(), map[string]any{"until": until.Format(time.RFC3339Nano)})
+		require.NoError(t, err)
+		require.False(t, time.Now().Before(until))
+		require.True(t, result.(WaitOutput).Until.Equal(until))
+	})
+
+	t.Run("until in the past", func(t *testing.T) {
+		result, err := activity.Execute(newTestContext(), map[string]any{"until": "2020-01-01T00:00:00Z"})
+		require.NoError(t, err)
+		require.Equal(t, time.Duration(0), result.(WaitOutput).Waited)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := workflow.WithCancel(newTestContext())
+		time.AfterFunc(20*time.Millisecond, cancel)
+		start := time.Now()
+		_, err := activity.Execute(ctx, map[string]any{"duration": "1m"})
+		require.LessOrEqual(t, time.Since(start), time.Second)
+		var wErr *workflow.WorkflowError
+		require.True(t, errors.As(err, &wErr))
+		require.Equal(t, workflow.ErrorTypeTimeout, wErr.Type)
+		require.True(t, errors.Is(err, context.Canceled))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := activity.Execute(newTestContext(), map[string]any{"duration": "1s", "until": "2030-01-01T00:00:00Z"})
+		require.Error(t, err)
+		_, err = activity.Execute(newTestContext(), map[string]any{"until": "tomorrow"})
+		require.Error(t, err)
+	})
+}
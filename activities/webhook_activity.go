@@ -0,0 +1,275 @@
+package activities
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow"
+)
+
+// webhookTopicPrefix starts the signal topic of every webhook callback.
+// The rest of the topic is the callback ID.
+const webhookTopicPrefix = "webhook:"
+
+// DefaultWebhookMaxBodyBytes caps the body a WebhookServer accepts.
+const DefaultWebhookMaxBodyBytes = 1 << 20
+
+// WebhookServer receives the inbound calls for the webhook.wait
+// activity and delivers each one as a signal to the execution that
+// allocated its URL. Mount it on an HTTP server at the path of its base
+// URL:
+//
+//	hooks := activities.NewWebhookServer("https://api.example.com/hooks", secret, signals)
+//	mux.Handle("/hooks/", hooks)
+//
+// Callback URLs look like <base>/<execution id>/<callback id>.<signature>,
+// where the signature is an HMAC of the execution and callback IDs.
+// The server keeps no state of its own: any URL it signed stays valid
+// across restarts as long as the secret does, and forged or mistyped
+// URLs are rejected with 404 before they reach the SignalStore.
+type WebhookServer struct {
+	base         *url.URL
+	secret       []byte
+	signals      workflow.SignalStore
+	maxBodyBytes int64
+}
+
+// NewWebhookServer returns a WebhookServer that builds callback URLs
+// under baseURL, signs them with secret, and delivers calls to signals,
+// which must be the SignalStore the executions are created with. An
+// empty secret is replaced by a random one, so URLs handed out by one
+// process are not accepted by the next.
+func NewWebhookServer(baseURL string, secret []byte, signals workflow.SignalStore) (*WebhookServer, error) {
+	base, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url %q: %w", baseURL, err)
+	}
+	if base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid base url %q: scheme and host are required", baseURL)
+	}
+	if signals == nil {
+		return nil, fmt.Errorf("signal store cannot be nil")
+	}
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("failed to generate secret: %w", err)
+		}
+	}
+	return &WebhookServer{
+		base:         base,
+		secret:       secret,
+		signals:      signals,
+		maxBodyBytes: DefaultWebhookMaxBodyBytes,
+	}, nil
+}
+
+// CallbackURL returns the URL that delivers to a webhook.wait step of
+// the given execution suspended on topic, as listed by
+// ExecutionResult.Topics. It returns false for topics that do not
+// belong to a webhook.wait step.
+func (s *WebhookServer) CallbackURL(executionID, topic string) (string, bool) {
+	id, ok := strings.CutPrefix(topic, webhookTopicPrefix)
+	if !ok || id == "" {
+		return "", false
+	}
+	return s.callbackURL(executionID, id), true
+}
+
+func (s *WebhookServer) callbackURL(executionID, id string) string {
+	return s.base.JoinPath(executionID, id+"."+s.sign(executionID, id)).String()
+}
+
+func (s *WebhookServer) sign(executionID, id string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(executionID + "\x00" + id))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// ServeHTTP accepts a POST or PUT to a callback URL, sends its body to
+// the waiting execution, and answers 202 Accepted. A call that arrives
+// before the step suspends is kept in the SignalStore until it does.
+func (s *WebhookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		w.Header().Set("Allow", "POST, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	rest, ok := strings.CutPrefix(r.URL.Path, s.base.Path+"/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	executionID, token, ok := strings.Cut(rest, "/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	id, signature, ok := strings.Cut(token, ".")
+	if !ok || executionID == "" || id == "" ||
+		!hmac.Equal([]byte(signature), []byte(s.sign(executionID, id))) {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.maxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	payload := map[string]any{
+		"body":         string(body),
+		"content_type": r.Header.Get("Content-Type"),
+		"received_at":  time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if err := s.signals.Send(r.Context(), executionID, webhookTopicPrefix+id, payload); err != nil {
+		http.Error(w, "failed to deliver callback", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// WebhookWaitInput defines the input parameters for the webhook.wait
+// activity.
+type WebhookWaitInput struct {
+	Timeout time.Duration `json:"timeout"` // "24h" or nanoseconds
+}
+
+// UnmarshalJSON accepts the timeout as a duration string such as "24h"
+// as well as a number of nanoseconds.
+func (in *WebhookWaitInput) UnmarshalJSON(data []byte) error {
+	type plain WebhookWaitInput
+	aux := struct {
+		*plain
+		Timeout any `json:"timeout"`
+	}{plain: (*plain)(in)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	switch v := aux.Timeout.(type) {
+	case nil:
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %q: %w", v, err)
+		}
+		in.Timeout = d
+	case float64:
+		in.Timeout = time.Duration(v)
+	default:
+		return fmt.Errorf("invalid timeout: expected a duration string or nanoseconds, got %T", v)
+	}
+	return nil
+}
+
+// WebhookWaitOutput holds the inbound call that ended the wait.
+type WebhookWaitOutput struct {
+	URL         string    `json:"url"`
+	Body        string    `json:"body"`
+	JSON        any       `json:"json,omitempty"` // the body decoded, when it is valid JSON
+	ContentType string    `json:"content_type,omitempty"`
+	ReceivedAt  time.Time `json:"received_at"`
+}
+
+// WebhookWaitActivity suspends a step until its callback URL is called.
+type WebhookWaitActivity struct {
+	server *WebhookServer
+}
+
+// NewWebhookWaitActivity returns the webhook wait activity, registered
+// as "webhook.wait". The step allocates a callback URL on server, logs
+// it, and waits on it with Context.Wait: when no call has arrived yet
+// the execution suspends, and it carries on once the host resumes it
+// after the call. The callback ID is kept in the step's activity
+// history, so the URL stays the same across resumes.
+//
+// A host learns the URL to hand out from the suspended result:
+//
+//	for _, topic := range result.Topics() {
+//		if u, ok := hooks.CallbackURL(exec.ID(), topic); ok {
+//			// send u to the external system
+//		}
+//	}
+//
+// When no call arrives within the timeout, the step fails with
+// workflow.ErrorTypeTimeout.
+func NewWebhookWaitActivity(server *WebhookServer) workflow.Activity {
+	return workflow.NewTypedActivity(&WebhookWaitActivity{server: server})
+}
+
+func (a *WebhookWaitActivity) Name() string {
+	return "webhook.wait"
+}
+
+// ParamSchema declares the parameters of WebhookWaitInput.
+func (a *WebhookWaitActivity) ParamSchema() map[string]workflow.ParamSpec {
+	return map[string]workflow.ParamSpec{
+		"timeout": {Type: workflow.InputTypeDuration, Required: true, Description: "How long to wait for the callback, as a duration string such as \"24h\" or nanoseconds"},
+	}
+}
+
+func (a *WebhookWaitActivity) Execute(ctx workflow.Context, params WebhookWaitInput) (WebhookWaitOutput, error) {
+	if params.Timeout <= 0 {
+		return WebhookWaitOutput{}, fmt.Errorf("timeout must be positive, got %s", params.Timeout)
+	}
+	executionID := workflow.ExecutionID(ctx)
+	if executionID == "" {
+		return WebhookWaitOutput{}, fmt.Errorf("webhook.wait must run inside a workflow execution")
+	}
+	recorded, err := ctx.History().RecordOrReplay("webhook_callback_id", func() (any, error) {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("failed to generate callback id: %w", err)
+		}
+		return hex.EncodeToString(b), nil
+	})
+	if err != nil {
+		return WebhookWaitOutput{}, err
+	}
+	id, _ := recorded.(string)
+	output := WebhookWaitOutput{URL: a.server.callbackURL(executionID, id)}
+
+	if workflow.IsDryRun(ctx) {
+		if logger := ctx.Logger(); logger != nil {
+			logger.Info("dry run: skipping webhook wait", "url", output.URL)
+		}
+		return output, nil
+	}
+	if logger := ctx.Logger(); logger != nil {
+		logger.Info("waiting for webhook", "url", output.URL, "timeout", params.Timeout)
+	}
+
+	received, err := ctx.Wait(webhookTopicPrefix+id, params.Timeout)
+	if err != nil {
+		return output, err
+	}
+	payload, ok := received.(map[string]any)
+	if !ok {
+		return output, fmt.Errorf("unexpected webhook payload of type %T", received)
+	}
+	output.Body, _ = payload["body"].(string)
+	output.ContentType, _ = payload["content_type"].(string)
+	if ts, ok := payload["received_at"].(string); ok {
+		output.ReceivedAt, _ = time.Parse(time.RFC3339Nano, ts)
+	}
+	var decoded any
+	if json.Unmarshal([]byte(output.Body), &decoded) == nil {
+		output.JSON = decoded
+	}
+	return output, nil
+}
@@ -0,0 +1,103 @@
+package activities
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow"
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestWebhookWaitActivity(t *testing.T) {
+	signals := workflow.NewMemorySignalStore()
+	hooks, err := NewWebhookServer("https://hooks.example.com/callbacks", []byte("secret"), signals)
+	require.NoError(t, err)
+
+	wf, err := workflow.New(workflow.Options{
+		Name: "await-callback",
+		Steps: []*workflow.Step{{
+			Name:       "await",
+			Activity:   "webhook.wait",
+			Parameters: map[string]any{"timeout": "1h"},
+			Store:      "callback",
+		}},
+		Outputs: []*workflow.Output{{Name: "callback", Variable: "callback"}},
+	})
+	require.NoError(t, err)
+	reg := workflow.NewActivityRegistry()
+	reg.MustRegister(NewWebhookWaitActivity(hooks))
+	cp, err := workflow.NewFileCheckpointer(t.TempDir())
+	require.NoError(t, err)
+
+	newExecution := func(opts ...workflow.ExecutionOption) *workflow.Execution {
+		opts = append(opts, workflow.WithCheckpointer(cp), workflow.WithSignalStore(signals))
+		exec, err := workflow.NewExecution(wf, reg, opts...)
+		require.NoError(t, err)
+		return exec
+	}
+	post := func(target, body string) int {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		hooks.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	ctx := context.Background()
+	runner := workflow.NewRunner()
+	exec1 := newExecution()
+	first, err := runner.Run(ctx, exec1)
+	require.NoError(t, err)
+	require.True(t, first.Suspended())
+	require.Len(t, first.Topics(), 1)
+
+	callbackURL, ok := hooks.CallbackURL(exec1.ID(), first.Topics()[0])
+	require.True(t, ok)
+	require.True(t, strings.HasPrefix(callbackURL, "https://hooks.example.com/callbacks/"+exec1.ID()+"/"))
+
+	// A URL with a bad signature or another execution's ID is rejected.
+	require.Equal(t, http.StatusNotFound, post(callbackURL+"0", `{}`))
+	require.Equal(t, http.StatusNotFound, post(strings.Replace(callbackURL, exec1.ID(), "other", 1), `{}`))
+	require.Equal(t, http.StatusAccepted, post(callbackURL, `{"approved": true}`))
+
+	second, err := runner.Run(ctx, newExecution(workflow.WithExecutionID(exec1.ID())), workflow.WithResumeFrom(exec1.ID()))
+	require.NoError(t, err)
+	require.Equal(t, workflow.ExecutionStatusCompleted, second.Status)
+	out, ok := second.Outputs["callback"].(WebhookWaitOutput)
+	require.True(t, ok, "output %T", second.Outputs["callback"])
+	require.Equal(t, callbackURL, out.URL)
+	require.Equal(t, `{"approved": true}`, out.Body)
+	require.Equal(t, map[string]any{"approved": true}, out.JSON)
+	require.Equal(t, "application/json", out.ContentType)
+	require.False(t, out.ReceivedAt.IsZero())
+}
+
+func TestWebhookServerRejections(t *testing.T) {
+	_, err := NewWebhookServer("/callbacks", nil, workflow.NewMemorySignalStore())
+	require.Error(t, err)
+	_, err = NewWebhookServer("https://hooks.example.com", nil, nil)
+	require.Error(t, err)
+
+	hooks, err := NewWebhookServer("https://hooks.example.com/callbacks", nil, workflow.NewMemorySignalStore())
+	require.NoError(t, err)
+	_, ok := hooks.CallbackURL("exec", "approval")
+	require.False(t, ok)
+	callbackURL, ok := hooks.CallbackURL("exec", "webhook:abc")
+	require.True(t, ok)
+
+	rec := httptest.NewRecorder()
+	hooks.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, callbackURL, nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	large := strings.NewReader(strings.Repeat("x", DefaultWebhookMaxBodyBytes+1))
+	hooks.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, callbackURL, large))
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	_, err = NewWebhookWaitActivity(hooks).Execute(newTestContext(), map[string]any{"timeout": "1h"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "inside a workflow execution")
+}
@@ -3,12 +3,19 @@ package workflow
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
 )
 
 // ExecuteActivityFunc is the signature for an Activity execution function.
 type ExecuteActivityFunc func(ctx Context, parameters map[string]any) (any, error)
 
 // Activity represents an action that can be executed as part of a workflow.
+//
+// A single Activity value is called concurrently: by parallel branches of
+// one execution, and by every execution that shares its ActivityRegistry.
+// Execute must therefore be safe for concurrent use. Keep per-call data in
+// locals, and share long-lived resources such as a *sql.DB or an
+// *http.Client that are themselves safe for concurrent use.
 type Activity interface {
 
 	// Name returns the name of the Activity
@@ -18,6 +25,28 @@ type Activity interface {
 	Execute(ctx Context, parameters map[string]any) (any, error)
 }
 
+// ParamSpec describes one parameter accepted by an activity. Type uses
+// the Input type names (InputTypeString, InputTypeInt, ...); an empty
+// Type accepts any value.
+type ParamSpec struct {
+	Type        string `json:"type,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// ActivityWithParamSchema is implemented by activities that declare the
+// parameters they accept. NewExecution then checks each step using the
+// activity: required parameters must be present, unknown parameters are
+// rejected, and literal values must match the declared Type. Values
+// containing ${...} templates are only checked for presence, since
+// their type is known only at run time.
+//
+// A nil schema means the activity declares none and is not checked.
+type ActivityWithParamSchema interface {
+	Activity
+	ParamSchema() map[string]ParamSpec
+}
+
 // TypedActivity is a parameterized interface for activities that assists with
 // marshalling parameters and results.
 type TypedActivity[TParams, TResult any] interface {
@@ -41,9 +70,11 @@ func (a *TypedActivityAdapter[TParams, TResult]) Name() string {
 
 // Execute the Activity.
 func (a *TypedActivityAdapter[TParams, TResult]) Execute(ctx Context, parameters map[string]any) (any, error) {
-	// Convert parameters to typed struct via JSON marshalling
+	// Convert parameters to typed struct via JSON marshalling. Struct
+	// fields are matched using their `json` tags, or `workflow` tags
+	// when present.
 	var typedParams TParams
-	jsonBytes, err := json.Marshal(parameters)
+	jsonBytes, err := json.Marshal(remapWorkflowTags(reflect.TypeOf(&typedParams).Elem(), parameters))
 	if err != nil {
 		return nil, fmt.Errorf("invalid parameters for activity %q: %w", a.Name(), err)
 	}
@@ -53,6 +84,23 @@ func (a *TypedActivityAdapter[TParams, TResult]) Execute(ctx Context, parameters
 	return a.activity.Execute(ctx, typedParams)
 }
 
+// ParamSchema returns the underlying activity's schema when it declares
+// one with a ParamSchema method, and nil otherwise.
+func (a *TypedActivityAdapter[TParams, TResult]) ParamSchema() map[string]ParamSpec {
+	if s, ok := a.activity.(interface {
+		ParamSchema() map[string]ParamSpec
+	}); ok {
+		return s.ParamSchema()
+	}
+	return nil
+}
+
+// ResultType returns TResult, the type of every result the activity
+// produces.
+func (a *TypedActivityAdapter[TParams, TResult]) ResultType() reflect.Type {
+	return reflect.TypeOf((*TResult)(nil)).Elem()
+}
+
 // Activity returns the underlying TypedActivity
 func (a *TypedActivityAdapter[TParams, TResult]) Activity() TypedActivity[TParams, TResult] {
 	return a.activity
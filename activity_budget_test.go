@@ -0,0 +1,150 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func newLoopingWorkflow(t *testing.T) *Workflow {
+	t.Helper()
+	wf, err := New(Options{
+		Name:  "runaway-loop",
+		State: map[string]any{"n": 0},
+		Steps: []*Step{
+			{
+				Name:     "call",
+				Activity: "increment",
+				Retry:    []*RetryConfig{{ErrorEquals: []string{ErrorTypeAll}, MaxRetries: 3}},
+				Catch:    []*CatchConfig{{ErrorEquals: []string{ErrorTypeAll}, Next: "recover"}},
+				Next:     []*Edge{{Step: "call", Condition: "state.n < 1000"}},
+			},
+			{Name: "recover", Activity: "increment"},
+		},
+	})
+	require.NoError(t, err)
+	return wf
+}
+
+func newIncrementRegistry(calls *int) *ActivityRegistry {
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("increment", func(ctx Context, params map[string]any) (any, error) {
+		*calls++
+		n, _ := ctx.Get("n")
+		ctx.Set("n", n.(int)+1)
+		return nil, nil
+	}))
+	return reg
+}
+
+func TestMaxActivityInvocations(t *testing.T) {
+	calls := 0
+	exec, err := NewExecution(newLoopingWorkflow(t), newIncrementRegistry(&calls),
+		WithScriptCompiler(newTestCompiler()),
+		WithMaxActivityInvocations(5),
+	)
+	require.NoError(t, err)
+
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusFailed, result.Status)
+	require.Equal(t, 5, calls, "retry and catch must not run past the budget")
+	require.Equal(t, 5, exec.state.GetActivityInvocations())
+	require.NotNil(t, result.Error)
+	require.ErrorIs(t, result.Error, ErrActivityBudgetExceeded)
+	require.Equal(t, 5, exec.state.ToCheckpoint().ActivityInvocations)
+}
+
+func TestMaxActivityInvocationsSurvivesResume(t *testing.T) {
+	checkpointer, err := NewFileCheckpointer(t.TempDir())
+	require.NoError(t, err)
+
+	calls := 0
+	first, err := NewExecution(newLoopingWorkflow(t), newIncrementRegistry(&calls),
+		WithScriptCompiler(newTestCompiler()),
+		WithCheckpointer(checkpointer),
+		WithMaxActivityInvocations(3),
+	)
+	require.NoError(t, err)
+	result, err := first.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusFailed, result.Status)
+	require.Equal(t, 3, calls)
+
+	// Resuming with the same budget does not grant a fresh allowance.
+	resumed, err := NewExecution(newLoopingWorkflow(t), newIncrementRegistry(&calls),
+		WithScriptCompiler(newTestCompiler()),
+		WithCheckpointer(checkpointer),
+		WithMaxActivityInvocations(3),
+	)
+	require.NoError(t, err)
+	result, err = resumed.Execute(context.Background(), ResumeFrom(first.ID()))
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusFailed, result.Status)
+	require.Equal(t, 3, calls)
+
+	// A larger budget lets the resumed execution continue from the count.
+	resumed, err = NewExecution(newLoopingWorkflow(t), newIncrementRegistry(&calls),
+		WithScriptCompiler(newTestCompiler()),
+		WithCheckpointer(checkpointer),
+		WithMaxActivityInvocations(5),
+	)
+	require.NoError(t, err)
+	result, err = resumed.Execute(context.Background(), ResumeFrom(first.ID()))
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusFailed, result.Status)
+	require.Equal(t, 5, calls)
+	require.Equal(t, 5, resumed.state.GetActivityInvocations())
+}
+
+func TestMaxSteps(t *testing.T) {
+	// The loop condition never becomes false.
+	wf, err := New(Options{
+		Name:    "broken-loop",
+		State:   map[string]any{"n": 0},
+		OnError: "handle",
+		Steps: []*Step{
+			{
+				Name:     "spin",
+				Activity: "increment",
+				Next:     []*Edge{{Step: "spin", Condition: "state.n > 0"}},
+			},
+			{Name: "handle", Activity: "increment", Store: "handled"},
+		},
+	})
+	require.NoError(t, err)
+	checkpointer, err := NewFileCheckpointer(t.TempDir())
+	require.NoError(t, err)
+
+	calls := 0
+	exec, err := NewExecution(wf, newIncrementRegistry(&calls),
+		WithScriptCompiler(newTestCompiler()),
+		WithCheckpointer(checkpointer),
+		WithMaxSteps(10),
+	)
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusFailed, result.Status)
+	require.ErrorIs(t, result.Error, ErrStepBudgetExceeded)
+	require.Contains(t, result.Error.Error(), `step limit of 10 reached before step "spin"`)
+	require.Equal(t, 10, calls)
+	require.Equal(t, 10, exec.state.GetStepCount())
+	require.Equal(t, 10, exec.state.ToCheckpoint().StepCount)
+	require.Nil(t, exec.state.GetBranchStates()["main"].Variables["handled"], "OnError must not intercept the budget")
+
+	// Resuming with a larger budget continues from the stored count.
+	resumed, err := NewExecution(wf, newIncrementRegistry(&calls),
+		WithScriptCompiler(newTestCompiler()),
+		WithCheckpointer(checkpointer),
+		WithMaxSteps(15),
+	)
+	require.NoError(t, err)
+	result, err = resumed.Execute(context.Background(), ResumeFrom(exec.ID()))
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusFailed, result.Status)
+	require.ErrorIs(t, result.Error, ErrStepBudgetExceeded)
+	require.Equal(t, 15, resumed.state.GetStepCount())
+	require.Equal(t, 15, calls)
+}
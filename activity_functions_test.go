@@ -82,3 +82,119 @@ func TestTypedActivityFunc(t *testing.T) {
 	require.Equal(t, reflect.TypeOf(Person{}), typedFunc.ParametersType())
 	require.Equal(t, reflect.TypeOf(""), typedFunc.ResultType())
 }
+
+func TestTypedActivityFuncFieldMapping(t *testing.T) {
+	type Address struct {
+		PostalCode string `json:"postal_code"`
+		Country    string `workflow:"country_code"`
+	}
+	type Audit struct {
+		RequestID string `workflow:"request_id"`
+	}
+	type Request struct {
+		Audit
+		UserID    int       `json:"user_id"`
+		FullName  string    `json:"name" workflow:"full_name"`
+		Address   Address   `json:"address"`
+		Previous  []Address `json:"previous"`
+		Tags      []string  `json:"tags"`
+		Ignored   string    `json:"-"`
+		Untouched string
+	}
+
+	var got Request
+	activity := TypedActivityFunc("decode", func(ctx Context, req Request) (any, error) {
+		got = req
+		return nil, nil
+	})
+
+	params := map[string]any{
+		"user_id":    42,
+		"full_name":  "Ada Lovelace",
+		"request_id": "req-1",
+		"address":    map[string]any{"postal_code": "N1", "country_code": "GB"},
+		"previous":   []any{map[string]any{"postal_code": "W1", "country_code": "FR"}},
+		"tags":       []any{"a", "b"},
+		"Ignored":    "x",
+		"Untouched":  "kept",
+	}
+	_, err := activity.Execute(nil, params)
+	require.NoError(t, err)
+	require.Equal(t, Request{
+		Audit:     Audit{RequestID: "req-1"},
+		UserID:    42,
+		FullName:  "Ada Lovelace",
+		Address:   Address{PostalCode: "N1", Country: "GB"},
+		Previous:  []Address{{PostalCode: "W1", Country: "FR"}},
+		Tags:      []string{"a", "b"},
+		Untouched: "kept",
+	}, got)
+
+	// The caller's parameters are not modified.
+	require.Equal(t, map[string]any{"postal_code": "N1", "country_code": "GB"}, params["address"])
+	_, renamed := params["name"]
+	require.False(t, renamed)
+}
+
+func TestTypedActivityFuncStoresTypedResult(t *testing.T) {
+	type Category struct {
+		Label string `json:"label"`
+	}
+	type NumberInput struct {
+		Number int `json:"number"`
+	}
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(TypedActivityFunc("generate", func(ctx Context, _ struct{}) (int, error) {
+		return 7, nil
+	}))
+	reg.MustRegister(TypedActivityFunc("is_odd", func(ctx Context, in NumberInput) (bool, error) {
+		return in.Number%2 == 1, nil
+	}))
+	reg.MustRegister(TypedActivityFunc("categorize", func(ctx Context, in NumberInput) (Category, error) {
+		return Category{Label: "small"}, nil
+	}))
+	stored := map[string]any{}
+	reg.MustRegister(ActivityFunc("inspect", func(ctx Context, params map[string]any) (any, error) {
+		for _, key := range []string{"number", "odd", "category"} {
+			stored[key], _ = ctx.Get(key)
+		}
+		return nil, nil
+	}))
+
+	wf, err := New(Options{
+		Name: "typed-store",
+		Steps: []*Step{
+			{Name: "generate", Activity: "generate", Store: "number", Next: []*Edge{{Step: "odd"}}},
+			{
+				Name: "odd", Activity: "is_odd", Store: "odd",
+				Parameters: map[string]any{"number": "${state.number}"},
+				Next:       []*Edge{{Step: "categorize"}},
+			},
+			{
+				Name: "categorize", Activity: "categorize", Store: "category",
+				Parameters: map[string]any{"number": "${state.number}"},
+				Next:       []*Edge{{Step: "inspect"}},
+			},
+			{Name: "inspect", Activity: "inspect"},
+		},
+	})
+	require.NoError(t, err)
+	exec, err := NewExecution(wf, reg)
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+
+	// Store receives each activity's declared result type unchanged.
+	require.Equal(t, map[string]any{
+		"number":   7,
+		"odd":      true,
+		"category": Category{Label: "small"},
+	}, stored)
+
+	// Parameters that do not decode into the input struct fail the call.
+	_, err = reg.activities["is_odd"].Execute(nil, map[string]any{"number": "seven"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `invalid parameters for activity "is_odd"`)
+}
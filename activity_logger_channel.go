@@ -0,0 +1,199 @@
+package workflow
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy controls what a ChannelActivityLogger does when a
+// subscriber's channel is full.
+type OverflowPolicy int
+
+const (
+	// OverflowDrop discards the entry for that subscriber and counts it
+	// in Dropped. Memory use is bounded by the channel buffer.
+	OverflowDrop OverflowPolicy = iota
+
+	// OverflowBuffer queues the entry in memory until the subscriber
+	// catches up, so no entry is lost. A subscriber that stops reading
+	// makes its queue grow without bound.
+	OverflowBuffer
+)
+
+// ChannelActivityLoggerOption configures a ChannelActivityLogger.
+type ChannelActivityLoggerOption func(*ChannelActivityLogger)
+
+// WithOverflowPolicy sets how entries are handled for a subscriber
+// whose channel is full. The default is OverflowDrop.
+func WithOverflowPolicy(policy OverflowPolicy) ChannelActivityLoggerOption {
+	return func(l *ChannelActivityLogger) {
+		l.policy = policy
+	}
+}
+
+// ChannelActivityLogger is an in-memory ActivityLogger that fans each
+// entry out to subscriber channels as it is logged, for example to feed
+// a live UI or to observe activities from a test. LogActivity never
+// blocks on a slow subscriber; see OverflowPolicy.
+//
+// Entries are shared between subscribers and the logger's history and
+// must be treated as read-only.
+type ChannelActivityLogger struct {
+	buffer  int
+	policy  OverflowPolicy
+	dropped atomic.Int64
+
+	mu          sync.Mutex
+	closed      bool
+	subscribers []*activitySubscriber
+	history     map[string][]*ActivityLogEntry
+}
+
+// NewChannelActivityLogger creates a logger whose subscriber channels
+// have the given buffer size.
+func NewChannelActivityLogger(buffer int, opts ...ChannelActivityLoggerOption) *ChannelActivityLogger {
+	if buffer < 0 {
+		buffer = 0
+	}
+	l := &ChannelActivityLogger{
+		buffer:  buffer,
+		history: map[string][]*ActivityLogEntry{},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Subscribe returns a channel that receives every entry logged from now
+// on. The channel is closed by Close once its pending entries have been
+// delivered. Subscribing after Close returns a closed channel.
+func (l *ChannelActivityLogger) Subscribe() <-chan *ActivityLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := &activitySubscriber{
+		ch:   make(chan *ActivityLogEntry, l.buffer),
+		wake: make(chan struct{}, 1),
+	}
+	if l.closed {
+		close(s.ch)
+		return s.ch
+	}
+	if l.policy == OverflowBuffer {
+		go s.pump()
+	}
+	l.subscribers = append(l.subscribers, s)
+	return s.ch
+}
+
+// LogActivity records the entry and delivers it to every subscriber.
+// Entries logged after Close are kept in the history but not delivered.
+func (l *ChannelActivityLogger) LogActivity(ctx context.Context, entry *ActivityLogEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.history[entry.ExecutionID] = append(l.history[entry.ExecutionID], entry)
+	if l.closed {
+		return nil
+	}
+	for _, s := range l.subscribers {
+		if l.policy == OverflowBuffer {
+			s.enqueue(entry)
+			continue
+		}
+		select {
+		case s.ch <- entry:
+		default:
+			l.dropped.Add(1)
+		}
+	}
+	return nil
+}
+
+// GetActivityHistory returns the entries logged for an execution.
+func (l *ChannelActivityLogger) GetActivityHistory(ctx context.Context, executionID string) ([]*ActivityLogEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]*ActivityLogEntry(nil), l.history[executionID]...), nil
+}
+
+// Dropped returns the number of deliveries discarded under OverflowDrop.
+func (l *ChannelActivityLogger) Dropped() int64 {
+	return l.dropped.Load()
+}
+
+// Close stops delivery to subscribers. Entries already accepted are
+// still delivered: each subscriber channel is closed after its buffered
+// and queued entries, so a subscriber ranging over its channel sees
+// every entry and then exits. Close does not wait for subscribers to
+// read. Calling Close more than once has no effect.
+func (l *ChannelActivityLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	for _, s := range l.subscribers {
+		if l.policy == OverflowBuffer {
+			s.close()
+		} else {
+			close(s.ch)
+		}
+	}
+	l.subscribers = nil
+	return nil
+}
+
+// activitySubscriber is one Subscribe channel. Under OverflowBuffer a
+// pump goroutine moves entries from queue to ch.
+type activitySubscriber struct {
+	ch   chan *ActivityLogEntry
+	wake chan struct{}
+
+	mu     sync.Mutex
+	queue  []*ActivityLogEntry
+	closed bool
+}
+
+func (s *activitySubscriber) enqueue(entry *ActivityLogEntry) {
+	s.mu.Lock()
+	s.queue = append(s.queue, entry)
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *activitySubscriber) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *activitySubscriber) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *activitySubscriber) pump() {
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				close(s.ch)
+				return
+			}
+			<-s.wake
+			continue
+		}
+		entry := s.queue[0]
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+		s.ch <- entry
+	}
+}
@@ -0,0 +1,94 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestChannelActivityLogger(t *testing.T) {
+	t.Run("streams entries from an execution", func(t *testing.T) {
+		logger := NewChannelActivityLogger(16)
+		entries := logger.Subscribe()
+
+		wf, err := New(Options{
+			Name: "streamed",
+			Steps: []*Step{
+				{Name: "first", Activity: "noop", Next: []*Edge{{Step: "second"}}},
+				{Name: "second", Activity: "noop"},
+			},
+		})
+		require.NoError(t, err)
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("noop", func(ctx Context, params map[string]any) (any, error) {
+			return nil, nil
+		}))
+		exec, err := NewExecution(wf, reg, WithActivityLogger(logger))
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		require.NoError(t, logger.Close())
+
+		var steps []string
+		for entry := range entries {
+			require.Equal(t, exec.ID(), entry.ExecutionID)
+			steps = append(steps, entry.StepName)
+		}
+		require.Equal(t, []string{"first", "second"}, steps)
+
+		history, err := logger.GetActivityHistory(context.Background(), exec.ID())
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+	})
+
+	entry := func(i int) *ActivityLogEntry {
+		return &ActivityLogEntry{ID: fmt.Sprint(i), ExecutionID: "exec"}
+	}
+
+	t.Run("drop policy never blocks on a slow subscriber", func(t *testing.T) {
+		logger := NewChannelActivityLogger(2)
+		slow := logger.Subscribe()
+		for i := 0; i < 5; i++ {
+			require.NoError(t, logger.LogActivity(context.Background(), entry(i)))
+		}
+		require.Equal(t, int64(3), logger.Dropped())
+		require.NoError(t, logger.Close())
+
+		var ids []string
+		for e := range slow {
+			ids = append(ids, e.ID)
+		}
+		require.Equal(t, []string{"0", "1"}, ids)
+	})
+
+	t.Run("buffer policy delivers every entry and drains on close", func(t *testing.T) {
+		logger := NewChannelActivityLogger(1, WithOverflowPolicy(OverflowBuffer))
+		first := logger.Subscribe()
+		second := logger.Subscribe()
+		for i := 0; i < 50; i++ {
+			require.NoError(t, logger.LogActivity(context.Background(), entry(i)))
+		}
+		require.NoError(t, logger.Close())
+		require.NoError(t, logger.Close())
+
+		for _, ch := range []<-chan *ActivityLogEntry{first, second} {
+			count := 0
+			for e := range ch {
+				require.Equal(t, fmt.Sprint(count), e.ID)
+				count++
+			}
+			require.Equal(t, 50, count)
+		}
+		require.Equal(t, int64(0), logger.Dropped())
+	})
+
+	t.Run("subscribe after close", func(t *testing.T) {
+		logger := NewChannelActivityLogger(1)
+		require.NoError(t, logger.Close())
+		_, ok := <-logger.Subscribe()
+		require.False(t, ok)
+	})
+}
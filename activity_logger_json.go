@@ -0,0 +1,179 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// JSONActivityLogger writes each ActivityLogEntry as a single line of
+// JSON (JSONL) to an io.Writer, for log pipelines that ingest
+// structured logs. Entries of all executions share the stream; each
+// line carries its execution_id, branch_id, step_name, activity,
+// duration, and error.
+//
+// The logger keeps no history: GetActivityHistory returns nothing.
+type JSONActivityLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONActivityLogger creates a logger that writes JSON lines to w.
+// Writes are serialized, so w need not be safe for concurrent use.
+func NewJSONActivityLogger(w io.Writer) *JSONActivityLogger {
+	return &JSONActivityLogger{w: w}
+}
+
+// LogActivity writes entry as one JSON line.
+func (l *JSONActivityLogger) LogActivity(ctx context.Context, entry *ActivityLogEntry) error {
+	line, err := marshalActivityLogLine(entry)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.w.Write(line)
+	return err
+}
+
+// GetActivityHistory returns nil; entries are not retained.
+func (l *JSONActivityLogger) GetActivityHistory(ctx context.Context, executionID string) ([]*ActivityLogEntry, error) {
+	return nil, nil
+}
+
+// RotatingFileActivityLogger writes JSON lines like JSONActivityLogger
+// to activity.jsonl in a directory, rotating by size. When a write
+// would grow the current file past the size limit, it is renamed to
+// activity.1.jsonl, older files shift up by one, and the oldest beyond
+// the file limit is deleted.
+type RotatingFileActivityLogger struct {
+	mu       sync.Mutex
+	dir      string
+	maxSize  int64
+	maxFiles int
+	size     int64
+}
+
+// NewRotatingFileActivityLogger creates a logger writing to dir, which
+// is created if needed. Files rotate once they reach maxSizeMB
+// megabytes, and at most maxFiles files, the current one included, are
+// kept.
+func NewRotatingFileActivityLogger(dir string, maxSizeMB, maxFiles int) (*RotatingFileActivityLogger, error) {
+	if maxSizeMB <= 0 {
+		return nil, fmt.Errorf("max size must be > 0 MB, got %d", maxSizeMB)
+	}
+	if maxFiles <= 0 {
+		return nil, fmt.Errorf("max files must be > 0, got %d", maxFiles)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory %s: %w", dir, err)
+	}
+	l := &RotatingFileActivityLogger{
+		dir:      dir,
+		maxSize:  int64(maxSizeMB) * 1024 * 1024,
+		maxFiles: maxFiles,
+	}
+	info, err := os.Stat(l.path(0))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if info != nil {
+		l.size = info.Size()
+	}
+	return l, nil
+}
+
+// path returns the name of the n-th file, 0 being the current one.
+func (l *RotatingFileActivityLogger) path(n int) string {
+	if n == 0 {
+		return filepath.Join(l.dir, "activity.jsonl")
+	}
+	return filepath.Join(l.dir, fmt.Sprintf("activity.%d.jsonl", n))
+}
+
+// LogActivity appends entry as one JSON line, rotating first if the
+// line would take the current file past the size limit.
+func (l *RotatingFileActivityLogger) LogActivity(ctx context.Context, entry *ActivityLogEntry) error {
+	line, err := marshalActivityLogLine(entry)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return fmt.Errorf("failed to rotate activity log: %w", err)
+		}
+	}
+	f, err := os.OpenFile(l.path(0), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	n, err := f.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// rotate shifts every file up by one and starts a new current file.
+func (l *RotatingFileActivityLogger) rotate() error {
+	if err := os.Remove(l.path(l.maxFiles - 1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for n := l.maxFiles - 2; n >= 0; n-- {
+		if err := os.Rename(l.path(n), l.path(n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	l.size = 0
+	return nil
+}
+
+// GetActivityHistory scans the retained files, oldest first, for the
+// entries of an execution. Entries in rotated-out files are gone.
+func (l *RotatingFileActivityLogger) GetActivityHistory(ctx context.Context, executionID string) ([]*ActivityLogEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var entries []*ActivityLogEntry
+	for n := l.maxFiles - 1; n >= 0; n-- {
+		f, err := os.Open(l.path(n))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		dec := json.NewDecoder(f)
+		for {
+			var entry ActivityLogEntry
+			if err = dec.Decode(&entry); err != nil {
+				break
+			}
+			if entry.ExecutionID == executionID {
+				entries = append(entries, &entry)
+			}
+		}
+		f.Close()
+		if err != io.EOF {
+			return nil, fmt.Errorf("failed to read %s: %w", l.path(n), err)
+		}
+	}
+	return entries, nil
+}
+
+func marshalActivityLogLine(entry *ActivityLogEntry) ([]byte, error) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
@@ -0,0 +1,97 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestJSONActivityLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewJSONActivityLogger(&buf)
+
+	wf, err := New(Options{
+		Name: "json-logged",
+		Steps: []*Step{
+			{Name: "first", Activity: "work", Next: []*Edge{{Step: "second"}}},
+			{Name: "second", Activity: "work"},
+		},
+	})
+	require.NoError(t, err)
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("work", func(ctx Context, params map[string]any) (any, error) {
+		return "ok", nil
+	}))
+	exec, err := NewExecution(wf, reg, WithActivityLogger(logger))
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var line map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &line))
+	require.Equal(t, exec.ID(), line["execution_id"])
+	require.Equal(t, "main", line["branch_id"])
+	require.Equal(t, "second", line["step_name"])
+	require.Equal(t, "work", line["activity"])
+	require.NotNil(t, line["duration"])
+}
+
+func TestRotatingFileActivityLogger(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	logger, err := NewRotatingFileActivityLogger(dir, 1, 3)
+	require.NoError(t, err)
+
+	// Each entry is a little over 300KB, so a 1MB file holds three.
+	padding := strings.Repeat("x", 300*1024)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, logger.LogActivity(ctx, &ActivityLogEntry{
+			ExecutionID: "exec-1",
+			StepName:    "step",
+			Parameters:  map[string]any{"i": i, "padding": padding},
+			Error:       "boom",
+		}))
+	}
+
+	for _, name := range []string{"activity.jsonl", "activity.1.jsonl", "activity.2.jsonl"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		require.NoError(t, err)
+		require.LessOrEqual(t, info.Size(), int64(1024*1024))
+	}
+	_, err = os.Stat(filepath.Join(dir, "activity.3.jsonl"))
+	require.True(t, os.IsNotExist(err))
+
+	// History covers the retained files in order; the oldest entries
+	// were rotated out.
+	history, err := logger.GetActivityHistory(ctx, "exec-1")
+	require.NoError(t, err)
+	require.Len(t, history, 7)
+	require.Equal(t, float64(3), history[0].Parameters["i"])
+	require.Equal(t, float64(9), history[6].Parameters["i"])
+	require.Equal(t, "boom", history[6].Error)
+
+	// A new logger picks up the current file's size.
+	reopened, err := NewRotatingFileActivityLogger(dir, 1, 3)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, reopened.LogActivity(ctx, &ActivityLogEntry{
+			ExecutionID: "exec-2",
+			Parameters:  map[string]any{"padding": padding},
+		}))
+	}
+	history, err = reopened.GetActivityHistory(ctx, "exec-1")
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+
+	_, err = NewRotatingFileActivityLogger(dir, 0, 3)
+	require.Error(t, err)
+}
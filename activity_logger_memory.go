@@ -0,0 +1,84 @@
+package workflow
+
+import (
+	"context"
+	"sync"
+)
+
+// ActivityLogFilter selects entries from a MemoryActivityLogger. Empty
+// fields match every entry; the fields that are set must all match.
+type ActivityLogFilter struct {
+	ExecutionID string
+	StepName    string
+	BranchID    string
+	Activity    string
+
+	// FailedOnly keeps only entries whose activity returned an error.
+	FailedOnly bool
+}
+
+func (f ActivityLogFilter) matches(entry *ActivityLogEntry) bool {
+	return (f.ExecutionID == "" || entry.ExecutionID == f.ExecutionID) &&
+		(f.StepName == "" || entry.StepName == f.StepName) &&
+		(f.BranchID == "" || entry.BranchID == f.BranchID) &&
+		(f.Activity == "" || entry.Activity == f.Activity) &&
+		(!f.FailedOnly || entry.Error != "")
+}
+
+// MemoryActivityLogger is an ActivityLogger that keeps every entry in
+// memory, in the order they were logged, so tests can assert which
+// activities ran with which parameters and an in-process UI can show
+// them. Entries accumulate until Reset; it is not meant for long-lived
+// production use.
+//
+// Returned entries are shared with the logger and must be treated as
+// read-only.
+type MemoryActivityLogger struct {
+	mu      sync.Mutex
+	entries []*ActivityLogEntry
+}
+
+// NewMemoryActivityLogger creates an empty in-memory logger.
+func NewMemoryActivityLogger() *MemoryActivityLogger {
+	return &MemoryActivityLogger{}
+}
+
+// LogActivity records entry.
+func (l *MemoryActivityLogger) LogActivity(ctx context.Context, entry *ActivityLogEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+	return nil
+}
+
+// GetActivityHistory returns the entries logged for an execution.
+func (l *MemoryActivityLogger) GetActivityHistory(ctx context.Context, executionID string) ([]*ActivityLogEntry, error) {
+	return l.Query(ActivityLogFilter{ExecutionID: executionID}), nil
+}
+
+// Entries returns every entry logged so far, across executions.
+func (l *MemoryActivityLogger) Entries() []*ActivityLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]*ActivityLogEntry(nil), l.entries...)
+}
+
+// Query returns the entries that match filter, in logging order.
+func (l *MemoryActivityLogger) Query(filter ActivityLogFilter) []*ActivityLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var matched []*ActivityLogEntry
+	for _, entry := range l.entries {
+		if filter.matches(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+// Reset discards every entry.
+func (l *MemoryActivityLogger) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = nil
+}
@@ -0,0 +1,75 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestMemoryActivityLogger(t *testing.T) {
+	wf, err := New(Options{
+		Name: "recorded",
+		Steps: []*Step{
+			{
+				Name:       "greet",
+				Activity:   "echo",
+				Parameters: map[string]any{"message": "hello"},
+				Next: []*Edge{
+					{Step: "left", BranchName: "left"},
+					{Step: "right", BranchName: "right"},
+				},
+			},
+			{Name: "left", Activity: "echo", Parameters: map[string]any{"message": "from left"}},
+			{
+				Name:     "right",
+				Activity: "broken",
+				Catch:    []*CatchConfig{{ErrorEquals: []string{ErrorTypeAll}, Next: "recover"}},
+			},
+			{Name: "recover", Activity: "echo", Parameters: map[string]any{"message": "recovered"}},
+		},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("echo", func(ctx Context, params map[string]any) (any, error) {
+		return params["message"], nil
+	}))
+	reg.MustRegister(ActivityFunc("broken", func(ctx Context, params map[string]any) (any, error) {
+		return nil, errors.New("boom")
+	}))
+
+	logger := NewMemoryActivityLogger()
+	exec, err := NewExecution(wf, reg, WithActivityLogger(logger))
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+
+	require.Len(t, logger.Entries(), 4)
+	history, err := logger.GetActivityHistory(context.Background(), exec.ID())
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+	require.Equal(t, "greet", history[0].StepName)
+
+	greet := logger.Query(ActivityLogFilter{StepName: "greet"})
+	require.Len(t, greet, 1)
+	require.Equal(t, map[string]any{"message": "hello"}, greet[0].Parameters)
+	require.Equal(t, "hello", greet[0].Result)
+
+	right := logger.Query(ActivityLogFilter{BranchID: "right"})
+	require.Len(t, right, 2)
+	require.Equal(t, "broken", right[0].Activity)
+	require.Equal(t, "recover", right[1].StepName)
+
+	failed := logger.Query(ActivityLogFilter{FailedOnly: true})
+	require.Len(t, failed, 1)
+	require.Contains(t, failed[0].Error, "boom")
+
+	require.Len(t, logger.Query(ActivityLogFilter{Activity: "echo", BranchID: "left"}), 1)
+	require.Empty(t, logger.Query(ActivityLogFilter{ExecutionID: "other"}))
+
+	logger.Reset()
+	require.Empty(t, logger.Entries())
+}
@@ -0,0 +1,44 @@
+package workflow
+
+import (
+	"context"
+	"strings"
+)
+
+// NamespacedActivityLogger isolates the activity logs of one tenant in
+// an ActivityLogger shared by many, prefixing execution IDs with the
+// namespace the same way as NamespacedCheckpointer.
+type NamespacedActivityLogger struct {
+	inner  ActivityLogger
+	prefix string
+}
+
+// NewNamespacedActivityLogger wraps inner so that all entries are
+// logged under namespace. It panics if namespace is empty or contains
+// '.', '/', or '\'.
+func NewNamespacedActivityLogger(inner ActivityLogger, namespace string) *NamespacedActivityLogger {
+	return &NamespacedActivityLogger{inner: inner, prefix: namespacePrefix(namespace)}
+}
+
+// LogActivity logs a copy of entry under the namespaced execution ID.
+func (l *NamespacedActivityLogger) LogActivity(ctx context.Context, entry *ActivityLogEntry) error {
+	wrapped := *entry
+	wrapped.ExecutionID = l.prefix + entry.ExecutionID
+	return l.inner.LogActivity(ctx, &wrapped)
+}
+
+// GetActivityHistory returns the entries of executionID in this
+// namespace.
+func (l *NamespacedActivityLogger) GetActivityHistory(ctx context.Context, executionID string) ([]*ActivityLogEntry, error) {
+	entries, err := l.inner.GetActivityHistory(ctx, l.prefix+executionID)
+	if err != nil {
+		return nil, err
+	}
+	local := make([]*ActivityLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		e := *entry
+		e.ExecutionID = strings.TrimPrefix(entry.ExecutionID, l.prefix)
+		local = append(local, &e)
+	}
+	return local, nil
+}
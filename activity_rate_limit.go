@@ -0,0 +1,151 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// RateLimiter paces activity calls. Wait blocks until the caller may
+// proceed, and returns an error if ctx is done first or the wait cannot
+// finish before ctx's deadline. *rate.Limiter from golang.org/x/time/rate
+// satisfies it, as does TokenBucket.
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+// NewRateLimitedActivity wraps inner so every call first waits on
+// limiter. Register the wrapper in place of inner: because a single
+// Activity value serves every branch and every execution sharing the
+// registry, the limit applies across all of them.
+//
+//	reg.MustRegister(workflow.NewRateLimitedActivity(
+//		httpx.NewHTTPActivity(),
+//		rate.NewLimiter(rate.Limit(5), 1), // or workflow.NewTokenBucket(5, 1)
+//	))
+//
+// A call that gives up waiting because the step's context ended fails
+// with ErrorTypeTimeout, so a retry config can try it again. The
+// wrapper keeps inner's name, ParamSchema, and result type.
+func NewRateLimitedActivity(inner Activity, limiter RateLimiter) Activity {
+	a := &rateLimitedActivity{inner: inner, limiter: limiter}
+	if typed, ok := inner.(interface{ ResultType() reflect.Type }); ok {
+		return &rateLimitedTypedActivity{rateLimitedActivity: a, resultType: typed.ResultType()}
+	}
+	return a
+}
+
+type rateLimitedActivity struct {
+	inner   Activity
+	limiter RateLimiter
+}
+
+func (a *rateLimitedActivity) Name() string {
+	return a.inner.Name()
+}
+
+func (a *rateLimitedActivity) Execute(ctx Context, parameters map[string]any) (any, error) {
+	if err := a.limiter.Wait(ctx); err != nil {
+		return nil, &WorkflowError{
+			Type:    ErrorTypeTimeout,
+			Cause:   fmt.Sprintf("rate limit wait for activity %q ended: %v", a.inner.Name(), err),
+			Wrapped: err,
+		}
+	}
+	return a.inner.Execute(ctx, parameters)
+}
+
+// ParamSchema returns inner's schema, or nil when it declares none.
+func (a *rateLimitedActivity) ParamSchema() map[string]ParamSpec {
+	if s, ok := a.inner.(ActivityWithParamSchema); ok {
+		return s.ParamSchema()
+	}
+	return nil
+}
+
+// rateLimitedTypedActivity also reports the result type of a typed
+// inner activity, for ActivityRegistry.ResultType.
+type rateLimitedTypedActivity struct {
+	*rateLimitedActivity
+	resultType reflect.Type
+}
+
+func (a *rateLimitedTypedActivity) ResultType() reflect.Type {
+	return a.resultType
+}
+
+// errRateLimitDeadline is returned by TokenBucket.Wait when the wait
+// would outlast the context's deadline.
+var errRateLimitDeadline = errors.New("rate limit wait would exceed context deadline")
+
+// TokenBucket is a RateLimiter that allows perSecond calls per second on
+// average, with bursts of up to burst calls. It is safe for concurrent
+// use. Use it when pulling in golang.org/x/time/rate is not wanted.
+type TokenBucket struct {
+	mu        sync.Mutex
+	perSecond float64
+	burst     float64
+	tokens    float64
+	last      time.Time
+}
+
+// NewTokenBucket returns a full TokenBucket. It panics if perSecond is
+// not positive; a burst below 1 is raised to 1.
+func NewTokenBucket(perSecond float64, burst int) *TokenBucket {
+	if perSecond <= 0 {
+		panic(fmt.Sprintf("workflow: NewTokenBucket: rate must be positive, got %v", perSecond))
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		perSecond: perSecond,
+		burst:     float64(burst),
+		tokens:    float64(burst),
+		last:      time.Now(),
+	}
+}
+
+// Wait takes a token, sleeping until one is available. A caller that
+// gives up returns its token, so abandoned waits do not slow others.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.perSecond)
+	b.last = now
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.perSecond * float64(time.Second))
+	}
+	b.mu.Unlock()
+	if delay == 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+		b.refund()
+		return errRateLimitDeadline
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.refund()
+		return ctx.Err()
+	}
+}
+
+func (b *TokenBucket) refund() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.burst, b.tokens+1)
+}
@@ -0,0 +1,102 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestRateLimitedActivityThrottlesConcurrentBranches(t *testing.T) {
+	var calls atomic.Int32
+	inner := ActivityFunc("fetch", func(ctx Context, params map[string]any) (any, error) {
+		calls.Add(1)
+		return "ok", nil
+	})
+	reg := NewActivityRegistry()
+	reg.MustRegister(NewRateLimitedActivity(inner, NewTokenBucket(50, 1)))
+
+	wf, err := New(Options{
+		Name: "fan-out",
+		Steps: []*Step{
+			{Name: "start", Activity: "fetch", Next: []*Edge{
+				{Step: "a", BranchName: "a"},
+				{Step: "b", BranchName: "b"},
+				{Step: "c", BranchName: "c"},
+				{Step: "d", BranchName: "d"},
+			}},
+			{Name: "a", Activity: "fetch"},
+			{Name: "b", Activity: "fetch"},
+			{Name: "c", Activity: "fetch"},
+			{Name: "d", Activity: "fetch"},
+		},
+	})
+	require.NoError(t, err)
+	exec, err := NewExecution(wf, reg)
+	require.NoError(t, err)
+
+	start := time.Now()
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.Equal(t, int32(5), calls.Load())
+	// One call goes through on the burst; the other four wait 20ms each.
+	require.True(t, time.Since(start) >= 75*time.Millisecond, "took %s", time.Since(start))
+}
+
+func TestRateLimitedActivityWaitFailure(t *testing.T) {
+	called := false
+	inner := TypedActivityFunc("typed", func(ctx Context, params struct{}) (int, error) {
+		called = true
+		return 1, nil
+	})
+	bucket := NewTokenBucket(1, 1)
+	activity := NewRateLimitedActivity(inner, bucket)
+	require.Equal(t, "typed", activity.Name())
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(activity)
+	resultType, ok := reg.ResultType("typed")
+	require.True(t, ok)
+	require.Equal(t, reflect.TypeOf(0), resultType)
+
+	// Drain the burst, then give up before the next token is due.
+	require.NoError(t, bucket.Wait(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := activity.Execute(NewContext(ctx, ExecutionContextOptions{}), map[string]any{})
+	require.Error(t, err)
+	var wfErr *WorkflowError
+	require.True(t, errors.As(err, &wfErr))
+	require.Equal(t, ErrorTypeTimeout, wfErr.Type)
+	require.False(t, called)
+}
+
+func TestTokenBucketRefundsAbandonedWaits(t *testing.T) {
+	bucket := NewTokenBucket(20, 1)
+	require.NoError(t, bucket.Wait(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.Error(t, bucket.Wait(ctx))
+		}()
+	}
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	// The cancelled waiters gave their tokens back, so the next call
+	// waits about one interval rather than eleven.
+	start := time.Now()
+	require.NoError(t, bucket.Wait(context.Background()))
+	require.True(t, time.Since(start) < 200*time.Millisecond, "took %s", time.Since(start))
+}
@@ -3,6 +3,7 @@ package workflow
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"sort"
 )
 
@@ -13,7 +14,10 @@ var ErrDuplicateActivity = errors.New("workflow: duplicate activity registration
 // ActivityRegistry owns the set of activities an Execution can call
 // by name. It is opaque — consumers construct one via
 // NewActivityRegistry and add activities through Register or
-// MustRegister. The registry is read-only once passed to NewExecution.
+// MustRegister. The registry is read-only once passed to NewExecution,
+// so one registry, and the Activity values in it, may be shared by any
+// number of concurrently running executions. Finish registering before
+// the first NewExecution call.
 type ActivityRegistry struct {
 	activities map[string]Activity
 }
@@ -55,6 +59,30 @@ func (r *ActivityRegistry) MustRegister(a Activity) *ActivityRegistry {
 	return r
 }
 
+// RegisterTyped registers fn as a typed activity named name, as
+// TypedActivityFunc does, and records O as its result type for
+// ActivityRegistry.ResultType. Callbacks read the result with
+// TypedResult[O]. It is a function rather than a method because Go
+// methods cannot have type parameters.
+func RegisterTyped[I, O any](r *ActivityRegistry, name string, fn func(ctx Context, params I) (O, error)) error {
+	return r.Register(TypedActivityFunc(name, fn))
+}
+
+// ResultType returns the result type of the typed activity registered
+// under name. It reports false for unknown names and for activities
+// that are not typed.
+func (r *ActivityRegistry) ResultType(name string) (reflect.Type, bool) {
+	a, ok := r.activities[name]
+	if !ok {
+		return nil, false
+	}
+	typed, ok := a.(interface{ ResultType() reflect.Type })
+	if !ok {
+		return nil, false
+	}
+	return typed.ResultType(), true
+}
+
 // Get returns the activity registered under name, if any.
 func (r *ActivityRegistry) Get(name string) (Activity, bool) {
 	a, ok := r.activities[name]
@@ -71,6 +99,54 @@ func (r *ActivityRegistry) Names() []string {
 	return names
 }
 
+// ActivityResolver supplies activities on demand. NewExecution consults
+// it for every activity a step references that is not in the static
+// ActivityRegistry, so activities that are expensive to construct (a
+// pooled database client, say) are only built by executions that use
+// them. The static registry always takes precedence.
+type ActivityResolver interface {
+	Resolve(name string) (Activity, bool)
+}
+
+// ActivityResolverFunc adapts a plain function to ActivityResolver.
+type ActivityResolverFunc func(name string) (Activity, bool)
+
+// Resolve calls f(name).
+func (f ActivityResolverFunc) Resolve(name string) (Activity, bool) {
+	return f(name)
+}
+
+// withResolved returns a registry containing r's activities plus any
+// activity referenced by wf that is missing from r but supplied by
+// resolver. r itself is never modified; when nothing needs resolving,
+// r is returned as is.
+func (r *ActivityRegistry) withResolved(wf *Workflow, resolver ActivityResolver) *ActivityRegistry {
+	if resolver == nil {
+		return r
+	}
+	var out *ActivityRegistry
+	for _, name := range wf.RequiredActivities() {
+		if _, ok := r.Get(name); ok {
+			continue
+		}
+		a, ok := resolver.Resolve(name)
+		if !ok || a == nil {
+			continue
+		}
+		if out == nil {
+			out = &ActivityRegistry{activities: make(map[string]Activity, len(r.activities)+1)}
+			for k, v := range r.activities {
+				out.activities[k] = v
+			}
+		}
+		out.activities[name] = a
+	}
+	if out == nil {
+		return r
+	}
+	return out
+}
+
 // internal accessor for the engine.
 func (r *ActivityRegistry) asMap() map[string]Activity {
 	if r == nil {
@@ -0,0 +1,98 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestActivityResolverSuppliesMissingActivities(t *testing.T) {
+	wf, err := New(Options{
+		Name: "resolver",
+		Steps: []*Step{
+			{Name: "static", Activity: "static", Store: "a", Next: []*Edge{{Step: "lazy"}}},
+			{Name: "lazy", Activity: "lazy", Store: "b"},
+		},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("static", func(ctx Context, params map[string]any) (any, error) {
+		return "from registry", nil
+	}))
+
+	var resolved []string
+	resolver := ActivityResolverFunc(func(name string) (Activity, bool) {
+		resolved = append(resolved, name)
+		switch name {
+		case "lazy":
+			return ActivityFunc("lazy", func(ctx Context, params map[string]any) (any, error) {
+				return "from resolver", nil
+			}), true
+		case "static":
+			return ActivityFunc("static", func(ctx Context, params map[string]any) (any, error) {
+				return "shadowed", nil
+			}), true
+		}
+		return nil, false
+	})
+
+	exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()), WithActivityResolver(resolver))
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+
+	// Only the missing activity is resolved; the registry wins otherwise.
+	require.Equal(t, []string{"lazy"}, resolved)
+	vars := exec.state.GetBranchStates()["main"].Variables
+	require.Equal(t, "from registry", vars["a"])
+	require.Equal(t, "from resolver", vars["b"])
+
+	// The caller's registry is not modified.
+	_, ok := reg.Get("lazy")
+	require.False(t, ok)
+}
+
+func TestActivityResolverMissReportsUnknownActivity(t *testing.T) {
+	wf, err := New(Options{
+		Name:  "resolver-miss",
+		Steps: []*Step{{Name: "step", Activity: "nowhere"}},
+	})
+	require.NoError(t, err)
+
+	resolver := ActivityResolverFunc(func(name string) (Activity, bool) { return nil, false })
+	_, err = NewExecution(wf, NewActivityRegistry(), WithActivityResolver(resolver))
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrUnknownActivity))
+}
+
+func TestRequiredActivitiesAreCheckedAtCreation(t *testing.T) {
+	wf, err := New(Options{
+		Name: "required",
+		Steps: []*Step{
+			{Name: "fetch", Activity: "fetch", Next: []*Edge{{Step: "notify"}}},
+			{Name: "notify", Activity: "notify", Next: []*Edge{{Step: "fetch again"}}},
+			{Name: "fetch again", Activity: "fetch"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"fetch", "notify"}, wf.RequiredActivities())
+
+	noop := func(ctx Context, params map[string]any) (any, error) { return nil, nil }
+	reg := NewActivityRegistry()
+	for _, name := range []string{"fetch", "unused", "also unused"} {
+		reg.MustRegister(ActivityFunc(name, noop))
+	}
+
+	// Unused activities are ignored; the missing one fails creation.
+	_, err = NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+	require.ErrorIs(t, err, ErrUnknownActivity)
+	require.Contains(t, err.Error(), `unknown activity "notify"`)
+
+	reg.MustRegister(ActivityFunc("notify", noop))
+	_, err = NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+	require.NoError(t, err)
+}
@@ -2,10 +2,14 @@ package workflow
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"math/rand/v2"
+	"reflect"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -29,6 +33,28 @@ type activityExecutor interface {
 	// ExecuteActivity runs an activity with automatic logging and checkpointing
 	// In the new branch-local state system, activities work directly with branch state
 	ExecuteActivity(ctx context.Context, stepName, branchID string, activity Activity, params map[string]interface{}, branchState *BranchLocalState) (result interface{}, err error)
+
+	// BeginStep is called before each step a branch runs. An error
+	// fails the branch without running the step.
+	BeginStep(stepName, branchID string) error
+
+	// CompletedActivity returns the result recorded for an Idempotent
+	// step whose activity succeeded before the execution stopped.
+	CompletedActivity(stepName, branchID string) (any, bool)
+
+	// SkipStep reports a step bypassed by its Skip condition.
+	SkipStep(ctx context.Context, stepName, branchID, activityName string)
+
+	// BeginEach starts tracking the iterations of an Each step over the
+	// given number of items. When resume is set and the branch's
+	// recorded progress belongs to the same step and item count, it is
+	// kept and the results of the finished iterations are returned;
+	// otherwise the progress is reset.
+	BeginEach(stepName, branchID string, items int, resume bool) map[int]any
+
+	// ExecuteEachItem runs iteration index of an Each step like
+	// ExecuteActivity and records its result in the Each progress.
+	ExecuteEachItem(ctx context.Context, stepName, branchID string, index int, activity Activity, params map[string]any, branchState *BranchLocalState) (any, error)
 }
 
 // branchOptions contains all dependencies needed by a branch, injected at construction
@@ -43,6 +69,10 @@ type branchOptions struct {
 	ScriptCompiler     script.Compiler
 	ExecutionCallbacks ExecutionCallbacks
 
+	// TemplateDelims are the expression delimiters for parameter and
+	// topic templates. The zero value means script.DefaultDelimiters.
+	TemplateDelims script.Delimiters
+
 	// SignalStore and ExecutionID are plumbed so declarative WaitSignal
 	// steps (which don't run through executeActivity) can reach the
 	// signal infrastructure directly. Both may be nil for workflows that
@@ -64,6 +94,28 @@ type branchOptions struct {
 	// InitialPauseReason seeds the runtime pause reason alongside
 	// InitialPauseRequested. Ignored when InitialPauseRequested is false.
 	InitialPauseReason string
+
+	// limiter is shared by every branch of an execution to enforce
+	// WithMaxParallelBranches. Nil means no limit.
+	limiter *branchLimiter
+
+	// MaxStepOutputs bounds the step outputs the branch retains; see
+	// WithMaxStepOutputs. Zero means no limit.
+	MaxStepOutputs int
+
+	// ParallelGroups maps a step to the run of independent steps it
+	// starts; see WithAutoParallelSteps. Nil disables the optimizer.
+	ParallelGroups map[string][]*Step
+
+	// StepOutputs and StepOutputOrder seed the outputs of steps the
+	// branch inherits, from its parent or from a checkpoint.
+	StepOutputs     map[string]any
+	StepOutputOrder []string
+
+	// ActivityTimeout bounds each activity step, parameter evaluation
+	// included, unless the step sets its own timeout; see
+	// WithDefaultActivityTimeout. Zero means no limit.
+	ActivityTimeout time.Duration
 }
 
 // branchSpec specifies how to create a new branch (ID generated by Execution)
@@ -71,22 +123,28 @@ type branchSpec struct {
 	Step      *Step
 	Variables map[string]any
 	Name      string // Optional name for the branch from Edge.branch
+
+	// StepOutputs and StepOutputOrder carry the parent's step outputs
+	// so the new branch can still read them through steps.
+	StepOutputs     map[string]any
+	StepOutputOrder []string
 }
 
 // branchSnapshot represents a snapshot of branch state for communication
 type branchSnapshot struct {
-	BranchID     string
-	Status       ExecutionStatus
-	StepName     string
-	StepOutput   any
-	NewBranches  []branchSpec
-	Error        error
-	Timestamp    time.Time
-	StartTime    time.Time
-	EndTime      time.Time
-	joinRequest  *joinRequest  // New field for join requests
-	waitRequest  *waitRequest  // Spike: branch is parking to wait for a signal
-	pauseRequest *pauseRequest // branch is parking due to a pause trigger
+	BranchID         string
+	Status           ExecutionStatus
+	StepName         string
+	StepOutput       any
+	NewBranches      []branchSpec
+	Error            error
+	Timestamp        time.Time
+	StartTime        time.Time
+	EndTime          time.Time
+	joinRequest      *joinRequest      // New field for join requests
+	waitRequest      *waitRequest      // Spike: branch is parking to wait for a signal
+	pauseRequest     *pauseRequest     // branch is parking due to a pause trigger
+	terminateRequest *terminateRequest // branch reached a triggered Terminate step
 }
 
 // waitRequest indicates a branch is hard-suspending on a durable wait
@@ -118,9 +176,42 @@ type branch struct {
 	endTime     time.Time
 	state       *BranchLocalState
 
+	// stepOutputOrder and maxStepOutputs bound stepOutputs to the most
+	// recent steps when WithMaxStepOutputs is set.
+	stepOutputOrder []string
+	maxStepOutputs  int
+
+	// parallelGroups and prefetched implement WithAutoParallelSteps:
+	// prefetched holds the outcomes of steps that already ran as part
+	// of a group, until the branch reaches them.
+	parallelGroups map[string][]*Step
+	prefetched     map[string]prefetchedStep
+
+	// activityTimeout is the WithDefaultActivityTimeout limit.
+	activityTimeout time.Duration
+
+	// handlingError is set once the branch has been routed to the
+	// workflow's OnError step, so a failure there is not routed again.
+	handlingError bool
+
+	// resumeEach is true until the branch has run its first step. Only
+	// that step can be an Each loop interrupted by a crash or
+	// suspension, whose recorded progress the branch picks up, or an
+	// Idempotent step whose recorded activity result is reused.
+	resumeEach bool
+
 	// Join coordination
 	resumeFromJoin chan struct{} // Channel to signal resumption from join
 
+	// cancel stops the branch's goroutine. Set by runBranches; used to
+	// abandon branches whose result is no longer needed.
+	cancel context.CancelFunc
+
+	// limiter bounds concurrently running branches; see branchOptions.
+	// holdsSlot is only touched by the branch's own goroutine.
+	limiter   *branchLimiter
+	holdsSlot bool
+
 	// Pause coordination
 	pauseMu     sync.Mutex
 	paused      bool
@@ -139,6 +230,7 @@ type branch struct {
 	logger             *slog.Logger
 	updates            chan<- branchSnapshot
 	scriptCompiler     script.Compiler
+	templateDelims     script.Delimiters
 	executionCallbacks ExecutionCallbacks
 }
 
@@ -152,13 +244,24 @@ func newBranch(id string, step *Step, opts branchOptions) *branch {
 
 	state := NewBranchLocalState(opts.Inputs, opts.Variables)
 
+	delims := opts.TemplateDelims
+	if delims == (script.Delimiters{}) {
+		delims = script.DefaultDelimiters
+	}
+
 	p := &branch{
 		id:                 id,
 		currentStep:        step,
 		status:             ExecutionStatusPending,
-		stepOutputs:        make(map[string]any),
+		stepOutputs:        copyMap(opts.StepOutputs),
+		stepOutputOrder:    slices.Clone(opts.StepOutputOrder),
+		maxStepOutputs:     opts.MaxStepOutputs,
+		parallelGroups:     opts.ParallelGroups,
+		activityTimeout:    opts.ActivityTimeout,
+		resumeEach:         true,
 		state:              state,
 		resumeFromJoin:     make(chan struct{}, 1), // Buffered channel for join resumption
+		limiter:            opts.limiter,
 		signalStore:        opts.SignalStore,
 		executionID:        opts.ExecutionID,
 		initialWait:        opts.InitialWait,
@@ -168,6 +271,7 @@ func newBranch(id string, step *Step, opts branchOptions) *branch {
 		logger:             logger,
 		updates:            opts.UpdatesChannel,
 		scriptCompiler:     opts.ScriptCompiler,
+		templateDelims:     delims,
 		executionCallbacks: opts.ExecutionCallbacks,
 	}
 	if opts.InitialPauseRequested {
@@ -260,7 +364,12 @@ func (p *branch) Run(ctx context.Context) error {
 
 		// Execute the current step
 		currentStep := p.currentStep
-		result, err := p.executeStep(ctx, currentStep)
+		var result any
+		err := p.activityExecutor.BeginStep(currentStep.Name, p.id)
+		if err == nil {
+			result, err = p.executeStep(ctx, currentStep)
+		}
+		p.resumeEach = false
 		if err != nil {
 			// Detect wait-unwind and park the branch instead of failing.
 			// The orchestrator will mark state as Suspended, checkpoint,
@@ -307,6 +416,9 @@ func (p *branch) Run(ctx context.Context) error {
 				}
 				return nil
 			}
+			if p.routeToOnError(ctx, currentStep, err) {
+				continue
+			}
 			p.status = ExecutionStatusFailed
 			p.endTime = time.Now()
 			p.updates <- branchSnapshot{
@@ -323,7 +435,12 @@ func (p *branch) Run(ctx context.Context) error {
 
 		// Handle join completion - continue normal execution
 		if result == "join_completed" {
-			// The join completed and this branch was resumed
+			// The join completed and this branch was resumed. A nil
+			// current step means the orchestrator finished the branch
+			// at the join; its goroutine has nothing left to do.
+			if p.currentStep == nil {
+				return nil
+			}
 			// Continue with normal execution flow (don't store output for join step)
 			continue
 		}
@@ -343,12 +460,23 @@ func (p *branch) Run(ctx context.Context) error {
 			continue
 		}
 
+		// A triggered Terminate step ends the branch here; the
+		// orchestrator cancels the other branches.
+		if req, ok := result.(*terminateRequest); ok {
+			p.updates <- p.terminateSnapshot(req)
+			return nil
+		}
+
 		// Store step output
-		p.stepOutputs[currentStep.Name] = result
+		p.stepOutputOrder = retainStepOutput(p.stepOutputs, p.stepOutputOrder,
+			currentStep.Name, result, p.maxStepOutputs)
 
 		// Handle branch branching (state is now current)
 		newBranchSpecs, err := p.handleBranching(ctx)
 		if err != nil {
+			if p.routeToOnError(ctx, currentStep, err) {
+				continue
+			}
 			p.status = ExecutionStatusFailed
 			p.endTime = time.Now()
 			p.updates <- branchSnapshot{
@@ -411,6 +539,26 @@ func (p *branch) executeStep(ctx context.Context, step *Step) (any, error) {
 		return p.handleJoinStep(ctx, step)
 	}
 
+	// A truthy Skip condition bypasses the step: nothing runs, Store is
+	// left untouched unless SkipValue is set, and the nil result flows
+	// on to Next.
+	if step.Skip != "" {
+		skip, err := p.evaluateCondition(ctx, step.Skip)
+		if err != nil {
+			return nil, fmt.Errorf("skip condition on step %q: %w", step.Name, err)
+		}
+		if skip {
+			p.logger.Debug("skipping step", "step_name", step.Name)
+			p.activityExecutor.SkipStep(ctx, step.Name, p.id, step.Activity)
+			if step.SkipValue != nil {
+				if err := p.storeValue(ctx, step, step.SkipValue); err != nil {
+					return nil, err
+				}
+			}
+			return nil, nil
+		}
+	}
+
 	// Check if this is a wait-signal step (declarative signal wait).
 	if step.WaitSignal != nil {
 		return p.handleWaitSignalStep(ctx, step)
@@ -426,15 +574,26 @@ func (p *branch) executeStep(ctx context.Context, step *Step) (any, error) {
 		return p.handlePauseStep(ctx, step)
 	}
 
+	// Check if this is a Terminate step.
+	if step.Terminate != nil {
+		return p.handleTerminateStep(ctx, step)
+	}
+
 	var result any
 	var err error
-
-	// Execute with retry logic if configured
-	retryConfigs := step.Retry
-	if len(retryConfigs) > 0 {
-		result, err = p.executeStepWithRetry(ctx, step, retryConfigs)
-	} else {
-		result, err = p.executeStepOnce(ctx, step)
+	if step.Before != "" {
+		err = p.runStepHook(ctx, step, "before", step.Before, nil)
+	}
+	// A failed Before script is handled like a failed activity.
+	if err == nil {
+		if prefetched, ok := p.prefetched[step.Name]; ok {
+			delete(p.prefetched, step.Name)
+			result, err = prefetched.result, prefetched.err
+		} else if group := p.parallelGroups[step.Name]; len(group) > 1 {
+			result, err = p.executeStepGroup(ctx, group)
+		} else {
+			result, err = p.executeStepActivity(ctx, step)
+		}
 	}
 
 	if err != nil {
@@ -446,8 +605,8 @@ func (p *branch) executeStep(ctx context.Context, step *Step) (any, error) {
 			return nil, err
 		}
 		// Try catch handlers for any step failure
-		if len(step.Catch) > 0 {
-			catchResult, catchErr := p.executeCatchHandler(step, err)
+		if len(step.Catch) > 0 || len(p.workflow.catchFallbacks) > 0 {
+			catchResult, catchErr := p.executeCatchHandler(ctx, step, err)
 			if catchErr == nil {
 				return catchResult, nil
 			}
@@ -458,23 +617,184 @@ func (p *branch) executeStep(ctx context.Context, step *Step) (any, error) {
 
 	// Store step result in branch-local state if not an each step (each steps handle their own storage)
 	if step.Each == nil {
-		// Store the result in a state variable if specified
-		if varName := step.Store; varName != "" {
-			// Strip "state." prefix if present
-			varName = strings.TrimPrefix(varName, "state.")
-
-			// Store the result directly in branch variables
-			var valueToStore interface{}
-			if result != nil {
-				valueToStore = result
-			}
-			p.state.Set(varName, valueToStore)
+		if err := p.storeStepResult(ctx, step, result); err != nil {
+			return nil, err
+		}
+	}
+
+	if step.After != "" {
+		if err := p.runStepHook(ctx, step, "after", step.After, result); err != nil {
+			return nil, err
 		}
 	}
 
 	return result, nil
 }
 
+// runStepHook evaluates a step's Before or After script with inputs
+// and state bound, plus result for After. State keys the script adds,
+// changes, or removes are written back to the branch, so they are
+// checkpointed like a stored result. The script fails the step if it
+// cannot be evaluated or evaluates to an error value.
+func (p *branch) runStepHook(ctx context.Context, step *Step, kind, code string, result any) error {
+	compiled, err := p.scriptCompiler.Compile(ctx, code)
+	if err != nil {
+		return fmt.Errorf("failed to compile %s script on step %q: %w", kind, step.Name, err)
+	}
+	globals := p.buildScriptGlobals()
+	state := globals["state"].(map[string]any)
+	original := copyMap(state)
+	if kind == "after" {
+		globals["result"] = script.NormalizeValue(result)
+	}
+	value, err := compiled.Evaluate(ctx, globals)
+	if err != nil {
+		return fmt.Errorf("%s script on step %q failed: %w", kind, step.Name, err)
+	}
+	if hookErr, ok := value.Value().(error); ok {
+		return fmt.Errorf("%s script on step %q failed: %w", kind, step.Name, hookErr)
+	}
+
+	for name, v := range state {
+		if prev, ok := original[name]; !ok || !reflect.DeepEqual(prev, v) {
+			p.setVariable(ctx, step.Name, name, v)
+		}
+	}
+	for name := range original {
+		if _, ok := state[name]; !ok {
+			p.deleteVariable(ctx, step.Name, name)
+		}
+	}
+	return nil
+}
+
+// executeStepActivity runs the step's activity, with retry logic if
+// configured. Workflow-level error policies are appended after the
+// step's own configs so they only apply when no step-level entry
+// matches.
+func (p *branch) executeStepActivity(ctx context.Context, step *Step) (any, error) {
+	retryConfigs := step.Retry
+	if policies := p.workflow.retryPolicies; len(policies) > 0 {
+		retryConfigs = append(retryConfigs[:len(retryConfigs):len(retryConfigs)], policies...)
+	}
+	if len(retryConfigs) > 0 {
+		return p.executeStepWithRetry(ctx, step, retryConfigs)
+	}
+	return p.executeStepOnce(ctx, step)
+}
+
+// storeStepResult writes result into the step's Store variable, if any.
+// When the step sets StoreExpression, the expression is evaluated with
+// result bound alongside state and inputs, and its value is stored
+// instead.
+func (p *branch) storeStepResult(ctx context.Context, step *Step, result any) error {
+	if step.Store == "" && step.StoreAppend == "" {
+		return nil
+	}
+	valueToStore := result
+	if step.StoreExpression != "" {
+		compiled, err := p.scriptCompiler.Compile(ctx, step.StoreExpression)
+		if err != nil {
+			return fmt.Errorf("failed to compile store expression %q: %w", step.StoreExpression, err)
+		}
+		globals := p.buildScriptGlobals()
+		globals["result"] = script.NormalizeValue(result)
+		value, err := compiled.Evaluate(ctx, globals)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate store expression %q: %w", step.StoreExpression, err)
+		}
+		valueToStore = value.Value()
+	}
+	return p.storeValue(ctx, step, valueToStore)
+}
+
+// storeValue sets the step's Store variable to value, or appends value
+// to its StoreAppend list.
+func (p *branch) storeValue(ctx context.Context, step *Step, value any) error {
+	// Strip "state." prefix if present
+	varName := strings.TrimPrefix(step.Store+step.StoreAppend, "state.")
+	valueToStore := value
+	// A nil result still sets the variable, so "stored nil" stays
+	// distinguishable from "never stored". Typed nil pointers become a
+	// plain nil so every script engine and ctx.Get caller sees the
+	// same value.
+	if isNilPointer(valueToStore) {
+		valueToStore = nil
+	}
+	if step.StoreAppend != "" {
+		existing, _ := p.state.Get(varName)
+		list, err := appendToList(existing, valueToStore)
+		if err != nil {
+			return fmt.Errorf("store_append %q on step %q: %w", varName, step.Name, err)
+		}
+		valueToStore = list
+	}
+	p.setVariable(ctx, step.Name, varName, valueToStore)
+	return nil
+}
+
+// appendToList returns a new []any holding the elements of list, which
+// may be nil or any slice or array, followed by value. The list is
+// copied so that earlier values of the variable, such as those held by
+// a checkpoint, are never modified.
+func appendToList(list, value any) ([]any, error) {
+	if list == nil {
+		return []any{value}, nil
+	}
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("existing value is a %T, not a list", list)
+	}
+	out := make([]any, 0, v.Len()+1)
+	for i := range v.Len() {
+		out = append(out, v.Index(i).Interface())
+	}
+	return append(out, value), nil
+}
+
+// deleteVariable removes a variable and reports the deletion to the
+// execution callbacks.
+func (p *branch) deleteVariable(ctx context.Context, stepName, name string) {
+	old, existed := p.state.remove(name)
+	if !existed || p.executionCallbacks == nil {
+		return
+	}
+	p.executionCallbacks.OnVariableChanged(ctx, &VariableChangeEvent{
+		ExecutionID:  p.executionID,
+		WorkflowName: p.workflow.Name(),
+		BranchID:     p.id,
+		StepName:     stepName,
+		Variable:     name,
+		OldValue:     old,
+		Deleted:      true,
+	})
+}
+
+// setVariable writes a Store target and reports the change to the
+// execution callbacks.
+func (p *branch) setVariable(ctx context.Context, stepName, name string, value any) {
+	old, _ := p.state.swap(name, value)
+	if p.executionCallbacks == nil {
+		return
+	}
+	p.executionCallbacks.OnVariableChanged(ctx, &VariableChangeEvent{
+		ExecutionID:  p.executionID,
+		WorkflowName: p.workflow.Name(),
+		BranchID:     p.id,
+		StepName:     stepName,
+		Variable:     name,
+		OldValue:     old,
+		NewValue:     value,
+	})
+}
+
+// isNilPointer reports whether v is a nil pointer held in a non-nil
+// interface.
+func isNilPointer(v any) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
 // handleWaitSignalStep executes a declarative WaitSignal step.
 //
 // Behavior (matches workflow.Wait):
@@ -539,7 +859,7 @@ func (p *branch) handleWaitSignalStep(ctx context.Context, step *Step) (any, err
 		// normal progress; processBranchSnapshot will clear BranchState.Wait.
 		if cfg.Store != "" {
 			varName := strings.TrimPrefix(cfg.Store, "state.")
-			p.state.Set(varName, sig.Payload)
+			p.setVariable(ctx, step.Name, varName, sig.Payload)
 		}
 		return sig.Payload, nil
 	}
@@ -742,11 +1062,17 @@ func (p *branch) handleJoinStep(ctx context.Context, step *Step) (any, error) {
 
 	p.logger.Debug("sent join request, waiting for other branches", "step_name", step.Name)
 
-	// Wait for the join to complete and this branch to be resumed
+	// Wait for the join to complete and this branch to be resumed. The
+	// branch gives up its concurrency slot while parked so the branches
+	// it waits for can run.
+	p.releaseSlot()
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	case <-p.resumeFromJoin:
+		if err := p.acquireSlot(ctx); err != nil {
+			return nil, err
+		}
 		p.logger.Debug("resumed from join", "step_name", step.Name)
 		// The branch's variables have been updated with merged state by the execution
 		// Continue normally - the current step will be updated by the execution
@@ -754,6 +1080,14 @@ func (p *branch) handleJoinStep(ctx context.Context, step *Step) (any, error) {
 	}
 }
 
+// releaseFromJoin lets a branch parked at a join exit without running
+// further steps. Called by the orchestrator after it has recorded the
+// branch as completed.
+func (p *branch) releaseFromJoin() {
+	p.currentStep = nil
+	p.resumeFromJoin <- struct{}{}
+}
+
 // executeStepOnce executes a step once without retry logic
 func (p *branch) executeStepOnce(ctx context.Context, step *Step) (any, error) {
 	// Handle steps with "each" blocks
@@ -773,33 +1107,82 @@ func (p *branch) executeStepOnce(ctx context.Context, step *Step) (any, error) {
 		return nil, fmt.Errorf("activity %q not found for step %q", activityName, step.Name)
 	}
 
+	if step.Idempotent && p.resumeEach {
+		if result, ok := p.activityExecutor.CompletedActivity(step.Name, p.id); ok {
+			p.logger.Info("reusing completed activity result", "step_name", step.Name, "activity", activityName)
+			return result, nil
+		}
+	}
+
+	// The default timeout starts before parameter evaluation so a slow
+	// template counts against the step.
+	stepCtx, finish := p.withDefaultTimeout(ctx, step)
+
 	// Prepare parameters by evaluating templates and script expressions
-	params, err := p.buildStepParameters(ctx, step)
+	params, err := p.buildStepParameters(stepCtx, step)
 	if err != nil {
-		return nil, err
+		return nil, finish(err)
 	}
 
 	// Execute activity through the activityExecutor with branch-local state
-	result, err := p.activityExecutor.ExecuteActivity(ctx, step.Name, p.id, activity, params, p.state)
-	if err != nil {
+	result, err := p.activityExecutor.ExecuteActivity(stepCtx, step.Name, p.id, activity, params, p.state)
+	if err = finish(err); err != nil {
 		return nil, fmt.Errorf("activity %q execution failed on step %q: %w",
 			activityName, step.Name, err)
 	}
 	return result, nil
 }
 
+// stepTimeoutKey marks a context whose deadline was set for the step
+// itself, which takes precedence over WithDefaultActivityTimeout.
+type stepTimeoutKey struct{}
+
+// withStepTimeout returns a context bounded by the step's own timeout.
+func withStepTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithValue(ctx, stepTimeoutKey{}, true), timeout)
+}
+
+// hasStepTimeout reports whether ctx carries a step's own timeout.
+func hasStepTimeout(ctx context.Context) bool {
+	set, _ := ctx.Value(stepTimeoutKey{}).(bool)
+	return set
+}
+
+// withDefaultTimeout bounds ctx by the default activity timeout unless
+// the step set its own. The returned finish function releases the
+// context and turns an error caused by the default deadline into a
+// timeout WorkflowError; it must be called exactly once.
+func (p *branch) withDefaultTimeout(ctx context.Context, step *Step) (context.Context, func(error) error) {
+	if p.activityTimeout <= 0 || hasStepTimeout(ctx) {
+		return ctx, func(err error) error { return err }
+	}
+	bounded, cancel := context.WithTimeout(ctx, p.activityTimeout)
+	return bounded, func(err error) error {
+		defer cancel()
+		if err == nil || ctx.Err() != nil || !errors.Is(bounded.Err(), context.DeadlineExceeded) {
+			return err
+		}
+		return &WorkflowError{
+			Type:    ErrorTypeTimeout,
+			Cause:   fmt.Sprintf("activity %q on step %q exceeded the default timeout of %s", step.Activity, step.Name, p.activityTimeout),
+			Wrapped: err,
+		}
+	}
+}
+
 // executeStepWithRetry executes a step with retry logic using multiple retry configurations
 func (p *branch) executeStepWithRetry(ctx context.Context, step *Step, retryConfigs []*RetryConfig) (any, error) {
 	var lastErr error
 	var activeRetryConfig *RetryConfig
 	attempts := 0
+	start := time.Now()
 
 	for {
 		// Create timeout context using the active retry config's timeout
 		stepCtx := ctx
 		var cancel context.CancelFunc
 		if activeRetryConfig != nil && activeRetryConfig.Timeout > 0 {
-			stepCtx, cancel = context.WithTimeout(ctx, activeRetryConfig.Timeout)
+			stepCtx, cancel = withStepTimeout(ctx, activeRetryConfig.Timeout)
 		}
 
 		result, err := p.executeStepOnce(stepCtx, step)
@@ -837,7 +1220,8 @@ func (p *branch) executeStepWithRetry(ctx context.Context, step *Step, retryConf
 		}
 
 		// Check if we've exceeded max attempts for the active retry configuration
-		if attempts >= activeRetryConfig.MaxRetries {
+		unlimited := activeRetryConfig.MaxRetries == UnlimitedRetries
+		if !unlimited && attempts >= activeRetryConfig.MaxRetries {
 			p.logger.Info("step exceeded max retry attempts",
 				"step_name", step.Name,
 				"attempts", attempts+1,
@@ -851,10 +1235,19 @@ func (p *branch) executeStepWithRetry(ctx context.Context, step *Step, retryConf
 		// Calculate and wait for backoff delay
 		delay := p.calculateBackoffDelay(attempts, activeRetryConfig)
 
+		// Stop if waiting out the delay would overrun the time budget
+		if budget := activeRetryConfig.MaxElapsed; budget > 0 && time.Since(start)+delay > budget {
+			p.logger.Info("step exceeded retry time budget",
+				"step_name", step.Name,
+				"attempts", attempts,
+				"max_elapsed", budget)
+			return nil, err
+		}
+
 		p.logger.Info("retrying step",
 			"step_name", step.Name,
 			"attempt", attempts+1,
-			"max_attempts", activeRetryConfig.MaxRetries+1,
+			"max_attempts", maxAttemptsLabel(activeRetryConfig),
 			"delay", delay,
 			"error_type", ClassifyError(err).Type)
 
@@ -873,29 +1266,17 @@ func (p *branch) handleBranching(ctx context.Context) ([]branchSpec, error) {
 		return nil, nil // No outgoing edges means this branch is complete
 	}
 
-	// Get the edge matching strategy for this step
-	strategy := p.currentStep.GetEdgeMatchingStrategy()
-
 	// Evaluate conditions and collect matching edges (state is now current)
-	var matchingEdges []*Edge
-	for _, edge := range edges {
-		if edge.Condition == "" {
-			matchingEdges = append(matchingEdges, edge)
-		} else {
-			match, err := p.evaluateCondition(ctx, edge.Condition)
-			if err != nil {
-				return nil, fmt.Errorf("failed to evaluate condition %q in step %q: %w",
-					edge.Condition, p.currentStep.Name, err)
-			}
-			if match {
-				matchingEdges = append(matchingEdges, edge)
-			}
-		}
-
-		// If using "first" strategy and we found a match, stop here
-		if strategy == EdgeMatchingFirst && len(matchingEdges) > 0 {
-			break
+	matchingEdges, err := selectEdges(p.currentStep, func(edge *Edge) (bool, error) {
+		match, err := p.evaluateEdge(ctx, edge)
+		if err != nil {
+			return false, fmt.Errorf("failed to evaluate %s in step %q: %w",
+				edge.describeCondition(), p.currentStep.Name, err)
 		}
+		return match, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Create branch specs for each matching edge, copying current branch's state
@@ -907,14 +1288,74 @@ func (p *branch) handleBranching(ctx context.Context) ([]branchSpec, error) {
 		}
 		// Copy current branch's variables to the new branch
 		pathSpecs = append(pathSpecs, branchSpec{
-			Step:      nextStep,
-			Variables: p.Variables(),
-			Name:      edge.BranchName,
+			Step:            nextStep,
+			Variables:       p.Variables(),
+			Name:            edge.BranchName,
+			StepOutputs:     copyMap(p.stepOutputs),
+			StepOutputOrder: slices.Clone(p.stepOutputOrder),
 		})
 	}
 	return pathSpecs, nil
 }
 
+// selectEdges returns the outgoing edges of step to follow, in order.
+// evaluate is called for each edge with a Condition or Predicate. Else
+// edges are returned only when no conditional edge matched (or, under
+// EdgeMatchingFirst, when no edge matched at all).
+func selectEdges(step *Step, evaluate func(edge *Edge) (bool, error)) ([]*Edge, error) {
+	strategy := step.GetEdgeMatchingStrategy()
+
+	var matching, elseEdges []*Edge
+	conditionMatched := false
+	for _, edge := range step.Next {
+		switch {
+		case edge.Else:
+			elseEdges = append(elseEdges, edge)
+			continue
+		case !edge.conditional():
+			matching = append(matching, edge)
+		default:
+			match, err := evaluate(edge)
+			if err != nil {
+				return nil, err
+			}
+			if match {
+				matching = append(matching, edge)
+				conditionMatched = true
+			}
+		}
+
+		// If using "first" strategy and we found a match, stop here
+		if strategy == EdgeMatchingFirst && len(matching) > 0 {
+			return matching, nil
+		}
+	}
+
+	if !conditionMatched && len(elseEdges) > 0 {
+		if strategy == EdgeMatchingFirst {
+			return elseEdges[:1], nil
+		}
+		matching = append(matching, elseEdges...)
+	}
+	return matching, nil
+}
+
+// evaluateEdge reports whether a conditional edge matches, calling its
+// Predicate when set and evaluating its Condition otherwise.
+func (p *branch) evaluateEdge(ctx context.Context, edge *Edge) (bool, error) {
+	if edge.Predicate == nil {
+		return p.evaluateCondition(ctx, edge.Condition)
+	}
+	return edge.Predicate(NewContext(ctx, ExecutionContextOptions{
+		BranchLocalState: p.state,
+		Logger:           p.logger,
+		Compiler:         p.scriptCompiler,
+		BranchID:         p.id,
+		StepName:         p.currentStep.Name,
+		ExecutionID:      p.executionID,
+	}))
+}
+
 // evaluateCondition evaluates a workflow condition. Conditions are
 // raw script expressions (e.g. "state.count > 3"). The literal strings
 // "true" and "false" are recognized as shortcuts.
@@ -941,7 +1382,7 @@ func (p *branch) evaluateCondition(ctx context.Context, condition string) (bool,
 // string. Used for signal topic names and other contexts that require
 // a string result.
 func (p *branch) evaluateTemplateString(ctx context.Context, template string) (string, error) {
-	tmpl, err := script.NewTemplate(p.scriptCompiler, template)
+	tmpl, err := script.NewTemplateWithDelimiters(p.scriptCompiler, template, p.templateDelims)
 	if err != nil {
 		return "", fmt.Errorf("failed to compile template: %w", err)
 	}
@@ -992,23 +1433,45 @@ func (p *branch) executeStepEach(ctx context.Context, step *Step) (any, error) {
 		}
 	}
 
+	// Iterations finished before a crash or suspension are not run
+	// again; their recorded results take their place.
+	completed := p.activityExecutor.BeginEach(step.Name, p.id, len(items), p.resumeEach)
+
+	if (each.Parallel || each.MaxConcurrency > 1) && len(items) > 1 {
+		results, err := p.executeEachConcurrently(ctx, step, activity, items, completed, restoreAs)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.storeStepResult(ctx, step, results); err != nil {
+			return nil, err
+		}
+		return results, nil
+	}
+
 	// Execute for each item
-	for _, item := range items {
+	for i, item := range items {
+		if result, ok := completed[i]; ok {
+			results = append(results, result)
+			continue
+		}
+
 		// Prepare additional parameters for this iteration
 		if each.As != "" {
 			p.state.Set(each.As, item)
 		}
 
-		// Prepare parameters for this iteration
-		params, err := p.buildStepParameters(ctx, step)
+		// Prepare parameters for this iteration; the default timeout
+		// covers them and the item's activity together.
+		itemCtx, finish := p.withDefaultTimeout(ctx, step)
+		params, err := p.buildStepParameters(itemCtx, step)
 		if err != nil {
 			restoreAs()
-			return nil, err
+			return nil, finish(err)
 		}
 
 		// Execute activity for this item
-		result, err := p.activityExecutor.ExecuteActivity(ctx, step.Name, p.id, activity, params, p.state)
-		if err != nil {
+		result, err := p.activityExecutor.ExecuteEachItem(itemCtx, step.Name, p.id, i, activity, params, p.state)
+		if err = finish(err); err != nil {
 			restoreAs()
 			return nil, err
 		}
@@ -1018,13 +1481,122 @@ func (p *branch) executeStepEach(ctx context.Context, step *Step) (any, error) {
 	restoreAs()
 
 	// Store result directly in branch variables if specified
-	if step.Store != "" {
-		varName := strings.TrimPrefix(step.Store, "state.")
-		p.state.Set(varName, results)
+	if err := p.storeStepResult(ctx, step, results); err != nil {
+		return nil, err
 	}
 	return results, nil
 }
 
+// executeEachConcurrently runs an Each step's iterations with at most
+// Each.MaxConcurrency activities in flight, or all of them for a
+// Parallel step without a limit. Parameters for every item are
+// evaluated up front, then restoreAs is called before any activity
+// starts. Each item's activity gets its own copy of the branch state,
+// taken while its As variable is set; without Parallel, the writes it
+// makes to that copy are merged back into the branch once it finishes,
+// while with Parallel they are discarded. Items
+// with a result in completed are not run again. The first failure
+// cancels the remaining iterations.
+func (p *branch) executeEachConcurrently(ctx context.Context, step *Step, activity Activity, items []any, completed map[int]any, restoreAs func()) ([]any, error) {
+	each := step.Each
+	params := make([]map[string]any, len(items))
+	states := make([]*BranchLocalState, len(items))
+	before := make([]map[string]any, len(items))
+	for i, item := range items {
+		if _, ok := completed[i]; ok {
+			continue
+		}
+		if each.As != "" {
+			p.state.Set(each.As, item)
+		}
+		paramCtx, finish := p.withDefaultTimeout(ctx, step)
+		itemParams, err := p.buildStepParameters(paramCtx, step)
+		if err = finish(err); err != nil {
+			restoreAs()
+			return nil, err
+		}
+		params[i] = itemParams
+		before[i] = p.Variables()
+		states[i] = NewBranchLocalState(p.state.inputsSnapshot(), before[i])
+	}
+	restoreAs()
+
+	limit := each.MaxConcurrency
+	if limit <= 0 {
+		limit = len(items)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+		slots    = make(chan struct{}, limit)
+		results  = make([]any, len(items))
+	)
+	for i := range items {
+		if result, ok := completed[i]; ok {
+			results[i] = result
+			continue
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			itemCtx, finish := p.withDefaultTimeout(ctx, step)
+			result, err := p.activityExecutor.ExecuteEachItem(itemCtx, step.Name, p.id, i, activity, params[i], states[i])
+			if !each.Parallel {
+				p.mergeEachItemWrites(each.As, before[i], states[i])
+			}
+			if err = finish(err); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			// Indexed writes keep item order regardless of completion order.
+			results[i] = result
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// mergeEachItemWrites applies the variable writes an Each item's
+// activity made to its copy of the branch state, taken as before, to
+// the branch itself. The As variable is left out, since it only held
+// the item. Items that finish later win when they write the same
+// variable.
+func (p *branch) mergeEachItemWrites(as string, before map[string]any, item *BranchLocalState) {
+	item.mu.RLock()
+	after := copyMap(item.variables)
+	item.mu.RUnlock()
+	var writes []patch
+	for _, w := range generatePatches(before, after) {
+		if w.variable != as {
+			writes = append(writes, w)
+		}
+	}
+	applyPatches(p.state, writes)
+}
+
 // resolveEachItems resolves the array of items for an Each block.
 // A string value is treated as a raw script expression evaluated
 // against the branch globals; array values are returned as-is.
@@ -1076,15 +1648,12 @@ func (p *branch) calculateBackoffDelay(attempt int, retryConfig *RetryConfig) ti
 		backoffRate = 2.0 // Default backoff rate
 	}
 
-	// Exponential backoff
-	delay := time.Duration(float64(baseDelay) * float64(int64(1)<<(attempt-1)))
-	if backoffRate != 2.0 {
-		// Use custom backoff rate instead of power of 2
-		multiplier := 1.0
-		for i := 1; i < attempt; i++ {
-			multiplier *= backoffRate
-		}
-		delay = time.Duration(float64(baseDelay) * multiplier)
+	// Exponential backoff, saturating rather than overflowing so that
+	// long runs of unlimited retries stay capped by MaxDelay
+	scaled := float64(baseDelay) * math.Pow(backoffRate, float64(attempt-1))
+	delay := time.Duration(math.MaxInt64)
+	if scaled < float64(math.MaxInt64) {
+		delay = time.Duration(scaled)
 	}
 
 	// Apply max delay cap if configured
@@ -1106,6 +1675,14 @@ func (p *branch) calculateBackoffDelay(attempt int, retryConfig *RetryConfig) ti
 	return delay
 }
 
+// maxAttemptsLabel reports a retry config's attempt limit for logging.
+func maxAttemptsLabel(rc *RetryConfig) any {
+	if rc.MaxRetries == UnlimitedRetries {
+		return "unlimited"
+	}
+	return rc.MaxRetries + 1
+}
+
 // findMatchingRetryConfig finds the first retry configuration that matches the given error
 func (p *branch) findMatchingRetryConfig(err error, retryConfigs []*RetryConfig) *RetryConfig {
 	for _, config := range retryConfigs {
@@ -1126,10 +1703,15 @@ func (p *branch) findMatchingRetryConfig(err error, retryConfigs []*RetryConfig)
 }
 
 // executeCatchHandler executes catch handling logic when an error occurs
-func (p *branch) executeCatchHandler(step *Step, err error) (any, error) {
+func (p *branch) executeCatchHandler(ctx context.Context, step *Step, err error) (any, error) {
 	wErr := ClassifyError(err)
+	// Step-level handlers take precedence over workflow-level policies
+	catchConfigs := step.Catch
+	if len(p.workflow.catchFallbacks) > 0 {
+		catchConfigs = append(catchConfigs[:len(catchConfigs):len(catchConfigs)], p.workflow.catchFallbacks...)
+	}
 	// Find matching catch configuration
-	for _, catchConfig := range step.Catch {
+	for _, catchConfig := range catchConfigs {
 		for _, errorType := range catchConfig.ErrorEquals {
 			if MatchesErrorType(err, errorType) {
 				// Found a matching catch handler
@@ -1145,7 +1727,7 @@ func (p *branch) executeCatchHandler(step *Step, err error) (any, error) {
 				if catchConfig.Store != "" {
 					resultPath := strings.TrimPrefix(catchConfig.Store, "state.")
 					if resultPath != "" {
-						p.state.Set(resultPath, errorOutput)
+						p.setVariable(ctx, step.Name, resultPath, errorOutput)
 					}
 				}
 
@@ -1168,15 +1750,47 @@ func (p *branch) executeCatchHandler(step *Step, err error) (any, error) {
 	return nil, err
 }
 
-// buildScriptGlobals creates globals used for script execution
+// routeToOnError moves the branch to the workflow's OnError step after
+// step failed with err, storing the error in state. It reports false
+// when the branch should fail instead: no OnError step is configured,
+// the branch is already handling an error, the execution was canceled,
+// or err is one that no catch handler may match.
+func (p *branch) routeToOnError(ctx context.Context, step *Step, err error) bool {
+	name := p.workflow.onError
+	if name == "" || p.handlingError || step.Name == name || ctx.Err() != nil ||
+		!MatchesErrorType(err, ErrorTypeAll) {
+		return false
+	}
+	handler, ok := p.workflow.GetStep(name)
+	if !ok {
+		return false
+	}
+	wErr := ClassifyError(err)
+	p.logger.Info("routing unhandled error to on_error step",
+		"step_name", step.Name,
+		"error_type", wErr.Type,
+		"next_step", name)
+	p.setVariable(ctx, step.Name, ErrorVariable, wErr.ToErrorOutput())
+	p.setVariable(ctx, step.Name, ErrorStepVariable, step.Name)
+	p.handlingError = true
+	p.currentStep = handler
+	return true
+}
+
+// buildScriptGlobals creates globals used for script execution. Numbers
+// are normalized with script.NormalizeValue so conditions see int and
+// float64 regardless of which activity or engine produced them. steps
+// holds the raw results of the steps this branch has completed, keyed
+// by step name.
 func (p *branch) buildScriptGlobals() map[string]any {
 	p.state.mu.RLock()
-	inputs := copyMap(p.state.inputs)
-	variables := copyMap(p.state.variables)
+	inputs := script.NormalizeMap(p.state.inputs)
+	variables := script.NormalizeMap(p.state.variables)
 	p.state.mu.RUnlock()
 	return map[string]any{
 		"inputs": inputs,
 		"state":  variables,
+		"steps":  script.NormalizeMap(p.stepOutputs),
 	}
 }
 
@@ -1216,11 +1830,11 @@ func (p *branch) evaluateParameterValue(ctx context.Context, value interface{},
 	if !ok {
 		return value, nil
 	}
-	if !strings.Contains(strValue, "${") {
+	if !p.templateDelims.IsTemplate(strValue) {
 		return value, nil
 	}
 
-	tmpl, err := script.NewTemplate(p.scriptCompiler, strValue)
+	tmpl, err := script.NewTemplateWithDelimiters(p.scriptCompiler, strValue, p.templateDelims)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compile parameter template %q in step %q: %w",
 			paramName, stepName, err)
@@ -2,6 +2,9 @@ package workflow
 
 import (
 	"context"
+	"errors"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -191,3 +194,279 @@ func TestBranchJoining(t *testing.T) {
 		require.Equal(t, 50, outputs["result"]) // 20 + 30
 	})
 }
+
+func TestNestedJoinsTwoLevelDiamond(t *testing.T) {
+	wf, err := New(Options{
+		Name: "nested-join",
+		Steps: []*Step{
+			{
+				Name:     "start",
+				Activity: "value",
+				Store:    "base",
+				Next: []*Edge{
+					{Step: "left_fan", BranchName: "left"},
+					{Step: "right_fan", BranchName: "right"},
+					{Step: "final_join", BranchName: "final"},
+				},
+			},
+			// Left diamond: l1 and l2 fan out and meet at left_join.
+			{
+				Name:     "left_fan",
+				Activity: "value",
+				Store:    "fan",
+				Next: []*Edge{
+					{Step: "work", BranchName: "l1"},
+					{Step: "work", BranchName: "l2"},
+					{Step: "left_join", BranchName: "left_merge"},
+				},
+			},
+			{
+				Name: "left_join",
+				Join: &JoinConfig{
+					Branches:       []string{"l1", "l2"},
+					BranchMappings: map[string]string{"l1.out": "parts.a", "l2.out": "parts.b"},
+				},
+				Next: []*Edge{{Step: "left_sum"}},
+			},
+			{Name: "left_sum", Activity: "sum_parts", Store: "subtotal"},
+			// Right diamond mirrors the left one.
+			{
+				Name:     "right_fan",
+				Activity: "value",
+				Store:    "fan",
+				Next: []*Edge{
+					{Step: "work", BranchName: "r1"},
+					{Step: "work", BranchName: "r2"},
+					{Step: "right_join", BranchName: "right_merge"},
+				},
+			},
+			{
+				Name: "right_join",
+				Join: &JoinConfig{
+					Branches:       []string{"r1", "r2"},
+					BranchMappings: map[string]string{"r1.out": "parts.a", "r2.out": "parts.b"},
+				},
+				Next: []*Edge{{Step: "right_sum"}},
+			},
+			{Name: "right_sum", Activity: "sum_parts", Store: "subtotal"},
+			// Shared leaf step used by all four leaf branches.
+			{Name: "work", Activity: "work", Store: "out"},
+			// The outer join consumes the results of the inner joins.
+			{
+				Name: "final_join",
+				Join: &JoinConfig{
+					Branches: []string{"left_merge", "right_merge"},
+					BranchMappings: map[string]string{
+						"left_merge.subtotal":  "totals.left",
+						"right_merge.subtotal": "totals.right",
+					},
+				},
+				Next: []*Edge{{Step: "grand_total"}},
+			},
+			{Name: "grand_total", Activity: "grand_total", Store: "total"},
+		},
+		Outputs: []*Output{
+			{Name: "total", Variable: "total", Branch: "final"},
+		},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("value", func(ctx Context, params map[string]any) (any, error) {
+		return 1, nil
+	}))
+	reg.MustRegister(ActivityFunc("work", func(ctx Context, params map[string]any) (any, error) {
+		// Leaf branches finish at staggered times so joins see partial
+		// completion before becoming ready.
+		if ctx.BranchID() == "l2" || ctx.BranchID() == "r1" {
+			time.Sleep(10 * time.Millisecond)
+		}
+		return map[string]any{"l1": 1, "l2": 2, "r1": 10, "r2": 20}[ctx.BranchID()], nil
+	}))
+	reg.MustRegister(ActivityFunc("sum_parts", func(ctx Context, params map[string]any) (any, error) {
+		parts, _ := ctx.Get("parts")
+		m := parts.(map[string]any)
+		return m["a"].(int) + m["b"].(int), nil
+	}))
+	reg.MustRegister(ActivityFunc("grand_total", func(ctx Context, params map[string]any) (any, error) {
+		totals, _ := ctx.Get("totals")
+		m := totals.(map[string]any)
+		return m["left"].(int) + m["right"].(int), nil
+	}))
+
+	exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := exec.Execute(ctx)
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.Equal(t, 33, result.Outputs["total"])
+
+	// Each join records the branches it consumed, and the joining branch
+	// keeps its own variables alongside the merged ones.
+	states := exec.state.GetBranchStates()
+	for _, id := range []string{"l1", "l2"} {
+		require.Equal(t, "left_join", states[id].JoinedBy)
+	}
+	for _, id := range []string{"r1", "r2"} {
+		require.Equal(t, "right_join", states[id].JoinedBy)
+	}
+	for _, id := range []string{"left_merge", "right_merge"} {
+		require.Equal(t, "final_join", states[id].JoinedBy)
+		require.Equal(t, 1, states[id].Variables["fan"])
+	}
+	require.Equal(t, 1, states["final"].Variables["base"])
+}
+
+func TestJoinModes(t *testing.T) {
+	// raceWorkflow fans out to three racing branches and a join step
+	// configured with the given mode and count.
+	raceWorkflow := func(t *testing.T, mode JoinMode, count int) *Workflow {
+		wf, err := New(Options{
+			Name: "join-race",
+			Steps: []*Step{
+				{
+					Name: "start",
+					Next: []*Edge{
+						{Step: "fetch", BranchName: "fast"},
+						{Step: "fetch", BranchName: "medium"},
+						{Step: "fetch", BranchName: "slow"},
+						{Step: "race", BranchName: "winner"},
+					},
+					Activity: "noop",
+				},
+				{Name: "fetch", Activity: "fetch", Store: "source", Next: []*Edge{{Step: "after_fetch"}}},
+				{Name: "after_fetch", Activity: "after_fetch"},
+				{
+					Name: "race",
+					Join: &JoinConfig{
+						Branches: []string{"fast", "medium", "slow"},
+						Mode:     mode,
+						Count:    count,
+						BranchMappings: map[string]string{
+							"fast.source":   "results.fast",
+							"medium.source": "results.medium",
+							"slow.source":   "results.slow",
+						},
+					},
+				},
+			},
+			Outputs: []*Output{{Name: "results", Variable: "results", Branch: "winner"}},
+		})
+		require.NoError(t, err)
+		return wf
+	}
+
+	type raceLog struct {
+		canceled []string
+		after    []string
+	}
+	run := func(t *testing.T, wf *Workflow, delays map[string]time.Duration) (*Execution, *ExecutionResult, *raceLog) {
+		log := &raceLog{}
+		var mu sync.Mutex
+		// All racers are inside the activity before any of them
+		// finishes, so the losers are canceled mid-flight.
+		var started sync.WaitGroup
+		started.Add(len(delays))
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("noop", func(ctx Context, params map[string]any) (any, error) {
+			return nil, nil
+		}))
+		reg.MustRegister(ActivityFunc("fetch", func(ctx Context, params map[string]any) (any, error) {
+			started.Done()
+			started.Wait()
+			select {
+			case <-time.After(delays[ctx.BranchID()]):
+				return ctx.BranchID(), nil
+			case <-ctx.Done():
+				mu.Lock()
+				defer mu.Unlock()
+				log.canceled = append(log.canceled, ctx.BranchID())
+				return nil, ctx.Err()
+			}
+		}))
+		reg.MustRegister(ActivityFunc("after_fetch", func(ctx Context, params map[string]any) (any, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			log.after = append(log.after, ctx.BranchID())
+			return nil, nil
+		}))
+		exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		result, err := exec.Execute(ctx)
+		require.NoError(t, err)
+		return exec, result, log
+	}
+
+	t.Run("any cancels the losing branches", func(t *testing.T) {
+		exec, result, log := run(t, raceWorkflow(t, JoinModeAny, 0), map[string]time.Duration{
+			"fast":   0,
+			"medium": time.Minute,
+			"slow":   time.Minute,
+		})
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		require.Equal(t, map[string]any{"fast": "fast"}, result.Outputs["results"])
+
+		states := exec.state.GetBranchStates()
+		require.Equal(t, ExecutionStatusCompleted, states["fast"].Status)
+		require.Equal(t, ExecutionStatusCanceled, states["medium"].Status)
+		require.Equal(t, ExecutionStatusCanceled, states["slow"].Status)
+		sort.Strings(log.canceled)
+		require.Equal(t, []string{"medium", "slow"}, log.canceled)
+		// Losers never advance past the step they were canceled in.
+		require.Equal(t, []string{"fast"}, log.after)
+	})
+
+	t.Run("count proceeds after N and cancels the rest", func(t *testing.T) {
+		exec, result, log := run(t, raceWorkflow(t, JoinModeCount, 2), map[string]time.Duration{
+			"fast":   0,
+			"medium": 10 * time.Millisecond,
+			"slow":   time.Minute,
+		})
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		require.Equal(t, map[string]any{"fast": "fast", "medium": "medium"}, result.Outputs["results"])
+		require.Equal(t, ExecutionStatusCanceled, exec.state.GetBranchStates()["slow"].Status)
+		require.Equal(t, []string{"slow"}, log.canceled)
+	})
+
+	t.Run("all waits for every listed branch", func(t *testing.T) {
+		exec, result, log := run(t, raceWorkflow(t, JoinModeAll, 0), map[string]time.Duration{
+			"fast":   0,
+			"medium": 5 * time.Millisecond,
+			"slow":   10 * time.Millisecond,
+		})
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		require.Len(t, result.Outputs["results"], 3)
+		require.Empty(t, log.canceled)
+		for _, id := range []string{"fast", "medium", "slow"} {
+			require.Equal(t, ExecutionStatusCompleted, exec.state.GetBranchStates()[id].Status)
+		}
+	})
+
+	t.Run("invalid mode configurations are rejected", func(t *testing.T) {
+		cases := []*JoinConfig{
+			{Mode: "first"},
+			{Mode: JoinModeAny},
+			{Mode: JoinModeCount, Branches: []string{"a"}},
+			{Mode: JoinModeCount, Branches: []string{"a"}, Count: 2},
+		}
+		for _, join := range cases {
+			_, err := New(Options{
+				Name: "bad-join",
+				Steps: []*Step{
+					{Name: "start", Activity: "noop", Next: []*Edge{
+						{Step: "work", BranchName: "a"},
+						{Step: "join", BranchName: "j"},
+					}},
+					{Name: "work", Activity: "noop"},
+					{Name: "join", Join: join},
+				},
+			})
+			require.Error(t, err)
+			require.True(t, errors.Is(err, ErrInvalidJoinConfig), "mode %q", join.Mode)
+		}
+	})
+}
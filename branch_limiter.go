@@ -0,0 +1,58 @@
+package workflow
+
+import "context"
+
+// branchLimiter bounds how many branches run at once. Branches beyond
+// the limit wait in runBranches until a running branch completes or
+// parks at a join. A nil *branchLimiter never blocks.
+type branchLimiter struct {
+	slots chan struct{}
+}
+
+// newBranchLimiter returns a limiter allowing n concurrent branches, or
+// nil when n is not positive.
+func newBranchLimiter(n int) *branchLimiter {
+	if n <= 0 {
+		return nil
+	}
+	return &branchLimiter{slots: make(chan struct{}, n)}
+}
+
+// acquire blocks until a slot is free or ctx is done.
+func (l *branchLimiter) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken by acquire.
+func (l *branchLimiter) release() {
+	if l == nil {
+		return
+	}
+	<-l.slots
+}
+
+// acquireSlot takes a concurrency slot for the branch, waiting for one
+// to free up if the execution is at its limit.
+func (p *branch) acquireSlot(ctx context.Context) error {
+	if err := p.limiter.acquire(ctx); err != nil {
+		return err
+	}
+	p.holdsSlot = true
+	return nil
+}
+
+// releaseSlot gives up the branch's slot, if it holds one.
+func (p *branch) releaseSlot() {
+	if p.holdsSlot {
+		p.holdsSlot = false
+		p.limiter.release()
+	}
+}
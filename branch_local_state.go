@@ -27,16 +27,30 @@ func NewBranchLocalState(inputs, variables map[string]any) *BranchLocalState {
 
 // Set writes a branch-local variable.
 func (s *BranchLocalState) Set(key string, value any) {
+	s.swap(key, value)
+}
+
+// Delete removes a branch-local variable.
+func (s *BranchLocalState) Delete(key string) {
+	s.remove(key)
+}
+
+// swap writes a variable and returns its previous value.
+func (s *BranchLocalState) swap(key string, value any) (old any, existed bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	old, existed = s.variables[key]
 	s.variables[key] = value
+	return old, existed
 }
 
-// Delete removes a branch-local variable.
-func (s *BranchLocalState) Delete(key string) {
+// remove deletes a variable and returns the value it had.
+func (s *BranchLocalState) remove(key string) (old any, existed bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	old, existed = s.variables[key]
 	delete(s.variables, key)
+	return old, existed
 }
 
 // Keys returns the names of all branch-local variables in sorted
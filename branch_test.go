@@ -2,8 +2,11 @@ package workflow
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"sync"
 	"testing"
 	"time"
 
@@ -227,6 +230,31 @@ func TestBranchConditionEvaluation(t *testing.T) {
 		require.False(t, result)
 	})
 
+	t.Run("composite boolean expressions", func(t *testing.T) {
+		// The test compiler is a minimal parser; use the default engine.
+		branch := newBranch("test-branch", step, branchOptions{
+			Workflow:         workflow,
+			Variables:        map[string]any{"count": 5, "enabled": true},
+			Inputs:           map[string]any{"threshold": 3},
+			ScriptCompiler:   DefaultScriptCompiler(),
+			UpdatesChannel:   make(chan branchSnapshot, 1),
+			Logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+			ActivityRegistry: map[string]Activity{},
+		})
+		for condition, want := range map[string]bool{
+			"state.count > 3 && state.enabled":                            true,
+			"state.count > 10 && state.enabled":                           false,
+			"state.count > 10 || state.enabled":                           true,
+			"state.count > 10 || !state.enabled":                          false,
+			"(state.count > 10 || state.enabled) && inputs.threshold < 5": true,
+			"!(state.count > 3 && state.enabled)":                         false,
+		} {
+			result, err := branch.evaluateCondition(ctx, condition)
+			require.NoError(t, err, condition)
+			require.Equal(t, want, result, condition)
+		}
+	})
+
 	t.Run("malformed expression returns error", func(t *testing.T) {
 		_, err := branch.evaluateCondition(ctx, "invalid (((( syntax")
 		require.Error(t, err)
@@ -240,6 +268,111 @@ func TestBranchConditionEvaluation(t *testing.T) {
 	})
 }
 
+// TestPollUntilCompositeCondition waits for two conditions joined by
+// AND: the poll step loops back on itself until both parts hold.
+func TestPollUntilCompositeCondition(t *testing.T) {
+	wf, err := New(Options{
+		Name: "poll-until",
+		Steps: []*Step{
+			{
+				Name:     "poll",
+				Activity: "poll",
+				Store:    "status",
+				Next: []*Edge{
+					{Step: "done", Condition: "state.status.export_ready && state.status.approved"},
+					{Step: "poll", Condition: "!(state.status.export_ready && state.status.approved)"},
+				},
+			},
+			{Name: "done", Activity: "poll", Store: "final"},
+		},
+		Outputs: []*Output{{Name: "final", Variable: "final"}},
+	})
+	require.NoError(t, err)
+
+	// The export becomes ready on the 2nd poll and approval arrives on
+	// the 4th; only then does the AND hold.
+	polls := 0
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("poll", func(ctx Context, params map[string]any) (any, error) {
+		polls++
+		return map[string]any{
+			"export_ready": polls >= 2,
+			"approved":     polls >= 4,
+			"polls":        polls,
+		}, nil
+	}))
+
+	exec, err := NewExecution(wf, reg)
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.Equal(t, 5, result.Outputs["final"].(map[string]any)["polls"])
+}
+
+// TestEdgePredicate routes with Go predicates instead of condition
+// strings, and checks that a Predicate wins over a Condition.
+func TestEdgePredicate(t *testing.T) {
+	belowLimit := func(ctx Context) (bool, error) {
+		n, _ := ctx.Get("n")
+		limit, _ := ctx.Inputs().Get("limit")
+		return n.(int) < limit.(int), nil
+	}
+	newWorkflow := func(final *Edge) *Workflow {
+		wf, err := New(Options{
+			Name:   "predicates",
+			Inputs: []*Input{{Name: "limit", Type: InputTypeInt}},
+			State:  map[string]any{"n": 0},
+			Steps: []*Step{
+				{
+					Name:     "count",
+					Activity: "increment",
+					Next: []*Edge{
+						{Step: "count", Predicate: belowLimit},
+						{Step: "done", Else: true},
+					},
+				},
+				{Name: "done", Activity: "increment", Next: []*Edge{final}},
+				{Name: "end", Activity: "increment"},
+			},
+			Outputs: []*Output{{Name: "n", Variable: "n"}},
+		})
+		require.NoError(t, err)
+		return wf
+	}
+	run := func(wf *Workflow) *ExecutionResult {
+		calls := 0
+		exec, err := NewExecution(wf, newIncrementRegistry(&calls),
+			WithScriptCompiler(newTestCompiler()),
+			WithInputs(map[string]any{"limit": 3}),
+		)
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		return result
+	}
+
+	always := func(ctx Context) (bool, error) { return true, nil }
+	result := run(newWorkflow(&Edge{Step: "end", Condition: "false", Predicate: always}))
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.Equal(t, 5, result.Outputs["n"], "three counts, done, then end via the predicate")
+
+	failing := func(ctx Context) (bool, error) { return false, errors.New("lookup failed") }
+	result = run(newWorkflow(&Edge{Step: "end", Predicate: failing}))
+	require.Equal(t, ExecutionStatusFailed, result.Status)
+	require.Contains(t, result.Error.Error(), `predicate of edge to "end"`)
+	require.Contains(t, result.Error.Error(), "lookup failed")
+
+	_, err := New(Options{
+		Name: "bad-else",
+		Steps: []*Step{
+			{Name: "a", Activity: "increment", Next: []*Edge{{Step: "b", Else: true, Predicate: always}}},
+			{Name: "b", Activity: "increment"},
+		},
+	})
+	require.True(t, errors.Is(err, ErrInvalidEdge))
+}
+
 func TestRetryConfigurationMatching(t *testing.T) {
 	// Create a branch for testing retry config matching
 	workflow := &Workflow{name: "test"}
@@ -308,6 +441,35 @@ func TestRetryConfigurationMatching(t *testing.T) {
 	})
 }
 
+func TestRetryConstructors(t *testing.T) {
+	p := &branch{}
+
+	t.Run("exponential delays are jittered and capped", func(t *testing.T) {
+		rc := ExponentialRetry(ErrorTypeTimeout, 10)
+		require.Equal(t, []string{ErrorTypeTimeout}, rc.ErrorEquals)
+		require.Equal(t, 10, rc.MaxRetries)
+		for attempt := 1; attempt <= rc.MaxRetries; attempt++ {
+			ceiling := min(DefaultRetryBaseDelay<<(attempt-1), DefaultRetryMaxDelay)
+			seen := map[time.Duration]bool{}
+			for range 20 {
+				delay := p.calculateBackoffDelay(attempt, rc)
+				require.GreaterOrEqual(t, delay, time.Duration(0))
+				require.LessOrEqual(t, delay, ceiling)
+				seen[delay] = true
+			}
+			require.Greater(t, len(seen), 1, "attempt %d delays are not jittered", attempt)
+		}
+	})
+
+	t.Run("linear delay is fixed", func(t *testing.T) {
+		rc := LinearRetry("", 5, 250*time.Millisecond)
+		require.Empty(t, rc.ErrorEquals)
+		for attempt := 1; attempt <= rc.MaxRetries; attempt++ {
+			require.Equal(t, 250*time.Millisecond, p.calculateBackoffDelay(attempt, rc))
+		}
+	})
+}
+
 func TestEdgeMatchingStrategies(t *testing.T) {
 	// Create test workflow steps
 	stepA := &Step{Name: "step-a"}
@@ -444,6 +606,58 @@ func TestEdgeMatchingStrategies(t *testing.T) {
 		require.NoError(t, err)
 		require.Len(t, pathSpecs, 2, "Should create branches for all matching edges by default")
 	})
+
+	stepNames := func(specs []branchSpec) []string {
+		names := make([]string, len(specs))
+		for i, spec := range specs {
+			names[i] = spec.Step.Name
+		}
+		return names
+	}
+
+	for _, strategy := range []EdgeMatchingStrategy{EdgeMatchingAll, EdgeMatchingFirst} {
+		t.Run("Else edge fires only when all conditions are false/"+string(strategy), func(t *testing.T) {
+			routing := func(threshold int) *Step {
+				return &Step{
+					Name:                 "current-step",
+					EdgeMatchingStrategy: strategy,
+					Next: []*Edge{
+						{Step: "step-c", Else: true},
+						{Step: "step-a", Condition: fmt.Sprintf("state.value > %d", threshold)},
+						{Step: "step-b", Condition: fmt.Sprintf("state.value > %d", threshold+100)},
+					},
+				}
+			}
+
+			pathSpecs, err := newBranch("test-branch", routing(10), pathOpts).handleBranching(ctx)
+			require.NoError(t, err)
+			require.Equal(t, []string{"step-a"}, stepNames(pathSpecs))
+
+			pathSpecs, err = newBranch("test-branch", routing(20), pathOpts).handleBranching(ctx)
+			require.NoError(t, err)
+			require.Equal(t, []string{"step-c"}, stepNames(pathSpecs))
+		})
+	}
+
+	t.Run("Else edge ignores unconditional edges under EdgeMatchingAll", func(t *testing.T) {
+		currentStep := &Step{
+			Name: "current-step",
+			Next: []*Edge{
+				{Step: "step-a"},
+				{Step: "step-b", Condition: "state.value > 20"},
+				{Step: "step-c", Else: true},
+			},
+		}
+		pathSpecs, err := newBranch("test-branch", currentStep, pathOpts).handleBranching(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"step-a", "step-c"}, stepNames(pathSpecs))
+
+		// Under EdgeMatchingFirst the unconditional edge wins outright.
+		currentStep.EdgeMatchingStrategy = EdgeMatchingFirst
+		pathSpecs, err = newBranch("test-branch", currentStep, pathOpts).handleBranching(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"step-a"}, stepNames(pathSpecs))
+	})
 }
 
 // MockActivity for testing executeStepEach
@@ -634,6 +848,25 @@ func (m *MockActivityExecutor) ExecuteActivity(ctx context.Context, stepName, br
 	return activity.Execute(mockCtx, params)
 }
 
+func (m *MockActivityExecutor) SkipStep(ctx context.Context, stepName, branchID, activityName string) {
+}
+
+func (m *MockActivityExecutor) CompletedActivity(stepName, branchID string) (any, bool) {
+	return nil, false
+}
+
+func (m *MockActivityExecutor) BeginStep(stepName, branchID string) error {
+	return nil
+}
+
+func (m *MockActivityExecutor) BeginEach(stepName, branchID string, items int, resume bool) map[int]any {
+	return nil
+}
+
+func (m *MockActivityExecutor) ExecuteEachItem(ctx context.Context, stepName, branchID string, index int, activity Activity, params map[string]any, branchState *BranchLocalState) (any, error) {
+	return m.ExecuteActivity(ctx, stepName, branchID, activity, params, branchState)
+}
+
 func TestExecuteCatchHandler(t *testing.T) {
 	// Create test workflow steps
 	stepA := &Step{Name: "step-a"}
@@ -679,7 +912,7 @@ func TestExecuteCatchHandler(t *testing.T) {
 		// Create a timeout error
 		timeoutErr := NewWorkflowError(ErrorTypeTimeout, "operation timed out")
 
-		result, err := branch.executeCatchHandler(currentStep, timeoutErr)
+		result, err := branch.executeCatchHandler(context.Background(), currentStep, timeoutErr)
 
 		// Should return catchErrorSentinel (successful catch handling)
 		require.NoError(t, err)
@@ -717,7 +950,7 @@ func TestExecuteCatchHandler(t *testing.T) {
 		// Create an activity failed error
 		activityErr := NewWorkflowError(ErrorTypeActivityFailed, "activity execution failed")
 
-		result, err := branch.executeCatchHandler(currentStep, activityErr)
+		result, err := branch.executeCatchHandler(context.Background(), currentStep, activityErr)
 
 		// Should return catchErrorSentinel (successful catch handling)
 		require.NoError(t, err)
@@ -753,7 +986,7 @@ func TestExecuteCatchHandler(t *testing.T) {
 		// Create any error
 		someErr := NewWorkflowError(ErrorTypeActivityFailed, "some error occurred")
 
-		result, err := branch.executeCatchHandler(currentStep, someErr)
+		result, err := branch.executeCatchHandler(context.Background(), currentStep, someErr)
 
 		// Should return catchErrorSentinel (successful catch handling)
 		require.NoError(t, err)
@@ -790,7 +1023,7 @@ func TestExecuteCatchHandler(t *testing.T) {
 		// Create a timeout error (should match first handler)
 		timeoutErr := NewWorkflowError(ErrorTypeTimeout, "timeout occurred")
 
-		result, err := branch.executeCatchHandler(currentStep, timeoutErr)
+		result, err := branch.executeCatchHandler(context.Background(), currentStep, timeoutErr)
 
 		// Should return catchErrorSentinel
 		require.NoError(t, err)
@@ -823,7 +1056,7 @@ func TestExecuteCatchHandler(t *testing.T) {
 		// Create an activity failed error (doesn't match timeout)
 		activityErr := NewWorkflowError(ErrorTypeActivityFailed, "activity failed")
 
-		result, err := branch.executeCatchHandler(currentStep, activityErr)
+		result, err := branch.executeCatchHandler(context.Background(), currentStep, activityErr)
 
 		// Should return the original error
 		require.Error(t, err)
@@ -851,7 +1084,7 @@ func TestExecuteCatchHandler(t *testing.T) {
 		// Create any error
 		someErr := NewWorkflowError(ErrorTypeActivityFailed, "some error")
 
-		result, err := branch.executeCatchHandler(currentStep, someErr)
+		result, err := branch.executeCatchHandler(context.Background(), currentStep, someErr)
 
 		// Should return an error about missing step
 		require.Error(t, err)
@@ -880,7 +1113,7 @@ func TestExecuteCatchHandler(t *testing.T) {
 		// Create a custom error type
 		customErr := NewWorkflowError("permission-denied", "access forbidden")
 
-		result, err := branch.executeCatchHandler(currentStep, customErr)
+		result, err := branch.executeCatchHandler(context.Background(), currentStep, customErr)
 
 		// Should return catchErrorSentinel
 		require.NoError(t, err)
@@ -915,7 +1148,7 @@ func TestExecuteCatchHandler(t *testing.T) {
 		// Create a fatal error (should not match ErrorTypeAll)
 		fatalErr := NewWorkflowError(ErrorTypeFatal, "fatal system error")
 
-		result, err := branch.executeCatchHandler(currentStep, fatalErr)
+		result, err := branch.executeCatchHandler(context.Background(), currentStep, fatalErr)
 
 		// Should return the original error (no match)
 		require.Error(t, err)
@@ -926,3 +1159,74 @@ func TestExecuteCatchHandler(t *testing.T) {
 		require.Equal(t, "current-step", branch.currentStep.Name)
 	})
 }
+
+func TestMapReduceStep(t *testing.T) {
+	steps := []*Step{{
+		Name:  "total",
+		Store: "total",
+		MapReduce: &MapReduceConfig{
+			Items:      "state.numbers",
+			As:         "n",
+			Activity:   "square",
+			Parameters: map[string]any{"n": "${state.n}"},
+			Reduce:     `sprintf("%v %d", result, count(result, it > 1))`,
+		},
+	}}
+	wf, err := New(Options{
+		Name:    "map-reduce",
+		State:   map[string]any{"numbers": []any{3, 1, 2}},
+		Steps:   steps,
+		Outputs: []*Output{{Name: "total", Variable: "total"}},
+	})
+	require.NoError(t, err)
+	require.Nil(t, steps[0].Each, "New must not modify the caller's step")
+	expanded := wf.Steps()[0]
+	require.Equal(t, "square", expanded.Activity)
+	require.True(t, expanded.Each.Parallel)
+
+	var mu sync.Mutex
+	var order []int
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("square", func(ctx Context, params map[string]any) (any, error) {
+		n := params["n"].(int)
+		// Larger items finish first, so completion order is reversed.
+		time.Sleep(time.Duration(4-n) * 10 * time.Millisecond)
+		mu.Lock()
+		order = append(order, n)
+		mu.Unlock()
+		return n * n, nil
+	}))
+	exec, err := NewExecution(wf, reg)
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.Equal(t, []int{3, 2, 1}, order)
+	// The reduce sees the squares in item order, not completion order.
+	require.Equal(t, "[9 1 4] 2", result.Outputs["total"])
+
+	t.Run("invalid configs", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			step *Step
+			err  error
+		}{
+			"missing reduce": {
+				&Step{Name: "s", Store: "x", MapReduce: &MapReduceConfig{Items: "state.a", Activity: "a"}},
+				ErrInvalidMapReduceConfig,
+			},
+			"missing store": {
+				&Step{Name: "s", MapReduce: &MapReduceConfig{Items: "state.a", Activity: "a", Reduce: "result"}},
+				ErrInvalidMapReduceConfig,
+			},
+			"with activity": {
+				&Step{Name: "s", Store: "x", Activity: "a", MapReduce: &MapReduceConfig{Items: "state.a", Activity: "a", Reduce: "result"}},
+				ErrInvalidStepKind,
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := New(Options{Name: "bad", Steps: []*Step{tc.step}})
+				require.ErrorIs(t, err, tc.err)
+			})
+		}
+	})
+}
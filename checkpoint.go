@@ -56,6 +56,9 @@ const CheckpointSchemaVersion = 1
 //     per-step replay cache used by Context.History. Without it,
 //     activities re-execute side effects on every wait-unwind
 //     replay.
+//   - BranchState.CompletedActivity — the result of an Idempotent
+//     step's activity. Without it, the activity runs again when the
+//     execution resumes at that step.
 //
 // All other fields are advisory or recoverable from the workflow
 // definition.
@@ -78,6 +81,13 @@ type Checkpoint struct {
 	// consult it on resume.
 	WorkflowName string `json:"workflow_name"`
 
+	// WorkflowFingerprint is the Workflow.Fingerprint of the definition
+	// that wrote the checkpoint. Resume fails with ErrWorkflowChanged
+	// when it differs from the fingerprint of the workflow being
+	// resumed. Empty in checkpoints written before fingerprints were
+	// recorded, which skips the check.
+	WorkflowFingerprint string `json:"workflow_fingerprint,omitempty"`
+
 	// Status is the execution status at the time the checkpoint was
 	// written.
 	Status ExecutionStatus `json:"status"`
@@ -86,6 +96,9 @@ type Checkpoint struct {
 	// NewExecution. Immutable for the lifetime of the execution.
 	Inputs map[string]any `json:"inputs"`
 
+	// Labels is the key/value metadata attached with WithLabels.
+	Labels map[string]string `json:"labels,omitempty"`
+
 	// Outputs is the declared workflow outputs extracted from the
 	// final branch states when the execution completes. Empty until
 	// then.
@@ -110,6 +123,20 @@ type Checkpoint struct {
 	// allocate unique IDs.
 	BranchCounter int `json:"branch_counter"`
 
+	// ActivityInvocations is the number of activities started so far.
+	// Persisted so WithMaxActivityInvocations bounds the execution as a
+	// whole, across resumes.
+	ActivityInvocations int `json:"activity_invocations,omitempty"`
+
+	// VariableHistory holds the variable change log of each branch,
+	// keyed by branch ID. Only saved with
+	// WithVariableHistoryInCheckpoints.
+	VariableHistory map[string][]PatchRecord `json:"variable_history,omitempty"`
+
+	// StepCount is the number of steps started so far. Persisted so
+	// WithMaxSteps bounds the execution as a whole, across resumes.
+	StepCount int `json:"step_count,omitempty"`
+
 	// Error is the terminal error message when Status is
 	// ExecutionStatusFailed. Empty otherwise.
 	Error string `json:"error,omitempty"`
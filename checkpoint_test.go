@@ -2,6 +2,7 @@ package workflow_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/deepnoodle-ai/workflow"
@@ -98,3 +99,45 @@ func TestAtomicCheckpointerInterface(t *testing.T) {
 	var _ workflow.Checkpointer = (*workflowtest.MemoryCheckpointer)(nil)
 	var _ workflow.AtomicCheckpointer = (*workflowtest.MemoryCheckpointer)(nil)
 }
+
+func TestResumeDetectsWorkflowDefinitionChange(t *testing.T) {
+	cp := workflowtest.NewMemoryCheckpointer()
+	reg := workflow.NewActivityRegistry()
+	reg.MustRegister(workflow.ActivityFunc("fail", func(ctx workflow.Context, params map[string]any) (any, error) {
+		return nil, errors.New("boom")
+	}))
+	reg.MustRegister(workflow.ActivityFunc("noop", func(ctx workflow.Context, params map[string]any) (any, error) {
+		return nil, nil
+	}))
+
+	newWorkflow := func(activity string) *workflow.Workflow {
+		wf, err := workflow.New(workflow.Options{
+			Name:  "drift-test",
+			Steps: []*workflow.Step{{Name: "start", Activity: activity}},
+		})
+		require.NoError(t, err)
+		return wf
+	}
+
+	original := newWorkflow("fail")
+	exec, err := workflow.NewExecution(original, reg, workflow.WithCheckpointer(cp))
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, workflow.ExecutionStatusFailed, result.Status)
+
+	loaded, err := cp.LoadCheckpoint(context.Background(), exec.ID())
+	require.NoError(t, err)
+	require.Equal(t, original.Fingerprint(), loaded.WorkflowFingerprint)
+
+	changed, err := workflow.NewExecution(newWorkflow("noop"), reg, workflow.WithCheckpointer(cp))
+	require.NoError(t, err)
+	_, err = changed.Execute(context.Background(), workflow.ResumeFrom(exec.ID()))
+	require.Error(t, err)
+	require.True(t, errors.Is(err, workflow.ErrWorkflowChanged))
+
+	same, err := workflow.NewExecution(newWorkflow("fail"), reg, workflow.WithCheckpointer(cp))
+	require.NoError(t, err)
+	_, err = same.Execute(context.Background(), workflow.ResumeFrom(exec.ID()))
+	require.NoError(t, err)
+}
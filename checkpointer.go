@@ -53,3 +53,18 @@ type AtomicCheckpointer interface {
 	// returns ErrNoCheckpoint without invoking fn.
 	AtomicUpdate(ctx context.Context, executionID string, fn func(*Checkpoint) error) error
 }
+
+// VersionedCheckpointer is an optional side interface for checkpointers
+// that keep earlier checkpoints of an execution rather than only the
+// latest one. Execution.ResumeFromCheckpoint requires it to rewind an
+// execution to a known-good point.
+type VersionedCheckpointer interface {
+	// LoadCheckpointByID returns the checkpoint of executionID whose
+	// Checkpoint.ID is checkpointID, or nil if it does not exist. The
+	// same SchemaVersion rule as LoadCheckpoint applies.
+	LoadCheckpointByID(ctx context.Context, executionID, checkpointID string) (*Checkpoint, error)
+
+	// ListCheckpoints returns the IDs of the checkpoints stored for
+	// executionID, oldest first.
+	ListCheckpoints(ctx context.Context, executionID string) ([]string, error)
+}
@@ -7,17 +7,32 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
 // FileCheckpointer is a file-based implementation that persists checkpoints to disk
 type FileCheckpointer struct {
-	dataDir string
+	dataDir    string
+	maxHistory int
+}
+
+// FileCheckpointerOption configures a FileCheckpointer.
+type FileCheckpointerOption func(*FileCheckpointer)
+
+// WithMaxCheckpointHistory retains only the n most recent
+// checkpoint-*.json files per execution, deleting older ones after each
+// save. latest.json always points at the newest checkpoint. Zero, the
+// default, keeps every checkpoint.
+func WithMaxCheckpointHistory(n int) FileCheckpointerOption {
+	return func(c *FileCheckpointer) {
+		c.maxHistory = n
+	}
 }
 
 // NewFileCheckpointer creates a new file-based checkpointer
-func NewFileCheckpointer(dataDir string) (*FileCheckpointer, error) {
+func NewFileCheckpointer(dataDir string, opts ...FileCheckpointerOption) (*FileCheckpointer, error) {
 	if dataDir == "" {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
@@ -31,7 +46,14 @@ func NewFileCheckpointer(dataDir string) (*FileCheckpointer, error) {
 		return nil, fmt.Errorf("failed to create data directory %s: %w", dataDir, err)
 	}
 
-	return &FileCheckpointer{dataDir: dataDir}, nil
+	c := &FileCheckpointer{dataDir: dataDir}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.maxHistory < 0 {
+		return nil, fmt.Errorf("max checkpoint history must be >= 0, got %d", c.maxHistory)
+	}
+	return c, nil
 }
 
 // SaveCheckpoint saves the execution checkpoint to disk
@@ -58,20 +80,118 @@ func (c *FileCheckpointer) SaveCheckpoint(ctx context.Context, checkpoint *Check
 		return fmt.Errorf("failed to update latest symlink: %w", err)
 	}
 
+	if c.maxHistory > 0 {
+		if err := c.pruneHistory(executionDir, checkpointPath); err != nil {
+			return fmt.Errorf("failed to prune checkpoint history: %w", err)
+		}
+	}
+	return nil
+}
+
+// pruneHistory deletes all but the newest maxHistory checkpoint files in
+// executionDir. Files are ordered by modification time, then by name;
+// the checkpoint just written is never deleted.
+func (c *FileCheckpointer) pruneHistory(executionDir, current string) error {
+	entries, err := os.ReadDir(executionDir)
+	if err != nil {
+		return err
+	}
+	type checkpointFile struct {
+		path    string
+		modTime time.Time
+	}
+	var files []checkpointFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, "checkpoint-") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		path := filepath.Join(executionDir, name)
+		if path == current {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // removed concurrently
+		}
+		files = append(files, checkpointFile{path: path, modTime: info.ModTime()})
+	}
+	keep := c.maxHistory - 1 // the current checkpoint takes one slot
+	if len(files) <= keep {
+		return nil
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].modTime.Equal(files[j].modTime) {
+			return files[i].modTime.Before(files[j].modTime)
+		}
+		return files[i].path < files[j].path
+	})
+	for _, f := range files[:len(files)-keep] {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
 	return nil
 }
 
 // LoadCheckpoint loads the latest checkpoint for an execution
 func (c *FileCheckpointer) LoadCheckpoint(ctx context.Context, executionID string) (*Checkpoint, error) {
-	latestPath := filepath.Join(c.dataDir, executionID, "latest.json")
+	return c.loadFile(filepath.Join(c.dataDir, executionID, "latest.json"))
+}
+
+// LoadCheckpointByID loads the checkpoint-<checkpointID>.json file of
+// an execution. Files removed by WithMaxCheckpointHistory are gone.
+func (c *FileCheckpointer) LoadCheckpointByID(ctx context.Context, executionID, checkpointID string) (*Checkpoint, error) {
+	if checkpointID == "" || strings.ContainsAny(checkpointID, `/\`) {
+		return nil, fmt.Errorf("invalid checkpoint ID %q", checkpointID)
+	}
+	return c.loadFile(filepath.Join(c.dataDir, executionID, fmt.Sprintf("checkpoint-%s.json", checkpointID)))
+}
+
+// ListCheckpoints returns the IDs of the checkpoint files kept for an
+// execution, oldest first. The engine numbers checkpoints from 1, so
+// numeric IDs are ordered by value; any others sort after them by
+// name.
+func (c *FileCheckpointer) ListCheckpoints(ctx context.Context, executionID string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(c.dataDir, executionID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read execution directory: %w", err)
+	}
+	ids := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, "checkpoint-") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(strings.TrimPrefix(name, "checkpoint-"), ".json"))
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, errA := strconv.Atoi(ids[i])
+		b, errB := strconv.Atoi(ids[j])
+		switch {
+		case errA == nil && errB == nil:
+			return a < b
+		case errA == nil || errB == nil:
+			return errA == nil
+		}
+		return ids[i] < ids[j]
+	})
+	return ids, nil
+}
 
-	// Check if latest checkpoint exists
-	if _, err := os.Stat(latestPath); os.IsNotExist(err) {
+// loadFile reads and decodes a checkpoint file, returning nil if the
+// file does not exist.
+func (c *FileCheckpointer) loadFile(path string) (*Checkpoint, error) {
+	// Check if the checkpoint exists
+	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return nil, nil // No checkpoint found
 	}
 
 	// Read the checkpoint file
-	data, err := os.ReadFile(latestPath)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read checkpoint file: %w", err)
 	}
@@ -147,6 +267,7 @@ func (c *FileCheckpointer) getExecutionSummary(executionID string) (*ExecutionSu
 		EndTime:      checkpoint.EndTime,
 		Duration:     c.calculateDuration(checkpoint),
 		Error:        checkpoint.Error,
+		Labels:       checkpoint.Labels,
 	}, nil
 }
 
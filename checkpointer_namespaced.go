@@ -0,0 +1,159 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// namespaceSeparator joins a namespace and an execution ID in the keys
+// written to the shared backend. Namespaces may not contain it, so no
+// namespace's prefix is a prefix of another's.
+const namespaceSeparator = "."
+
+// namespacePrefix validates namespace and returns the key prefix for it.
+func namespacePrefix(namespace string) string {
+	if namespace == "" || strings.Contains(namespace, namespaceSeparator) ||
+		strings.ContainsAny(namespace, `/\`) {
+		panic(fmt.Sprintf("workflow: invalid namespace %q: must be non-empty and contain no '.', '/', or '\\'", namespace))
+	}
+	return namespace + namespaceSeparator
+}
+
+// NamespacedCheckpointer isolates the checkpoints of one tenant in a
+// Checkpointer shared by many. Execution IDs are prefixed with the
+// namespace on the way in and stripped on the way out, so callers use
+// plain execution IDs and can only load, delete, or list executions
+// saved through a NamespacedCheckpointer with the same namespace.
+//
+// Isolation holds only if every tenant reaches the shared backend
+// through its own NamespacedCheckpointer.
+type NamespacedCheckpointer struct {
+	inner  Checkpointer
+	prefix string
+}
+
+// NewNamespacedCheckpointer wraps inner so that all executions are
+// stored under namespace. It panics if namespace is empty or contains
+// '.', '/', or '\'.
+func NewNamespacedCheckpointer(inner Checkpointer, namespace string) *NamespacedCheckpointer {
+	return &NamespacedCheckpointer{inner: inner, prefix: namespacePrefix(namespace)}
+}
+
+// SaveCheckpoint saves a copy of checkpoint under the namespaced ID.
+func (c *NamespacedCheckpointer) SaveCheckpoint(ctx context.Context, checkpoint *Checkpoint) error {
+	return c.inner.SaveCheckpoint(ctx, c.wrap(checkpoint))
+}
+
+// LoadCheckpoint loads the checkpoint for executionID in this namespace.
+func (c *NamespacedCheckpointer) LoadCheckpoint(ctx context.Context, executionID string) (*Checkpoint, error) {
+	checkpoint, err := c.inner.LoadCheckpoint(ctx, c.prefix+executionID)
+	if err != nil || checkpoint == nil {
+		return checkpoint, err
+	}
+	return c.unwrap(checkpoint), nil
+}
+
+// DeleteCheckpoint deletes the checkpoints of executionID in this
+// namespace.
+func (c *NamespacedCheckpointer) DeleteCheckpoint(ctx context.Context, executionID string) error {
+	return c.inner.DeleteCheckpoint(ctx, c.prefix+executionID)
+}
+
+// AtomicUpdate delegates to the inner checkpointer when it implements
+// AtomicCheckpointer, and otherwise falls back to load-modify-save.
+func (c *NamespacedCheckpointer) AtomicUpdate(ctx context.Context, executionID string, fn func(*Checkpoint) error) error {
+	if atomic, ok := c.inner.(AtomicCheckpointer); ok {
+		return atomic.AtomicUpdate(ctx, c.prefix+executionID, func(checkpoint *Checkpoint) error {
+			local := c.unwrap(checkpoint)
+			if err := fn(local); err != nil {
+				return err
+			}
+			*checkpoint = *c.wrap(local)
+			return nil
+		})
+	}
+	checkpoint, err := c.LoadCheckpoint(ctx, executionID)
+	if err != nil {
+		return err
+	}
+	if checkpoint == nil {
+		return ErrNoCheckpoint
+	}
+	if err := fn(checkpoint); err != nil {
+		return err
+	}
+	return c.SaveCheckpoint(ctx, checkpoint)
+}
+
+// ListExecutions lists the executions of this namespace. The inner
+// checkpointer must provide ListExecutions, as FileCheckpointer does.
+func (c *NamespacedCheckpointer) ListExecutions(ctx context.Context) ([]*ExecutionSummary, error) {
+	lister, ok := c.inner.(interface {
+		ListExecutions(ctx context.Context) ([]*ExecutionSummary, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("checkpointer %T does not support listing executions", c.inner)
+	}
+	all, err := lister.ListExecutions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summaries := []*ExecutionSummary{}
+	for _, summary := range all {
+		id, ok := strings.CutPrefix(summary.ExecutionID, c.prefix)
+		if !ok {
+			continue
+		}
+		local := *summary
+		local.ExecutionID = id
+		summaries = append(summaries, &local)
+	}
+	return summaries, nil
+}
+
+// LoadCheckpointByID loads one checkpoint of executionID in this
+// namespace. The inner checkpointer must implement
+// VersionedCheckpointer.
+func (c *NamespacedCheckpointer) LoadCheckpointByID(ctx context.Context, executionID, checkpointID string) (*Checkpoint, error) {
+	versioned, err := c.versioned()
+	if err != nil {
+		return nil, err
+	}
+	checkpoint, err := versioned.LoadCheckpointByID(ctx, c.prefix+executionID, checkpointID)
+	if err != nil || checkpoint == nil {
+		return checkpoint, err
+	}
+	return c.unwrap(checkpoint), nil
+}
+
+// ListCheckpoints lists the checkpoint IDs of executionID in this
+// namespace. The inner checkpointer must implement
+// VersionedCheckpointer.
+func (c *NamespacedCheckpointer) ListCheckpoints(ctx context.Context, executionID string) ([]string, error) {
+	versioned, err := c.versioned()
+	if err != nil {
+		return nil, err
+	}
+	return versioned.ListCheckpoints(ctx, c.prefix+executionID)
+}
+
+func (c *NamespacedCheckpointer) versioned() (VersionedCheckpointer, error) {
+	versioned, ok := c.inner.(VersionedCheckpointer)
+	if !ok {
+		return nil, fmt.Errorf("checkpointer %T does not support loading checkpoints by ID", c.inner)
+	}
+	return versioned, nil
+}
+
+func (c *NamespacedCheckpointer) wrap(checkpoint *Checkpoint) *Checkpoint {
+	wrapped := *checkpoint
+	wrapped.ExecutionID = c.prefix + checkpoint.ExecutionID
+	return &wrapped
+}
+
+func (c *NamespacedCheckpointer) unwrap(checkpoint *Checkpoint) *Checkpoint {
+	local := *checkpoint
+	local.ExecutionID = strings.TrimPrefix(checkpoint.ExecutionID, c.prefix)
+	return &local
+}
@@ -0,0 +1,85 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestNamespacedCheckpointerIsolatesTenants(t *testing.T) {
+	ctx := context.Background()
+	shared, err := NewFileCheckpointer(t.TempDir())
+	require.NoError(t, err)
+	sharedLog := NewFileActivityLogger(t.TempDir())
+
+	wf, err := New(Options{
+		Name:  "tenant-job",
+		Steps: []*Step{{Name: "work", Activity: "work"}},
+	})
+	require.NoError(t, err)
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("work", func(ctx Context, params map[string]any) (any, error) {
+		return "done", nil
+	}))
+
+	run := func(namespace string) {
+		exec, err := NewExecution(wf, reg,
+			WithExecutionID("job-1"),
+			WithCheckpointer(NewNamespacedCheckpointer(shared, namespace)),
+			WithActivityLogger(NewNamespacedActivityLogger(sharedLog, namespace)))
+		require.NoError(t, err)
+		result, err := exec.Execute(ctx)
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+	}
+	// Both tenants use the same execution ID without colliding.
+	run("acme")
+	run("globex")
+
+	acme := NewNamespacedCheckpointer(shared, "acme")
+	initech := NewNamespacedCheckpointer(shared, "initech")
+
+	checkpoint, err := acme.LoadCheckpoint(ctx, "job-1")
+	require.NoError(t, err)
+	require.Equal(t, "job-1", checkpoint.ExecutionID)
+
+	summaries, err := acme.ListExecutions(ctx)
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	require.Equal(t, "job-1", summaries[0].ExecutionID)
+
+	// A tenant with no executions sees none of the others'.
+	checkpoint, err = initech.LoadCheckpoint(ctx, "job-1")
+	require.NoError(t, err)
+	require.True(t, checkpoint == nil)
+	summaries, err = initech.ListExecutions(ctx)
+	require.NoError(t, err)
+	require.Empty(t, summaries)
+	ids, err := initech.ListCheckpoints(ctx, "job-1")
+	require.NoError(t, err)
+	require.Empty(t, ids)
+
+	ids, err = acme.ListCheckpoints(ctx, "job-1")
+	require.NoError(t, err)
+	require.NotEmpty(t, ids)
+	checkpoint, err = acme.LoadCheckpointByID(ctx, "job-1", ids[0])
+	require.NoError(t, err)
+	require.Equal(t, "job-1", checkpoint.ExecutionID)
+	_, err = NewNamespacedActivityLogger(sharedLog, "initech").GetActivityHistory(ctx, "job-1")
+	require.Error(t, err)
+
+	entries, err := NewNamespacedActivityLogger(sharedLog, "acme").GetActivityHistory(ctx, "job-1")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "job-1", entries[0].ExecutionID)
+
+	// Deleting in one namespace leaves the other untouched.
+	require.NoError(t, acme.DeleteCheckpoint(ctx, "job-1"))
+	summaries, err = NewNamespacedCheckpointer(shared, "globex").ListExecutions(ctx)
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+
+	require.Panics(t, func() { NewNamespacedCheckpointer(shared, "") })
+	require.Panics(t, func() { NewNamespacedCheckpointer(shared, "a.b") })
+}
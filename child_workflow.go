@@ -49,6 +49,9 @@ type ChildWorkflowExecutor interface {
 
 	// GetResult retrieves the result of an asynchronous execution
 	GetResult(ctx context.Context, handle *ChildWorkflowHandle) (*ChildWorkflowResult, error)
+
+	// Cancel stops an asynchronous execution and marks it canceled
+	Cancel(ctx context.Context, handle *ChildWorkflowHandle) error
 }
 
 // WorkflowRegistry manages a collection of workflow definitions
@@ -118,8 +121,16 @@ type DefaultChildWorkflowExecutor struct {
 	checkpointer       Checkpointer
 	scriptCompiler     script.Compiler
 	cleanupTimeout     time.Duration
-	asyncExecutions    map[string]*Execution // Track async executions by ID
-	asyncExecutionsMtx sync.RWMutex          // Protect concurrent access to async executions
+	asyncExecutions    map[string]*asyncChild // Track async executions by ID
+	asyncExecutionsMtx sync.RWMutex           // Protect concurrent access to async executions
+}
+
+// asyncChild tracks an execution started by ExecuteAsync.
+type asyncChild struct {
+	execution *Execution
+	cancel    context.CancelFunc
+	done      chan struct{} // closed when Execute returns
+	canceled  bool          // set by Cancel; guarded by asyncExecutionsMtx
 }
 
 // ChildWorkflowExecutorOptions configures a DefaultChildWorkflowExecutor
@@ -160,7 +171,7 @@ func NewDefaultChildWorkflowExecutor(opts ChildWorkflowExecutorOptions) (*Defaul
 		checkpointer:       opts.Checkpointer,
 		scriptCompiler:     opts.ScriptCompiler,
 		cleanupTimeout:     cleanup,
-		asyncExecutions:    make(map[string]*Execution),
+		asyncExecutions:    make(map[string]*asyncChild),
 		asyncExecutionsMtx: sync.RWMutex{},
 	}, nil
 }
@@ -281,16 +292,27 @@ func (e *DefaultChildWorkflowExecutor) ExecuteAsync(ctx context.Context, spec *C
 		return nil, err
 	}
 
+	// Use context.Background() instead of the caller's context so that the
+	// async child workflow is not cancelled when the caller's context
+	// completes. Cancel uses the stored cancel func instead.
+	execCtx, cancel := context.WithCancel(context.Background())
+	if spec.Timeout > 0 {
+		execCtx, cancel = context.WithTimeout(context.Background(), spec.Timeout)
+	}
+	child := &asyncChild{
+		execution: execution,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+
 	// Track the async execution
 	e.asyncExecutionsMtx.Lock()
-	e.asyncExecutions[execution.ID()] = execution
+	e.asyncExecutions[execution.ID()] = child
 	e.asyncExecutionsMtx.Unlock()
 
 	cleanup := e.cleanupTimeout
 
-	// Start execution in a goroutine. Use context.Background() instead of
-	// the caller's context so that the async child workflow is not cancelled
-	// when the caller's context completes.
+	// Start execution in a goroutine.
 	go func() {
 		defer func() {
 			if cleanup < 0 {
@@ -303,13 +325,8 @@ func (e *DefaultChildWorkflowExecutor) ExecuteAsync(ctx context.Context, spec *C
 				e.asyncExecutionsMtx.Unlock()
 			}()
 		}()
-
-		execCtx := context.Background()
-		if spec.Timeout > 0 {
-			var cancel context.CancelFunc
-			execCtx, cancel = context.WithTimeout(execCtx, spec.Timeout)
-			defer cancel()
-		}
+		defer close(child.done)
+		defer cancel()
 
 		execution.Execute(execCtx)
 	}()
@@ -328,15 +345,25 @@ func (e *DefaultChildWorkflowExecutor) GetResult(ctx context.Context, handle *Ch
 
 	// Look up the async execution
 	e.asyncExecutionsMtx.RLock()
-	execution, exists := e.asyncExecutions[handle.ExecutionID]
+	child, exists := e.asyncExecutions[handle.ExecutionID]
+	canceled := exists && child.canceled
 	e.asyncExecutionsMtx.RUnlock()
 
 	if !exists {
 		return nil, fmt.Errorf("async execution %q not found or has expired", handle.ExecutionID)
 	}
+	execution := child.execution
 
 	// Check execution status
 	status := execution.Status()
+	if canceled {
+		select {
+		case <-child.done:
+			status = ExecutionStatusCanceled
+		default:
+			// Still winding down; report it as running until it stops.
+		}
+	}
 
 	// For running executions, return current status without outputs
 	if status == ExecutionStatusRunning || status == ExecutionStatusPending {
@@ -359,8 +386,49 @@ func (e *DefaultChildWorkflowExecutor) GetResult(ctx context.Context, handle *Ch
 		result.Outputs[k] = v
 	}
 
+	if status == ExecutionStatusCanceled {
+		return result, fmt.Errorf("%w: %s", ErrChildWorkflowCanceled, execution.ID())
+	}
 	if status == ExecutionStatusFailed {
 		return result, fmt.Errorf("child workflow execution failed")
 	}
 	return result, nil
 }
+
+// Cancel stops an asynchronous child execution started by ExecuteAsync.
+// It cancels the child's context, marks it canceled, and waits for the
+// child to stop or for ctx to be done. Afterwards GetResult reports
+// ExecutionStatusCanceled and an error wrapping ErrChildWorkflowCanceled.
+//
+// Canceling a child that already finished leaves its result unchanged.
+// Activities running in the child observe the cancellation through
+// their context, so long-running activities should watch ctx.Done().
+func (e *DefaultChildWorkflowExecutor) Cancel(ctx context.Context, handle *ChildWorkflowHandle) error {
+	if handle == nil {
+		return fmt.Errorf("handle cannot be nil")
+	}
+
+	e.asyncExecutionsMtx.Lock()
+	child, exists := e.asyncExecutions[handle.ExecutionID]
+	if exists {
+		select {
+		case <-child.done:
+			// Already finished; nothing to cancel.
+		default:
+			child.canceled = true
+		}
+	}
+	e.asyncExecutionsMtx.Unlock()
+
+	if !exists {
+		return fmt.Errorf("async execution %q not found or has expired", handle.ExecutionID)
+	}
+
+	child.cancel()
+	select {
+	case <-child.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
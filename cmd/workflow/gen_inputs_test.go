@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow"
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestGenInputs(t *testing.T) {
+	dir := t.TempDir()
+	wfPath := filepath.Join(dir, "wf.json")
+	require.NoError(t, os.WriteFile(wfPath, []byte(`{
+		"name": "gen-inputs",
+		"inputs": [
+			{"name": "url", "type": "string"},
+			{"name": "count", "type": "int", "default": 3},
+			{"name": "mode", "type": "string", "enum": ["fast", "slow"]},
+			{"name": "verbose", "type": "bool"},
+			{"name": "timeout", "type": "duration"},
+			{"name": "tags", "type": "array"}
+		],
+		"steps": [{"name": "run", "activity": "print", "parameters": {"message": "${inputs.url}"}}]
+	}`), 0644))
+
+	outPath := filepath.Join(dir, "inputs.json")
+	require.NoError(t, runGenInputs([]string{"-file", wfPath, "-o", outPath}))
+
+	data, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	var inputs map[string]any
+	require.NoError(t, json.Unmarshal(data, &inputs))
+
+	// Defaults and enums are used as given.
+	require.Equal(t, float64(3), inputs["count"])
+	require.Equal(t, "fast", inputs["mode"])
+	// Required inputs get placeholders for their type.
+	require.Equal(t, "", inputs["url"])
+	require.Equal(t, false, inputs["verbose"])
+	require.Equal(t, "1m", inputs["timeout"])
+	require.Equal(t, []any{}, inputs["tags"])
+
+	// The generated file is accepted as the execution's inputs.
+	wf, err := loadWorkflow(wfPath)
+	require.NoError(t, err)
+	reg := workflow.NewActivityRegistry()
+	reg.MustRegister(workflow.ActivityFunc("print", func(ctx workflow.Context, params map[string]any) (any, error) {
+		return nil, nil
+	}))
+	_, err = workflow.NewExecution(wf, reg, workflow.WithInputs(inputs))
+	require.NoError(t, err)
+}
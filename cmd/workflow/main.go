@@ -19,16 +19,20 @@ import (
 
 // CLI configuration
 type Config struct {
-	WorkflowFile  string
-	Inputs        map[string]interface{}
-	LogsDir       string
-	ExecutionsDir string
-	Timeout       time.Duration
-	Verbose       bool
-	JSON          bool
-	ShowInputs    bool
-	ShowOutputs   bool
-	EnableChild   bool
+	WorkflowFile   string
+	Inputs         map[string]interface{}
+	LogsDir        string
+	ExecutionsDir  string
+	Timeout        time.Duration
+	Verbose        bool
+	JSON           bool
+	ShowInputs     bool
+	ShowDOT        bool
+	ShowOutputs    bool
+	EnableChild    bool
+	OutputFile     string
+	PartialOutputs bool
+	HTTPRate       float64
 }
 
 // info writes an informational line to stderr so that stdout stays
@@ -38,6 +42,14 @@ func info(format string, args ...any) {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "gen-inputs" {
+		if err := runGenInputs(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	config := parseFlags()
 
 	// Validate required arguments
@@ -74,6 +86,12 @@ func main() {
 		return
 	}
 
+	// Print the workflow graph if requested and exit
+	if config.ShowDOT {
+		fmt.Print(wf.ToDOT())
+		return
+	}
+
 	// Validate and prepare inputs
 	inputs, err := prepareInputs(wf, config.Inputs)
 	if err != nil {
@@ -163,6 +181,9 @@ func parseFlags() *Config {
 	flag.StringVar(&config.WorkflowFile, "file", "", "Path to the JSON workflow definition file (required)")
 	flag.StringVar(&config.WorkflowFile, "f", "", "Path to the JSON workflow definition file (shorthand)")
 
+	var inputsFile string
+	flag.StringVar(&inputsFile, "inputs", "", "JSON file of input values, e.g. from gen-inputs (-input flags override it)")
+
 	var inputFlags stringSlice
 	flag.Var(&inputFlags, "input", "Input parameter in format key=value (can be used multiple times)")
 	flag.Var(&inputFlags, "i", "Input parameter in format key=value (shorthand, can be used multiple times)")
@@ -181,14 +202,19 @@ func parseFlags() *Config {
 
 	flag.BoolVar(&config.JSON, "json", false, "Output results in JSON format")
 	flag.BoolVar(&config.ShowInputs, "show-inputs", false, "Show workflow input requirements and exit")
+	flag.BoolVar(&config.ShowDOT, "dot", false, "Print the workflow graph in Graphviz DOT format and exit")
 	flag.BoolVar(&config.ShowOutputs, "show-outputs", true, "Show workflow outputs after execution (default: true)")
 	flag.BoolVar(&config.EnableChild, "enable-child-workflows", false, "Enable child workflow support")
+	flag.StringVar(&config.OutputFile, "output-file", "", "Write the workflow outputs to this file as JSON after a successful run")
+	flag.BoolVar(&config.PartialOutputs, "partial-outputs", false, "With -output-file, also write the outputs extracted before a failure")
+	flag.Float64Var(&config.HTTPRate, "http-rate", 0, "Maximum http activity requests per second across all paths (0 = unlimited)")
 
 	// Custom usage
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Workflow CLI - Execute JSON-defined workflows
 
 Usage: %s [options] -file <workflow.json>
+       %s gen-inputs -file <workflow.json> [-o inputs.json]
 
 Examples:
   # Execute a simple workflow
@@ -200,8 +226,18 @@ Examples:
   # Execute with timeout and checkpointing
   %s -file workflow.json -timeout 30s -executions ./checkpoints
 
+  # Write the outputs to a file for a downstream step
+  %s -file workflow.json -output-file results.json
+
+  # Render the workflow graph
+  %s -file workflow.json -dot | dot -Tpng -o workflow.png
+
+  # Generate a sample inputs file, edit it, then run with it
+  %s gen-inputs -file workflow.json -o inputs.json
+  %s -file workflow.json -inputs inputs.json
+
 Options:
-`, os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+`, os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
 		flag.PrintDefaults()
 
 		fmt.Fprintf(os.Stderr, `
@@ -212,14 +248,15 @@ Supported Activities:
   http           - Make HTTP requests
   file           - Read, write, and manage files
   json           - Parse, query, and stringify JSON
+  json.validate  - Validate data against a JSON Schema
   random         - Generate random numbers, strings, and UUIDs
   shell          - Execute shell commands
   workflow.child - Execute child workflows (with -enable-child-workflows)
 
 Edge conditions and ${...} parameter templates are evaluated by
 github.com/deepnoodle-ai/expr (the engine's default script compiler).
-No separate %q activity is provided: state mutation should happen in
-Go activities, not embedded scripts.
+The %q activity is not registered by the CLI: the default compiler is
+expression-only, so state mutation should happen in Go activities.
 
 Input Format:
   Use -input key=value for each input parameter.
@@ -230,6 +267,19 @@ Input Format:
 
 	flag.Parse()
 
+	// Load the inputs file first so -input flags can override it
+	if inputsFile != "" {
+		data, err := os.ReadFile(inputsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := json.Unmarshal(data, &config.Inputs); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid inputs file %q: %v\n", inputsFile, err)
+			os.Exit(1)
+		}
+	}
+
 	// Parse input flags
 	for _, input := range inputFlags {
 		parts := strings.SplitN(input, "=", 2)
@@ -276,13 +326,23 @@ func setupLogger(verbose bool) *slog.Logger {
 }
 
 func createActivityRegistry(config *Config, logger *slog.Logger) []workflow.Activity {
+	httpActivity := httpx.NewHTTPActivity()
+	if config.HTTPRate > 0 {
+		httpActivity = workflow.NewRateLimitedActivity(httpActivity, workflow.NewTokenBucket(config.HTTPRate, 1))
+	}
+
 	activityList := []workflow.Activity{
 		activities.NewPrintActivity(),
 		activities.NewTimeActivity(),
 		activities.NewFailActivity(),
 		activities.NewJSONActivity(),
+		activities.NewJSONSchemaActivity(),
 		activities.NewRandomActivity(),
-		httpx.NewHTTPActivity(),
+		activities.NewTemplateActivity(),
+		activities.NewWaitActivity(),
+		activities.NewDiffActivity(),
+		activities.NewCompressActivity(),
+		httpActivity,
 		contrib.NewFileActivity(),
 		contrib.NewShellActivity(),
 	}
@@ -327,10 +387,22 @@ func showWorkflowInputs(wf *workflow.Workflow) {
 			required = " (required)"
 		}
 
+		if input.FromEnv != "" {
+			defaultValue += fmt.Sprintf(" [env: %s]", input.FromEnv)
+		}
+
 		fmt.Printf("  %s (%s)%s%s\n", input.Name, input.Type, required, defaultValue)
 		if input.Description != "" {
 			fmt.Printf("    %s\n", input.Description)
 		}
+		if len(input.Enum) > 0 {
+			if enumBytes, err := json.Marshal(input.Enum); err == nil {
+				fmt.Printf("    one of: %s\n", string(enumBytes))
+			}
+		}
+		if input.Pattern != "" {
+			fmt.Printf("    pattern: %s\n", input.Pattern)
+		}
 	}
 }
 
@@ -341,6 +413,10 @@ func prepareInputs(wf *workflow.Workflow, providedInputs map[string]interface{})
 	for _, input := range wf.Inputs() {
 		if value, provided := providedInputs[input.Name]; provided {
 			inputs[input.Name] = value
+		} else if input.FromEnv != "" {
+			// Left to NewExecution, which reads the environment
+			// variable before falling back to the default.
+			continue
 		} else if input.Default != nil {
 			inputs[input.Name] = input.Default
 		} else if input.IsRequired() {
@@ -380,6 +456,11 @@ func showExecutionResults(result *workflow.ExecutionResult, err error, duration
 
 	info("Status: %s", result.Status)
 
+	if err := writeOutputFile(result, config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output file: %v\n", err)
+		os.Exit(1)
+	}
+
 	if result.Failed() {
 		if result.Error != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", result.Error)
@@ -411,3 +492,27 @@ func showExecutionResults(result *workflow.ExecutionResult, err error, duration
 		}
 	}
 }
+
+// writeOutputFile writes the outputs of result as JSON to the -output-file
+// path, independent of the console display flags. The outputs of a failed
+// execution are only written with -partial-outputs; they hold whatever
+// was extracted before the failure, which may be nothing.
+func writeOutputFile(result *workflow.ExecutionResult, config *Config) error {
+	if config.OutputFile == "" || (result.Failed() && !config.PartialOutputs) {
+		return nil
+	}
+	outputs := result.Outputs
+	if outputs == nil {
+		outputs = map[string]any{}
+	}
+	data, err := json.MarshalIndent(outputs, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(config.OutputFile, data, 0644); err != nil {
+		return err
+	}
+	info("Outputs written to: %s", config.OutputFile)
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow"
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestWriteOutputFile(t *testing.T) {
+	// "total" is always produced; the required "receipt" only when the
+	// "receipt" input is set, so the execution fails otherwise.
+	wf, err := workflow.New(workflow.Options{
+		Name:   "outputs",
+		Inputs: []*workflow.Input{{Name: "receipt", Type: workflow.InputTypeBool}},
+		Steps: []*workflow.Step{
+			{Name: "total", Activity: "value", Store: "total", Next: []*workflow.Edge{{Step: "receipt"}}},
+			{Name: "receipt", Activity: "value", Store: "receipt", Skip: "!inputs.receipt"},
+		},
+		Outputs: []*workflow.Output{
+			{Name: "total", Variable: "total"},
+			{Name: "receipt", Variable: "receipt", Required: true},
+		},
+	})
+	require.NoError(t, err)
+	reg := workflow.NewActivityRegistry()
+	reg.MustRegister(workflow.ActivityFunc("value", func(ctx workflow.Context, params map[string]any) (any, error) {
+		return 10, nil
+	}))
+	run := func(receipt bool) *workflow.ExecutionResult {
+		exec, err := workflow.NewExecution(wf, reg, workflow.WithInputs(map[string]any{"receipt": receipt}))
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		return result
+	}
+	readOutputs := func(path string) map[string]any {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		var outputs map[string]any
+		require.NoError(t, json.Unmarshal(data, &outputs))
+		return outputs
+	}
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "success.json")
+	require.NoError(t, writeOutputFile(run(true), &Config{OutputFile: path}))
+	require.Equal(t, map[string]any{"total": float64(10), "receipt": float64(10)}, readOutputs(path))
+
+	// A failed run writes nothing unless partial outputs are requested.
+	failed := run(false)
+	require.True(t, failed.Failed())
+	path = filepath.Join(dir, "failed.json")
+	require.NoError(t, writeOutputFile(failed, &Config{OutputFile: path}))
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, writeOutputFile(failed, &Config{OutputFile: path, PartialOutputs: true}))
+	require.Equal(t, map[string]any{"total": float64(10)}, readOutputs(path))
+}
@@ -0,0 +1,371 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+// inFlightTracker records the peak number of concurrent activity calls.
+type inFlightTracker struct {
+	current atomic.Int32
+	peak    atomic.Int32
+	calls   atomic.Int32
+}
+
+func (tr *inFlightTracker) activity(name string) Activity {
+	return ActivityFunc(name, func(ctx Context, params map[string]any) (any, error) {
+		n := tr.current.Add(1)
+		defer tr.current.Add(-1)
+		tr.calls.Add(1)
+		for {
+			peak := tr.peak.Load()
+			if n <= peak || tr.peak.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return params["item"], nil
+	})
+}
+
+func TestMaxParallelBranches(t *testing.T) {
+	t.Run("queues branches beyond the limit", func(t *testing.T) {
+		var edges []*Edge
+		steps := []*Step{{Name: "start", Activity: "noop"}}
+		for i := 0; i < 6; i++ {
+			name := fmt.Sprintf("work%d", i)
+			edges = append(edges, &Edge{Step: name, BranchName: name})
+			steps = append(steps, &Step{Name: name, Activity: "work"})
+		}
+		steps[0].Next = edges
+		wf, err := New(Options{Name: "max-parallel", Steps: steps})
+		require.NoError(t, err)
+
+		tracker := &inFlightTracker{}
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("noop", func(ctx Context, params map[string]any) (any, error) {
+			return nil, nil
+		}))
+		reg.MustRegister(tracker.activity("work"))
+
+		exec, err := NewExecution(wf, reg, WithMaxParallelBranches(2))
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		require.Equal(t, int32(6), tracker.calls.Load())
+		require.LessOrEqual(t, tracker.peak.Load(), int32(2))
+	})
+
+	t.Run("branch parked at a join frees its slot", func(t *testing.T) {
+		wf, err := New(Options{
+			Name: "max-parallel-join",
+			Steps: []*Step{
+				{
+					Name:     "start",
+					Activity: "work",
+					Next: []*Edge{
+						{Step: "final", BranchName: "final"},
+						{Step: "a", BranchName: "a"},
+						{Step: "b", BranchName: "b"},
+						{Step: "c", BranchName: "c"},
+					},
+				},
+				{Name: "a", Activity: "work"},
+				{Name: "b", Activity: "work"},
+				{Name: "c", Activity: "work"},
+				{Name: "final", Join: &JoinConfig{Branches: []string{"a", "b", "c"}}},
+			},
+		})
+		require.NoError(t, err)
+
+		tracker := &inFlightTracker{}
+		reg := NewActivityRegistry()
+		reg.MustRegister(tracker.activity("work"))
+
+		exec, err := NewExecution(wf, reg, WithMaxParallelBranches(1))
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		result, err := exec.Execute(ctx)
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		require.Equal(t, int32(4), tracker.calls.Load())
+		require.Equal(t, int32(1), tracker.peak.Load())
+	})
+}
+
+func TestEachMaxConcurrency(t *testing.T) {
+	items := []any{1, 2, 3, 4, 5, 6, 7, 8}
+	newWorkflow := func(limit int) (*Workflow, error) {
+		return New(Options{
+			Name:  "each-concurrency",
+			State: map[string]any{"items": items},
+			Steps: []*Step{
+				{
+					Name:       "fan",
+					Activity:   "work",
+					Each:       &Each{Items: "state.items", As: "item", MaxConcurrency: limit},
+					Parameters: map[string]any{"item": "${state.item}"},
+					Store:      "results",
+				},
+			},
+			Outputs: []*Output{{Name: "results", Variable: "results"}},
+		})
+	}
+
+	t.Run("bounds in-flight iterations and keeps item order", func(t *testing.T) {
+		wf, err := newWorkflow(3)
+		require.NoError(t, err)
+
+		tracker := &inFlightTracker{}
+		reg := NewActivityRegistry()
+		reg.MustRegister(tracker.activity("work"))
+
+		exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		require.Equal(t, items, result.Outputs["results"])
+		require.Equal(t, int32(len(items)), tracker.calls.Load())
+		require.LessOrEqual(t, tracker.peak.Load(), int32(3))
+		require.Greater(t, tracker.peak.Load(), int32(1))
+
+		// The loop variable does not leak into state.
+		_, ok := exec.state.GetBranchStates()["main"].Variables["item"]
+		require.False(t, ok)
+	})
+
+	t.Run("results follow item order, not completion order", func(t *testing.T) {
+		wf, err := newWorkflow(len(items))
+		require.NoError(t, err)
+
+		// Later items finish first.
+		var mu sync.Mutex
+		var completed []any
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("work", func(ctx Context, params map[string]any) (any, error) {
+			item := params["item"].(int)
+			time.Sleep(time.Duration(len(items)-item) * 10 * time.Millisecond)
+			mu.Lock()
+			completed = append(completed, item)
+			mu.Unlock()
+			return item * 10, nil
+		}))
+
+		exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		require.Equal(t, []any{10, 20, 30, 40, 50, 60, 70, 80}, result.Outputs["results"])
+		mu.Lock()
+		defer mu.Unlock()
+		require.NotEqual(t, items, completed)
+	})
+
+	t.Run("activities see their own item and keep their writes", func(t *testing.T) {
+		wf, err := newWorkflow(3)
+		require.NoError(t, err)
+
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("work", func(ctx Context, params map[string]any) (any, error) {
+			own, _ := ctx.Get("item")
+			ctx.Set(fmt.Sprintf("seen_%v", params["item"]), own)
+			return own, nil
+		}))
+
+		exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		require.Equal(t, items, result.Outputs["results"])
+
+		vars := exec.state.GetBranchStates()["main"].Variables
+		for _, item := range items {
+			require.Equal(t, item, vars[fmt.Sprintf("seen_%v", item)])
+		}
+		_, ok := vars["item"]
+		require.False(t, ok)
+	})
+
+	t.Run("first failure stops remaining iterations", func(t *testing.T) {
+		wf, err := newWorkflow(2)
+		require.NoError(t, err)
+
+		var mu sync.Mutex
+		var seen []any
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("work", func(ctx Context, params map[string]any) (any, error) {
+			mu.Lock()
+			seen = append(seen, params["item"])
+			mu.Unlock()
+			if params["item"] == 2 {
+				return nil, NewWorkflowError(ErrorTypeFatal, "bad item")
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Second):
+				return params["item"], nil
+			}
+		}))
+
+		exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusFailed, result.Status)
+		mu.Lock()
+		defer mu.Unlock()
+		require.Greater(t, len(items), len(seen))
+	})
+
+	t.Run("negative limit is rejected", func(t *testing.T) {
+		_, err := newWorkflow(-1)
+		require.True(t, errors.Is(err, ErrInvalidEachConfig))
+	})
+}
+
+func TestEachParallel(t *testing.T) {
+	items := []any{1, 2, 3, 4, 5}
+	wf, err := New(Options{
+		Name:  "each-parallel",
+		State: map[string]any{"items": items, "item": "outer", "shared": "original"},
+		Steps: []*Step{
+			{
+				Name:       "fan",
+				Activity:   "work",
+				Each:       &Each{Items: "state.items", As: "item", Parallel: true},
+				Parameters: map[string]any{"item": "${state.item}"},
+				Store:      "results",
+			},
+		},
+		Outputs: []*Output{{Name: "results", Variable: "results"}},
+	})
+	require.NoError(t, err)
+
+	// Every iteration waits until all of them are running, and later
+	// items finish first.
+	var started sync.WaitGroup
+	started.Add(len(items))
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("work", func(ctx Context, params map[string]any) (any, error) {
+		started.Done()
+		started.Wait()
+		item := params["item"].(int)
+		time.Sleep(time.Duration(len(items)-item) * 5 * time.Millisecond)
+
+		// Each iteration sees its own item and its own copy of state.
+		own, _ := ctx.Get("item")
+		shared, _ := ctx.Get("shared")
+		ctx.Set("shared", fmt.Sprintf("written by %d", item))
+		return fmt.Sprintf("%v:%v:%v", item, own, shared), nil
+	}))
+
+	exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := exec.Execute(ctx)
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.Equal(t, []any{
+		"1:1:original", "2:2:original", "3:3:original", "4:4:original", "5:5:original",
+	}, result.Outputs["results"])
+
+	// Writes made by iterations stay in their copies.
+	vars := exec.state.GetBranchStates()["main"].Variables
+	require.Equal(t, "original", vars["shared"])
+	require.Equal(t, "outer", vars["item"])
+}
+
+// fakeDB stands in for a connection pool shared by every execution.
+type fakeDB struct {
+	mu   sync.Mutex
+	rows map[string][]string // execution ID -> inserted values
+}
+
+func (db *fakeDB) insert(executionID, value string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.rows[executionID] = append(db.rows[executionID], value)
+}
+
+// dbActivity holds only the shared pool; everything else is per call.
+type dbActivity struct{ db *fakeDB }
+
+func (a *dbActivity) Name() string { return "db.insert" }
+
+func (a *dbActivity) Execute(ctx Context, params map[string]any) (any, error) {
+	run, _ := ctx.Inputs().Get("run")
+	value := fmt.Sprintf("%s:%v", ctx.BranchID(), params["value"])
+	a.db.insert(run.(string), value)
+	return value, nil
+}
+
+func TestSharedActivityAcrossExecutions(t *testing.T) {
+	wf, err := New(Options{
+		Name:   "shared-pool",
+		Inputs: []*Input{{Name: "run", Type: InputTypeString}},
+		Steps: []*Step{
+			{
+				Name:       "start",
+				Activity:   "db.insert",
+				Parameters: map[string]any{"value": "start"},
+				Next: []*Edge{
+					{Step: "final", BranchName: "final"},
+					{Step: "a", BranchName: "a"},
+					{Step: "b", BranchName: "b"},
+					{Step: "c", BranchName: "c"},
+				},
+			},
+			{Name: "a", Activity: "db.insert", Parameters: map[string]any{"value": "${state.n}"}, Store: "a"},
+			{Name: "b", Activity: "db.insert", Parameters: map[string]any{"value": "${state.n}"}, Store: "b"},
+			{Name: "c", Activity: "db.insert", Parameters: map[string]any{"value": "${state.n}"}, Store: "c"},
+			{Name: "final", Join: &JoinConfig{Branches: []string{"a", "b", "c"}}},
+		},
+		State: map[string]any{"n": 1},
+	})
+	require.NoError(t, err)
+
+	// One registry and one activity instance for every execution.
+	db := &fakeDB{rows: map[string][]string{}}
+	reg := NewActivityRegistry()
+	reg.MustRegister(&dbActivity{db: db})
+
+	const executions = 8
+	var wg sync.WaitGroup
+	statuses := make([]ExecutionStatus, executions)
+	errs := make([]error, executions)
+	for i := range executions {
+		exec, err := NewExecution(wf, reg, WithInputs(map[string]any{"run": fmt.Sprintf("run-%d", i)}))
+		require.NoError(t, err)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := exec.Execute(context.Background())
+			errs[i] = err
+			if result != nil {
+				statuses[i] = result.Status
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range executions {
+		require.NoError(t, errs[i])
+		require.Equal(t, ExecutionStatusCompleted, statuses[i])
+		require.Len(t, db.rows[fmt.Sprintf("run-%d", i)], 4)
+	}
+	require.Len(t, db.rows, executions)
+}
@@ -3,6 +3,7 @@ package workflow
 import (
 	"context"
 	"log/slog"
+	"math"
 	"sort"
 	"time"
 
@@ -176,8 +177,15 @@ type executionContext struct {
 	pendingWait      *WaitState
 	history          *History
 	progressReporter func(detail ProgressDetail) // nil when no store is configured
+	variableObserver variableObserver            // nil outside the engine
+	deadline         time.Time                   // of the execution; zero when unbounded
+	dryRun           bool
 }
 
+// variableObserver is told about each change an activity makes to a
+// branch variable; see ExecutionCallbacks.OnVariableChanged.
+type variableObserver func(name string, old, value any, deleted bool)
+
 type ExecutionContextOptions struct {
 	BranchLocalState *BranchLocalState
 	Logger           *slog.Logger
@@ -196,6 +204,12 @@ type ExecutionContextOptions struct {
 	// activity; nil for handler contexts that don't execute activity
 	// code.
 	ActivityHistory *History
+	// DryRun reports to activities that the execution was started with
+	// WithDryRun. See IsDryRun.
+	DryRun bool
+	// ExecutionDeadline is the deadline of the context the execution
+	// was run with, zero when it has none. See ExecutionDeadline.
+	ExecutionDeadline time.Time
 }
 
 // NewContext creates a new workflow context with direct state access.
@@ -211,6 +225,8 @@ func NewContext(ctx context.Context, opts ExecutionContextOptions) *executionCon
 		signalStore:      opts.SignalStore,
 		pendingWait:      opts.PendingWait,
 		history:          opts.ActivityHistory,
+		deadline:         opts.ExecutionDeadline,
+		dryRun:           opts.DryRun,
 	}
 }
 
@@ -234,6 +250,23 @@ func (w *executionContext) BranchID() string { return w.branchID }
 // StepName returns the current step name.
 func (w *executionContext) StepName() string { return w.stepName }
 
+// Set writes a branch-local variable and reports the change.
+func (w *executionContext) Set(key string, value any) {
+	old, _ := w.BranchLocalState.swap(key, value)
+	if w.variableObserver != nil {
+		w.variableObserver(key, old, value, false)
+	}
+}
+
+// Delete removes a branch-local variable and reports the change if it
+// was set.
+func (w *executionContext) Delete(key string) {
+	old, existed := w.BranchLocalState.remove(key)
+	if existed && w.variableObserver != nil {
+		w.variableObserver(key, old, nil, true)
+	}
+}
+
 // ReportProgress forwards the progress detail to the configured
 // StepProgressStore, if any.
 func (w *executionContext) ReportProgress(detail ProgressDetail) {
@@ -253,6 +286,52 @@ func (w *executionContext) History() *History {
 	return w.history
 }
 
+// IsDryRun reports whether the activity is running as part of a dry
+// run (see WithDryRun). Side-effecting activities should log what they
+// would do and return a simulated result when it is true.
+func IsDryRun(ctx Context) bool {
+	if wc, ok := ctx.(*executionContext); ok {
+		return wc.dryRun
+	}
+	return false
+}
+
+// ExecutionDeadline returns the deadline of the context the execution
+// was run with — the one passed to Execute or Runner.Run, including a
+// Runner timeout — and whether there is one. Unlike ctx.Deadline, it
+// ignores the per-attempt timeout of a step's retry config, so it
+// tells an activity how long the whole execution has left rather than
+// the current attempt. Outside the engine it falls back to
+// ctx.Deadline.
+func ExecutionDeadline(ctx Context) (time.Time, bool) {
+	if wc, ok := ctx.(*executionContext); ok {
+		return wc.deadline, !wc.deadline.IsZero()
+	}
+	return ctx.Deadline()
+}
+
+// RemainingTime returns the time left until ExecutionDeadline, which
+// is negative once the deadline has passed. An execution without a
+// deadline has math.MaxInt64 nanoseconds left, so a check such as
+// RemainingTime(ctx) < time.Minute works either way.
+func RemainingTime(ctx Context) time.Duration {
+	deadline, ok := ExecutionDeadline(ctx)
+	if !ok {
+		return math.MaxInt64
+	}
+	return time.Until(deadline)
+}
+
+// ExecutionID returns the ID of the execution running the activity, or
+// "" outside the engine. Activities use it to address signals and
+// callbacks back to their own execution.
+func ExecutionID(ctx Context) string {
+	if wc, ok := ctx.(*executionContext); ok {
+		return wc.executionID
+	}
+	return ""
+}
+
 // internal accessors for the signal and wait subsystems. They are not
 // part of the exported Context interface but let wait.go reach the
 // plumbing without re-opening the struct.
@@ -277,6 +356,9 @@ func WithTimeout(parent Context, timeout time.Duration) (Context, context.Cancel
 			pendingWait:      wc.pendingWait,
 			history:          wc.history,
 			progressReporter: wc.progressReporter,
+			variableObserver: wc.variableObserver,
+			deadline:         wc.deadline,
+			dryRun:           wc.dryRun,
 		}, cancel
 	}
 
@@ -300,6 +382,9 @@ func WithCancel(parent Context) (Context, context.CancelFunc) {
 			pendingWait:      wc.pendingWait,
 			history:          wc.history,
 			progressReporter: wc.progressReporter,
+			variableObserver: wc.variableObserver,
+			deadline:         wc.deadline,
+			dryRun:           wc.dryRun,
 		}, cancel
 	}
 
@@ -361,6 +361,88 @@ func TestSetNestedField(t *testing.T) {
 	})
 }
 
+func TestNestedFieldIndexing(t *testing.T) {
+	newData := func() map[string]any {
+		return map[string]any{
+			"orders": []any{
+				map[string]any{"id": "o-1", "items": []any{
+					map[string]any{"sku": "a"},
+					map[string]any{"sku": "b"},
+				}},
+				map[string]any{"id": "o-2", "items": []any{}},
+			},
+			"matrix": [][]int{{1, 2}, {3, 4}},
+			"tags":   []string{"x", "y", "z"},
+		}
+	}
+
+	t.Run("get", func(t *testing.T) {
+		data := newData()
+		for path, want := range map[string]any{
+			"orders[0].id":           "o-1",
+			"orders[-1].id":          "o-2",
+			"orders[0].items[1].sku": "b",
+			"orders[0].items[-2]":    map[string]any{"sku": "a"},
+			"matrix[1][0]":           3,
+			"matrix[-1][-1]":         4,
+			"tags[2]":                "z",
+		} {
+			v, ok := getNestedField(data, path)
+			require.True(t, ok, path)
+			require.Equal(t, want, v, path)
+		}
+	})
+
+	t.Run("get misses", func(t *testing.T) {
+		data := newData()
+		for _, path := range []string{
+			"orders[2]", "orders[-3]", "orders[1].items[0]", "orders[0].sku",
+			"tags[0].name", "orders.id", "orders[x]", "orders[0", "orders[]", "[0]",
+			"orders[0]x",
+		} {
+			_, ok := getNestedField(data, path)
+			require.False(t, ok, path)
+		}
+	})
+
+	t.Run("set", func(t *testing.T) {
+		data := newData()
+		setNestedField(data, "orders[0].items[-1].qty", 2)
+		setNestedField(data, "orders[1].status.code", "shipped")
+		setNestedField(data, "orders[-1].id", "o-2b")
+		v, _ := getNestedField(data, "orders[0].items[1]")
+		require.Equal(t, map[string]any{"sku": "b", "qty": 2}, v)
+		v, _ = getNestedField(data, "orders[1].status.code")
+		require.Equal(t, "shipped", v)
+		v, _ = getNestedField(data, "orders[1].id")
+		require.Equal(t, "o-2b", v)
+
+		// Indexes are never created or grown.
+		setNestedField(data, "orders[5].id", "o-6")
+		setNestedField(data, "missing[0].id", "none")
+		require.Len(t, data["orders"], 2)
+		require.NotContains(t, data, "missing")
+	})
+
+	t.Run("workflow output", func(t *testing.T) {
+		wf, err := New(Options{
+			Name:    "indexed-output",
+			Steps:   []*Step{{Name: "list", Activity: "list", Store: "orders"}},
+			Outputs: []*Output{{Name: "last_id", Variable: "orders[-1].id"}},
+		})
+		require.NoError(t, err)
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("list", func(ctx Context, params map[string]any) (any, error) {
+			return newData()["orders"], nil
+		}))
+		exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "o-2", result.Outputs["last_id"])
+	})
+}
+
 // --- executionState ---
 
 func TestExecutionState_NextBranchID(t *testing.T) {
@@ -442,6 +524,19 @@ func TestExecutionState_IsJoinReady(t *testing.T) {
 		state.AddBranchToJoin("join-step", "waiter", &JoinConfig{}, nil, nil)
 		require.False(t, state.IsJoinReady("join-step"))
 	})
+
+	t.Run("count-based join skips branches consumed by another join", func(t *testing.T) {
+		state := newExecutionState("exec-1", "wf", nil)
+		state.SetBranchState("p1", &BranchState{ID: "p1", Status: ExecutionStatusCompleted})
+		state.SetBranchState("p2", &BranchState{ID: "p2", Status: ExecutionStatusCompleted})
+		state.SetBranchState("p3", &BranchState{ID: "p3", Status: ExecutionStatusCompleted})
+		state.MarkBranchesJoined("inner-join", []string{"p1", "p2"})
+		state.AddBranchToJoin("join-step", "waiter", &JoinConfig{Count: 2}, nil, nil)
+		require.False(t, state.IsJoinReady("join-step"))
+
+		state.SetBranchState("p4", &BranchState{ID: "p4", Status: ExecutionStatusCompleted})
+		require.True(t, state.IsJoinReady("join-step"))
+	})
 }
 
 // --- MemoryWorkflowRegistry ---
@@ -625,6 +720,43 @@ func TestFileCheckpointer_ListExecutions(t *testing.T) {
 	require.Len(t, executions, 2)
 }
 
+func TestFileCheckpointer_MaxCheckpointHistory(t *testing.T) {
+	dir := t.TempDir()
+	cp, err := NewFileCheckpointer(dir, WithMaxCheckpointHistory(3))
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		err := cp.SaveCheckpoint(context.Background(), &Checkpoint{
+			SchemaVersion: CheckpointSchemaVersion,
+			ID:            fmt.Sprintf("cp-%02d", i),
+			ExecutionID:   "exec-1",
+			Status:        "running",
+			CheckpointAt:  time.Now(),
+		})
+		require.NoError(t, err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "exec-1"))
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	require.Equal(t, []string{
+		"checkpoint-cp-07.json",
+		"checkpoint-cp-08.json",
+		"checkpoint-cp-09.json",
+		"latest.json",
+	}, names)
+
+	latest, err := cp.LoadCheckpoint(context.Background(), "exec-1")
+	require.NoError(t, err)
+	require.Equal(t, "cp-09", latest.ID)
+
+	_, err = NewFileCheckpointer(dir, WithMaxCheckpointHistory(-1))
+	require.Error(t, err)
+}
+
 // --- FencedCheckpointer DeleteCheckpoint ---
 
 func TestFencedCheckpointer_DeleteCheckpoint(t *testing.T) {
@@ -1023,6 +1155,61 @@ func TestDefaultChildWorkflowExecutor_AsyncNotFound(t *testing.T) {
 	require.Contains(t, err.Error(), "not found in registry")
 }
 
+func TestDefaultChildWorkflowExecutor_Cancel(t *testing.T) {
+	wf, err := New(Options{
+		Name: "slow-child",
+		Steps: []*Step{
+			{Name: "wait", Activity: "wait"},
+		},
+	})
+	require.NoError(t, err)
+
+	reg := NewMemoryWorkflowRegistry()
+	reg.Register(wf)
+
+	started := make(chan struct{})
+	waitActivity := ActivityFunc("wait", func(ctx Context, params map[string]any) (any, error) {
+		close(started)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Minute):
+			return "finished", nil
+		}
+	})
+
+	executor, err := NewDefaultChildWorkflowExecutor(ChildWorkflowExecutorOptions{
+		WorkflowRegistry: reg,
+		Activities:       []Activity{waitActivity},
+	})
+	require.NoError(t, err)
+
+	handle, err := executor.ExecuteAsync(context.Background(), &ChildWorkflowSpec{
+		WorkflowName: "slow-child",
+		Timeout:      time.Hour,
+	})
+	require.NoError(t, err)
+	<-started
+
+	result, err := executor.GetResult(context.Background(), handle)
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusRunning, result.Status)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, executor.Cancel(ctx, handle))
+
+	// Cancel waits for the child to stop, so the result is final.
+	result, err = executor.GetResult(context.Background(), handle)
+	require.ErrorIs(t, err, ErrChildWorkflowCanceled)
+	require.Equal(t, ExecutionStatusCanceled, result.Status)
+
+	// Canceling again is a no-op; unknown handles are rejected.
+	require.NoError(t, executor.Cancel(ctx, handle))
+	require.Error(t, executor.Cancel(ctx, &ChildWorkflowHandle{ExecutionID: "missing"}))
+	require.Error(t, executor.Cancel(ctx, nil))
+}
+
 // --- Execution: branching workflow ---
 
 func TestExecution_Branching(t *testing.T) {
@@ -1148,6 +1335,63 @@ func TestExecution_Retry(t *testing.T) {
 	require.Equal(t, 3, attempts)
 }
 
+func TestExecution_RetryUnlimitedWithBudget(t *testing.T) {
+	newWorkflow := func(budget time.Duration) *Workflow {
+		wf, err := New(Options{
+			Name: "retry-unlimited",
+			Steps: []*Step{
+				{
+					Name:     "poll",
+					Activity: "poll",
+					Retry: []*RetryConfig{{
+						MaxRetries: UnlimitedRetries,
+						MaxElapsed: budget,
+						BaseDelay:  time.Millisecond,
+						MaxDelay:   5 * time.Millisecond,
+					}},
+				},
+			},
+		})
+		require.NoError(t, err)
+		return wf
+	}
+
+	t.Run("retries past any fixed count", func(t *testing.T) {
+		attempts := 0
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("poll", func(ctx Context, params map[string]any) (any, error) {
+			attempts++
+			if attempts < 20 {
+				return nil, fmt.Errorf("not ready")
+			}
+			return "ready", nil
+		}))
+		exec, err := NewExecution(newWorkflow(10*time.Second), reg)
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		require.Equal(t, 20, attempts)
+	})
+
+	t.Run("stops when the budget runs out", func(t *testing.T) {
+		attempts := 0
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("poll", func(ctx Context, params map[string]any) (any, error) {
+			attempts++
+			return nil, fmt.Errorf("not ready")
+		}))
+		exec, err := NewExecution(newWorkflow(50*time.Millisecond), reg)
+		require.NoError(t, err)
+		start := time.Now()
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusFailed, result.Status)
+		require.Greater(t, attempts, 2)
+		require.LessOrEqual(t, time.Since(start), time.Second)
+	})
+}
+
 // --- Execution: template parameters ---
 
 func TestExecution_TemplateParameters(t *testing.T) {
@@ -0,0 +1,104 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ToDOT renders the workflow graph in Graphviz DOT format, for example
+// to pipe into `dot -Tpng`. Each step is a node labeled with its name
+// and kind: joins are drawn as diamonds and steps without outgoing
+// edges are shaded. Edges are labeled with their condition, "else" for
+// Else edges, or "always" when unconditional, plus the branch name for edges that
+// start a named branch. Catch handlers are dashed edges labeled with
+// the error types they match, and WaitSignal timeouts are dotted.
+// Workflow-level CatchPolicies and OnError are not drawn.
+func (w *Workflow) ToDOT() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(w.name))
+	b.WriteString("  rankdir=TB;\n")
+	b.WriteString("  node [shape=box, style=rounded];\n")
+	b.WriteString("  \"__start\" [shape=point];\n")
+	fmt.Fprintf(&b, "  \"__start\" -> %s;\n", dotQuote(w.start.Name))
+
+	for _, step := range w.steps {
+		attrs := []string{"label=" + dotLabel(step.Name, stepKind(step))}
+		if step.Join != nil {
+			attrs = append(attrs, "shape=diamond")
+		}
+		if len(step.Next) == 0 {
+			attrs = append(attrs, `style="rounded,filled"`, "fillcolor=lightgray")
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(step.Name), strings.Join(attrs, ", "))
+	}
+
+	for _, step := range w.steps {
+		for _, edge := range step.Next {
+			label := edge.Condition
+			switch {
+			case edge.Else:
+				label = "else"
+			case label == "" && edge.Predicate != nil:
+				label = "predicate"
+			case label == "":
+				label = "always"
+			}
+			lines := []string{label}
+			if edge.BranchName != "" {
+				lines = append(lines, "branch: "+edge.BranchName)
+			}
+			fmt.Fprintf(&b, "  %s -> %s [label=%s];\n",
+				dotQuote(step.Name), dotQuote(edge.Step), dotLabel(lines...))
+		}
+		for _, c := range step.Catch {
+			fmt.Fprintf(&b, "  %s -> %s [label=%s, style=dashed];\n",
+				dotQuote(step.Name), dotQuote(c.Next), dotLabel("catch: "+strings.Join(c.ErrorEquals, ", ")))
+		}
+		if ws := step.WaitSignal; ws != nil && ws.OnTimeout != "" {
+			fmt.Fprintf(&b, "  %s -> %s [label=\"timeout\", style=dotted];\n",
+				dotQuote(step.Name), dotQuote(ws.OnTimeout))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// stepKind describes what a step does, for node labels.
+func stepKind(step *Step) string {
+	switch {
+	case step.Join != nil:
+		return "join"
+	case step.WaitSignal != nil:
+		return "wait_signal"
+	case step.Sleep != nil:
+		return "sleep"
+	case step.Pause != nil:
+		return "pause"
+	case step.Terminate != nil:
+		return "terminate"
+	case step.Each != nil:
+		return step.Activity + " (each)"
+	}
+	return step.Activity
+}
+
+// dotLabel joins non-empty lines into a quoted, multi-line DOT label.
+func dotLabel(lines ...string) string {
+	var kept []string
+	for _, line := range lines {
+		if line != "" {
+			kept = append(kept, dotEscape(line))
+		}
+	}
+	return `"` + strings.Join(kept, `\n`) + `"`
+}
+
+func dotQuote(s string) string {
+	return `"` + dotEscape(s) + `"`
+}
+
+func dotEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
@@ -0,0 +1,61 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestWorkflowToDOT(t *testing.T) {
+	wf, err := New(Options{
+		Name: `order "flow"`,
+		Steps: []*Step{
+			{
+				Name:     "fetch",
+				Activity: "http",
+				Catch:    []*CatchConfig{{ErrorEquals: []string{"timeout", "http.5xx"}, Next: "alert"}},
+				Next: []*Edge{
+					{Step: "left", BranchName: "a"},
+					{Step: "right", Condition: `state.kind == "b"`, BranchName: "b"},
+					{Step: "merge"},
+				},
+			},
+			{Name: "left", Activity: "work"},
+			{Name: "right", Activity: "work"},
+			{
+				Name: "merge",
+				Join: &JoinConfig{Branches: []string{"a", "b"}},
+				Next: []*Edge{{Step: "approve"}},
+			},
+			{
+				Name:       "approve",
+				WaitSignal: &WaitSignalConfig{Topic: "approval", Timeout: time.Hour, OnTimeout: "alert"},
+				Next:       []*Edge{{Step: "done"}},
+			},
+			{Name: "done", Activity: "print"},
+			{Name: "alert", Activity: "print"},
+		},
+	})
+	require.NoError(t, err)
+
+	dot := wf.ToDOT()
+	require.True(t, strings.HasPrefix(dot, `digraph "order \"flow\"" {`), dot)
+	require.True(t, strings.HasSuffix(dot, "}\n"))
+
+	for _, want := range []string{
+		`"__start" -> "fetch";`,
+		`"fetch" [label="fetch\nhttp"];`,
+		`"merge" [label="merge\njoin", shape=diamond];`,
+		`"done" [label="done\nprint", style="rounded,filled", fillcolor=lightgray];`,
+		`"fetch" -> "left" [label="always\nbranch: a"];`,
+		`"fetch" -> "right" [label="state.kind == \"b\"\nbranch: b"];`,
+		`"fetch" -> "merge" [label="always"];`,
+		`"fetch" -> "alert" [label="catch: timeout, http.5xx", style=dashed];`,
+		`"approve" -> "alert" [label="timeout", style=dotted];`,
+	} {
+		require.Contains(t, dot, want)
+	}
+	require.NotContains(t, dot, `"fetch" [label="fetch\nhttp", style=`)
+}
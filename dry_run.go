@@ -0,0 +1,169 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DryRunIssue describes a step parameter whose template failed to
+// compile or evaluate during Execution.DryRun.
+type DryRunIssue struct {
+	Step  string
+	Param string
+	Err   error
+}
+
+func (i DryRunIssue) String() string {
+	return fmt.Sprintf("step %q param %q: %v", i.Step, i.Param, i.Err)
+}
+
+// stateRefPattern matches the root variable of a state.<name> reference
+// inside a template expression.
+var stateRefPattern = regexp.MustCompile(`\bstate\.([A-Za-z_][A-Za-z0-9_]*)`)
+
+// DryRun evaluates the parameter templates of every step reachable from
+// the start step against the execution's inputs and the workflow's
+// initial state, without calling any activity. It returns one issue per
+// parameter that fails to compile or evaluate; an empty result means
+// every template that can be checked up front is sound.
+//
+// Templates that read a variable produced at runtime (a Store target,
+// an Each.As variable, a join mapping destination) and absent from the
+// initial state, or that read step results through the steps global,
+// cannot be resolved before the workflow runs, so DryRun skips them
+// rather than reporting a false failure.
+//
+// DryRun does not start the execution and may be called before Execute.
+func (e *Execution) DryRun(ctx context.Context) []DryRunIssue {
+	opts := e.branchOptions
+	opts.ExecutionID = e.state.ID()
+	probe := newBranch("dry-run", e.workflow.Start(), opts)
+
+	initial := e.workflow.InitialState()
+	produced := e.workflow.runtimeVariables()
+	deferred := func(template string) bool {
+		if stepsRefPattern.MatchString(template) {
+			return true
+		}
+		for _, m := range stateRefPattern.FindAllStringSubmatch(template, -1) {
+			name := m[1]
+			if _, ok := initial[name]; ok {
+				continue
+			}
+			if produced[name] {
+				return true
+			}
+		}
+		return false
+	}
+
+	var issues []DryRunIssue
+	var check func(step *Step, param string, value any)
+	check = func(step *Step, param string, value any) {
+		switch v := value.(type) {
+		case map[string]any:
+			for _, key := range sortedKeys(v) {
+				check(step, param+"."+key, v[key])
+			}
+		case string:
+			if !probe.templateDelims.IsTemplate(v) || deferred(v) {
+				return
+			}
+			if _, err := probe.evaluateParameterValue(ctx, v, step.Name, param); err != nil {
+				issues = append(issues, DryRunIssue{Step: step.Name, Param: param, Err: err})
+			}
+		}
+	}
+
+	for _, step := range e.workflow.reachableSteps() {
+		if ctx.Err() != nil {
+			break
+		}
+		for _, name := range sortedKeys(step.Parameters) {
+			check(step, name, step.Parameters[name])
+		}
+	}
+	return issues
+}
+
+// reachableSteps returns the steps reachable from the start step by
+// following edges, catch handlers, and wait timeouts, in breadth-first
+// order.
+func (w *Workflow) reachableSteps() []*Step {
+	seen := map[string]bool{w.start.Name: true}
+	queue := []*Step{w.start}
+	var out []*Step
+	visit := func(name string) {
+		if name == "" || seen[name] {
+			return
+		}
+		if step, ok := w.stepsByName[name]; ok {
+			seen[name] = true
+			queue = append(queue, step)
+		}
+	}
+	for len(queue) > 0 {
+		step := queue[0]
+		queue = queue[1:]
+		out = append(out, step)
+		for _, edge := range step.Next {
+			visit(edge.Step)
+		}
+		for _, c := range step.Catch {
+			visit(c.Next)
+		}
+		if step.WaitSignal != nil {
+			visit(step.WaitSignal.OnTimeout)
+		}
+		for _, c := range w.catchFallbacks {
+			visit(c.Next)
+		}
+	}
+	return out
+}
+
+// runtimeVariables returns the names of the branch variables that steps
+// write while the workflow runs.
+func (w *Workflow) runtimeVariables() map[string]bool {
+	names := map[string]bool{}
+	addPath := func(path string) {
+		path = strings.TrimPrefix(path, "state.")
+		if root, _, _ := strings.Cut(path, "."); root != "" {
+			names[root] = true
+		}
+	}
+	for _, step := range w.steps {
+		addPath(step.Store)
+		addPath(step.StoreAppend)
+		if step.Each != nil {
+			addPath(step.Each.As)
+		}
+		for _, c := range step.Catch {
+			addPath(c.Store)
+		}
+		if step.WaitSignal != nil {
+			addPath(step.WaitSignal.Store)
+		}
+		if step.Join != nil {
+			for _, dest := range step.Join.BranchMappings {
+				addPath(dest)
+			}
+		}
+	}
+	for _, c := range w.catchFallbacks {
+		addPath(c.Store)
+	}
+	return names
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
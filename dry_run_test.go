@@ -0,0 +1,121 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestExecutionDryRun(t *testing.T) {
+	called := false
+	noop := func(ctx Context, params map[string]any) (any, error) {
+		called = true
+		return nil, nil
+	}
+
+	wf, err := New(Options{
+		Name:   "dry-run-templates",
+		Inputs: []*Input{{Name: "url", Type: "string"}},
+		State:  map[string]any{"limit": 10},
+		Steps: []*Step{
+			{
+				Name:     "fetch",
+				Activity: "noop",
+				Store:    "response",
+				Parameters: map[string]any{
+					"url":   "${inputs.url}",
+					"limit": "${state.limit}",
+					"headers": map[string]any{
+						"token": "${inputs.token}",
+					},
+				},
+				Next: []*Edge{{Step: "process"}},
+			},
+			{
+				Name:     "process",
+				Activity: "noop",
+				Parameters: map[string]any{
+					// Produced by "fetch" at runtime: skipped, not reported.
+					"body": "${state.response}",
+					// Never produced anywhere: reported.
+					"mode": "${state.missing}",
+				},
+			},
+			{
+				// Unreachable: never checked.
+				Name:       "orphan",
+				Activity:   "noop",
+				Parameters: map[string]any{"x": "${state.nope}"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("noop", noop))
+	exec, err := NewExecution(wf, reg,
+		WithScriptCompiler(newTestCompiler()),
+		WithInputs(map[string]any{"url": "https://example.com"}),
+	)
+	require.NoError(t, err)
+
+	issues := exec.DryRun(context.Background())
+	require.False(t, called, "dry run must not execute activities")
+	require.Len(t, issues, 2)
+	require.Equal(t, "fetch", issues[0].Step)
+	require.Equal(t, "headers.token", issues[0].Param)
+	require.Error(t, issues[0].Err)
+	require.Equal(t, "process", issues[1].Step)
+	require.Equal(t, "mode", issues[1].Param)
+
+	// The execution is still usable afterwards.
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusFailed, result.Status)
+}
+
+func TestExecutionDryRunClean(t *testing.T) {
+	wf, err := New(Options{
+		Name: "dry-run-clean",
+		Steps: []*Step{
+			{Name: "a", Activity: "noop", Parameters: map[string]any{"n": "${1 + 2}"}},
+		},
+	})
+	require.NoError(t, err)
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("noop", func(ctx Context, params map[string]any) (any, error) {
+		return nil, nil
+	}))
+	exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+	require.NoError(t, err)
+	require.Empty(t, exec.DryRun(context.Background()))
+}
+
+func TestExecutionDryRunSkipsStepResults(t *testing.T) {
+	wf, err := New(Options{
+		Name: "dry-run-steps",
+		Steps: []*Step{
+			{Name: "fetch", Activity: "fetch", Next: []*Edge{{Step: "process"}}},
+			{Name: "process", Activity: "process", Parameters: map[string]any{"body": "${steps.fetch.body}"}},
+		},
+	})
+	require.NoError(t, err)
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("fetch", func(ctx Context, params map[string]any) (any, error) {
+		return map[string]any{"body": "hello"}, nil
+	}))
+	var got any
+	reg.MustRegister(ActivityFunc("process", func(ctx Context, params map[string]any) (any, error) {
+		got = params["body"]
+		return nil, nil
+	}))
+	exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+	require.NoError(t, err)
+	require.Empty(t, exec.DryRun(context.Background()))
+
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.Equal(t, "hello", got)
+}
@@ -0,0 +1,50 @@
+package workflow
+
+import "sort"
+
+// policyErrorTypes returns the keys of a policy map in match order:
+// specific error types sorted by name, then the ErrorTypeActivityFailed
+// and ErrorTypeAll wildcards, so neither shadows a more specific policy.
+func policyErrorTypes[T any](policies map[string]T) []string {
+	keys := make([]string, 0, len(policies))
+	for key := range policies {
+		if key != ErrorTypeActivityFailed && key != ErrorTypeAll {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, wildcard := range []string{ErrorTypeActivityFailed, ErrorTypeAll} {
+		if _, ok := policies[wildcard]; ok {
+			keys = append(keys, wildcard)
+		}
+	}
+	return keys
+}
+
+func flattenRetryPolicies(policies map[string]*RetryConfig) []*RetryConfig {
+	var out []*RetryConfig
+	for _, errorType := range policyErrorTypes(policies) {
+		rc := policies[errorType]
+		if rc == nil {
+			continue
+		}
+		c := *rc
+		c.ErrorEquals = []string{errorType}
+		out = append(out, &c)
+	}
+	return out
+}
+
+func flattenCatchPolicies(policies map[string]*CatchConfig) []*CatchConfig {
+	var out []*CatchConfig
+	for _, errorType := range policyErrorTypes(policies) {
+		cc := policies[errorType]
+		if cc == nil {
+			continue
+		}
+		c := *cc
+		c.ErrorEquals = []string{errorType}
+		out = append(out, &c)
+	}
+	return out
+}
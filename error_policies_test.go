@@ -0,0 +1,264 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestErrorPolicyAppliesToStepWithoutRetry(t *testing.T) {
+	wf, err := New(Options{
+		Name: "error-policy",
+		Steps: []*Step{
+			{Name: "fetch", Activity: "fetch", Store: "body"},
+		},
+		ErrorPolicies: map[string]*RetryConfig{
+			"http.429": {MaxRetries: 3, BaseDelay: time.Millisecond},
+		},
+	})
+	require.NoError(t, err)
+
+	calls := 0
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("fetch", func(ctx Context, params map[string]any) (any, error) {
+		calls++
+		if calls < 3 {
+			return nil, NewWorkflowError("http.429", "too many requests")
+		}
+		return "ok", nil
+	}))
+
+	exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.Equal(t, 3, calls)
+}
+
+func TestErrorPolicyActivityFailedDoesNotShadowSpecific(t *testing.T) {
+	wf, err := New(Options{
+		Name: "error-policy-wildcards",
+		Steps: []*Step{
+			{Name: "fetch", Activity: "fetch", Store: "body"},
+		},
+		ErrorPolicies: map[string]*RetryConfig{
+			ErrorTypeActivityFailed: {MaxRetries: 1, BaseDelay: time.Millisecond},
+			"http.429":              {MaxRetries: 5, BaseDelay: time.Millisecond},
+		},
+	})
+	require.NoError(t, err)
+
+	calls := 0
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("fetch", func(ctx Context, params map[string]any) (any, error) {
+		calls++
+		return nil, NewWorkflowError("http.429", "too many requests")
+	}))
+
+	exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusFailed, result.Status)
+	require.Equal(t, 6, calls)
+}
+
+func TestErrorPolicyLayeredUnderStepRetry(t *testing.T) {
+	wf, err := New(Options{
+		Name: "error-policy-layering",
+		Steps: []*Step{
+			{
+				Name:     "fetch",
+				Activity: "fetch",
+				Retry: []*RetryConfig{
+					{ErrorEquals: []string{"http.429"}, MaxRetries: 1, BaseDelay: time.Millisecond},
+				},
+			},
+		},
+		ErrorPolicies: map[string]*RetryConfig{
+			"http.429": {MaxRetries: 5, BaseDelay: time.Millisecond},
+		},
+	})
+	require.NoError(t, err)
+
+	calls := 0
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("fetch", func(ctx Context, params map[string]any) (any, error) {
+		calls++
+		return nil, NewWorkflowError("http.429", "too many requests")
+	}))
+
+	exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusFailed, result.Status)
+	// The step's own config wins: one retry, not five.
+	require.Equal(t, 2, calls)
+}
+
+func TestCatchPolicyRoutesUnhandledError(t *testing.T) {
+	wf, err := New(Options{
+		Name: "catch-policy",
+		Steps: []*Step{
+			{Name: "charge", Activity: "charge"},
+			{Name: "refund", Activity: "refund", Store: "refunded"},
+		},
+		CatchPolicies: map[string]*CatchConfig{
+			"payment.declined": {Next: "refund", Store: "failure"},
+		},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("charge", func(ctx Context, params map[string]any) (any, error) {
+		return nil, NewWorkflowError("payment.declined", "card declined")
+	}))
+	reg.MustRegister(ActivityFunc("refund", func(ctx Context, params map[string]any) (any, error) {
+		return true, nil
+	}))
+
+	exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+
+	branch := exec.state.GetBranchStates()["main"]
+	require.Equal(t, true, branch.Variables["refunded"])
+	failure, ok := branch.Variables["failure"].(ErrorOutput)
+	require.True(t, ok)
+	require.Equal(t, "payment.declined", failure.Error)
+}
+
+func TestOnErrorRoutesUnhandledFailure(t *testing.T) {
+	var mu sync.Mutex
+	var reports []ErrorOutput
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("ok", func(ctx Context, params map[string]any) (any, error) {
+		return "ok", nil
+	}))
+	reg.MustRegister(ActivityFunc("broken", func(ctx Context, params map[string]any) (any, error) {
+		return nil, NewWorkflowError("upstream", "service unavailable")
+	}))
+	reg.MustRegister(ActivityFunc("report", func(ctx Context, params map[string]any) (any, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, params["error"].(ErrorOutput))
+		if params["step"] == "fragile" {
+			return nil, errors.New("report failed")
+		}
+		return nil, nil
+	}))
+
+	newWorkflow := func(steps ...*Step) *Workflow {
+		steps = append(steps, &Step{
+			Name:     "report",
+			Activity: "report",
+			Parameters: map[string]any{
+				"error": "${state.error}",
+				"step":  "${state.error_step}",
+			},
+		})
+		wf, err := New(Options{Name: "on-error", Steps: steps, OnError: "report"})
+		require.NoError(t, err)
+		return wf
+	}
+	run := func(wf *Workflow) (*Execution, *ExecutionResult) {
+		reports = nil
+		exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		return exec, result
+	}
+
+	t.Run("unhandled failure routes to the handler", func(t *testing.T) {
+		exec, result := run(newWorkflow(
+			&Step{Name: "start", Activity: "ok", Next: []*Edge{
+				{Step: "fetch", BranchName: "fetch"},
+				{Step: "side", BranchName: "side"},
+			}},
+			&Step{Name: "fetch", Activity: "broken", Next: []*Edge{{Step: "unreached"}}},
+			&Step{Name: "side", Activity: "ok", Store: "side"},
+			&Step{Name: "unreached", Activity: "ok", Store: "unreached"},
+		))
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		require.Len(t, reports, 1)
+		require.Equal(t, "upstream", reports[0].Error)
+		require.Equal(t, "service unavailable", reports[0].Cause)
+
+		branches := exec.state.GetBranchStates()
+		require.Equal(t, "fetch", branches["fetch"].Variables[ErrorStepVariable])
+		_, reached := branches["fetch"].Variables["unreached"]
+		require.False(t, reached)
+		require.Equal(t, "ok", branches["side"].Variables["side"])
+	})
+
+	t.Run("step catch takes precedence", func(t *testing.T) {
+		_, result := run(newWorkflow(
+			&Step{Name: "fetch", Activity: "broken", Catch: []*CatchConfig{
+				{ErrorEquals: []string{ErrorTypeAll}, Next: "recover"},
+			}},
+			&Step{Name: "recover", Activity: "ok"},
+		))
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		require.Empty(t, reports)
+	})
+
+	t.Run("failure in the handler fails the path", func(t *testing.T) {
+		_, result := run(newWorkflow(&Step{Name: "fragile", Activity: "broken"}))
+		require.Equal(t, ExecutionStatusFailed, result.Status)
+		require.Len(t, reports, 1)
+		require.Contains(t, result.Error.Error(), "report failed")
+	})
+
+	t.Run("fatal errors are not routed", func(t *testing.T) {
+		reg.MustRegister(ActivityFunc("fatal", func(ctx Context, params map[string]any) (any, error) {
+			return nil, NewWorkflowError(ErrorTypeFatal, "corrupt input")
+		}))
+		_, result := run(newWorkflow(&Step{Name: "parse", Activity: "fatal"}))
+		require.Equal(t, ExecutionStatusFailed, result.Status)
+		require.Empty(t, reports)
+	})
+
+	t.Run("unknown step", func(t *testing.T) {
+		_, err := New(Options{Name: "bad", Steps: []*Step{{Name: "a", Activity: "ok"}}, OnError: "missing"})
+		require.ErrorIs(t, err, ErrUnknownCatchTarget)
+	})
+}
+
+func TestErrorPolicyValidation(t *testing.T) {
+	_, err := New(Options{
+		Name:  "bad-policies",
+		Steps: []*Step{{Name: "a", Activity: "a"}},
+		ErrorPolicies: map[string]*RetryConfig{
+			"http.429": {MaxRetries: -1},
+		},
+		CatchPolicies: map[string]*CatchConfig{
+			"all": {Next: "missing"},
+		},
+	})
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrInvalidRetryConfig))
+	require.True(t, errors.Is(err, ErrUnknownCatchTarget))
+}
+
+func TestFlattenRetryPoliciesOrdersWildcardLast(t *testing.T) {
+	configs := flattenRetryPolicies(map[string]*RetryConfig{
+		ErrorTypeAll:            {MaxRetries: 1},
+		ErrorTypeActivityFailed: {MaxRetries: 4},
+		"b":                     {MaxRetries: 2},
+		"a":                     {MaxRetries: 3},
+	})
+	require.Len(t, configs, 4)
+	require.Equal(t, []string{"a"}, configs[0].ErrorEquals)
+	require.Equal(t, []string{"b"}, configs[1].ErrorEquals)
+	require.Equal(t, []string{ErrorTypeActivityFailed}, configs[2].ErrorEquals)
+	require.Equal(t, []string{ErrorTypeAll}, configs[3].ErrorEquals)
+}
@@ -10,6 +10,35 @@ import (
 // checkpoint for the given execution ID. Use errors.Is to check for it.
 var ErrNoCheckpoint = errors.New("workflow: no checkpoint found")
 
+// ErrWorkflowChanged is returned when resuming from a checkpoint that was
+// written by a workflow definition with a different Fingerprint.
+var ErrWorkflowChanged = errors.New("workflow: definition changed since checkpoint")
+
+// ErrChildWorkflowCanceled is returned by ChildWorkflowExecutor.GetResult
+// for an async child execution that was stopped with Cancel.
+var ErrChildWorkflowCanceled = errors.New("workflow: child workflow canceled")
+
+// ErrActivityBudgetExceeded is returned when an execution tries to start
+// more activities than WithMaxActivityInvocations allows. Like
+// ErrFenceViolation it bypasses retry and catch handlers, so the
+// execution fails.
+var ErrActivityBudgetExceeded = errors.New("workflow: activity invocation budget exceeded")
+
+// ErrStepBudgetExceeded is wrapped by the error that fails an execution
+// trying to run more steps than WithMaxSteps allows. It bypasses catch
+// handlers and OnError, so the execution fails.
+var ErrStepBudgetExceeded = errors.New("workflow: step budget exceeded")
+
+// ErrStepOutputsPruned is returned by NewExecution when
+// WithMaxStepOutputs sets a limit for a workflow whose expressions read
+// the steps global. Pruning would remove results those expressions
+// read, so they could fail or take a different path.
+var ErrStepOutputsPruned = errors.New("workflow: step outputs pruned while expressions read steps")
+
+// ErrRequiredOutputMissing fails an execution whose paths all completed
+// without producing a non-nil value for an Output marked Required.
+var ErrRequiredOutputMissing = errors.New("workflow: required output missing")
+
 // ErrAlreadyStarted is returned when Run/Execute is called on an Execution
 // that has already been started.
 var ErrAlreadyStarted = errors.New("workflow: execution already started")
@@ -24,6 +53,16 @@ var ErrInvalidHeartbeatInterval = errors.New("workflow: heartbeat interval must
 // ErrNilHeartbeatFunc is returned when a HeartbeatConfig has a nil Func.
 var ErrNilHeartbeatFunc = errors.New("workflow: heartbeat func must not be nil")
 
+// ErrNoSignalStore is returned by Execution.Signal when the execution
+// was created without WithSignalStore.
+var ErrNoSignalStore = errors.New("workflow: no signal store configured")
+
+// ErrReplayMismatch is returned when a replayed execution calls an
+// activity that the Recording has no entry for, or a different activity
+// than the one recorded. The workflow or its inputs no longer match the
+// recorded run.
+var ErrReplayMismatch = errors.New("workflow: replay does not match recording")
+
 // Structural validation sentinels. All are reported as ValidationProblem
 // fields on *ValidationError when workflow.New runs.
 var (
@@ -37,6 +76,9 @@ var (
 	// ErrUnknownEdgeTarget is reported when an edge points at a step
 	// that does not exist in the workflow.
 	ErrUnknownEdgeTarget = errors.New("workflow: edge destination not found")
+	// ErrInvalidEdge is reported when an edge combines fields that
+	// cannot be used together, such as Else with a Condition.
+	ErrInvalidEdge = errors.New("workflow: invalid edge")
 	// ErrUnknownCatchTarget is reported when a catch handler points at
 	// a step that does not exist in the workflow.
 	ErrUnknownCatchTarget = errors.New("workflow: catch destination not found")
@@ -44,7 +86,7 @@ var (
 	// a branch that no upstream edge declares.
 	ErrUnknownJoinBranch = errors.New("workflow: join branch not found")
 	// ErrInvalidStepKind is reported when a step mixes multiple step
-	// kinds (activity/join/wait_signal/sleep/pause).
+	// kinds (activity/join/wait_signal/sleep/pause/terminate).
 	ErrInvalidStepKind = errors.New("workflow: conflicting step kinds")
 	// ErrInvalidModifier is reported when a modifier field (Retry,
 	// Catch) is attached to a step kind that cannot use it.
@@ -52,9 +94,29 @@ var (
 	// ErrInvalidRetryConfig is reported when a RetryConfig has
 	// nonsensical bounds (negative retries, MaxDelay < BaseDelay, etc.).
 	ErrInvalidRetryConfig = errors.New("workflow: invalid retry config")
+	// ErrInvalidJoinConfig is reported when a JoinConfig has an unknown
+	// Mode, or a Mode whose required Branches or Count is missing.
+	ErrInvalidJoinConfig = errors.New("workflow: invalid join config")
+	// ErrInvalidEachConfig is reported when an Each block has a
+	// negative MaxConcurrency.
+	ErrInvalidEachConfig = errors.New("workflow: invalid each config")
+	// ErrInvalidMapReduceConfig is reported when a MapReduceConfig is
+	// missing its items, activity, or reduce expression, has a negative
+	// MaxConcurrency, or has no Store or StoreAppend to receive the
+	// reduced value.
+	ErrInvalidMapReduceConfig = errors.New("workflow: invalid map_reduce config")
+	// ErrInvalidInputConfig is reported when an Input's Pattern is not
+	// a valid regular expression.
+	ErrInvalidInputConfig = errors.New("workflow: invalid input config")
+	// ErrInvalidOutputConfig is reported when an Output declares
+	// conflicting fields, such as Required with a Default.
+	ErrInvalidOutputConfig = errors.New("workflow: invalid output config")
 	// ErrInvalidSleepConfig is reported when a SleepConfig has a
 	// non-positive Duration.
 	ErrInvalidSleepConfig = errors.New("workflow: invalid sleep config")
+	// ErrInvalidTerminateConfig is reported when a TerminateConfig has
+	// a Status other than completed or failed.
+	ErrInvalidTerminateConfig = errors.New("workflow: invalid terminate config")
 	// ErrInvalidWaitConfig is reported when a WaitSignalConfig has a
 	// missing topic, non-positive timeout, or dangling OnTimeout.
 	ErrInvalidWaitConfig = errors.New("workflow: invalid wait_signal config")
@@ -68,6 +130,11 @@ var (
 	// name that is not registered on the ActivityRegistry passed to
 	// NewExecution. Surfaced as a ValidationProblem on *ValidationError.
 	ErrUnknownActivity = errors.New("workflow: activity not registered")
+	// ErrInvalidParameter is reported when a step's parameters do not
+	// match the ParamSchema of an ActivityWithParamSchema: a required
+	// parameter is missing, a parameter is unknown, or a literal value
+	// has the wrong type.
+	ErrInvalidParameter = errors.New("workflow: invalid activity parameter")
 	// ErrInvalidTemplate is reported when a parameter template or
 	// WaitSignalConfig.Topic template fails to parse or compile.
 	ErrInvalidTemplate = errors.New("workflow: invalid template")
@@ -102,6 +169,12 @@ const (
 	// ErrWaitTimeout for it to be classified here.
 	ErrorTypeTimeout = "timeout"
 
+	// ErrorTypePanic is the type of the error an activity call fails
+	// with when the activity panics. The panic fails only the calling
+	// path; the error's Details hold the stack trace, and retry and
+	// catch handlers match it like any other failure.
+	ErrorTypePanic = "panic"
+
 	// ErrorTypeFatal indicates an execution failed due to a fatal error.
 	// The approach we're taking is that by default, unknown errors are
 	// classified as activity failed errors. This is because we want to
@@ -196,10 +269,38 @@ func ClassifyError(err error) *WorkflowError {
 	}
 }
 
+// ErrorClassifier maps an activity error to an error type, such as
+// ErrorTypeTimeout or a custom type named in a retry or catch config.
+// It returns "" to leave the error to the default classification.
+// Install one with WithErrorClassifier.
+type ErrorClassifier func(err error) string
+
+// classifyActivityError applies classify to an activity error that is
+// not already a WorkflowError. Engine sentinels that must stay
+// unmatchable are left alone.
+func classifyActivityError(err error, classify ErrorClassifier) error {
+	var workflowError *WorkflowError
+	if errors.As(err, &workflowError) ||
+		errors.Is(err, ErrFenceViolation) || errors.Is(err, ErrActivityBudgetExceeded) {
+		return err
+	}
+	errorType := classify(err)
+	if errorType == "" {
+		return err
+	}
+	return &WorkflowError{
+		Type:    errorType,
+		Cause:   err.Error(),
+		Wrapped: err,
+	}
+}
+
 // MatchesErrorType checks if an error matches a specified error type pattern
 func MatchesErrorType(err error, errorType string) bool {
-	// Fence violations are never retryable or catchable
-	if errors.Is(err, ErrFenceViolation) {
+	// Fence violations and exhausted budgets are never retryable or
+	// catchable
+	if errors.Is(err, ErrFenceViolation) || errors.Is(err, ErrActivityBudgetExceeded) ||
+		errors.Is(err, ErrStepBudgetExceeded) {
 		return false
 	}
 	// Wait-unwinds are not failures — they are suspensions — and must
@@ -3,7 +3,9 @@ package workflow
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/deepnoodle-ai/workflow/internal/require"
 )
@@ -71,3 +73,71 @@ func TestErrorMatching(t *testing.T) {
 	require.True(t, MatchesErrorType(taskErr, ErrorTypeActivityFailed))
 	require.False(t, MatchesErrorType(timeoutErr, ErrorTypeActivityFailed))
 }
+
+type rateLimitError struct{}
+
+func (rateLimitError) Error() string { return "rate limited" }
+
+func TestErrorClassifier(t *testing.T) {
+	wf, err := New(Options{
+		Name: "error-classifier",
+		Steps: []*Step{
+			{
+				Name:     "fetch",
+				Activity: "fetch",
+				Store:    "body",
+				Retry: []*RetryConfig{
+					{ErrorEquals: []string{"rate_limited"}, MaxRetries: 3, BaseDelay: time.Millisecond},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	classifier := func(err error) string {
+		var rl rateLimitError
+		if errors.As(err, &rl) {
+			return "rate_limited"
+		}
+		return ""
+	}
+
+	calls := 0
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("fetch", func(ctx Context, params map[string]any) (any, error) {
+		calls++
+		if calls < 3 {
+			return nil, fmt.Errorf("fetch: %w", rateLimitError{})
+		}
+		return "ok", nil
+	}))
+
+	exec, err := NewExecution(wf, reg, WithErrorClassifier(classifier))
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.Equal(t, 3, calls)
+
+	t.Run("unclassified errors keep the default type", func(t *testing.T) {
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("fetch", func(ctx Context, params map[string]any) (any, error) {
+			return nil, errors.New("boom")
+		}))
+		exec, err := NewExecution(wf, reg, WithErrorClassifier(classifier))
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusFailed, result.Status)
+		require.Equal(t, ErrorTypeActivityFailed, result.Error.Type)
+	})
+
+	t.Run("workflow errors are not reclassified", func(t *testing.T) {
+		err := classifyActivityError(NewWorkflowError("custom", "x"), func(error) string { return "other" })
+		require.Equal(t, "custom", ClassifyError(err).Type)
+
+		err = classifyActivityError(rateLimitError{}, classifier)
+		require.Equal(t, "rate_limited", ClassifyError(err).Type)
+		require.True(t, errors.As(err, new(rateLimitError)))
+	})
+}
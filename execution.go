@@ -8,6 +8,11 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -54,6 +59,12 @@ const (
 	ExecutionStatusPaused    ExecutionStatus = "paused"
 	ExecutionStatusCompleted ExecutionStatus = "completed"
 	ExecutionStatusFailed    ExecutionStatus = "failed"
+	// ExecutionStatusCanceled is for branches the engine abandoned
+	// because their result is no longer needed, such as the losers of
+	// an "any" or "count" join. A canceled branch is terminal and does
+	// not fail the execution. Async child workflows stopped with
+	// ChildWorkflowExecutor.Cancel also report this status.
+	ExecutionStatusCanceled ExecutionStatus = "canceled"
 )
 
 // ExecutionOption is a functional option for NewExecution.
@@ -63,14 +74,32 @@ type ExecutionOption func(*executionConfig)
 // implementation detail; consumers compose it through With* options.
 type executionConfig struct {
 	inputs             map[string]any
+	labels             map[string]string
 	activityLogger     ActivityLogger
 	checkpointer       Checkpointer
 	logger             *slog.Logger
 	executionID        string
 	scriptCompiler     script.Compiler
+	templateDelims     script.Delimiters
 	executionCallbacks ExecutionCallbacks
 	stepProgressStore  StepProgressStore
 	signalStore        SignalStore
+	dryRun             bool
+	activityResolver   ActivityResolver
+	maxParallel        int
+	maxInvocations     int
+	maxSteps           int
+	stepCache          Cache
+	maxStepOutputs     int
+	autoParallelSteps  bool
+	recorder           *Recorder
+	replay             *Recording
+	errorClassifier    ErrorClassifier
+	paramMiddleware    ParameterMiddleware
+	redactedParams     []string
+	activityTimeout    time.Duration
+	variableHistory    bool
+	checkpointVarLogs  bool
 }
 
 // WithInputs sets the workflow input values for this execution. Values
@@ -80,6 +109,16 @@ func WithInputs(m map[string]any) ExecutionOption {
 	return func(c *executionConfig) { c.inputs = m }
 }
 
+// WithLabels attaches key/value labels to the execution, such as a
+// tenant ID or what triggered it. Labels do not affect how the workflow
+// runs; they are saved in checkpoints, passed to workflow callbacks in
+// WorkflowExecutionEvent, and reported in ExecutionSummary so
+// executions can be filtered. A resumed execution keeps the labels of
+// its checkpoint, with any given here added or replacing them.
+func WithLabels(labels map[string]string) ExecutionOption {
+	return func(c *executionConfig) { c.labels = labels }
+}
+
 // WithCheckpointer configures where checkpoint snapshots are saved.
 // Defaults to a null checkpointer that discards everything.
 func WithCheckpointer(cp Checkpointer) ExecutionOption {
@@ -130,6 +169,203 @@ func WithScriptCompiler(sc script.Compiler) ExecutionOption {
 	return func(c *executionConfig) { c.scriptCompiler = sc }
 }
 
+// WithTemplateDelimiters changes the delimiters that mark expressions
+// in parameter and WaitSignal topic templates from the default "${"
+// and "}". Use it when parameter values contain literal "${...}" text,
+// such as shell scripts. With the default delimiters, "$${" is the
+// escape for a literal "${"; with custom delimiters the escape is "$"
+// followed by left. NewExecution rejects empty delimiters.
+func WithTemplateDelimiters(left, right string) ExecutionOption {
+	return func(c *executionConfig) {
+		c.templateDelims = script.Delimiters{Left: left, Right: right}
+	}
+}
+
+// WithActivityResolver installs a resolver that NewExecution consults
+// for activities referenced by the workflow but absent from the
+// registry. Resolved activities are bound to this execution only; the
+// registry passed to NewExecution is left untouched.
+func WithActivityResolver(r ActivityResolver) ExecutionOption {
+	return func(c *executionConfig) { c.activityResolver = r }
+}
+
+// WithDryRun marks the execution as a dry run. Activities can check
+// IsDryRun and, when it reports true, log the action they would have
+// taken and return a simulated result instead of causing side effects.
+// The built-in http, file, and shell activities honor it; read-only
+// operations run normally.
+func WithDryRun(dryRun bool) ExecutionOption {
+	return func(c *executionConfig) { c.dryRun = dryRun }
+}
+
+// WithMaxParallelBranches caps how many branches run at once. When a
+// fan-out creates more branches than the limit, the rest are queued and
+// started as running branches complete. A branch parked at a join gives
+// up its slot while it waits, so joins cannot starve the branches they
+// wait for. Queued branches are reported as running. Zero, the default,
+// means no limit.
+func WithMaxParallelBranches(n int) ExecutionOption {
+	return func(c *executionConfig) { c.maxParallel = n }
+}
+
+// WithMaxActivityInvocations caps the total number of activity calls an
+// execution may make, across all branches, retries, and resumes. The
+// call that would exceed the cap fails with ErrActivityBudgetExceeded,
+// which retry and catch handlers do not intercept, so the execution
+// fails. Use it as a blast-radius limit for untrusted or buggy
+// workflows. Zero, the default, means no limit.
+func WithMaxActivityInvocations(n int) ExecutionOption {
+	return func(c *executionConfig) { c.maxInvocations = n }
+}
+
+// WithMaxSteps caps the total number of steps an execution may run,
+// across all branches and resumes. Every step visit counts, including
+// skipped steps and each pass through a loop, so a loop whose exit
+// condition never becomes true fails instead of running forever. The
+// step that would exceed the cap fails with ErrStepBudgetExceeded,
+// which catch handlers and OnError do not intercept. Zero, the
+// default, means no limit.
+func WithMaxSteps(n int) ExecutionOption {
+	return func(c *executionConfig) { c.maxSteps = n }
+}
+
+// WithStepCache shares the results of Cacheable steps across
+// executions through cache. A Cacheable step whose activity and
+// resolved parameters match a stored entry skips the activity and
+// stores the cached result instead. Dry runs and replays neither read
+// nor write the cache. Without it, Cacheable has no effect.
+func WithStepCache(cache Cache) ExecutionOption {
+	return func(c *executionConfig) { c.stepCache = cache }
+}
+
+// WithMaxStepOutputs bounds the step outputs each branch retains to the
+// n most recent steps. Older outputs are dropped from BranchState and
+// from checkpoints, which otherwise grow with every step a long branch
+// runs. Pruning also removes the results the steps global exposes to
+// expressions, so NewExecution rejects a limit with
+// ErrStepOutputsPruned when any parameter template, condition, or
+// script of the workflow reads steps. Zero, the default, means no
+// limit.
+func WithMaxStepOutputs(n int) ExecutionOption {
+	return func(c *executionConfig) { c.maxStepOutputs = n }
+}
+
+// WithAutoParallelSteps enables an optimizer that runs consecutive
+// steps concurrently when their definitions show no dependency between
+// them. A run of plain activity steps joined by single unconditional
+// edges is started together as long as no step's Store variable is
+// read (in parameter templates or StoreExpression) or stored by
+// another step of the run. Results are still stored, checkpointed, and
+// reported in workflow order.
+//
+// Every step of a run calls its activity before the first step's
+// result is known. If an earlier step fails, the execution reports
+// that step's error and later steps' results are never stored, but
+// their activities have already run and their side effects have
+// happened, which sequential execution would not do. Results of later
+// steps are held in memory until their turn, not checkpointed, so an
+// execution resumed after a crash runs those activities again.
+//
+// The analysis only sees the workflow definition. It is opt-in
+// because activities that exchange data through Context.Set and
+// Context.Get, or whose side effects must happen in order, are not
+// safe to reorder. Steps with Each, Skip, Catch, or a wait, sleep,
+// pause, or join are never parallelized, and neither is any step of a
+// workflow with catch fallbacks.
+func WithAutoParallelSteps(enabled bool) ExecutionOption {
+	return func(c *executionConfig) { c.autoParallelSteps = enabled }
+}
+
+// WithDefaultActivityTimeout bounds every activity call with a context
+// deadline of d, as a safety net against a hung activity holding up
+// the execution. An activity that fails once the deadline has passed
+// fails with ErrorTypeTimeout, so retry and catch handlers can match it.
+// A RetryConfig.Timeout takes precedence for the attempts it covers.
+// Activities must watch ctx.Done for the deadline to interrupt them.
+// Zero, the default, means no limit.
+func WithDefaultActivityTimeout(d time.Duration) ExecutionOption {
+	return func(c *executionConfig) { c.activityTimeout = d }
+}
+
+// WithErrorClassifier installs fn to classify errors returned by
+// activities that are not already a *WorkflowError. A non-empty type
+// from fn wraps the error in a WorkflowError of that type, which retry
+// and catch handlers then match against; an empty type leaves the error
+// to the default classification of ClassifyError.
+func WithErrorClassifier(fn ErrorClassifier) ExecutionOption {
+	return func(c *executionConfig) { c.errorClassifier = fn }
+}
+
+// ParameterMiddleware rewrites the parameters of an activity call. It
+// receives the parameters after template evaluation and returns the
+// parameters the activity is called with; returning nil keeps them.
+type ParameterMiddleware func(ctx Context, step string, activity string, params map[string]any) map[string]any
+
+// WithParameterMiddleware installs fn to run before every activity call
+// of the execution, for example to inject an auth token or correlation
+// ID without listing it in each step. The parameters fn returns are the
+// ones passed to the activity, recorded, and written to the activity
+// log.
+func WithParameterMiddleware(fn ParameterMiddleware) ExecutionOption {
+	return func(c *executionConfig) { c.paramMiddleware = fn }
+}
+
+// WithRedactedParameters replaces the values of the named activity
+// parameters with RedactedValue in ActivityExecutionEvent.Parameters and
+// ActivityLogEntry.Parameters, so secrets such as tokens do not reach
+// callbacks or the activity logger. The activity still receives the
+// real values. A name applies to the parameter of that name in every
+// activity call; a dotted name such as "headers.Authorization" redacts
+// a key of a nested map. Repeated calls add to the names.
+func WithRedactedParameters(names ...string) ExecutionOption {
+	return func(c *executionConfig) { c.redactedParams = append(c.redactedParams, names...) }
+}
+
+// WithRecorder records every activity invocation of the execution into
+// r: the parameters, the result, and any error. Pass the Recording to
+// WithReplay to re-run the execution without calling activities.
+func WithRecorder(r *Recorder) ExecutionOption {
+	return func(c *executionConfig) { c.recorder = r }
+}
+
+// WithReplay replays a recorded execution for debugging. Activities
+// are not called; each activity step receives the result or error
+// recorded for it, matched by branch, step, and call order, so the
+// execution takes the same path and produces the same outputs as the
+// recorded run. Activities missing from the registry are stubbed, so a
+// recording can be replayed without the services it originally talked
+// to. The recorded inputs are used unless WithInputs is also given. A
+// call with no matching recording fails the execution with
+// ErrReplayMismatch.
+//
+// Sleep steps and signal waits are not part of the recording and run
+// normally. Each steps with concurrency replay in recorded order only
+// when items map to the same call order, so record and replay them
+// sequentially.
+func WithReplay(rec *Recording) ExecutionOption {
+	return func(c *executionConfig) { c.replay = rec }
+}
+
+// WithVariableHistory records a change log per branch of every change
+// made to its variables — by Store, Context.Set and Context.Delete,
+// scripts, stored signals, and caught errors — with the step that made
+// it. Read it with Execution.VariableHistory. Use it to debug how a
+// variable got its value; the log grows with every change, so it is
+// off by default.
+func WithVariableHistory(enabled bool) ExecutionOption {
+	return func(c *executionConfig) { c.variableHistory = enabled }
+}
+
+// WithVariableHistoryInCheckpoints records the change logs of
+// WithVariableHistory and also saves them in every checkpoint, so they
+// survive a resume and can be inspected from a stored checkpoint.
+func WithVariableHistoryInCheckpoints(enabled bool) ExecutionOption {
+	return func(c *executionConfig) {
+		c.variableHistory = enabled
+		c.checkpointVarLogs = enabled
+	}
+}
+
 // ExecuteOption configures a single call to Execution.Execute.
 type ExecuteOption func(*executeConfig)
 
@@ -173,12 +409,26 @@ type Execution struct {
 	executionCallbacks ExecutionCallbacks
 	signalStore        SignalStore
 	adapter            *executionAdapter
+	dryRun             bool
+	deadline           time.Time // of the context passed to run
+	maxInvocations     int
+	maxSteps           int
+	stepCache          Cache
+	recorder           *Recorder
+	replayer           *replayer
+	errorClassifier    ErrorClassifier
+	paramMiddleware    ParameterMiddleware
+	redactedParams     []string
 
 	logger *slog.Logger
 
 	// Step progress tracking
 	stepProgressTracker *stepProgressTracker
 
+	// Variable change logs; nil unless WithVariableHistory is set
+	variableHistory   *variableHistory
+	checkpointVarLogs bool
+
 	// Single mutex for orchestration data
 	mutex             sync.RWMutex
 	doneWg            sync.WaitGroup
@@ -212,6 +462,11 @@ func NewExecution(wf *Workflow, reg *ActivityRegistry, opts ...ExecutionOption)
 	if cfg.scriptCompiler == nil {
 		cfg.scriptCompiler = DefaultScriptCompiler()
 	}
+	if cfg.templateDelims == (script.Delimiters{}) {
+		cfg.templateDelims = script.DefaultDelimiters
+	} else if err := cfg.templateDelims.Validate(); err != nil {
+		return nil, fmt.Errorf("workflow: %w", err)
+	}
 	if cfg.logger == nil {
 		cfg.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
 	}
@@ -228,33 +483,67 @@ func NewExecution(wf *Workflow, reg *ActivityRegistry, opts ...ExecutionOption)
 		cfg.executionCallbacks = &BaseExecutionCallbacks{}
 	}
 
+	// Fill in activities the registry lacks from the resolver, if any,
+	// before binding validation checks every reference.
+	reg = reg.withResolved(wf, cfg.activityResolver)
+	if cfg.replay != nil {
+		reg = reg.withResolved(wf, ActivityResolverFunc(func(name string) (Activity, bool) {
+			return replayActivity{name: name}, true
+		}))
+		if cfg.inputs == nil {
+			cfg.inputs = cfg.replay.Inputs
+		}
+	}
+
+	if cfg.maxStepOutputs > 0 {
+		if step := wf.stepReadingStepResults(); step != "" {
+			return nil, fmt.Errorf("%w: WithMaxStepOutputs(%d) would prune results that step %q reads",
+				ErrStepOutputsPruned, cfg.maxStepOutputs, step)
+		}
+	}
+
 	// Binding-level validation: activity references, templates,
 	// expressions, and store-path shape. Runs after defaults are
 	// applied so the compiler and logger are always present.
-	if err := wf.validateBinding(reg, cfg.scriptCompiler, cfg.signalStore != nil, cfg.logger); err != nil {
+	if err := wf.validateBinding(reg, cfg.scriptCompiler, cfg.templateDelims, cfg.signalStore != nil, cfg.logger); err != nil {
 		return nil, err
 	}
 
-	// Determine input values from inputs map or defaults.
+	// Determine input values from the inputs map, then the environment
+	// for inputs with FromEnv, then defaults.
 	inputs := make(map[string]any, len(cfg.inputs))
 	for _, input := range wf.Inputs() {
 		if v, ok := cfg.inputs[input.Name]; ok {
 			inputs[input.Name] = v
-		} else {
-			if input.Default == nil {
-				return nil, fmt.Errorf("input %q is required", input.Name)
-			}
-			inputs[input.Name] = input.Default
+			continue
+		}
+		v, ok, err := envInputValue(input)
+		if err != nil {
+			return nil, err
 		}
+		if ok {
+			inputs[input.Name] = v
+			continue
+		}
+		if input.Default == nil {
+			return nil, fmt.Errorf("input %q is required", input.Name)
+		}
+		inputs[input.Name] = input.Default
 	}
 	for k := range cfg.inputs {
 		if _, ok := inputs[k]; !ok {
 			return nil, fmt.Errorf("unknown input %q", k)
 		}
 	}
+	inputs, err := coerceInputs(wf.Inputs(), inputs)
+	if err != nil {
+		return nil, err
+	}
 
 	activities := reg.asMap()
 	state := newExecutionState(cfg.executionID, wf.Name(), inputs)
+	state.sensitive = sensitiveInputs(wf.Inputs())
+	state.labels = maps.Clone(cfg.labels)
 
 	execution := &Execution{
 		workflow:           wf,
@@ -268,8 +557,22 @@ func NewExecution(wf *Workflow, reg *ActivityRegistry, opts ...ExecutionOption)
 		compiler:           cfg.scriptCompiler,
 		executionCallbacks: cfg.executionCallbacks,
 		signalStore:        cfg.signalStore,
+		dryRun:             cfg.dryRun,
+		maxInvocations:     cfg.maxInvocations,
+		maxSteps:           cfg.maxSteps,
+		stepCache:          cfg.stepCache,
+		recorder:           cfg.recorder,
+		errorClassifier:    cfg.errorClassifier,
+		paramMiddleware:    cfg.paramMiddleware,
+		redactedParams:     cfg.redactedParams,
 	}
 	execution.adapter = &executionAdapter{execution: execution}
+	if cfg.replay != nil {
+		execution.replayer = newReplayer(cfg.replay)
+	}
+	if cfg.recorder != nil {
+		cfg.recorder.start(wf.Name(), cfg.executionID, inputs)
+	}
 
 	// Wire step progress tracker if a store is configured.
 	if cfg.stepProgressStore != nil {
@@ -279,19 +582,34 @@ func NewExecution(wf *Workflow, reg *ActivityRegistry, opts ...ExecutionOption)
 		execution.executionCallbacks = chain
 	}
 
+	// Record variable change logs through the callbacks as well.
+	if cfg.variableHistory {
+		execution.variableHistory = newVariableHistory()
+		execution.checkpointVarLogs = cfg.checkpointVarLogs
+		execution.executionCallbacks = NewCallbackChain(execution.executionCallbacks, execution.variableHistory)
+	}
+
 	// Set up branch options template. ExecutionID is populated per-call in
 	// createBranch* from e.state.ID() so that a resumed execution whose ID
 	// was restored from a checkpoint sees the right value.
 	execution.branchOptions = branchOptions{
-		Workflow:         wf,
-		ActivityRegistry: activities,
-		Logger:           cfg.logger,
-		Inputs:           copyMap(inputs),
-		Variables:        copyMap(wf.InitialState()),
-		activityExecutor: execution.adapter,
-		UpdatesChannel:   execution.branchSnapshots,
-		ScriptCompiler:   cfg.scriptCompiler,
-		SignalStore:      cfg.signalStore,
+		Workflow:           wf,
+		ActivityRegistry:   activities,
+		Logger:             cfg.logger,
+		Inputs:             copyMap(inputs),
+		Variables:          copyMap(wf.InitialState()),
+		activityExecutor:   execution.adapter,
+		UpdatesChannel:     execution.branchSnapshots,
+		ScriptCompiler:     cfg.scriptCompiler,
+		TemplateDelims:     cfg.templateDelims,
+		SignalStore:        cfg.signalStore,
+		ExecutionCallbacks: execution.executionCallbacks,
+		limiter:            newBranchLimiter(cfg.maxParallel),
+		MaxStepOutputs:     cfg.maxStepOutputs,
+		ActivityTimeout:    cfg.activityTimeout,
+	}
+	if cfg.autoParallelSteps {
+		execution.branchOptions.ParallelGroups = wf.parallelStepGroups()
 	}
 
 	return execution, nil
@@ -356,6 +674,20 @@ func (e *Execution) ID() string {
 	return e.state.ID()
 }
 
+// Signal delivers payload on topic to this execution through its
+// SignalStore. It is shorthand for calling Send on the store with the
+// execution's ID. Signals are addressed by topic rather than branch: a
+// WaitSignal step or ctx.Wait in any branch that waits on topic
+// consumes it. A suspended execution picks the signal up when it is
+// resumed from its checkpoint. Returns ErrNoSignalStore if the
+// execution was created without WithSignalStore.
+func (e *Execution) Signal(ctx context.Context, topic string, payload any) error {
+	if e.signalStore == nil {
+		return ErrNoSignalStore
+	}
+	return e.signalStore.Send(ctx, e.ID(), topic, payload)
+}
+
 // Status returns the current execution status
 func (e *Execution) Status() ExecutionStatus {
 	return e.state.GetStatus()
@@ -366,6 +698,34 @@ func (e *Execution) GetOutputs() map[string]any {
 	return e.state.GetOutputs()
 }
 
+// BranchStates returns a snapshot of every branch's state, keyed by
+// branch ID. The states are deep copies, so it is safe to call while
+// the execution is running, for example to poll progress from a
+// monitoring UI.
+func (e *Execution) BranchStates() map[string]*BranchState {
+	return e.state.GetBranchStates()
+}
+
+// ActiveBranchCount returns the number of branches currently running.
+// Branches that have finished, failed, or unwound on a durable wait are
+// not counted.
+func (e *Execution) ActiveBranchCount() int {
+	return e.activeBranchCount()
+}
+
+// CurrentSteps maps the ID of each running branch to the name of the
+// step it is executing. Branches that are waiting, paused, or finished
+// are omitted.
+func (e *Execution) CurrentSteps() map[string]string {
+	steps := map[string]string{}
+	for id, state := range e.state.GetBranchStates() {
+		if state.Status == ExecutionStatusRunning && state.CurrentStep != "" {
+			steps[id] = state.CurrentStep
+		}
+	}
+	return steps
+}
+
 // saveCheckpoint saves the current execution state. Safe to call
 // concurrently from the orchestrator goroutine and from activity
 // goroutines; calls are serialised via checkpointMu so writers cannot
@@ -377,30 +737,67 @@ func (e *Execution) saveCheckpoint(ctx context.Context) error {
 	checkpoint := e.state.ToCheckpoint()
 	checkpoint.ID = fmt.Sprintf("%d", e.checkpointCounter)
 	checkpoint.SchemaVersion = CheckpointSchemaVersion
+	checkpoint.WorkflowFingerprint = e.workflow.Fingerprint()
+	if e.checkpointVarLogs {
+		checkpoint.VariableHistory = e.variableHistory.snapshot()
+	}
 	return e.checkpointer.SaveCheckpoint(ctx, checkpoint)
 }
 
-// loadCheckpoint loads execution state from the latest checkpoint.
+// loadCheckpoint loads execution state from the latest checkpoint, or
+// from the checkpoint with the given ID when checkpointID is set.
 //
 // The checkpoint's execution ID is preserved as the execution's identity.
 // Callers that need to resume into a specific ID should pass it via
 // ExecutionOptions.ExecutionID when constructing the execution; that ID must
 // match the checkpoint's ID. Rotating the ID on resume would silently break
 // SignalStore lookups keyed on (executionID, topic).
-func (e *Execution) loadCheckpoint(ctx context.Context, priorExecutionID string) error {
+func (e *Execution) loadCheckpoint(ctx context.Context, priorExecutionID, checkpointID string) error {
 	// Load state from checkpoint
-	checkpoint, err := e.checkpointer.LoadCheckpoint(ctx, priorExecutionID)
+	var checkpoint *Checkpoint
+	var err error
+	if checkpointID == "" {
+		checkpoint, err = e.checkpointer.LoadCheckpoint(ctx, priorExecutionID)
+	} else if versioned, ok := e.checkpointer.(VersionedCheckpointer); ok {
+		checkpoint, err = versioned.LoadCheckpointByID(ctx, priorExecutionID, checkpointID)
+	} else {
+		return fmt.Errorf("checkpointer %T does not support loading checkpoints by ID", e.checkpointer)
+	}
 	if err != nil {
 		return fmt.Errorf("loading checkpoint: %w", err)
 	}
 	if checkpoint == nil {
+		if checkpointID != "" {
+			return fmt.Errorf("%w: execution %q checkpoint %q", ErrNoCheckpoint, priorExecutionID, checkpointID)
+		}
 		return fmt.Errorf("%w: execution %q", ErrNoCheckpoint, priorExecutionID)
 	}
 	if checkpoint.SchemaVersion < 1 || checkpoint.SchemaVersion > CheckpointSchemaVersion {
 		return fmt.Errorf("checkpoint schema version %d is not supported (supported: 1..%d)",
 			checkpoint.SchemaVersion, CheckpointSchemaVersion)
 	}
+	if fp := e.workflow.Fingerprint(); checkpoint.WorkflowFingerprint != "" && fp != "" && checkpoint.WorkflowFingerprint != fp {
+		return fmt.Errorf("%w: execution %q was checkpointed with definition %s, resuming with %s",
+			ErrWorkflowChanged, priorExecutionID, shortFingerprint(checkpoint.WorkflowFingerprint), shortFingerprint(fp))
+	}
 	e.state.FromCheckpoint(checkpoint)
+	if e.variableHistory != nil {
+		e.variableHistory.restore(checkpoint.VariableHistory)
+	}
+
+	// Continue numbering after the loaded checkpoint, so new checkpoints
+	// do not overwrite the ones that led up to it.
+	if n, err := strconv.Atoi(checkpoint.ID); err == nil {
+		e.checkpointMu.Lock()
+		e.checkpointCounter = n
+		e.checkpointMu.Unlock()
+	}
+
+	// Checkpointed inputs have been through JSON; restore the Go types
+	// NewExecution gave them.
+	if inputs, err := coerceInputs(e.workflow.Inputs(), e.state.GetInputs()); err == nil {
+		e.state.SetInputs(inputs)
+	}
 
 	// Preserve the checkpoint's execution ID so signals keyed on
 	// (executionID, topic) remain discoverable across resumes.
@@ -495,7 +892,7 @@ func (e *Execution) runExecution(ctx context.Context, priorExecutionID string) e
 	e.ran = false
 
 	if priorExecutionID != "" {
-		err := e.resumeFromCheckpoint(ctx, priorExecutionID)
+		err := e.resumeFromCheckpoint(ctx, priorExecutionID, "")
 		if err == nil {
 			return nil
 		}
@@ -511,14 +908,32 @@ func (e *Execution) runExecution(ctx context.Context, priorExecutionID string) e
 	return e.run(ctx)
 }
 
+// ResumeFromCheckpoint rewinds executionID to one of its earlier
+// checkpoints and runs it from there, instead of from the latest
+// checkpoint as ResumeFrom does. Use it to re-run an execution from a
+// known-good point, for example after fixing the data that made a
+// later step fail. IDs come from ListCheckpoints on the checkpointer,
+// which must implement VersionedCheckpointer.
+//
+// Unlike ResumeFrom, a missing checkpoint is an error wrapping
+// ErrNoCheckpoint rather than a fresh run. New checkpoints are numbered
+// on from checkpointID, replacing the later ones of the original run.
+// The result and error follow the same contract as Execute.
+func (e *Execution) ResumeFromCheckpoint(ctx context.Context, executionID, checkpointID string) (*ExecutionResult, error) {
+	e.ran = false
+	err := e.resumeFromCheckpoint(ctx, executionID, checkpointID)
+	return e.buildResult(err)
+}
+
 // resumeFromCheckpoint loads the prior checkpoint, marks the execution
 // as started, and runs it to completion. Returns ErrNoCheckpoint if no
-// checkpoint exists for priorExecutionID.
-func (e *Execution) resumeFromCheckpoint(ctx context.Context, priorExecutionID string) error {
+// checkpoint exists for priorExecutionID. An empty checkpointID loads
+// the latest checkpoint.
+func (e *Execution) resumeFromCheckpoint(ctx context.Context, priorExecutionID, checkpointID string) error {
 	// Load checkpoint FIRST, before marking as started.
 	// This way a failed load (e.g., no checkpoint) leaves the execution
 	// object clean for a subsequent fresh run.
-	if err := e.loadCheckpoint(ctx, priorExecutionID); err != nil {
+	if err := e.loadCheckpoint(ctx, priorExecutionID, checkpointID); err != nil {
 		return err
 	}
 
@@ -650,6 +1065,7 @@ func (e *Execution) buildSuspensionInfo() *SuspensionInfo {
 // run the workflow execution, blocking until completion or error
 func (e *Execution) run(ctx context.Context) error {
 	e.ran = true
+	e.deadline, _ = ctx.Deadline()
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -665,7 +1081,8 @@ func (e *Execution) run(ctx context.Context) error {
 		WorkflowName: e.workflow.Name(),
 		Status:       e.state.GetStatus(),
 		StartTime:    e.state.GetStartTime(),
-		Inputs:       copyMap(e.state.GetInputs()),
+		Inputs:       e.state.GetRedactedInputs(),
+		Labels:       e.state.GetLabels(),
 		PathCount:    e.activeBranchCount(),
 	})
 
@@ -758,7 +1175,7 @@ func (e *Execution) run(ctx context.Context) error {
 	default:
 		finalStatus = ExecutionStatusCompleted
 		// Extract workflow outputs from final branch variables
-		if err := e.extractWorkflowOutputs(); err != nil {
+		if err := e.extractWorkflowOutputs(ctx); err != nil {
 			e.logger.Error("failed to extract workflow outputs", "error", err)
 			finalErr = err
 			finalStatus = ExecutionStatusFailed
@@ -777,22 +1194,25 @@ func (e *Execution) run(ctx context.Context) error {
 		StartTime:    e.state.GetStartTime(),
 		EndTime:      endTime,
 		Duration:     duration,
-		Inputs:       e.state.GetInputs(),
+		Inputs:       e.state.GetRedactedInputs(),
 		Outputs:      e.state.GetOutputs(),
+		Labels:       e.state.GetLabels(),
 		PathCount:    len(e.state.GetBranchStates()),
 		Error:        finalErr,
 	})
 
-	// Final checkpoint
-	if checkpointErr := e.saveCheckpoint(ctx); checkpointErr != nil {
+	// Final checkpoint. A failed branch cancels ctx above, so save
+	// without its cancellation or the terminal status would be lost.
+	if checkpointErr := e.saveCheckpoint(context.WithoutCancel(ctx)); checkpointErr != nil {
 		e.logger.Error("failed to save final checkpoint", "error", checkpointErr)
 	}
 
 	return finalErr
 }
 
-// extractWorkflowOutputs extracts workflow outputs from final branch variables.
-func (e *Execution) extractWorkflowOutputs() error {
+// extractWorkflowOutputs extracts workflow outputs from final branch
+// variables, evaluating Output.Expression where set.
+func (e *Execution) extractWorkflowOutputs(ctx context.Context) error {
 	branchStates := e.state.GetBranchStates()
 	outputs := e.workflow.Outputs()
 
@@ -803,6 +1223,24 @@ func (e *Execution) extractWorkflowOutputs() error {
 			variableName = outputName
 		}
 
+		if outputDef.FromAllPaths {
+			values, err := collectCompletedBranchOutput(outputDef, variableName, branchStates)
+			if err != nil {
+				return err
+			}
+			e.state.SetOutput(outputName, values)
+			continue
+		}
+
+		if len(outputDef.Branches) > 0 {
+			values, err := collectBranchOutput(outputDef, variableName, branchStates)
+			if err != nil {
+				return err
+			}
+			e.state.SetOutput(outputName, values)
+			continue
+		}
+
 		targetBranch := outputDef.Branch
 		if targetBranch == "" {
 			targetBranch = "main"
@@ -810,11 +1248,37 @@ func (e *Execution) extractWorkflowOutputs() error {
 
 		branchState, found := branchStates[targetBranch]
 		if !found {
+			if outputDef.Required {
+				return fmt.Errorf("%w: output %q: branch %q not found", ErrRequiredOutputMissing, outputName, targetBranch)
+			}
 			return fmt.Errorf("output branch %q not found for output %q", targetBranch, outputName)
 		}
 
-		if value, exists := getNestedField(branchState.Variables, variableName); exists {
+		if outputDef.Expression != "" {
+			value, err := e.evaluateOutputExpression(ctx, outputDef.Expression, branchState.Variables)
+			if err != nil {
+				return fmt.Errorf("workflow output %q: %w", outputName, err)
+			}
+			if outputDef.Required && value == nil {
+				return fmt.Errorf("%w: output %q: expression %q produced nil", ErrRequiredOutputMissing, outputName, outputDef.Expression)
+			}
+			e.state.SetOutput(outputName, value)
+			continue
+		}
+
+		value, exists := getNestedField(branchState.Variables, variableName)
+		if outputDef.Required && (!exists || value == nil) {
+			reason := "not set"
+			if exists {
+				reason = "nil"
+			}
+			return fmt.Errorf("%w: output %q: variable %q is %s in branch %q",
+				ErrRequiredOutputMissing, outputName, variableName, reason, targetBranch)
+		}
+		if exists {
 			e.state.SetOutput(outputName, value)
+		} else if outputDef.Default != nil {
+			e.state.SetOutput(outputName, outputDef.Default)
 		} else {
 			return fmt.Errorf("workflow output variable %q not found in branch %q", variableName, targetBranch)
 		}
@@ -822,6 +1286,83 @@ func (e *Execution) extractWorkflowOutputs() error {
 	return nil
 }
 
+// collectBranchOutput gathers variableName from each of the output's
+// Branches, in order.
+func collectBranchOutput(outputDef *Output, variableName string, branchStates map[string]*BranchState) ([]any, error) {
+	values := make([]any, 0, len(outputDef.Branches))
+	for _, name := range outputDef.Branches {
+		var value any
+		var exists bool
+		branchState, found := branchStates[name]
+		if found {
+			value, exists = getNestedField(branchState.Variables, variableName)
+		}
+		if exists {
+			values = append(values, value)
+			continue
+		}
+		if outputDef.SkipMissing {
+			continue
+		}
+		if !found {
+			return nil, fmt.Errorf("output branch %q not found for output %q", name, outputDef.Name)
+		}
+		return nil, fmt.Errorf("workflow output variable %q not found in branch %q", variableName, name)
+	}
+	if outputDef.Required && len(values) == 0 {
+		return nil, fmt.Errorf("%w: output %q: variable %q is not set in any of branches %v",
+			ErrRequiredOutputMissing, outputDef.Name, variableName, outputDef.Branches)
+	}
+	return values, nil
+}
+
+// collectCompletedBranchOutput gathers variableName from every completed
+// branch that set it, ordered by branch start time and then ID, for an
+// output with FromAllPaths.
+func collectCompletedBranchOutput(outputDef *Output, variableName string, branchStates map[string]*BranchState) ([]any, error) {
+	var completed []*BranchState
+	for _, state := range branchStates {
+		if state.Status == ExecutionStatusCompleted {
+			completed = append(completed, state)
+		}
+	}
+	sort.Slice(completed, func(i, j int) bool {
+		if !completed[i].StartTime.Equal(completed[j].StartTime) {
+			return completed[i].StartTime.Before(completed[j].StartTime)
+		}
+		return completed[i].ID < completed[j].ID
+	})
+	values := []any{}
+	for _, state := range completed {
+		if value, exists := getNestedField(state.Variables, variableName); exists {
+			values = append(values, value)
+		}
+	}
+	if outputDef.Required && len(values) == 0 {
+		return nil, fmt.Errorf("%w: output %q: variable %q is not set in any completed branch",
+			ErrRequiredOutputMissing, outputDef.Name, variableName)
+	}
+	return values, nil
+}
+
+// evaluateOutputExpression evaluates an Output.Expression against a
+// branch's final variables and the execution inputs. Numbers in the
+// result are normalized like script globals.
+func (e *Execution) evaluateOutputExpression(ctx context.Context, expr string, variables map[string]any) (any, error) {
+	compiled, err := e.compiler.Compile(ctx, expr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile expression %q: %w", expr, err)
+	}
+	value, err := compiled.Evaluate(ctx, map[string]any{
+		"inputs": script.NormalizeMap(e.state.GetInputs()),
+		"state":  script.NormalizeMap(variables),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate expression %q: %w", expr, err)
+	}
+	return script.NormalizeValue(value.Value()), nil
+}
+
 // runBranches begins executing one or more new execution branches in goroutines.
 // It does not wait for the branches to complete.
 func (e *Execution) runBranches(ctx context.Context, branches ...*branch) {
@@ -831,30 +1372,38 @@ func (e *Execution) runBranches(ctx context.Context, branches ...*branch) {
 		startTime := time.Now()
 
 		// Preserve prior BranchState fields (step outputs, pending Wait,
-		// pause flag, activity history) when a resumed branch is being
-		// restarted. A freshly-created branch has no prior state, so
-		// this collapses to the initial set.
+		// pause flag, activity history, completed activity) when a
+		// resumed branch is being restarted. A freshly-created branch
+		// has no prior state, so this collapses to the initial set.
 		existing := e.state.GetBranchStates()[branchID]
 		var (
 			stepOutputs         map[string]any
+			stepOutputOrder     []string
 			pendingWait         *WaitState
 			priorStart          time.Time
 			pauseRequested      bool
 			pauseReason         string
 			activityHistory     map[string]any
 			activityHistoryStep string
+			eachProgress        *EachProgress
+			completedActivity   *CompletedActivity
 		)
 		if existing != nil {
 			stepOutputs = existing.StepOutputs
+			stepOutputOrder = existing.StepOutputOrder
 			pendingWait = existing.Wait
 			priorStart = existing.StartTime
 			pauseRequested = existing.PauseRequested
 			pauseReason = existing.PauseReason
 			activityHistory = existing.ActivityHistory
 			activityHistoryStep = existing.ActivityHistoryStep
+			eachProgress = existing.EachProgress
+			completedActivity = existing.CompletedActivity
 		}
 		if stepOutputs == nil {
-			stepOutputs = map[string]any{}
+			// A forked branch starts with its parent's outputs.
+			stepOutputs = copyMap(br.stepOutputs)
+			stepOutputOrder = slices.Clone(br.stepOutputOrder)
 		}
 		if priorStart.IsZero() {
 			priorStart = startTime
@@ -866,12 +1415,15 @@ func (e *Execution) runBranches(ctx context.Context, branches ...*branch) {
 			CurrentStep:         br.CurrentStep().Name,
 			StartTime:           priorStart,
 			StepOutputs:         stepOutputs,
+			StepOutputOrder:     stepOutputOrder,
 			Variables:           br.Variables(), // Store branch's current variables
 			Wait:                pendingWait,
 			PauseRequested:      pauseRequested,
 			PauseReason:         pauseReason,
 			ActivityHistory:     activityHistory,
 			ActivityHistoryStep: activityHistoryStep,
+			EachProgress:        eachProgress,
+			CompletedActivity:   completedActivity,
 		})
 
 		// Trigger branch start callback
@@ -885,15 +1437,28 @@ func (e *Execution) runBranches(ctx context.Context, branches ...*branch) {
 			StepOutputs:  map[string]any{},
 		})
 
+		branchCtx, cancel := context.WithCancel(ctx)
+		br.cancel = cancel
 		e.doneWg.Add(1)
 		go func(p *branch) {
 			defer e.doneWg.Done()
-			p.Run(ctx)
+			defer cancel()
+			if err := p.acquireSlot(branchCtx); err != nil {
+				return
+			}
+			defer p.releaseSlot()
+			p.Run(branchCtx)
 		}(br)
 	}
 }
 
 func (e *Execution) processBranchSnapshot(ctx context.Context, snapshot branchSnapshot) error {
+	// A canceled branch may still report the step it was interrupted in;
+	// its outcome no longer matters.
+	if branchState := e.state.GetBranchStates()[snapshot.BranchID]; branchState != nil && branchState.Status == ExecutionStatusCanceled {
+		return nil
+	}
+
 	if snapshot.Error != nil {
 		e.state.UpdateBranchState(snapshot.BranchID, func(state *BranchState) {
 			state.Status = ExecutionStatusFailed
@@ -969,9 +1534,16 @@ func (e *Execution) processBranchSnapshot(ctx context.Context, snapshot branchSn
 		return nil
 	}
 
+	// Handle terminate requests: the branch reached a triggered
+	// Terminate step, which ends the whole execution.
+	if snapshot.terminateRequest != nil {
+		return e.processTerminateRequest(ctx, snapshot)
+	}
+
 	// Store step output and update status
 	e.state.UpdateBranchState(snapshot.BranchID, func(state *BranchState) {
-		state.StepOutputs[snapshot.StepName] = snapshot.StepOutput
+		state.StepOutputOrder = retainStepOutput(state.StepOutputs, state.StepOutputOrder,
+			snapshot.StepName, snapshot.StepOutput, e.branchOptions.MaxStepOutputs)
 		state.Status = snapshot.Status
 		if snapshot.Status == ExecutionStatusCompleted {
 			state.EndTime = snapshot.EndTime
@@ -984,6 +1556,14 @@ func (e *Execution) processBranchSnapshot(ctx context.Context, snapshot branchSn
 		// clear keeps checkpoints from accumulating stale history.
 		state.ActivityHistory = nil
 		state.ActivityHistoryStep = ""
+		// Likewise a completed Each step's progress. The branch may
+		// already be running another Each step, whose progress stays.
+		if state.EachProgress != nil && state.EachProgress.Step == snapshot.StepName {
+			state.EachProgress = nil
+		}
+		if state.CompletedActivity != nil && state.CompletedActivity.Step == snapshot.StepName {
+			state.CompletedActivity = nil
+		}
 
 		// Update branch variables from the active branch (if it still exists)
 		if activeBranch, exists := e.getActiveBranch(snapshot.BranchID); exists {
@@ -1031,7 +1611,7 @@ func (e *Execution) processBranchSnapshot(ctx context.Context, snapshot branchSn
 				return fmt.Errorf("failed to generate branch ID: %w", err)
 			}
 			// Use the specific variables from the branch spec (copied from parent branch)
-			newBranch := e.createBranchWithVariables(branchID, spec.Step, spec.Variables)
+			newBranch := e.createBranchFromSpec(branchID, spec)
 			newBranches = append(newBranches, newBranch)
 		}
 		e.runBranches(ctx, newBranches...)
@@ -1111,10 +1691,24 @@ func (e *Execution) processJoinCompletion(ctx context.Context, stepName string,
 	}
 
 	// Merge state from completed required branches (already handles branch mappings and nested fields)
-	mergedVariables, err := e.mergeJoinedBranchState(joinState)
+	mergedVariables, joinedBranches, err := e.mergeJoinedBranchState(joinState)
 	if err != nil {
 		return fmt.Errorf("failed to merge joined branch state: %w", err)
 	}
+	e.state.MarkBranchesJoined(stepName, joinedBranches)
+
+	// In "any" and "count" modes the listed branches that lost the race
+	// are no longer needed.
+	if mode := joinState.Config.Mode; mode == JoinModeAny || mode == JoinModeCount {
+		branchStates := e.state.GetBranchStates()
+		for _, branchID := range joinState.Config.Branches {
+			branchState, exists := branchStates[branchID]
+			if !exists || branchID == joinState.WaitingBranchID || branchState.Status == ExecutionStatusCompleted {
+				continue
+			}
+			e.cancelBranch(ctx, branchID, stepName)
+		}
+	}
 
 	// Find the waiting branch
 	waitingBranchID := joinState.WaitingBranchID
@@ -1128,10 +1722,20 @@ func (e *Execution) processJoinCompletion(ctx context.Context, stepName string,
 		continuingBranch.state.Set(key, value)
 	}
 
-	// Update branch state to show it's running again
+	// The joined state is the waiting branch's own variables overlaid with
+	// the merged ones. Branches fanned out from the join inherit it, so a
+	// join whose result feeds another join keeps its full lineage.
+	var joinedVariables map[string]any
 	e.state.UpdateBranchState(waitingBranchID, func(state *BranchState) {
+		joinedVariables = copyMap(state.Variables)
+		if joinedVariables == nil {
+			joinedVariables = make(map[string]any, len(mergedVariables))
+		}
+		for key, value := range mergedVariables {
+			joinedVariables[key] = value
+		}
 		state.Status = ExecutionStatusRunning
-		state.Variables = mergedVariables
+		state.Variables = joinedVariables
 		state.EndTime = time.Time{} // Clear end time since branch is continuing
 	})
 
@@ -1139,7 +1743,7 @@ func (e *Execution) processJoinCompletion(ctx context.Context, stepName string,
 	e.state.RemoveJoinState(stepName)
 
 	// Handle next steps from the join step for the continuing branch
-	newBranchSpecs, err := e.evaluateJoinNextSteps(ctx, step, mergedVariables)
+	newBranchSpecs, err := e.evaluateJoinNextSteps(ctx, step, copyMap(joinedVariables))
 	if err != nil {
 		return fmt.Errorf("failed to evaluate next steps for join %q: %w", stepName, err)
 	}
@@ -1162,6 +1766,7 @@ func (e *Execution) processJoinCompletion(ctx context.Context, stepName string,
 			state.EndTime = time.Now()
 		})
 		e.removeActiveBranch(waitingBranchID)
+		continuingBranch.releaseFromJoin()
 
 		// Create new branches for branching
 		newBranches := make([]*branch, 0, len(newBranchSpecs))
@@ -1170,7 +1775,7 @@ func (e *Execution) processJoinCompletion(ctx context.Context, stepName string,
 			if err != nil {
 				return fmt.Errorf("failed to generate branch ID for joined branch: %w", err)
 			}
-			newBranch := e.createBranchWithVariables(branchID, spec.Step, spec.Variables)
+			newBranch := e.createBranchFromSpec(branchID, spec)
 			newBranches = append(newBranches, newBranch)
 		}
 		e.runBranches(ctx, newBranches...)
@@ -1181,13 +1786,63 @@ func (e *Execution) processJoinCompletion(ctx context.Context, stepName string,
 			state.EndTime = time.Now()
 		})
 		e.removeActiveBranch(waitingBranchID)
+		continuingBranch.releaseFromJoin()
 	}
 
 	return nil
 }
 
+// cancelBranch abandons a branch that has not finished: it stops the
+// branch's goroutine if one is running, marks the branch canceled, and
+// drops any join the branch was waiting at. Branches that already
+// reached a terminal status are left alone.
+func (e *Execution) cancelBranch(ctx context.Context, branchID, reason string) {
+	var previous BranchState
+	e.state.UpdateBranchState(branchID, func(state *BranchState) {
+		previous = *state
+		switch state.Status {
+		case ExecutionStatusCompleted, ExecutionStatusFailed, ExecutionStatusCanceled:
+			return
+		}
+		state.Status = ExecutionStatusCanceled
+		state.EndTime = time.Now()
+		state.Wait = nil
+	})
+	switch previous.Status {
+	case ExecutionStatusCompleted, ExecutionStatusFailed, ExecutionStatusCanceled:
+		return
+	}
+
+	if br, exists := e.getActiveBranch(branchID); exists {
+		if br.cancel != nil {
+			br.cancel()
+		}
+		e.removeActiveBranch(branchID)
+	}
+	for stepName, joinState := range e.state.GetAllJoinStates() {
+		if joinState.WaitingBranchID == branchID {
+			e.state.RemoveJoinState(stepName)
+		}
+	}
+
+	e.logger.Info("branch canceled", "branch_id", branchID, "reason", reason)
+	endTime := time.Now()
+	e.executionCallbacks.AfterBranchExecution(ctx, &BranchExecutionEvent{
+		ExecutionID:  e.state.ID(),
+		WorkflowName: e.workflow.Name(),
+		BranchID:     branchID,
+		Status:       ExecutionStatusCanceled,
+		StartTime:    previous.StartTime,
+		EndTime:      endTime,
+		Duration:     endTime.Sub(previous.StartTime),
+		CurrentStep:  previous.CurrentStep,
+		StepOutputs:  copyMap(previous.StepOutputs),
+	})
+}
+
 // mergeJoinedBranchState stores each branch's variables under specified keys and returns the merged result
-func (e *Execution) mergeJoinedBranchState(joinState *JoinState) (map[string]any, error) {
+// along with the IDs of the branches that contributed to it
+func (e *Execution) mergeJoinedBranchState(joinState *JoinState) (map[string]any, []string, error) {
 	// Get all branch states
 	branchStates := e.state.GetBranchStates()
 
@@ -1197,16 +1852,17 @@ func (e *Execution) mergeJoinedBranchState(joinState *JoinState) (map[string]any
 		// Use specified branches
 		requiredBranches = joinState.Config.Branches
 	} else {
-		// Use all completed branches except the waiting branch
+		// Use all completed branches except the waiting branch and those
+		// already consumed by another join
 		for branchID, branchState := range branchStates {
-			if branchID != joinState.WaitingBranchID && branchState.Status == ExecutionStatusCompleted {
+			if branchID != joinState.WaitingBranchID && isJoinCandidate(branchState) {
 				requiredBranches = append(requiredBranches, branchID)
 			}
 		}
 	}
 
 	if len(requiredBranches) == 0 {
-		return nil, fmt.Errorf("no required branches found for join")
+		return nil, nil, fmt.Errorf("no required branches found for join")
 	}
 
 	// Create the merged variables map
@@ -1263,10 +1919,10 @@ func (e *Execution) mergeJoinedBranchState(joinState *JoinState) (map[string]any
 	}
 
 	if len(processedBranches) == 0 {
-		return nil, fmt.Errorf("no completed required branches found for join")
+		return nil, nil, fmt.Errorf("no completed required branches found for join")
 	}
 
-	return mergedVariables, nil
+	return mergedVariables, sortedKeys(processedBranches), nil
 }
 
 // parseBranchMapping parses a branch mapping key into branchID and optional variable name
@@ -1306,29 +1962,17 @@ func (e *Execution) evaluateJoinNextSteps(ctx context.Context, step *Step, merge
 	branchOptions.Variables = mergedVariables
 	tempBranch := newBranch("temp", step, branchOptions)
 
-	// Get the edge matching strategy for this step
-	strategy := step.GetEdgeMatchingStrategy()
-
 	// Evaluate conditions and collect matching edges
-	var matchingEdges []*Edge
-	for _, edge := range edges {
-		if edge.Condition == "" {
-			matchingEdges = append(matchingEdges, edge)
-		} else {
-			match, err := tempBranch.evaluateCondition(ctx, edge.Condition)
-			if err != nil {
-				return nil, fmt.Errorf("failed to evaluate condition %q in join step %q: %w",
-					edge.Condition, step.Name, err)
-			}
-			if match {
-				matchingEdges = append(matchingEdges, edge)
-			}
-		}
-
-		// If using "first" strategy and we found a match, stop here
-		if strategy == EdgeMatchingFirst && len(matchingEdges) > 0 {
-			break
+	matchingEdges, err := selectEdges(step, func(edge *Edge) (bool, error) {
+		match, err := tempBranch.evaluateEdge(ctx, edge)
+		if err != nil {
+			return false, fmt.Errorf("failed to evaluate %s in join step %q: %w",
+				edge.describeCondition(), step.Name, err)
 		}
+		return match, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Create branch specs for each matching edge
@@ -1442,12 +2086,100 @@ func (e *Execution) createBranchWithVariables(id string, step *Step, variables m
 		// called.
 		opts.InitialPauseRequested = ps.PauseRequested
 		opts.InitialPauseReason = ps.PauseReason
+		opts.StepOutputs = ps.StepOutputs
+		opts.StepOutputOrder = ps.StepOutputOrder
 	}
 	return newBranch(id, step, opts)
 }
 
-// executeActivity implements simple activity execution with logging and checkpointing
-func (e *Execution) executeActivity(ctx context.Context, stepName, branchID string, activity Activity, params map[string]any, branchState *BranchLocalState) (any, error) {
+// createBranchFromSpec creates a branch forked from a parent, starting
+// with the parent's variables and step outputs.
+func (e *Execution) createBranchFromSpec(id string, spec branchSpec) *branch {
+	opts := e.branchOptions
+	opts.Variables = spec.Variables
+	opts.StepOutputs = spec.StepOutputs
+	opts.StepOutputOrder = spec.StepOutputOrder
+	opts.UpdatesChannel = e.branchSnapshots
+	opts.ExecutionID = e.state.ID()
+	return newBranch(id, spec.Step, opts)
+}
+
+// noEachItem is the eachIndex of an activity that is not an iteration of
+// an Each step.
+const noEachItem = -1
+
+// beginEach resets or resumes the Each progress of a branch; see
+// activityExecutor.BeginEach.
+func (e *Execution) beginEach(stepName, branchID string, items int, resume bool) map[int]any {
+	var completed map[int]any
+	e.state.UpdateBranchState(branchID, func(state *BranchState) {
+		prior := state.EachProgress
+		if resume && prior != nil && prior.Step == stepName && prior.Items == items {
+			completed = prior.Copy().Results
+			return
+		}
+		state.EachProgress = &EachProgress{Step: stepName, Items: items, Results: map[int]any{}}
+	})
+	return completed
+}
+
+// completedActivity returns the recorded result of an Idempotent
+// step's activity on the branch, if it belongs to stepName.
+func (e *Execution) completedActivity(stepName, branchID string) (any, bool) {
+	ps, ok := e.state.GetBranchStates()[branchID]
+	if !ok || ps == nil || ps.CompletedActivity == nil || ps.CompletedActivity.Step != stepName {
+		return nil, false
+	}
+	return ps.CompletedActivity.Result, true
+}
+
+// callActivity runs activity.Execute, converting a panic into a
+// WorkflowError of type ErrorTypePanic with the stack trace in its
+// Details, so a buggy activity fails its path instead of the process.
+func (e *Execution) callActivity(ctx Context, stepName string, activity Activity, params map[string]any) (result any, err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		stack := string(debug.Stack())
+		e.logger.Error("activity panicked", "step_name", stepName,
+			"activity", activity.Name(), "panic", r, "stack", stack)
+		wErr := &WorkflowError{
+			Type:    ErrorTypePanic,
+			Cause:   fmt.Sprintf("activity %q panicked: %v", activity.Name(), r),
+			Details: stack,
+		}
+		if rErr, ok := r.(error); ok {
+			wErr.Wrapped = rErr
+		}
+		result, err = nil, wErr
+	}()
+	return activity.Execute(ctx, params)
+}
+
+// beginStep counts a step against the WithMaxSteps budget.
+func (e *Execution) beginStep(stepName string) error {
+	if e.state.ReserveStep(e.maxSteps) {
+		return nil
+	}
+	return &WorkflowError{
+		Type:    ErrorTypeFatal,
+		Cause:   fmt.Sprintf("step limit of %d reached before step %q", e.maxSteps, stepName),
+		Wrapped: ErrStepBudgetExceeded,
+	}
+}
+
+// executeActivity implements simple activity execution with logging and
+// checkpointing. An eachIndex other than noEachItem records a successful
+// result in the branch's Each progress, so the checkpoint that follows
+// includes it.
+func (e *Execution) executeActivity(ctx context.Context, stepName, branchID string, eachIndex int, activity Activity, params map[string]any, branchState *BranchLocalState) (any, error) {
+	if !e.state.ReserveActivityInvocation(e.maxInvocations) {
+		return nil, fmt.Errorf("%w: limit of %d reached before step %q",
+			ErrActivityBudgetExceeded, e.maxInvocations, stepName)
+	}
+
 	// If this branch is being replayed from a wait-unwind checkpoint, pass
 	// the pending WaitState through so workflow.Wait can reuse the
 	// original deadline instead of restarting the clock. Also seed
@@ -1483,17 +2215,32 @@ func (e *Execution) executeActivity(ctx context.Context, stepName, branchID stri
 
 	// Create enhanced WorkflowContext with direct state access
 	workflowCtx := NewContext(ctx, ExecutionContextOptions{
-		BranchLocalState: branchState,
-		Logger:           e.logger,
-		Compiler:         e.compiler,
-		BranchID:         branchID,
-		StepName:         stepName,
-		ExecutionID:      e.state.ID(),
-		SignalStore:      e.signalStore,
-		PendingWait:      pendingWait,
-		ActivityHistory:  history,
+		BranchLocalState:  branchState,
+		Logger:            e.logger,
+		Compiler:          e.compiler,
+		BranchID:          branchID,
+		StepName:          stepName,
+		ExecutionID:       e.state.ID(),
+		SignalStore:       e.signalStore,
+		PendingWait:       pendingWait,
+		ActivityHistory:   history,
+		DryRun:            e.dryRun,
+		ExecutionDeadline: e.deadline,
 	})
 
+	workflowCtx.variableObserver = func(name string, old, value any, deleted bool) {
+		e.executionCallbacks.OnVariableChanged(workflowCtx, &VariableChangeEvent{
+			ExecutionID:  e.state.ID(),
+			WorkflowName: e.workflow.Name(),
+			BranchID:     branchID,
+			StepName:     stepName,
+			Variable:     name,
+			OldValue:     old,
+			NewValue:     value,
+			Deleted:      deleted,
+		})
+	}
+
 	// Inject progress reporter if step progress tracking is configured
 	if e.stepProgressTracker != nil {
 		workflowCtx.progressReporter = func(detail ProgressDetail) {
@@ -1501,6 +2248,20 @@ func (e *Execution) executeActivity(ctx context.Context, stepName, branchID stri
 		}
 	}
 
+	if e.paramMiddleware != nil {
+		if rewritten := e.paramMiddleware(workflowCtx, stepName, activity.Name(), params); rewritten != nil {
+			params = rewritten
+		}
+	}
+
+	// A cache hit skips the activity entirely: no callbacks, activity
+	// log entry, or checkpoint, as if the step had run before.
+	cacheKey, cached, hit := e.cachedResult(ctx, stepName, activity.Name(), params)
+	if hit {
+		e.logger.Info("using cached step result", "step_name", stepName, "activity", activity.Name())
+		return cached, nil
+	}
+
 	// Trigger activity start callback
 	startTime := time.Now()
 	activityEvent := &ActivityExecutionEvent{
@@ -1509,13 +2270,20 @@ func (e *Execution) executeActivity(ctx context.Context, stepName, branchID stri
 		BranchID:     branchID,
 		StepName:     stepName,
 		ActivityName: activity.Name(),
-		Parameters:   copyMap(params),
+		Parameters:   redactParameters(params, e.redactedParams),
 		StartTime:    startTime,
 	}
 	e.executionCallbacks.BeforeActivityExecution(workflowCtx, activityEvent)
 
-	// Execute the activity with the enhanced WorkflowContext
-	result, err := activity.Execute(workflowCtx, params)
+	// Execute the activity with the enhanced WorkflowContext, or take
+	// its recorded outcome when replaying.
+	var result any
+	var err error
+	if e.replayer != nil {
+		result, err = e.replayer.next(branchID, stepName, activity.Name())
+	} else {
+		result, err = e.callActivity(workflowCtx, stepName, activity, params)
+	}
 	endTime := time.Now()
 	duration := endTime.Sub(startTime)
 
@@ -1531,6 +2299,15 @@ func (e *Execution) executeActivity(ctx context.Context, stepName, branchID stri
 	if isWaitUnwind(err) {
 		return nil, err
 	}
+	if err != nil && e.errorClassifier != nil {
+		err = classifyActivityError(err, e.errorClassifier)
+	}
+	if err == nil && cacheKey != "" {
+		e.storeCachedResult(ctx, stepName, cacheKey, result)
+	}
+	if e.recorder != nil {
+		e.recorder.record(branchID, stepName, activity.Name(), params, result, err)
+	}
 
 	// Update activity event with results
 	activityEvent.Result = result
@@ -1545,7 +2322,7 @@ func (e *Execution) executeActivity(ctx context.Context, stepName, branchID stri
 		StepName:    stepName,
 		BranchID:    branchID,
 		Activity:    activity.Name(),
-		Parameters:  params,
+		Parameters:  redactParameters(params, e.redactedParams),
 		Result:      result,
 		StartTime:   startTime,
 		Duration:    duration.Seconds(),
@@ -1564,6 +2341,19 @@ func (e *Execution) executeActivity(ctx context.Context, stepName, branchID stri
 		return nil, logErr
 	}
 
+	if err == nil && eachIndex != noEachItem {
+		e.state.UpdateBranchState(branchID, func(state *BranchState) {
+			if state.EachProgress != nil && state.EachProgress.Step == stepName {
+				state.EachProgress.Results[eachIndex] = result
+			}
+		})
+	}
+	if step, ok := e.workflow.GetStep(stepName); err == nil && ok && step.Idempotent && eachIndex == noEachItem {
+		e.state.UpdateBranchState(branchID, func(state *BranchState) {
+			state.CompletedActivity = &CompletedActivity{Step: stepName, Result: result}
+		})
+	}
+
 	// Checkpoint after activity execution
 	if checkpointErr := e.saveCheckpoint(ctx); checkpointErr != nil {
 		e.logger.Error("failed to save checkpoint", "error", checkpointErr)
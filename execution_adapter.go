@@ -7,5 +7,27 @@ type executionAdapter struct {
 }
 
 func (e *executionAdapter) ExecuteActivity(ctx context.Context, stepName string, branchID string, activity Activity, params map[string]any, state *BranchLocalState) (any, error) {
-	return e.execution.executeActivity(ctx, stepName, branchID, activity, params, state)
+	return e.execution.executeActivity(ctx, stepName, branchID, noEachItem, activity, params, state)
+}
+
+func (e *executionAdapter) CompletedActivity(stepName, branchID string) (any, bool) {
+	return e.execution.completedActivity(stepName, branchID)
+}
+
+func (e *executionAdapter) BeginStep(stepName, branchID string) error {
+	return e.execution.beginStep(stepName)
+}
+
+func (e *executionAdapter) BeginEach(stepName, branchID string, items int, resume bool) map[int]any {
+	return e.execution.beginEach(stepName, branchID, items, resume)
+}
+
+func (e *executionAdapter) ExecuteEachItem(ctx context.Context, stepName string, branchID string, index int, activity Activity, params map[string]any, state *BranchLocalState) (any, error) {
+	return e.execution.executeActivity(ctx, stepName, branchID, index, activity, params, state)
+}
+
+func (e *executionAdapter) SkipStep(ctx context.Context, stepName, branchID, activityName string) {
+	if e.execution.stepProgressTracker != nil {
+		e.execution.stepProgressTracker.skipStep(ctx, stepName, branchID, activityName)
+	}
 }
@@ -18,6 +18,12 @@ type ExecutionCallbacks interface {
 	// Activity-level callbacks
 	BeforeActivityExecution(ctx context.Context, event *ActivityExecutionEvent)
 	AfterActivityExecution(ctx context.Context, event *ActivityExecutionEvent)
+
+	// Variable-level callback, fired on every change to a branch
+	// variable made by an activity through Context.Set or
+	// Context.Delete (including the state changes of a script step) or
+	// by a Store on a step, catch handler, or wait_signal step.
+	OnVariableChanged(ctx context.Context, event *VariableChangeEvent)
 }
 
 // WorkflowExecutionEvent provides context for workflow-level execution events
@@ -30,6 +36,7 @@ type WorkflowExecutionEvent struct {
 	Duration     time.Duration
 	Inputs       map[string]any
 	Outputs      map[string]any
+	Labels       map[string]string
 	PathCount    int
 	Error        error
 }
@@ -63,6 +70,36 @@ type ActivityExecutionEvent struct {
 	Error        error
 }
 
+// VariableChangeEvent describes one change to a branch variable. Every
+// write is reported, even one that stores a value equal to the old one;
+// deleting a variable that is not set is not a change.
+type VariableChangeEvent struct {
+	ExecutionID  string
+	WorkflowName string
+	BranchID     string
+	StepName     string
+	Variable     string
+	OldValue     any // nil if the variable was not set
+	NewValue     any // nil when Deleted
+	Deleted      bool
+}
+
+// TypedResult returns the result of an activity event as an O. It
+// reports false when the activity failed or its result is not an O.
+// Activities registered with RegisterTyped or TypedActivityFunc always
+// produce their declared result type, so observers can read results
+// without asserting on Result themselves:
+//
+//	if quote, ok := workflow.TypedResult[Quote](event); ok { ... }
+func TypedResult[O any](event *ActivityExecutionEvent) (O, bool) {
+	if event.Error != nil {
+		var zero O
+		return zero, false
+	}
+	result, ok := event.Result.(O)
+	return result, ok
+}
+
 // BaseExecutionCallbacks provides a default implementation that does nothing
 type BaseExecutionCallbacks struct{}
 
@@ -90,6 +127,10 @@ func (n *BaseExecutionCallbacks) AfterActivityExecution(ctx context.Context, eve
 	// noop
 }
 
+func (n *BaseExecutionCallbacks) OnVariableChanged(ctx context.Context, event *VariableChangeEvent) {
+	// noop
+}
+
 // NewBaseExecutionCallbacks creates a new no-op callbacks implementation.
 // Embed this in your own callbacks to get a default implementation that does nothing.
 func NewBaseExecutionCallbacks() ExecutionCallbacks {
@@ -146,3 +187,9 @@ func (c *CallbackChain) AfterActivityExecution(ctx context.Context, event *Activ
 		callback.AfterActivityExecution(ctx, event)
 	}
 }
+
+func (c *CallbackChain) OnVariableChanged(ctx context.Context, event *VariableChangeEvent) {
+	for _, callback := range c.callbacks {
+		callback.OnVariableChanged(ctx, event)
+	}
+}
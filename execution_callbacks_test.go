@@ -63,6 +63,10 @@ func (t *TestCallbacksImplementation) OnActivityFailure(ctx context.Context, eve
 		event.ExecutionID, event.ActivityName, event.Error))
 }
 
+func (t *TestCallbacksImplementation) OnVariableChanged(ctx context.Context, event *workflow.VariableChangeEvent) {
+	// Variable changes are not part of the event sequence asserted here.
+}
+
 func (t *TestCallbacksImplementation) GetEvents() []string {
 	return t.events
 }
@@ -271,3 +275,261 @@ func TestCallbackChain(t *testing.T) {
 	fmt.Printf("Callback chain 1 received %d events\n", len(events1))
 	fmt.Printf("Callback chain 2 received %d events\n", len(events2))
 }
+
+type quoteObserver struct {
+	workflow.BaseExecutionCallbacks
+	quotes []quote
+}
+
+func (o *quoteObserver) AfterActivityExecution(ctx context.Context, event *workflow.ActivityExecutionEvent) {
+	if q, ok := workflow.TypedResult[quote](event); ok {
+		o.quotes = append(o.quotes, q)
+	}
+}
+
+type quote struct {
+	Symbol string  `json:"symbol"`
+	Price  float64 `json:"price"`
+}
+
+func TestTypedResultInCallbacks(t *testing.T) {
+	reg := workflow.NewActivityRegistry()
+	require.NoError(t, workflow.RegisterTyped(reg, "quote",
+		func(ctx workflow.Context, params struct {
+			Symbol string `json:"symbol"`
+		}) (quote, error) {
+			return quote{Symbol: params.Symbol, Price: 42.5}, nil
+		}))
+	reg.MustRegister(workflow.ActivityFunc("untyped", func(ctx workflow.Context, params map[string]any) (any, error) {
+		return "not a quote", nil
+	}))
+
+	resultType, ok := reg.ResultType("quote")
+	require.True(t, ok)
+	require.Equal(t, "quote", resultType.Name())
+	_, ok = reg.ResultType("untyped")
+	require.False(t, ok)
+
+	wf, err := workflow.New(workflow.Options{
+		Name: "typed-results",
+		Steps: []*workflow.Step{
+			{Name: "price", Activity: "quote", Parameters: map[string]any{"symbol": "ACME"}, Next: []*workflow.Edge{{Step: "other"}}},
+			{Name: "other", Activity: "untyped"},
+		},
+	})
+	require.NoError(t, err)
+
+	observer := &quoteObserver{}
+	execution, err := workflow.NewExecution(wf, reg, workflow.WithExecutionCallbacks(observer))
+	require.NoError(t, err)
+	result, err := execution.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, workflow.ExecutionStatusCompleted, result.Status)
+	require.Equal(t, []quote{{Symbol: "ACME", Price: 42.5}}, observer.quotes)
+
+	// Registering the same name again fails like Register.
+	err = workflow.RegisterTyped(reg, "quote", func(ctx workflow.Context, params map[string]any) (quote, error) {
+		return quote{}, nil
+	})
+	require.ErrorIs(t, err, workflow.ErrDuplicateActivity)
+}
+
+type inputsObserver struct {
+	workflow.BaseExecutionCallbacks
+	started, finished map[string]any
+}
+
+func (o *inputsObserver) BeforeWorkflowExecution(ctx context.Context, event *workflow.WorkflowExecutionEvent) {
+	o.started = event.Inputs
+}
+
+func (o *inputsObserver) AfterWorkflowExecution(ctx context.Context, event *workflow.WorkflowExecutionEvent) {
+	o.finished = event.Inputs
+}
+
+func TestSensitiveInputsAreRedacted(t *testing.T) {
+	wf, err := workflow.New(workflow.Options{
+		Name: "call-api",
+		Inputs: []*workflow.Input{
+			{Name: "api_key", Type: "string", Sensitive: true},
+			{Name: "region", Type: "string"},
+		},
+		Steps: []*workflow.Step{{
+			Name:       "call",
+			Activity:   "call",
+			Parameters: map[string]any{"key": "${inputs.api_key}"},
+		}},
+	})
+	require.NoError(t, err)
+
+	var keys []any
+	reg := workflow.NewActivityRegistry()
+	reg.MustRegister(workflow.ActivityFunc("call", func(ctx workflow.Context, params map[string]any) (any, error) {
+		keys = append(keys, params["key"])
+		return nil, nil
+	}))
+	checkpointer, err := workflow.NewFileCheckpointer(t.TempDir())
+	require.NoError(t, err)
+
+	observer := &inputsObserver{}
+	exec, err := workflow.NewExecution(wf, reg,
+		workflow.WithInputs(map[string]any{"api_key": "sk-secret", "region": "eu"}),
+		workflow.WithCheckpointer(checkpointer),
+		workflow.WithExecutionCallbacks(observer))
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, workflow.ExecutionStatusCompleted, result.Status)
+
+	// The activity sees the secret; callbacks and checkpoints do not.
+	require.Equal(t, []any{"sk-secret"}, keys)
+	want := map[string]any{"api_key": workflow.RedactedValue, "region": "eu"}
+	require.Equal(t, want, observer.started)
+	require.Equal(t, want, observer.finished)
+	checkpoint, err := checkpointer.LoadCheckpoint(context.Background(), exec.ID())
+	require.NoError(t, err)
+	require.Equal(t, want, checkpoint.Inputs)
+
+	// A resumed execution uses the value it is given again.
+	checkpoint.Status = workflow.ExecutionStatusFailed
+	checkpoint.BranchStates["main"].Status = workflow.ExecutionStatusFailed
+	require.NoError(t, checkpointer.SaveCheckpoint(context.Background(), checkpoint))
+	resumed, err := workflow.NewExecution(wf, reg,
+		workflow.WithInputs(map[string]any{"api_key": "sk-rotated", "region": "eu"}),
+		workflow.WithCheckpointer(checkpointer))
+	require.NoError(t, err)
+	result, err = resumed.Execute(context.Background(), workflow.ResumeFrom(exec.ID()))
+	require.NoError(t, err)
+	require.Equal(t, workflow.ExecutionStatusCompleted, result.Status)
+	require.Equal(t, []any{"sk-secret", "sk-rotated"}, keys)
+}
+
+type paramsObserver struct {
+	workflow.BaseExecutionCallbacks
+	before, after map[string]any
+}
+
+func (o *paramsObserver) BeforeActivityExecution(ctx context.Context, event *workflow.ActivityExecutionEvent) {
+	o.before = event.Parameters
+}
+
+func (o *paramsObserver) AfterActivityExecution(ctx context.Context, event *workflow.ActivityExecutionEvent) {
+	o.after = event.Parameters
+}
+
+func TestRedactedParameters(t *testing.T) {
+	wf, err := workflow.New(workflow.Options{
+		Name: "call-api",
+		Steps: []*workflow.Step{{
+			Name:     "call",
+			Activity: "call",
+			Parameters: map[string]any{
+				"token":   "sk-secret",
+				"url":     "https://example.com",
+				"headers": map[string]any{"Authorization": "Bearer sk-secret", "Accept": "json"},
+			},
+		}},
+	})
+	require.NoError(t, err)
+
+	var got map[string]any
+	reg := workflow.NewActivityRegistry()
+	reg.MustRegister(workflow.ActivityFunc("call", func(ctx workflow.Context, params map[string]any) (any, error) {
+		got = params
+		return nil, nil
+	}))
+	logger := workflow.NewMemoryActivityLogger()
+	observer := &paramsObserver{}
+	exec, err := workflow.NewExecution(wf, reg,
+		workflow.WithActivityLogger(logger),
+		workflow.WithExecutionCallbacks(observer),
+		workflow.WithRedactedParameters("token", "headers.Authorization"),
+		workflow.WithRedactedParameters("missing.key"))
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, workflow.ExecutionStatusCompleted, result.Status)
+
+	// The activity sees the secrets; callbacks and the activity log do not.
+	require.Equal(t, "sk-secret", got["token"])
+	require.Equal(t, "Bearer sk-secret", got["headers"].(map[string]any)["Authorization"])
+	want := map[string]any{
+		"token":   workflow.RedactedValue,
+		"url":     "https://example.com",
+		"headers": map[string]any{"Authorization": workflow.RedactedValue, "Accept": "json"},
+	}
+	require.Equal(t, want, observer.before)
+	require.Equal(t, want, observer.after)
+	entries := logger.Entries()
+	require.Len(t, entries, 1)
+	require.Equal(t, want, entries[0].Parameters)
+}
+
+type labelsObserver struct {
+	workflow.BaseExecutionCallbacks
+	started, finished map[string]string
+}
+
+func (o *labelsObserver) BeforeWorkflowExecution(ctx context.Context, event *workflow.WorkflowExecutionEvent) {
+	o.started = event.Labels
+}
+
+func (o *labelsObserver) AfterWorkflowExecution(ctx context.Context, event *workflow.WorkflowExecutionEvent) {
+	o.finished = event.Labels
+}
+
+func TestExecutionLabels(t *testing.T) {
+	wf, err := workflow.New(workflow.Options{
+		Name:  "labeled",
+		Steps: []*workflow.Step{{Name: "call", Activity: "call"}},
+	})
+	require.NoError(t, err)
+	reg := workflow.NewActivityRegistry()
+	reg.MustRegister(workflow.ActivityFunc("call", func(ctx workflow.Context, params map[string]any) (any, error) {
+		return nil, nil
+	}))
+	checkpointer, err := workflow.NewFileCheckpointer(t.TempDir())
+	require.NoError(t, err)
+
+	run := func(labels map[string]string, observer workflow.ExecutionCallbacks, opts ...workflow.ExecuteOption) *workflow.Execution {
+		exec, err := workflow.NewExecution(wf, reg,
+			workflow.WithLabels(labels),
+			workflow.WithCheckpointer(checkpointer),
+			workflow.WithExecutionCallbacks(observer))
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background(), opts...)
+		require.NoError(t, err)
+		require.Equal(t, workflow.ExecutionStatusCompleted, result.Status)
+		return exec
+	}
+
+	labels := map[string]string{"tenant": "acme", "trigger": "cron"}
+	observer := &labelsObserver{}
+	exec := run(labels, observer)
+	run(map[string]string{"tenant": "globex"}, &labelsObserver{})
+	require.Equal(t, labels, observer.started)
+	require.Equal(t, labels, observer.finished)
+
+	checkpoint, err := checkpointer.LoadCheckpoint(context.Background(), exec.ID())
+	require.NoError(t, err)
+	require.Equal(t, labels, checkpoint.Labels)
+
+	summaries, err := checkpointer.ListExecutions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	var acme []string
+	for _, summary := range summaries {
+		if summary.HasLabels(map[string]string{"tenant": "acme"}) {
+			acme = append(acme, summary.ExecutionID)
+		}
+	}
+	require.Equal(t, []string{exec.ID()}, acme)
+
+	// A resumed execution keeps the saved labels under any it is given.
+	checkpoint.Status = workflow.ExecutionStatusFailed
+	checkpoint.BranchStates["main"].Status = workflow.ExecutionStatusFailed
+	require.NoError(t, checkpointer.SaveCheckpoint(context.Background(), checkpoint))
+	observer = &labelsObserver{}
+	run(map[string]string{"trigger": "retry"}, observer, workflow.ResumeFrom(exec.ID()))
+	require.Equal(t, map[string]string{"tenant": "acme", "trigger": "retry"}, observer.finished)
+}
@@ -3,6 +3,10 @@ package workflow
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +23,10 @@ type BranchState struct {
 	ErrorMessage string          `json:"error_message,omitempty"`
 	StepOutputs  map[string]any  `json:"step_outputs"`
 	Variables    map[string]any  `json:"variables"`
+	// StepOutputOrder lists the steps in StepOutputs from least to most
+	// recently completed. It is only maintained when the execution
+	// limits retained step outputs with WithMaxStepOutputs.
+	StepOutputOrder []string `json:"step_output_order,omitempty"`
 	// Wait is populated when the branch is hard-suspended on a durable
 	// wait (signal-wait or durable sleep). nil otherwise.
 	Wait *WaitState `json:"wait,omitempty"`
@@ -46,6 +54,47 @@ type BranchState struct {
 	// history, the mismatch discards the stale entries so they do not
 	// leak into the next activity.
 	ActivityHistoryStep string `json:"activity_history_step,omitempty"`
+	// JoinedBy names the join step whose completion merged this
+	// branch's variables. Joins that select branches by Count (or the
+	// default of two) skip branches already consumed this way, so an
+	// inner join's inputs are not counted again by an outer join.
+	JoinedBy string `json:"joined_by,omitempty"`
+	// EachProgress records the finished iterations of the Each step the
+	// branch is running, so a resumed execution continues the loop
+	// instead of starting it over. Cleared when the step completes.
+	EachProgress *EachProgress `json:"each_progress,omitempty"`
+	// CompletedActivity holds the result of an Idempotent step's
+	// activity from when it succeeds until the step completes, so a
+	// resumed execution does not run the activity again.
+	CompletedActivity *CompletedActivity `json:"completed_activity,omitempty"`
+}
+
+// CompletedActivity is the recorded result of an Idempotent step's
+// activity.
+type CompletedActivity struct {
+	Step   string `json:"step"`
+	Result any    `json:"result"`
+}
+
+// EachProgress holds the results of the finished iterations of an Each
+// step, keyed by item index. Iterations of a concurrent loop can finish
+// out of order, so the indexes need not be contiguous.
+type EachProgress struct {
+	Step    string      `json:"step"`
+	Items   int         `json:"items"`
+	Results map[int]any `json:"results"`
+}
+
+// Copy returns a copy of the progress with its own results map.
+func (p *EachProgress) Copy() *EachProgress {
+	if p == nil {
+		return nil
+	}
+	results := make(map[int]any, len(p.Results))
+	for i, result := range p.Results {
+		results[i] = result
+	}
+	return &EachProgress{Step: p.Step, Items: p.Items, Results: results}
 }
 
 // JoinState tracks a branch waiting at a join step
@@ -71,13 +120,25 @@ func (p *BranchState) Copy() *BranchState {
 		EndTime:             p.EndTime,
 		ErrorMessage:        p.ErrorMessage,
 		StepOutputs:         copyMap(p.StepOutputs),
+		StepOutputOrder:     slices.Clone(p.StepOutputOrder),
 		Variables:           copyMap(p.Variables),
 		Wait:                wait,
 		PauseRequested:      p.PauseRequested,
 		PauseReason:         p.PauseReason,
 		ActivityHistory:     copyMap(p.ActivityHistory),
 		ActivityHistoryStep: p.ActivityHistoryStep,
+		JoinedBy:            p.JoinedBy,
+		EachProgress:        p.EachProgress.Copy(),
+		CompletedActivity:   p.CompletedActivity.copy(),
+	}
+}
+
+func (c *CompletedActivity) copy() *CompletedActivity {
+	if c == nil {
+		return nil
 	}
+	cp := *c
+	return &cp
 }
 
 // executionState consolidates all execution state into a single structure. All
@@ -92,6 +153,10 @@ type executionState struct {
 	inputs       map[string]any
 	outputs      map[string]any
 	pathCounter  int
+	sensitive    map[string]bool // Sensitive input names, redacted in checkpoints
+	invocations  int             // activity invocations, for WithMaxActivityInvocations
+	steps        int             // steps started, for WithMaxSteps
+	labels       map[string]string
 	branchStates map[string]*BranchState
 	joinStates   map[string]*JoinState // stepName -> JoinState
 	mutex        sync.RWMutex
@@ -255,6 +320,31 @@ func (s *executionState) GetInputs() map[string]any {
 	return copyMap(s.inputs)
 }
 
+// GetRedactedInputs returns a copy of the inputs with Sensitive
+// values replaced by RedactedValue, for callbacks and checkpoints.
+func (s *executionState) GetRedactedInputs() map[string]any {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return redactInputs(s.inputs, s.sensitive)
+}
+
+// GetLabels returns a copy of the execution's labels
+func (s *executionState) GetLabels() map[string]string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return maps.Clone(s.labels)
+}
+
+// SetInputs replaces the inputs
+func (s *executionState) SetInputs(inputs map[string]any) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.inputs = copyMap(inputs)
+}
+
 // SetOutput sets an output value
 func (s *executionState) SetOutput(key string, value any) {
 	s.mutex.Lock()
@@ -381,6 +471,14 @@ func (s *executionState) IsJoinReady(stepName string) bool {
 
 	config := joinState.Config
 
+	// Explicit modes over the listed branches (excluding the waiting branch)
+	switch {
+	case config.Mode == JoinModeAny:
+		return s.countCompletedListed(joinState) >= 1
+	case config.Mode == JoinModeCount && len(config.Branches) > 0:
+		return s.countCompletedListed(joinState) >= config.Count
+	}
+
 	// If specific branches are specified, check if all are completed (excluding the waiting branch)
 	if len(config.Branches) > 0 {
 		for _, requiredBranch := range config.Branches {
@@ -396,25 +494,54 @@ func (s *executionState) IsJoinReady(stepName string) bool {
 		return true
 	}
 
-	// If count is specified, count completed branches (excluding the waiting branch)
-	if config.Count > 0 {
-		completedCount := 0
-		for branchID, branchState := range s.branchStates {
-			if branchID != joinState.WaitingBranchID && branchState.Status == ExecutionStatusCompleted {
-				completedCount++
-			}
-		}
-		return completedCount >= config.Count
+	// Otherwise count completed branches (excluding the waiting branch and
+	// branches already consumed by an earlier join). Default to at least 2,
+	// the minimum for a join.
+	required := config.Count
+	if required <= 0 {
+		required = 2
 	}
-
-	// Default: wait for at least 2 branches to complete (minimum for a join)
 	completedCount := 0
 	for branchID, branchState := range s.branchStates {
-		if branchID != joinState.WaitingBranchID && branchState.Status == ExecutionStatusCompleted {
+		if branchID != joinState.WaitingBranchID && isJoinCandidate(branchState) {
 			completedCount++
 		}
 	}
-	return completedCount >= 2
+	return completedCount >= required
+}
+
+// countCompletedListed counts the completed branches listed in a join's
+// config, excluding the waiting branch. Callers must hold the mutex.
+func (s *executionState) countCompletedListed(joinState *JoinState) int {
+	completed := 0
+	for _, branchID := range joinState.Config.Branches {
+		if branchID == joinState.WaitingBranchID {
+			continue
+		}
+		if branchState, exists := s.branchStates[branchID]; exists && branchState.Status == ExecutionStatusCompleted {
+			completed++
+		}
+	}
+	return completed
+}
+
+// isJoinCandidate reports whether a branch can satisfy a join that does
+// not list its branches explicitly.
+func isJoinCandidate(branchState *BranchState) bool {
+	return branchState.Status == ExecutionStatusCompleted && branchState.JoinedBy == ""
+}
+
+// MarkBranchesJoined records that the given branches were merged by the
+// named join step.
+func (s *executionState) MarkBranchesJoined(stepName string, branchIDs []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, branchID := range branchIDs {
+		if branchState := s.branchStates[branchID]; branchState != nil {
+			branchState.JoinedBy = stepName
+		}
+	}
 }
 
 // GetJoinState returns a copy of the join state for a step
@@ -458,6 +585,52 @@ func (s *executionState) GetAllJoinStates() map[string]*JoinState {
 	return result
 }
 
+// ReserveActivityInvocation counts one activity invocation and returns
+// true. When limit is positive and already reached, it returns false
+// without counting.
+func (s *executionState) ReserveActivityInvocation(limit int) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if limit > 0 && s.invocations >= limit {
+		return false
+	}
+	s.invocations++
+	return true
+}
+
+// ReserveStep counts a step about to run. It returns false, without
+// counting, when limit is positive and that many steps have already
+// run.
+func (s *executionState) ReserveStep(limit int) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if limit > 0 && s.steps >= limit {
+		return false
+	}
+	s.steps++
+	return true
+}
+
+// GetStepCount returns the number of steps counted so far, including
+// those before a resume.
+func (s *executionState) GetStepCount() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.steps
+}
+
+// GetActivityInvocations returns the number of activity invocations
+// counted so far, including those before a resume.
+func (s *executionState) GetActivityInvocations() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.invocations
+}
+
 // ToCheckpoint converts the execution state to a checkpoint
 func (s *executionState) ToCheckpoint() *Checkpoint {
 	s.mutex.RLock()
@@ -469,7 +642,8 @@ func (s *executionState) ToCheckpoint() *Checkpoint {
 		ExecutionID:   s.executionID,
 		WorkflowName:  s.workflowName,
 		Status:        s.status,
-		Inputs:        copyMap(s.inputs),
+		Inputs:        redactInputs(s.inputs, s.sensitive),
+		Labels:        maps.Clone(s.labels),
 		Outputs:       copyMap(s.outputs),
 		Variables:     map[string]any{}, // Variables are now per-branch, so global variables are empty
 		BranchStates:  copyBranchStates(s.branchStates),
@@ -479,6 +653,9 @@ func (s *executionState) ToCheckpoint() *Checkpoint {
 		EndTime:       s.endTime,
 		CheckpointAt:  time.Now(),
 		Error:         s.err,
+
+		ActivityInvocations: s.invocations,
+		StepCount:           s.steps,
 	}
 }
 
@@ -490,7 +667,21 @@ func (s *executionState) FromCheckpoint(checkpoint *Checkpoint) {
 	s.executionID = checkpoint.ExecutionID
 	s.workflowName = checkpoint.WorkflowName
 	s.status = checkpoint.Status
-	s.inputs = copyMap(checkpoint.Inputs)
+	// Sensitive inputs are redacted in checkpoints; keep the values
+	// this execution was given.
+	inputs := copyMap(checkpoint.Inputs)
+	for name := range s.sensitive {
+		if v, ok := s.inputs[name]; ok && inputs[name] == RedactedValue {
+			inputs[name] = v
+		}
+	}
+	s.inputs = inputs
+	// Labels given to this execution are layered over the saved ones.
+	if len(checkpoint.Labels) > 0 {
+		labels := maps.Clone(checkpoint.Labels)
+		maps.Copy(labels, s.labels)
+		s.labels = labels
+	}
 	s.outputs = copyMap(checkpoint.Outputs)
 	s.branchStates = copyBranchStates(checkpoint.BranchStates)
 
@@ -502,6 +693,8 @@ func (s *executionState) FromCheckpoint(checkpoint *Checkpoint) {
 	}
 
 	s.pathCounter = checkpoint.BranchCounter
+	s.invocations = checkpoint.ActivityInvocations
+	s.steps = checkpoint.StepCount
 	s.startTime = checkpoint.StartTime
 	s.endTime = checkpoint.EndTime
 	s.err = checkpoint.Error
@@ -516,6 +709,24 @@ func copyMap(m map[string]any) map[string]any {
 	return copy
 }
 
+// retainStepOutput records output for step in outputs and returns the
+// updated completion order. With a positive limit, the oldest outputs
+// beyond it are deleted; a zero limit keeps everything and tracks no
+// order. A step that runs again moves to the end of the order.
+func retainStepOutput(outputs map[string]any, order []string, step string, output any, limit int) []string {
+	outputs[step] = output
+	if limit <= 0 {
+		return order
+	}
+	order = slices.DeleteFunc(order, func(name string) bool { return name == step })
+	order = append(order, step)
+	for len(order) > limit {
+		delete(outputs, order[0])
+		order = order[1:]
+	}
+	return order
+}
+
 // copyBranchStates creates a deep copy of a branch states map
 func copyBranchStates(m map[string]*BranchState) map[string]*BranchState {
 	copy := make(map[string]*BranchState, len(m))
@@ -539,93 +750,162 @@ func copyJoinStates(m map[string]*JoinState) map[string]*JoinState {
 	return copy
 }
 
-// getNestedField retrieves a nested field from a map using dot notation
-// e.g., "user.profile.name" -> map["user"]["profile"]["name"]
-func getNestedField(data map[string]any, branch string) (any, bool) {
-	if branch == "" {
+// pathSegment is one step of a field path: a map key, or an index
+// into a slice or array when isIndex is set. Negative indexes count
+// from the end.
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parseFieldPath splits a field path such as "orders[0].items[-1].id"
+// into segments. Dots separate map keys and brackets hold integer
+// indexes. It reports false for an empty path, an empty key, or a
+// malformed index.
+func parseFieldPath(path string) ([]pathSegment, bool) {
+	if path == "" {
+		return nil, false
+	}
+	var segments []pathSegment
+	for _, part := range strings.Split(path, ".") {
+		key, rest, _ := strings.Cut(part, "[")
+		if key == "" {
+			return nil, false
+		}
+		segments = append(segments, pathSegment{key: key})
+		if !strings.Contains(part, "[") {
+			continue
+		}
+		rest = "[" + rest
+		for rest != "" {
+			closing := strings.IndexByte(rest, ']')
+			if rest[0] != '[' || closing < 0 {
+				return nil, false
+			}
+			index, err := strconv.Atoi(rest[1:closing])
+			if err != nil {
+				return nil, false
+			}
+			segments = append(segments, pathSegment{index: index, isIndex: true})
+			rest = rest[closing+1:]
+		}
+	}
+	return segments, true
+}
+
+// indexSlice returns element index of a slice or array value, counting
+// from the end when index is negative.
+func indexSlice(value any, index int) (any, bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+	if index < 0 {
+		index += v.Len()
+	}
+	if index < 0 || index >= v.Len() {
 		return nil, false
 	}
+	return v.Index(index).Interface(), true
+}
 
-	// Handle simple case with no dots
-	if !strings.Contains(branch, ".") {
+// getNestedField retrieves a nested field from a map using dot notation
+// for map keys and brackets for slice indexes, e.g. "user.profile.name"
+// -> map["user"]["profile"]["name"] and "items[-1].id" -> the id of the
+// last element of map["items"].
+func getNestedField(data map[string]any, branch string) (any, bool) {
+	// Handle simple case with no dots or indexes
+	if branch != "" && !strings.ContainsAny(branch, ".[") {
 		value, exists := data[branch]
 		return value, exists
 	}
 
-	// Split branch by dots and traverse
-	parts := strings.Split(branch, ".")
-	current := data
-
-	for i, part := range parts {
-		if part == "" {
-			return nil, false // Empty part in branch
-		}
-
-		value, exists := current[part]
-		if !exists {
-			return nil, false
+	segments, ok := parseFieldPath(branch)
+	if !ok {
+		return nil, false
+	}
+	var current any = data
+	for _, segment := range segments {
+		if segment.isIndex {
+			if current, ok = indexSlice(current, segment.index); !ok {
+				return nil, false
+			}
+			continue
 		}
-
-		// If this is the last part, return the value
-		if i == len(parts)-1 {
-			return value, true
+		m, isMap := current.(map[string]any)
+		if !isMap {
+			return nil, false // Path leads through a non-map value
 		}
-
-		// Otherwise, expect the value to be a map and continue traversing
-		if nextMap, ok := value.(map[string]any); ok {
-			current = nextMap
-		} else {
-			return nil, false // Path leads to non-map value before the end
+		if current, ok = m[segment.key]; !ok {
+			return nil, false
 		}
 	}
-
-	return nil, false
+	return current, true
 }
 
-// setNestedField sets a nested field in a map using dot notation
-// e.g., "user.profile.name" -> map["user"]["profile"]["name"] = value
-// Creates intermediate maps as needed
+// setNestedField sets a nested field in a map using the path syntax of
+// getNestedField, e.g. "user.profile.name" -> map["user"]["profile"]["name"] = value.
+// Missing or non-map intermediate keys are replaced with new maps.
+// Slices are never created or grown: a path through an index that is
+// out of range, or through a value that is not a []any, sets nothing.
 func setNestedField(data map[string]any, branch string, value any) {
-	if branch == "" {
+	// Handle simple case with no dots or indexes
+	if branch != "" && !strings.ContainsAny(branch, ".[") {
+		data[branch] = value
 		return
 	}
 
-	// Handle simple case with no dots
-	if !strings.Contains(branch, ".") {
-		data[branch] = value
+	segments, ok := parseFieldPath(branch)
+	if !ok {
 		return
 	}
-
-	// Split branch by dots and traverse, creating maps as needed
-	parts := strings.Split(branch, ".")
 	current := data
-
-	for i, part := range parts {
-		if part == "" {
-			return // Empty part in branch
-		}
-
-		// If this is the last part, set the value
-		if i == len(parts)-1 {
-			current[part] = value
+	for i := 0; i < len(segments); i++ {
+		segment := segments[i]
+		last := i == len(segments)-1
+		if last {
+			current[segment.key] = value
 			return
 		}
+		next := segments[i+1]
+		if !next.isIndex {
+			// Ensure the next level exists as a map
+			nextMap, isMap := current[segment.key].(map[string]any)
+			if !isMap {
+				nextMap = make(map[string]any)
+				current[segment.key] = nextMap
+			}
+			current = nextMap
+			continue
+		}
 
-		// Otherwise, ensure the next level exists as a map
-		if existing, exists := current[part]; exists {
-			if nextMap, ok := existing.(map[string]any); ok {
-				current = nextMap
-			} else {
-				// Existing value is not a map, replace it with a map
-				newMap := make(map[string]any)
-				current[part] = newMap
-				current = newMap
+		// Walk the indexes that follow the key, then either set the
+		// final element or continue into the map it holds.
+		container := current[segment.key]
+		for i+1 < len(segments) && segments[i+1].isIndex {
+			i++
+			list, isList := container.([]any)
+			if !isList {
+				return
+			}
+			index := segments[i].index
+			if index < 0 {
+				index += len(list)
+			}
+			if index < 0 || index >= len(list) {
+				return
 			}
-		} else {
-			// Create new map for this level
-			newMap := make(map[string]any)
-			current[part] = newMap
-			current = newMap
+			if i == len(segments)-1 {
+				list[index] = value
+				return
+			}
+			container = list[index]
+		}
+		nextMap, isMap := container.(map[string]any)
+		if !isMap {
+			return
 		}
+		current = nextMap
 	}
 }
@@ -4,11 +4,25 @@ import "time"
 
 // ExecutionSummary provides a summary view of an execution
 type ExecutionSummary struct {
-	ExecutionID  string        `json:"execution_id"`
-	WorkflowName string        `json:"workflow_name"`
-	Status       string        `json:"status"`
-	StartTime    time.Time     `json:"start_time"`
-	EndTime      time.Time     `json:"end_time,omitempty"`
-	Duration     time.Duration `json:"duration"`
-	Error        string        `json:"error,omitempty"`
+	ExecutionID  string            `json:"execution_id"`
+	WorkflowName string            `json:"workflow_name"`
+	Status       string            `json:"status"`
+	StartTime    time.Time         `json:"start_time"`
+	EndTime      time.Time         `json:"end_time,omitempty"`
+	Duration     time.Duration     `json:"duration"`
+	Error        string            `json:"error,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+}
+
+// HasLabels reports whether the execution carries every label in
+// labels with the same value, for filtering the result of
+// ListExecutions by tenant or other metadata. Empty labels match every
+// execution.
+func (s *ExecutionSummary) HasLabels(labels map[string]string) bool {
+	for k, v := range labels {
+		if got, ok := s.Labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
 }
@@ -5,12 +5,16 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/deepnoodle-ai/workflow/internal/require"
+	"github.com/deepnoodle-ai/workflow/script"
 )
 
 func TestNewExecutionID(t *testing.T) {
@@ -331,6 +335,201 @@ func TestWorkflowOutputCapture(t *testing.T) {
 		require.NotNil(t, outputs)
 		require.Equal(t, "GREAT SUCCESS", outputs["status"])
 	})
+
+	t.Run("computed output and default", func(t *testing.T) {
+		wf, err := New(Options{
+			Name:   "test-workflow-computed-output",
+			Inputs: []*Input{{Name: "bonus", Type: InputTypeInt, Default: 1}},
+			Steps: []*Step{
+				{Name: "a", Activity: "num", Store: "a", Next: []*Edge{{Step: "b"}}},
+				{Name: "b", Activity: "num", Store: "b"},
+			},
+			Outputs: []*Output{
+				{Name: "summary", Expression: "state.a + state.b + inputs.bonus"},
+				{Name: "note", Variable: "note", Default: "none"},
+			},
+		})
+		require.NoError(t, err)
+
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("num", func(ctx Context, params map[string]any) (any, error) {
+			return int64(20), nil
+		}))
+		execution, err := NewExecution(wf, reg, WithScriptCompiler(DefaultScriptCompiler()))
+		require.NoError(t, err)
+
+		result, err := execution.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		require.Equal(t, 41, result.Outputs["summary"])
+		require.Equal(t, "none", result.Outputs["note"])
+	})
+
+	t.Run("invalid output expression fails binding", func(t *testing.T) {
+		wf, err := New(Options{
+			Name:    "test-workflow-bad-output-expression",
+			Steps:   []*Step{{Name: "a", Activity: "num", Store: "a"}},
+			Outputs: []*Output{{Name: "summary", Expression: "state.a +"}},
+		})
+		require.NoError(t, err)
+
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("num", func(ctx Context, params map[string]any) (any, error) {
+			return 1, nil
+		}))
+		_, err = NewExecution(wf, reg, WithScriptCompiler(DefaultScriptCompiler()))
+		require.ErrorIs(t, err, ErrInvalidExpression)
+	})
+	t.Run("required output not produced fails the execution", func(t *testing.T) {
+		wf, err := New(Options{
+			Name:   "test-workflow-required-output",
+			Inputs: []*Input{{Name: "skip_scoring", Type: InputTypeBool}},
+			Steps: []*Step{
+				{Name: "fetch", Activity: "num", Store: "data", Next: []*Edge{{Step: "score"}}},
+				{Name: "score", Activity: "num", Store: "score", Skip: "inputs.skip_scoring"},
+			},
+			Outputs: []*Output{
+				{Name: "score", Variable: "score", Required: true},
+				{Name: "data", Variable: "data"},
+			},
+		})
+		require.NoError(t, err)
+
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("num", func(ctx Context, params map[string]any) (any, error) {
+			return 7, nil
+		}))
+		run := func(skip bool) *ExecutionResult {
+			execution, err := NewExecution(wf, reg, WithInputs(map[string]any{"skip_scoring": skip}))
+			require.NoError(t, err)
+			result, err := execution.Execute(context.Background())
+			require.NoError(t, err)
+			return result
+		}
+
+		result := run(false)
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		require.Equal(t, 7, result.Outputs["score"])
+
+		// Skipping the step that sets the output fails the otherwise
+		// completed execution.
+		result = run(true)
+		require.Equal(t, ExecutionStatusFailed, result.Status)
+		require.ErrorIs(t, result.Error, ErrRequiredOutputMissing)
+		require.Contains(t, result.Error.Error(), `output "score": variable "score" is not set in branch "main"`)
+	})
+
+	t.Run("required output rejects a nil value", func(t *testing.T) {
+		wf, err := New(Options{
+			Name:    "test-workflow-required-nil-output",
+			Steps:   []*Step{{Name: "a", Activity: "nothing", Store: "a"}},
+			Outputs: []*Output{{Name: "a", Required: true}},
+		})
+		require.NoError(t, err)
+
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("nothing", func(ctx Context, params map[string]any) (any, error) {
+			return nil, nil
+		}))
+		execution, err := NewExecution(wf, reg)
+		require.NoError(t, err)
+		result, err := execution.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusFailed, result.Status)
+		require.ErrorIs(t, result.Error, ErrRequiredOutputMissing)
+		require.Contains(t, result.Error.Error(), "is nil")
+	})
+
+	t.Run("output collected from several branches", func(t *testing.T) {
+		newWorkflow := func(out *Output) (*Workflow, error) {
+			return New(Options{
+				Name: "test-workflow-branches-output",
+				Steps: []*Step{
+					{Name: "start", Activity: "count", Next: []*Edge{
+						{Step: "tally", BranchName: "west"},
+						{Step: "tally", BranchName: "east"},
+						{Step: "idle", BranchName: "north"},
+					}},
+					{Name: "tally", Activity: "count", Store: "count"},
+					{Name: "idle", Activity: "count"},
+				},
+				Outputs: []*Output{out},
+			})
+		}
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("count", func(ctx Context, params map[string]any) (any, error) {
+			return ctx.BranchID(), nil
+		}))
+		run := func(out *Output) *ExecutionResult {
+			wf, err := newWorkflow(out)
+			require.NoError(t, err)
+			execution, err := NewExecution(wf, reg)
+			require.NoError(t, err)
+			result, err := execution.Execute(context.Background())
+			require.NoError(t, err)
+			return result
+		}
+
+		// Values follow the listed order, not completion order.
+		result := run(&Output{Name: "counts", Variable: "count", Branches: []string{"west", "east"}})
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		require.Equal(t, []any{"west", "east"}, result.Outputs["counts"])
+
+		// A branch without the variable fails unless SkipMissing is set.
+		result = run(&Output{Name: "counts", Variable: "count", Branches: []string{"west", "north"}})
+		require.Equal(t, ExecutionStatusFailed, result.Status)
+		require.Contains(t, result.Error.Error(), `workflow output variable "count" not found in branch "north"`)
+
+		result = run(&Output{Name: "counts", Variable: "count", Branches: []string{"north", "east", "south"}, SkipMissing: true})
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		require.Equal(t, []any{"east"}, result.Outputs["counts"])
+
+		_, err := newWorkflow(&Output{Name: "counts", Variable: "count", Branch: "west", Branches: []string{"east"}})
+		require.ErrorIs(t, err, ErrInvalidOutputConfig)
+	})
+
+	t.Run("output collected from all completed paths", func(t *testing.T) {
+		newWorkflow := func(out *Output) (*Workflow, error) {
+			return New(Options{
+				Name: "test-workflow-all-paths-output",
+				Steps: []*Step{
+					{Name: "start", Activity: "label", Next: []*Edge{
+						{Step: "red"}, {Step: "green"}, {Step: "blue"},
+					}},
+					{Name: "red", Activity: "label", Store: "color"},
+					{Name: "green", Activity: "label", Store: "color"},
+					{Name: "blue", Activity: "label", Store: "color"},
+				},
+				Outputs: []*Output{out},
+			})
+		}
+		wf, err := newWorkflow(&Output{Name: "colors", Variable: "color", FromAllPaths: true})
+		require.NoError(t, err)
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("label", func(ctx Context, params map[string]any) (any, error) {
+			return ctx.StepName(), nil
+		}))
+		execution, err := NewExecution(wf, reg)
+		require.NoError(t, err)
+		result, err := execution.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		// The main branch never sets color, so only the three fanned-out
+		// paths contribute, in the order they were started.
+		require.Equal(t, []any{"red", "green", "blue"}, result.Outputs["colors"])
+
+		_, err = newWorkflow(&Output{Name: "colors", Variable: "color", FromAllPaths: true, Branches: []string{"main"}})
+		require.ErrorIs(t, err, ErrInvalidOutputConfig)
+	})
+
+	t.Run("required output cannot have a default", func(t *testing.T) {
+		_, err := New(Options{
+			Name:    "test-workflow-required-default",
+			Steps:   []*Step{{Name: "a", Activity: "num", Store: "a"}},
+			Outputs: []*Output{{Name: "a", Required: true, Default: 1}},
+		})
+		require.ErrorIs(t, err, ErrInvalidOutputConfig)
+	})
 }
 
 func TestFileCheckpointerSavesCheckpoints(t *testing.T) {
@@ -1518,3 +1717,617 @@ func TestNamedBranches(t *testing.T) {
 		}
 	})
 }
+
+func TestWithDryRunReachesActivities(t *testing.T) {
+	wf, err := New(Options{
+		Name:  "dry-run",
+		Steps: []*Step{{Name: "check", Activity: "check", Store: "dry"}},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("check", func(ctx Context, params map[string]any) (any, error) {
+		return IsDryRun(ctx), nil
+	}))
+
+	for _, dryRun := range []bool{true, false} {
+		exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()), WithDryRun(dryRun))
+		require.NoError(t, err)
+		_, err = exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, dryRun, exec.state.GetBranchStates()["main"].Variables["dry"])
+	}
+}
+
+func TestRemainingTimeReflectsExecutionDeadline(t *testing.T) {
+	type observed struct {
+		deadline   time.Time
+		step       time.Time
+		hasStep    bool
+		remaining  time.Duration
+		hasOverall bool
+	}
+	var seen observed
+	attempts := 0
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("check", func(ctx Context, params map[string]any) (any, error) {
+		// The retry timeout applies from the second attempt on.
+		attempts++
+		if attempts%2 == 1 {
+			return nil, errors.New("try again")
+		}
+		seen.deadline, seen.hasOverall = ExecutionDeadline(ctx)
+		seen.step, seen.hasStep = ctx.Deadline()
+		seen.remaining = RemainingTime(ctx)
+		return nil, nil
+	}))
+	wf, err := New(Options{
+		Name: "deadline",
+		Steps: []*Step{{
+			Name:     "check",
+			Activity: "check",
+			Retry: []*RetryConfig{{
+				ErrorEquals: []string{ErrorTypeAll},
+				MaxRetries:  1,
+				BaseDelay:   time.Millisecond,
+				Timeout:     time.Second,
+			}},
+		}},
+	})
+	require.NoError(t, err)
+
+	exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	want, _ := ctx.Deadline()
+	_, err = exec.Execute(ctx)
+	require.NoError(t, err)
+
+	// The step's one-second retry timeout bounds ctx.Deadline but not
+	// the execution deadline.
+	require.True(t, seen.hasOverall)
+	require.Equal(t, want, seen.deadline)
+	require.True(t, seen.hasStep)
+	require.True(t, seen.step.Before(want))
+	require.Greater(t, seen.remaining, 59*time.Minute)
+
+	exec, err = NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()))
+	require.NoError(t, err)
+	_, err = exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.False(t, seen.hasOverall)
+	require.Equal(t, time.Duration(math.MaxInt64), seen.remaining)
+}
+
+func TestExecutionLiveBranchStates(t *testing.T) {
+	release := make(chan struct{})
+	block := ActivityFunc("block", func(ctx Context, params map[string]any) (any, error) {
+		<-release
+		return nil, nil
+	})
+	noop := ActivityFunc("noop", func(ctx Context, params map[string]any) (any, error) {
+		return nil, nil
+	})
+
+	wf, err := New(Options{
+		Name: "live-branches",
+		Steps: []*Step{
+			{
+				Name:     "start",
+				Activity: "noop",
+				Next: []*Edge{
+					{Step: "left", BranchName: "left"},
+					{Step: "right", BranchName: "right"},
+				},
+			},
+			{Name: "left", Activity: "block"},
+			{Name: "right", Activity: "block"},
+		},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(block)
+	reg.MustRegister(noop)
+	exec, err := NewExecution(wf, reg)
+	require.NoError(t, err)
+
+	done := make(chan *ExecutionResult, 1)
+	go func() {
+		result, _ := exec.Execute(context.Background())
+		done <- result
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(exec.CurrentSteps()) == 2
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, map[string]string{"left": "left", "right": "right"}, exec.CurrentSteps())
+	require.Equal(t, 2, exec.ActiveBranchCount())
+
+	// Snapshots are copies: mutating one does not affect the execution.
+	states := exec.BranchStates()
+	require.Equal(t, ExecutionStatusRunning, states["left"].Status)
+	states["left"].Status = ExecutionStatusFailed
+	require.Equal(t, ExecutionStatusRunning, exec.BranchStates()["left"].Status)
+
+	close(release)
+	result := <-done
+	require.NotNil(t, result)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.Equal(t, 0, exec.ActiveBranchCount())
+	require.Empty(t, exec.CurrentSteps())
+	require.Equal(t, ExecutionStatusCompleted, exec.BranchStates()["right"].Status)
+}
+
+func TestParameterMiddleware(t *testing.T) {
+	wf, err := New(Options{
+		Name:   "parameter-middleware",
+		Inputs: []*Input{{Name: "host", Type: InputTypeString}},
+		Steps: []*Step{
+			{
+				Name:       "call",
+				Activity:   "call",
+				Parameters: map[string]any{"url": "https://${inputs.host}/items"},
+				Store:      "seen",
+			},
+		},
+		Outputs: []*Output{{Name: "seen", Variable: "seen"}},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("call", func(ctx Context, params map[string]any) (any, error) {
+		return params, nil
+	}))
+
+	var seenURL any
+	middleware := func(ctx Context, step, activity string, params map[string]any) map[string]any {
+		seenURL = params["url"]
+		out := copyMap(params)
+		out["headers"] = map[string]any{"X-Correlation-ID": ctx.StepName() + "/" + activity}
+		return out
+	}
+
+	logger := NewChannelActivityLogger(4)
+	exec, err := NewExecution(wf, reg,
+		WithInputs(map[string]any{"host": "example.com"}),
+		WithActivityLogger(logger),
+		WithParameterMiddleware(middleware))
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+
+	// The middleware sees evaluated templates, and the activity and the
+	// activity log see its changes.
+	require.Equal(t, "https://example.com/items", seenURL)
+	want := map[string]any{
+		"url":     "https://example.com/items",
+		"headers": map[string]any{"X-Correlation-ID": "call/call"},
+	}
+	require.Equal(t, want, result.Outputs["seen"])
+	history, err := logger.GetActivityHistory(context.Background(), exec.ID())
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, want, history[0].Parameters)
+}
+
+// hookCompiler extends the test compiler with statements for Before and
+// After scripts: "set name <expr>", "delete name", and "fail <message>",
+// one per line.
+type hookCompiler struct{ testCompiler }
+
+func (c hookCompiler) Compile(ctx context.Context, code string) (script.Script, error) {
+	first, _, _ := strings.Cut(strings.TrimSpace(code), " ")
+	if first != "set" && first != "delete" && first != "fail" {
+		return c.testCompiler.Compile(ctx, code)
+	}
+	var s hookScript
+	for _, line := range strings.Split(code, "\n") {
+		op, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+		stmt := hookStmt{op: op, arg: rest}
+		if op == "set" {
+			name, expr, _ := strings.Cut(rest, " ")
+			compiled, err := c.testCompiler.Compile(ctx, expr)
+			if err != nil {
+				return nil, err
+			}
+			stmt.arg, stmt.expr = name, compiled
+		}
+		s = append(s, stmt)
+	}
+	return s, nil
+}
+
+type hookStmt struct {
+	op, arg string
+	expr    script.Script
+}
+
+type hookScript []hookStmt
+
+func (s hookScript) Evaluate(ctx context.Context, globals map[string]any) (script.Value, error) {
+	state := globals["state"].(map[string]any)
+	for _, stmt := range s {
+		switch stmt.op {
+		case "set":
+			v, err := stmt.expr.Evaluate(ctx, globals)
+			if err != nil {
+				return nil, err
+			}
+			state[stmt.arg] = v.Value()
+		case "delete":
+			delete(state, stmt.arg)
+		case "fail":
+			return nil, errors.New(stmt.arg)
+		}
+	}
+	return &testValue{}, nil
+}
+
+func TestStepBeforeAfterScripts(t *testing.T) {
+	wf, err := New(Options{
+		Name:  "step-hooks",
+		State: map[string]any{"visits": 1},
+		Steps: []*Step{
+			{
+				Name:       "charge",
+				Activity:   "work",
+				Before:     "set amount 40 + 2\nset visits state.visits + 1",
+				Parameters: map[string]any{"amount": "${state.amount}"},
+				Store:      "charged",
+				After:      "set receipt result\ndelete amount",
+				Next:       []*Edge{{Step: "refund"}},
+			},
+			{
+				Name:     "refund",
+				Activity: "work",
+				Before:   "fail refunds are disabled",
+				Catch: []*CatchConfig{
+					{ErrorEquals: []string{ErrorTypeAll}, Next: "handled", Store: "refund_error"},
+				},
+			},
+			{Name: "handled", Activity: "work"},
+		},
+	})
+	require.NoError(t, err)
+
+	var calls []string
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("work", func(ctx Context, params map[string]any) (any, error) {
+		calls = append(calls, ctx.StepName())
+		return params["amount"], nil
+	}))
+	checkpointer, err := NewFileCheckpointer(t.TempDir())
+	require.NoError(t, err)
+	exec, err := NewExecution(wf, reg,
+		WithScriptCompiler(hookCompiler{}),
+		WithCheckpointer(checkpointer))
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.Equal(t, []string{"charge", "handled"}, calls)
+
+	checkpoint, err := checkpointer.LoadCheckpoint(context.Background(), exec.ID())
+	require.NoError(t, err)
+	vars := checkpoint.BranchStates["main"].Variables
+	require.Equal(t, float64(42), vars["charged"])
+	require.Equal(t, float64(42), vars["receipt"])
+	require.Equal(t, float64(2), vars["visits"])
+	_, ok := vars["amount"]
+	require.False(t, ok)
+	require.Contains(t, fmt.Sprint(vars["refund_error"]), "refunds are disabled")
+
+	_, err = New(Options{
+		Name:  "hook-on-sleep",
+		Steps: []*Step{{Name: "nap", Sleep: &SleepConfig{Duration: time.Second}, Before: "set x 1"}},
+	})
+	require.ErrorIs(t, err, ErrInvalidModifier)
+}
+
+func TestCatchFatalErrorForCleanup(t *testing.T) {
+	newWorkflow := func(catch ...*CatchConfig) *Workflow {
+		wf, err := New(Options{
+			Name: "fatal-cleanup",
+			Steps: []*Step{
+				{Name: "provision", Activity: "provision", Catch: catch},
+				{Name: "fallback", Activity: "record"},
+				{Name: "cleanup", Activity: "record"},
+			},
+		})
+		require.NoError(t, err)
+		return wf
+	}
+	run := func(wf *Workflow) (*ExecutionResult, []string, map[string]any) {
+		var calls []string
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("provision", func(ctx Context, params map[string]any) (any, error) {
+			return nil, NewWorkflowError(ErrorTypeFatal, "quota exhausted")
+		}))
+		reg.MustRegister(ActivityFunc("record", func(ctx Context, params map[string]any) (any, error) {
+			calls = append(calls, ctx.StepName())
+			return nil, nil
+		}))
+		exec, err := NewExecution(wf, reg)
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		return result, calls, exec.state.GetBranchStates()["main"].Variables
+	}
+
+	// The catch-all is listed first but never sees the fatal error; the
+	// dedicated fatal handler routes to cleanup.
+	result, calls, vars := run(newWorkflow(
+		&CatchConfig{ErrorEquals: []string{ErrorTypeAll}, Next: "fallback"},
+		&CatchConfig{ErrorEquals: []string{ErrorTypeFatal}, Next: "cleanup", Store: "failure"},
+	))
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.Equal(t, []string{"cleanup"}, calls)
+	failure := vars["failure"].(ErrorOutput)
+	require.Equal(t, ErrorTypeFatal, failure.Error)
+	require.Equal(t, "quota exhausted", failure.Cause)
+
+	// With only a catch-all, the fatal error fails the path.
+	result, calls, _ = run(newWorkflow(
+		&CatchConfig{ErrorEquals: []string{ErrorTypeAll}, Next: "fallback"},
+	))
+	require.Equal(t, ExecutionStatusFailed, result.Status)
+	require.Empty(t, calls)
+}
+
+func TestDefaultActivityTimeout(t *testing.T) {
+	wf, err := New(Options{
+		Name: "default-timeout",
+		Steps: []*Step{
+			{
+				Name:       "hang",
+				Activity:   "wait",
+				Parameters: map[string]any{"for": "1h"},
+				Catch: []*CatchConfig{
+					{ErrorEquals: []string{ErrorTypeTimeout}, Next: "slow", Store: "timeout"},
+				},
+			},
+			{
+				// The first attempt gets the 20ms default; the retry's own
+				// timeout replaces it.
+				Name:       "slow",
+				Activity:   "wait",
+				Parameters: map[string]any{"for": "60ms"},
+				Retry: []*RetryConfig{
+					{ErrorEquals: []string{ErrorTypeTimeout}, MaxRetries: 1, BaseDelay: time.Millisecond, Timeout: time.Second},
+				},
+				Store: "slow",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	var attempts int
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("wait", func(ctx Context, params map[string]any) (any, error) {
+		if ctx.StepName() == "slow" {
+			attempts++
+		}
+		d, _ := time.ParseDuration(params["for"].(string))
+		select {
+		case <-time.After(d):
+			return "done", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}))
+	exec, err := NewExecution(wf, reg, WithDefaultActivityTimeout(20*time.Millisecond))
+	require.NoError(t, err)
+	start := time.Now()
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.LessOrEqual(t, time.Since(start), 5*time.Second)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+
+	vars := exec.state.GetBranchStates()["main"].Variables
+	timeout := vars["timeout"].(ErrorOutput)
+	require.Equal(t, ErrorTypeTimeout, timeout.Error)
+	require.Contains(t, timeout.Cause, "default timeout of 20ms")
+	require.Equal(t, "done", vars["slow"])
+	require.Equal(t, 2, attempts)
+}
+
+// slowCompiler evaluates expressions with the test compiler after a
+// delay that honors context cancellation, like a long-running template.
+type slowCompiler struct {
+	testCompiler
+	delay time.Duration
+}
+
+func (c slowCompiler) Compile(ctx context.Context, code string) (script.Script, error) {
+	compiled, err := c.testCompiler.Compile(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return slowScript{Script: compiled, delay: c.delay}, nil
+}
+
+type slowScript struct {
+	script.Script
+	delay time.Duration
+}
+
+func (s slowScript) Evaluate(ctx context.Context, globals map[string]any) (script.Value, error) {
+	select {
+	case <-time.After(s.delay):
+		return s.Script.Evaluate(ctx, globals)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestDefaultActivityTimeoutCoversParameters(t *testing.T) {
+	wf, err := New(Options{
+		Name:   "timeout-covers-parameters",
+		Inputs: []*Input{{Name: "wait", Type: InputTypeString, Default: "60ms"}},
+		Steps: []*Step{
+			{
+				Name:       "slow",
+				Activity:   "wait",
+				Parameters: map[string]any{"for": "${inputs.wait}"},
+				Catch: []*CatchConfig{
+					{ErrorEquals: []string{ErrorTypeTimeout}, Next: "done", Store: "timeout"},
+				},
+			},
+			{Name: "done", Activity: "wait", Parameters: map[string]any{"for": "0s"}},
+		},
+	})
+	require.NoError(t, err)
+
+	var started atomic.Bool
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("wait", func(ctx Context, params map[string]any) (any, error) {
+		if ctx.StepName() == "slow" {
+			started.Store(true)
+		}
+		d, _ := time.ParseDuration(params["for"].(string))
+		select {
+		case <-time.After(d):
+			return "done", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}))
+
+	// The template and the activity each take 60ms, under the 100ms
+	// limit on their own but not together.
+	exec, err := NewExecution(wf, reg,
+		WithScriptCompiler(slowCompiler{delay: 60 * time.Millisecond}),
+		WithDefaultActivityTimeout(100*time.Millisecond))
+	require.NoError(t, err)
+	start := time.Now()
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.True(t, started.Load())
+	require.LessOrEqual(t, time.Since(start), 5*time.Second)
+
+	timeout := exec.state.GetBranchStates()["main"].Variables["timeout"].(ErrorOutput)
+	require.Equal(t, ErrorTypeTimeout, timeout.Error)
+	require.Contains(t, timeout.Cause, "default timeout of 100ms")
+}
+
+func TestStepOutputsInScope(t *testing.T) {
+	wf, err := New(Options{
+		Name: "step-outputs",
+		Steps: []*Step{
+			{
+				Name:     "Fetch Data",
+				Activity: "fetch",
+				Next:     []*Edge{{Step: "check"}},
+			},
+			{
+				Name:       "check",
+				Activity:   "echo",
+				Parameters: map[string]any{"value": `${steps["Fetch Data"].status}`},
+				Next: []*Edge{
+					{Step: "ok", BranchName: "ok", Condition: `steps.check == 200`},
+					{Step: "other", BranchName: "other", Condition: `steps.check != 200`},
+				},
+			},
+			{
+				// A forked branch still sees its parent's step results.
+				Name:       "ok",
+				Activity:   "echo",
+				Parameters: map[string]any{"value": `${steps["Fetch Data"].body}`},
+				Store:      "body",
+			},
+			{Name: "other", Activity: "echo", Parameters: map[string]any{"value": "unexpected"}},
+		},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("fetch", func(ctx Context, params map[string]any) (any, error) {
+		return map[string]any{"status": 200, "body": "hello"}, nil
+	}))
+	reg.MustRegister(ActivityFunc("echo", func(ctx Context, params map[string]any) (any, error) {
+		return params["value"], nil
+	}))
+	exec, err := NewExecution(wf, reg)
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+
+	branches := exec.state.GetBranchStates()
+	require.Equal(t, "hello", branches["ok"].Variables["body"])
+	_, ranOther := branches["other"]
+	require.False(t, ranOther)
+}
+
+func TestActivityPanicFailsOnlyItsPath(t *testing.T) {
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("explode", func(ctx Context, params map[string]any) (any, error) {
+		var m map[string]int
+		m["boom"]++ // panics: assignment to entry in nil map
+		return nil, nil
+	}))
+	reg.MustRegister(ActivityFunc("record", func(ctx Context, params map[string]any) (any, error) {
+		return nil, nil
+	}))
+
+	t.Run("uncaught panic fails the execution gracefully", func(t *testing.T) {
+		wf, err := New(Options{
+			Name: "panic-uncaught",
+			Steps: []*Step{
+				{Name: "start", Activity: "record", Next: []*Edge{
+					{Step: "bad", BranchName: "bad"},
+					{Step: "good", BranchName: "good"},
+				}},
+				{Name: "bad", Activity: "explode"},
+				{Name: "good", Activity: "record"},
+			},
+		})
+		require.NoError(t, err)
+		exec, err := NewExecution(wf, reg)
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusFailed, result.Status)
+		require.Contains(t, result.Error.Error(), `activity "explode" panicked`)
+		var wErr *WorkflowError
+		require.True(t, errors.As(result.Error, &wErr))
+		require.Equal(t, ErrorTypePanic, wErr.Type)
+		require.Contains(t, fmt.Sprint(wErr.Details), "runtime/debug.Stack")
+
+		// The failure cancels the sibling path, so only the panicking
+		// path's status is certain.
+		require.Equal(t, ExecutionStatusFailed, exec.state.GetBranchStates()["bad"].Status)
+	})
+
+	t.Run("panic is matchable by catch", func(t *testing.T) {
+		wf, err := New(Options{
+			Name: "panic-caught",
+			Steps: []*Step{
+				{
+					Name:     "bad",
+					Activity: "explode",
+					Retry:    []*RetryConfig{{ErrorEquals: []string{ErrorTypeTimeout}, MaxRetries: 3}},
+					Catch: []*CatchConfig{
+						{ErrorEquals: []string{ErrorTypePanic}, Next: "recover", Store: "crash"},
+					},
+				},
+				{Name: "recover", Activity: "record"},
+			},
+		})
+		require.NoError(t, err)
+		exec, err := NewExecution(wf, reg)
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+
+		crash := exec.state.GetBranchStates()["main"].Variables["crash"].(ErrorOutput)
+		require.Equal(t, ErrorTypePanic, crash.Error)
+		require.Contains(t, crash.Cause, "assignment to entry in nil map")
+		require.Contains(t, fmt.Sprint(crash.Details), "runtime/debug.Stack")
+	})
+}
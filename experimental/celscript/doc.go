@@ -0,0 +1,47 @@
+// Package celscript provides a script.Compiler backed by the Common
+// Expression Language (github.com/google/cel-go).
+//
+// CEL programs cannot assign variables, loop without bound, or call
+// anything the environment does not declare, and each evaluation is
+// capped by a cost limit. That makes the engine a fit for workflow
+// definitions from untrusted sources, where edge conditions and
+// parameter templates must be pure:
+//
+//	exec, err := workflow.NewExecution(wf, reg,
+//	    workflow.WithScriptCompiler(celscript.NewCELEngine()),
+//	)
+//
+// Expressions see the same globals as the default engine: state,
+// inputs, and steps as maps of dynamic values, and result as a dynamic
+// value:
+//
+//	state.count > 3 && inputs.mode == "fast"
+//	"retry" in state && state.retry < inputs.max_retries
+//	steps.fetch.status_code == 200
+//	result.filter(r, r.ok).size()
+//	"${state.user.name}"   // template: typed value
+//	"Hello ${state.name}!" // template: string interpolation
+//
+// CEL's standard library and the strings extension
+// (https://github.com/google/cel-go/tree/master/ext) are available, for
+// example size(state.items), state.name.lowerAscii(), and
+// state.items.all(i, i > 0). WithEnvOptions adds further libraries or
+// function declarations.
+//
+// Differences from the default expr engine:
+//
+//   - Reading a missing key is an error rather than nil. Guard optional
+//     variables with has(state.x) or "x" in state.
+//   - Integers are int64 and unsigned integers uint64; the default
+//     engine's builtin functions (len, upper, ...) are not available,
+//     use the CEL equivalents (size, upperAscii, ...).
+//   - There is no mutation, so the "script" activity can compute a
+//     value from state but cannot change it.
+//   - Only the "state", "inputs", "steps", and "result" globals are
+//     declared. Other keys passed to Evaluate are ignored.
+//   - Template expressions cannot contain "}", so map literals are
+//     only usable in edge conditions, not inside ${...}.
+//
+// time.Time and time.Duration values (for example inputs declared as
+// "timestamp" or "duration") map to CEL timestamps and durations.
+package celscript
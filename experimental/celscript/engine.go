@@ -0,0 +1,166 @@
+package celscript
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/deepnoodle-ai/workflow/script"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"github.com/google/cel-go/ext"
+)
+
+// DefaultCostLimit bounds the work a single evaluation may do, in CEL
+// cost units. Roughly one unit per operation.
+const DefaultCostLimit = 1_000_000
+
+// Option configures the CEL engine.
+type Option func(*Engine)
+
+// WithCostLimit sets the maximum cost of one evaluation. Evaluations
+// that exceed it fail. Zero disables the limit.
+func WithCostLimit(limit uint64) Option {
+	return func(e *Engine) { e.costLimit = limit }
+}
+
+// WithEnvOptions adds CEL environment options, such as extra
+// libraries or function declarations, to the engine.
+func WithEnvOptions(opts ...cel.EnvOption) Option {
+	return func(e *Engine) { e.envOptions = append(e.envOptions, opts...) }
+}
+
+// Engine compiles workflow expressions to CEL programs. It implements
+// script.Compiler and is safe for concurrent use.
+type Engine struct {
+	env        *cel.Env
+	envErr     error
+	costLimit  uint64
+	envOptions []cel.EnvOption
+}
+
+// NewCELEngine returns a CEL-backed script.Compiler with "state",
+// "inputs", and "steps" declared as maps of dynamic values, and
+// "result", the value a step's StoreExpression or a MapReduce's Reduce
+// transforms, as a dynamic value.
+func NewCELEngine(opts ...Option) *Engine {
+	e := &Engine{costLimit: DefaultCostLimit}
+	for _, opt := range opts {
+		opt(e)
+	}
+	envOptions := []cel.EnvOption{
+		cel.Variable("state", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("inputs", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("steps", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("result", cel.DynType),
+		ext.Strings(),
+	}
+	e.env, e.envErr = cel.NewEnv(append(envOptions, e.envOptions...)...)
+	return e
+}
+
+// Compile parses and type-checks code and returns a program that can
+// be evaluated repeatedly.
+func (e *Engine) Compile(ctx context.Context, code string) (script.Script, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if e.envErr != nil {
+		return nil, fmt.Errorf("cel: invalid environment: %w", e.envErr)
+	}
+	ast, issues := e.env.Compile(code)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("cel: %w", issues.Err())
+	}
+	programOptions := []cel.ProgramOption{cel.InterruptCheckFrequency(100)}
+	if e.costLimit > 0 {
+		programOptions = append(programOptions, cel.CostLimit(e.costLimit))
+	}
+	program, err := e.env.Program(ast, programOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("cel: %w", err)
+	}
+	return &celScript{program: program}, nil
+}
+
+type celScript struct {
+	program cel.Program
+}
+
+func (s *celScript) Evaluate(ctx context.Context, globals map[string]any) (script.Value, error) {
+	vars := map[string]any{
+		"state":  map[string]any{},
+		"inputs": map[string]any{},
+		"steps":  map[string]any{},
+		"result": globals["result"],
+	}
+	for _, name := range []string{"state", "inputs", "steps"} {
+		if m, ok := globals[name].(map[string]any); ok {
+			vars[name] = m
+		}
+	}
+	out, _, err := s.program.ContextEval(ctx, vars)
+	if err != nil {
+		return nil, fmt.Errorf("cel: %w", err)
+	}
+	native, err := toNative(out)
+	if err != nil {
+		return nil, fmt.Errorf("cel: %w", err)
+	}
+	return value{v: native}, nil
+}
+
+// toNative converts a CEL value to plain Go values: lists become []any
+// and maps become map[string]any, recursively.
+func toNative(v ref.Val) (any, error) {
+	switch val := v.(type) {
+	case types.Null:
+		return nil, nil
+	case traits.Mapper:
+		out := map[string]any{}
+		it := val.Iterator()
+		for it.HasNext() == types.True {
+			key := it.Next()
+			k, ok := key.Value().(string)
+			if !ok {
+				k = fmt.Sprint(key.Value())
+			}
+			item, err := toNative(val.Get(key))
+			if err != nil {
+				return nil, err
+			}
+			out[k] = item
+		}
+		return out, nil
+	case traits.Lister:
+		var out []any
+		it := val.Iterator()
+		for it.HasNext() == types.True {
+			item, err := toNative(it.Next())
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, item)
+		}
+		if out == nil {
+			out = []any{}
+		}
+		return out, nil
+	case *types.Err:
+		return nil, val
+	}
+	return v.Value(), nil
+}
+
+type value struct{ v any }
+
+func (v value) Value() any            { return v.v }
+func (v value) IsTruthy() bool        { return script.IsTruthyValue(v.v) }
+func (v value) Items() ([]any, error) { return script.EachValue(v.v) }
+func (v value) String() string {
+	if v.v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", v.v)
+}
@@ -0,0 +1,236 @@
+package celscript_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow"
+	"github.com/deepnoodle-ai/workflow/experimental/celscript"
+	"github.com/deepnoodle-ai/workflow/script"
+)
+
+func eval(t *testing.T, engine *celscript.Engine, code string, globals map[string]any) script.Value {
+	t.Helper()
+	compiled, err := engine.Compile(context.Background(), code)
+	if err != nil {
+		t.Fatalf("compile %q: %v", code, err)
+	}
+	v, err := compiled.Evaluate(context.Background(), globals)
+	if err != nil {
+		t.Fatalf("evaluate %q: %v", code, err)
+	}
+	return v
+}
+
+func TestEngineExpressions(t *testing.T) {
+	engine := celscript.NewCELEngine()
+	globals := map[string]any{
+		"state": map[string]any{
+			"count": 5,
+			"name":  "Ada",
+			"items": []any{"a", "b"},
+			"user":  map[string]any{"role": "admin"},
+		},
+		"inputs": map[string]any{"max": 3, "timeout": 2 * time.Second},
+		"steps":  map[string]any{"Fetch Data": map[string]any{"status": 200}},
+	}
+
+	if !eval(t, engine, `state.count > inputs.max && state.user.role == "admin"`, globals).IsTruthy() {
+		t.Error("condition should be true")
+	}
+	if eval(t, engine, `"missing" in state && state.missing > 0`, globals).IsTruthy() {
+		t.Error("guarded condition should be false")
+	}
+	if got := eval(t, engine, `size(state.items)`, globals).Value(); got != int64(2) {
+		t.Errorf("size = %#v, want int64(2)", got)
+	}
+	if got := eval(t, engine, `state.name.upperAscii()`, globals).Value(); got != "ADA" {
+		t.Errorf("upperAscii = %#v", got)
+	}
+	if got := eval(t, engine, `inputs.timeout > duration("1s")`, globals).Value(); got != true {
+		t.Errorf("duration comparison = %#v", got)
+	}
+	items, err := eval(t, engine, `state.items.map(i, i + "!")`, globals).Items()
+	if err != nil || len(items) != 2 || items[0] != "a!" {
+		t.Errorf("items = %#v, %v", items, err)
+	}
+	m, _ := eval(t, engine, `{"role": state.user.role}`, globals).Value().(map[string]any)
+	if m["role"] != "admin" {
+		t.Errorf("map = %#v", m)
+	}
+	if !eval(t, engine, `steps["Fetch Data"].status == 200 && !("other" in steps)`, globals).IsTruthy() {
+		t.Error("steps condition should be true")
+	}
+	if got := eval(t, engine, `null`, globals).String(); got != "" {
+		t.Errorf("null string = %q", got)
+	}
+}
+
+func TestEngineErrors(t *testing.T) {
+	engine := celscript.NewCELEngine()
+
+	// Undeclared globals and syntax errors fail at compile time.
+	for _, code := range []string{`missing.a`, `state.x = 1`, `state.count >`} {
+		if _, err := engine.Compile(context.Background(), code); err == nil {
+			t.Errorf("compile %q: expected error", code)
+		}
+	}
+
+	// Reading a missing key is an evaluation error.
+	compiled, err := engine.Compile(context.Background(), `state.missing`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := compiled.Evaluate(context.Background(), map[string]any{"state": map[string]any{}}); err == nil {
+		t.Error("expected no such key error")
+	}
+
+	// The cost limit stops runaway expressions.
+	limited := celscript.NewCELEngine(celscript.WithCostLimit(10))
+	compiled, err = limited.Compile(context.Background(), `state.items.all(i, i > 0)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := make([]any, 100)
+	for i := range items {
+		items[i] = i + 1
+	}
+	_, err = compiled.Evaluate(context.Background(), map[string]any{"state": map[string]any{"items": items}})
+	if err == nil || !strings.Contains(err.Error(), "cost limit") {
+		t.Errorf("err = %v, want cost limit error", err)
+	}
+}
+
+func TestEngineInWorkflow(t *testing.T) {
+	wf, err := workflow.New(workflow.Options{
+		Name:   "cel-workflow",
+		Inputs: []*workflow.Input{{Name: "threshold", Type: "number"}},
+		State:  map[string]any{"score": 7},
+		Steps: []*workflow.Step{
+			{
+				Name:       "route",
+				Activity:   "greet",
+				Parameters: map[string]any{"message": "hi"},
+				Next: []*workflow.Edge{
+					{Step: "high", Condition: `state.score >= inputs.threshold && steps.route.message == "hi"`},
+					{Step: "low", Condition: `state.score < inputs.threshold`},
+				},
+			},
+			{
+				Name:       "high",
+				Activity:   "greet",
+				Parameters: map[string]any{"message": "score ${state.score} of ${inputs.threshold}", "score": "${state.score * 10}"},
+				Store:      "result",
+			},
+			{Name: "low", Activity: "greet", Store: "result"},
+		},
+		Outputs: []*workflow.Output{{Name: "result", Variable: "result"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg := workflow.NewActivityRegistry()
+	reg.MustRegister(workflow.ActivityFunc("greet", func(ctx workflow.Context, params map[string]any) (any, error) {
+		return params, nil
+	}))
+	exec, err := workflow.NewExecution(wf, reg,
+		workflow.WithScriptCompiler(celscript.NewCELEngine()),
+		workflow.WithInputs(map[string]any{"threshold": 5}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := exec.Execute(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != workflow.ExecutionStatusCompleted {
+		t.Fatalf("status = %s, error = %v", result.Status, result.Error)
+	}
+	params, _ := result.Outputs["result"].(map[string]any)
+	if params["message"] != "score 7 of 5" || params["score"] != int64(70) {
+		t.Fatalf("result = %#v", params)
+	}
+
+	// Invalid CEL is reported when the execution is created.
+	bad, err := workflow.New(workflow.Options{
+		Name: "cel-invalid",
+		Steps: []*workflow.Step{
+			{Name: "a", Next: []*workflow.Edge{{Step: "b", Condition: `state.x ==`}}},
+			{Name: "b"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := workflow.NewExecution(bad, reg, workflow.WithScriptCompiler(celscript.NewCELEngine())); err == nil {
+		t.Fatal("expected validation error for invalid condition")
+	}
+}
+
+func TestEngineResultInWorkflow(t *testing.T) {
+	wf, err := workflow.New(workflow.Options{
+		Name:  "cel-result",
+		State: map[string]any{"scores": []any{3, 8, 9}},
+		Steps: []*workflow.Step{
+			{
+				Name:            "fetch",
+				Activity:        "double",
+				Parameters:      map[string]any{"n": 21},
+				StoreExpression: `result + 1`,
+				Store:           "answer",
+				Next:            []*workflow.Edge{{Step: "check"}},
+			},
+			{
+				Name:  "check",
+				Store: "passing",
+				MapReduce: &workflow.MapReduceConfig{
+					Items:      "state.scores",
+					As:         "score",
+					Activity:   "double",
+					Parameters: map[string]any{"n": "${state.score}"},
+					Reduce:     `result.filter(r, r > 10).size()`,
+				},
+			},
+		},
+		Outputs: []*workflow.Output{
+			{Name: "answer", Variable: "answer"},
+			{Name: "passing", Variable: "passing"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg := workflow.NewActivityRegistry()
+	reg.MustRegister(workflow.ActivityFunc("double", func(ctx workflow.Context, params map[string]any) (any, error) {
+		switch n := params["n"].(type) {
+		case int:
+			return n * 2, nil
+		case int64:
+			return n * 2, nil
+		}
+		return nil, fmt.Errorf("n = %#v", params["n"])
+	}))
+	exec, err := workflow.NewExecution(wf, reg, workflow.WithScriptCompiler(celscript.NewCELEngine()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := exec.Execute(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != workflow.ExecutionStatusCompleted {
+		t.Fatalf("status = %s, error = %v", result.Status, result.Error)
+	}
+	if got := result.Outputs["answer"]; got != int64(43) {
+		t.Errorf("answer = %#v, want int64(43)", got)
+	}
+	if got := result.Outputs["passing"]; got != int64(2) {
+		t.Errorf("passing = %#v, want int64(2)", got)
+	}
+}
@@ -0,0 +1,22 @@
+module github.com/deepnoodle-ai/workflow/experimental/celscript
+
+go 1.26.1
+
+require (
+	github.com/deepnoodle-ai/workflow v0.0.0-00010101000000-000000000000
+	github.com/google/cel-go v0.26.1
+)
+
+require (
+	cel.dev/expr v0.24.0 // indirect
+	github.com/antlr4-go/antlr/v4 v4.13.0 // indirect
+	github.com/deepnoodle-ai/expr v0.0.1 // indirect
+	github.com/stoewer/go-strcase v1.2.0 // indirect
+	golang.org/x/exp v0.0.0-20230515195305-f3d0a9c9a5cc // indirect
+	golang.org/x/text v0.22.0 // indirect
+	google.golang.org/genproto/googleapis/api v0.0.0-20240826202546-f6391c0de4c7 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240826202546-f6391c0de4c7 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+)
+
+replace github.com/deepnoodle-ai/workflow => ../../
@@ -0,0 +1,28 @@
+// Package grpcx provides an activity for calling unary gRPC methods.
+//
+// NewGRPCActivity returns an activity registered as "grpc". Given a
+// target, a fully-qualified method, and a request map, it resolves the
+// method's descriptors from GRPCOptions.Files or through the server
+// reflection service, encodes the request as protobuf JSON, and returns
+// the response as a map:
+//
+//	reg.MustRegister(grpcx.NewGRPCActivity(grpcx.GRPCOptions{}))
+//
+//	{
+//	    Name:     "Lookup",
+//	    Activity: "grpc",
+//	    Parameters: map[string]any{
+//	        "target":   "orders.internal:50051",
+//	        "method":   "orders.v1.Orders/GetOrder",
+//	        "request":  map[string]any{"id": "${inputs.order_id}"},
+//	        "metadata": map[string]any{"authorization": "Bearer ${inputs.token}"},
+//	        "timeout":  "5s",
+//	    },
+//	    Store: "order",
+//	}
+//
+// gRPC status codes are mapped to workflow error types by ErrorType so
+// retry and catch configs apply: DEADLINE_EXCEEDED is a timeout, codes a
+// retry cannot fix are fatal, and the rest become "grpc.<CODE>" types
+// such as "grpc.UNAVAILABLE".
+package grpcx
@@ -0,0 +1,319 @@
+package grpcx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// GRPCInput defines the input parameters for the gRPC activity
+type GRPCInput struct {
+	Target   string            `json:"target"`   // host:port or any grpc.NewClient target
+	Method   string            `json:"method"`   // fully-qualified, e.g. "pkg.Service/Method"
+	Request  map[string]any    `json:"request"`  // request message in protobuf JSON form
+	Metadata map[string]string `json:"metadata"` // outgoing request headers
+	Timeout  time.Duration     `json:"timeout"`  // 0 uses GRPCOptions.DefaultTimeout
+}
+
+// GRPCOptions configures the gRPC activity.
+type GRPCOptions struct {
+	// Files resolves service and message descriptors. When nil, or when
+	// a method is not found in it, descriptors are fetched from the
+	// target through the gRPC server reflection service.
+	Files *protoregistry.Files
+
+	// DialOptions are passed to grpc.NewClient for every target. When
+	// empty, connections use insecure transport credentials.
+	DialOptions []grpc.DialOption
+
+	// DefaultTimeout bounds each call when the input sets no timeout.
+	// Defaults to 30 seconds.
+	DefaultTimeout time.Duration
+}
+
+// GRPCActivity calls unary gRPC methods using dynamic protobuf messages.
+// Connections are opened lazily and reused per target; call Close to
+// release them.
+type GRPCActivity struct {
+	opts GRPCOptions
+
+	mu      sync.Mutex
+	conns   map[string]*grpc.ClientConn
+	methods map[string]protoreflect.MethodDescriptor // target + method -> descriptor
+}
+
+// NewGRPCActivity returns the gRPC activity, registered as "grpc".
+func NewGRPCActivity(opts GRPCOptions) *GRPCActivity {
+	if opts.DefaultTimeout <= 0 {
+		opts.DefaultTimeout = 30 * time.Second
+	}
+	if len(opts.DialOptions) == 0 {
+		opts.DialOptions = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	return &GRPCActivity{
+		opts:    opts,
+		conns:   map[string]*grpc.ClientConn{},
+		methods: map[string]protoreflect.MethodDescriptor{},
+	}
+}
+
+func (a *GRPCActivity) Name() string {
+	return "grpc"
+}
+
+// Execute implements workflow.Activity.
+func (a *GRPCActivity) Execute(ctx workflow.Context, params map[string]any) (any, error) {
+	var input GRPCInput
+	if err := decodeInput(params, &input); err != nil {
+		return nil, workflow.NewWorkflowError(workflow.ErrorTypeFatal, err.Error())
+	}
+	return a.Call(ctx, input)
+}
+
+// Call invokes a unary method and returns the response as a map in
+// protobuf JSON form. gRPC status errors are returned as
+// *workflow.WorkflowError values; see ErrorType for the mapping.
+func (a *GRPCActivity) Call(ctx context.Context, input GRPCInput) (map[string]any, error) {
+	if input.Target == "" {
+		return nil, workflow.NewWorkflowError(workflow.ErrorTypeFatal, "target cannot be empty")
+	}
+	service, method, err := splitMethod(input.Method)
+	if err != nil {
+		return nil, workflow.NewWorkflowError(workflow.ErrorTypeFatal, err.Error())
+	}
+
+	timeout := input.Timeout
+	if timeout <= 0 {
+		timeout = a.opts.DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := a.conn(input.Target)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := a.method(ctx, conn, input.Target, service, method)
+	if err != nil {
+		return nil, err
+	}
+	if desc.IsStreamingClient() || desc.IsStreamingServer() {
+		return nil, workflow.NewWorkflowError(workflow.ErrorTypeFatal,
+			fmt.Sprintf("method %s is streaming; only unary methods are supported", desc.FullName()))
+	}
+
+	request := dynamicpb.NewMessage(desc.Input())
+	if input.Request != nil {
+		data, err := json.Marshal(input.Request)
+		if err != nil {
+			return nil, workflow.NewWorkflowError(workflow.ErrorTypeFatal, fmt.Sprintf("failed to marshal request: %v", err))
+		}
+		if err := protojson.Unmarshal(data, request); err != nil {
+			return nil, workflow.NewWorkflowError(workflow.ErrorTypeFatal,
+				fmt.Sprintf("request does not match %s: %v", desc.Input().FullName(), err))
+		}
+	}
+	if len(input.Metadata) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(input.Metadata))
+	}
+
+	response := dynamicpb.NewMessage(desc.Output())
+	if err := conn.Invoke(ctx, "/"+service+"/"+method, request, response); err != nil {
+		return nil, statusError(err)
+	}
+
+	data, err := protojson.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+	result := map[string]any{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return result, nil
+}
+
+// Close closes every connection opened by the activity.
+func (a *GRPCActivity) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var firstErr error
+	for target, conn := range a.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(a.conns, target)
+	}
+	return firstErr
+}
+
+func (a *GRPCActivity) conn(target string) (*grpc.ClientConn, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if conn, ok := a.conns[target]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.NewClient(target, a.opts.DialOptions...)
+	if err != nil {
+		return nil, workflow.NewWorkflowError(workflow.ErrorTypeFatal, fmt.Sprintf("invalid target %q: %v", target, err))
+	}
+	a.conns[target] = conn
+	return conn, nil
+}
+
+// method resolves a method descriptor from the configured files, then
+// from server reflection. Reflected descriptors are cached per target.
+func (a *GRPCActivity) method(ctx context.Context, conn *grpc.ClientConn, target, service, method string) (protoreflect.MethodDescriptor, error) {
+	if a.opts.Files != nil {
+		if desc, ok := findMethod(a.opts.Files, service, method); ok {
+			return desc, nil
+		}
+	}
+
+	key := target + "/" + service + "/" + method
+	a.mu.Lock()
+	desc, ok := a.methods[key]
+	a.mu.Unlock()
+	if ok {
+		return desc, nil
+	}
+
+	files, err := reflectFiles(ctx, conn, service)
+	if err != nil {
+		return nil, err
+	}
+	desc, ok = findMethod(files, service, method)
+	if !ok {
+		return nil, workflow.NewWorkflowError(workflow.ErrorTypeFatal,
+			fmt.Sprintf("method %s/%s not found on %s", service, method, target))
+	}
+	a.mu.Lock()
+	a.methods[key] = desc
+	a.mu.Unlock()
+	return desc, nil
+}
+
+func findMethod(files *protoregistry.Files, service, method string) (protoreflect.MethodDescriptor, bool) {
+	d, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, false
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, false
+	}
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	return md, md != nil
+}
+
+// splitMethod accepts "pkg.Service/Method", "/pkg.Service/Method", or
+// "pkg.Service.Method".
+func splitMethod(fullMethod string) (service, method string, err error) {
+	name := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(name, "/"); i > 0 {
+		service, method = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, "."); i > 0 {
+		service, method = name[:i], name[i+1:]
+	}
+	if service == "" || method == "" {
+		return "", "", fmt.Errorf("method %q must be fully qualified, e.g. \"pkg.Service/Method\"", fullMethod)
+	}
+	return service, method, nil
+}
+
+// ErrorType returns the workflow error type a gRPC status code maps to.
+//
+//   - DEADLINE_EXCEEDED maps to workflow.ErrorTypeTimeout.
+//   - Codes that a retry cannot fix (INVALID_ARGUMENT, NOT_FOUND,
+//     ALREADY_EXISTS, PERMISSION_DENIED, UNAUTHENTICATED,
+//     FAILED_PRECONDITION, OUT_OF_RANGE, UNIMPLEMENTED) map to
+//     workflow.ErrorTypeFatal.
+//   - Everything else maps to "grpc." plus the code name, for example
+//     "grpc.UNAVAILABLE", so retry and catch configs can target it;
+//     these also match the "all" and "activity_failed" patterns.
+func ErrorType(code codes.Code) string {
+	switch code {
+	case codes.DeadlineExceeded:
+		return workflow.ErrorTypeTimeout
+	case codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.Unauthenticated, codes.FailedPrecondition,
+		codes.OutOfRange, codes.Unimplemented:
+		return workflow.ErrorTypeFatal
+	default:
+		return "grpc." + codeName(code)
+	}
+}
+
+// statusError converts a gRPC call error into a classified WorkflowError.
+// Details carries the status code name and message.
+func statusError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	return &workflow.WorkflowError{
+		Type:  ErrorType(st.Code()),
+		Cause: fmt.Sprintf("grpc %s: %s", codeName(st.Code()), st.Message()),
+		Details: map[string]any{
+			"code":    codeName(st.Code()),
+			"message": st.Message(),
+		},
+		Wrapped: err,
+	}
+}
+
+// codeName returns the canonical upper-case name of a status code.
+func codeName(code codes.Code) string {
+	name := code.String()
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToUpper(b.String())
+}
+
+// decodeInput converts activity parameters into a GRPCInput. Timeouts may
+// be given as a duration string ("5s") or as nanoseconds.
+func decodeInput(params map[string]any, input *GRPCInput) error {
+	params = cloneParams(params)
+	if raw, ok := params["timeout"].(string); ok {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %q: %w", raw, err)
+		}
+		params["timeout"] = d
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+	if err := json.Unmarshal(data, input); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+	return nil
+}
+
+func cloneParams(params map[string]any) map[string]any {
+	out := make(map[string]any, len(params))
+	for k, v := range params {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,190 @@
+package grpcx_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow"
+	"github.com/deepnoodle-ai/workflow/experimental/grpcx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// startServer serves the standard health service, optionally with server
+// reflection, and returns its address.
+func startServer(t *testing.T, withReflection bool, interceptor grpc.UnaryServerInterceptor) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var opts []grpc.ServerOption
+	if interceptor != nil {
+		opts = append(opts, grpc.UnaryInterceptor(interceptor))
+	}
+	srv := grpc.NewServer(opts...)
+	hs := health.NewServer()
+	hs.SetServingStatus("orders", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	if withReflection {
+		reflection.Register(srv)
+	}
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func runActivity(t *testing.T, activity *grpcx.GRPCActivity, params map[string]any) (*workflow.ExecutionResult, map[string]any) {
+	t.Helper()
+	wf, err := workflow.New(workflow.Options{
+		Name: "grpc-test",
+		Steps: []*workflow.Step{
+			{Name: "call", Activity: "grpc", Parameters: params, Store: "response"},
+		},
+		Outputs: []*workflow.Output{{Name: "response", Variable: "response"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := workflow.NewActivityRegistry()
+	reg.MustRegister(activity)
+	exec, err := workflow.NewExecution(wf, reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := exec.Execute(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, _ := result.Outputs["response"].(map[string]any)
+	return result, response
+}
+
+func TestGRPCActivity_Reflection(t *testing.T) {
+	var gotHeader []string
+	addr := startServer(t, true, func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		gotHeader = md.Get("x-request-id")
+		return handler(ctx, req)
+	})
+	activity := grpcx.NewGRPCActivity(grpcx.GRPCOptions{})
+	defer activity.Close()
+
+	result, response := runActivity(t, activity, map[string]any{
+		"target":   addr,
+		"method":   "grpc.health.v1.Health/Check",
+		"request":  map[string]any{"service": "orders"},
+		"metadata": map[string]any{"x-request-id": "abc"},
+		"timeout":  "5s",
+	})
+	if result.Status != workflow.ExecutionStatusCompleted {
+		t.Fatalf("status = %s, error = %v", result.Status, result.Error)
+	}
+	if response["status"] != "SERVING" {
+		t.Fatalf("response = %v, want status SERVING", response)
+	}
+	if len(gotHeader) != 1 || gotHeader[0] != "abc" {
+		t.Fatalf("x-request-id header = %v, want [abc]", gotHeader)
+	}
+}
+
+func TestGRPCActivity_RegisteredDescriptors(t *testing.T) {
+	// No reflection: the descriptor must come from GRPCOptions.Files.
+	addr := startServer(t, false, nil)
+	activity := grpcx.NewGRPCActivity(grpcx.GRPCOptions{Files: protoregistry.GlobalFiles})
+	defer activity.Close()
+
+	response, err := activity.Call(context.Background(), grpcx.GRPCInput{
+		Target:  addr,
+		Method:  "/grpc.health.v1.Health/Check",
+		Request: map[string]any{"service": "orders"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response["status"] != "SERVING" {
+		t.Fatalf("response = %v, want status SERVING", response)
+	}
+
+	// Without descriptors or reflection the method cannot be resolved.
+	bare := grpcx.NewGRPCActivity(grpcx.GRPCOptions{})
+	defer bare.Close()
+	_, err = bare.Call(context.Background(), grpcx.GRPCInput{Target: addr, Method: "grpc.health.v1.Health/Check"})
+	if !workflow.MatchesErrorType(err, workflow.ErrorTypeFatal) {
+		t.Fatalf("err = %v, want fatal", err)
+	}
+}
+
+func TestGRPCActivity_StatusErrors(t *testing.T) {
+	addr := startServer(t, true, nil)
+	activity := grpcx.NewGRPCActivity(grpcx.GRPCOptions{})
+	defer activity.Close()
+
+	// The health server answers NOT_FOUND for unknown services.
+	_, err := activity.Call(context.Background(), grpcx.GRPCInput{
+		Target:  addr,
+		Method:  "grpc.health.v1.Health/Check",
+		Request: map[string]any{"service": "missing"},
+	})
+	var wfErr *workflow.WorkflowError
+	if !errors.As(err, &wfErr) {
+		t.Fatalf("err = %v, want *workflow.WorkflowError", err)
+	}
+	if wfErr.Type != workflow.ErrorTypeFatal {
+		t.Fatalf("type = %q, want %q", wfErr.Type, workflow.ErrorTypeFatal)
+	}
+	if details, _ := wfErr.Details.(map[string]any); details["code"] != "NOT_FOUND" {
+		t.Fatalf("details = %v, want code NOT_FOUND", wfErr.Details)
+	}
+
+	// Streaming methods are rejected up front.
+	_, err = activity.Call(context.Background(), grpcx.GRPCInput{
+		Target: addr,
+		Method: "grpc.health.v1.Health/Watch",
+	})
+	if !workflow.MatchesErrorType(err, workflow.ErrorTypeFatal) {
+		t.Fatalf("err = %v, want fatal", err)
+	}
+
+	// A server that takes longer than the timeout surfaces as a timeout.
+	slow := startServer(t, true, func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	_, err = activity.Call(context.Background(), grpcx.GRPCInput{
+		Target:  slow,
+		Method:  "grpc.health.v1.Health/Check",
+		Timeout: 100 * time.Millisecond,
+	})
+	if !workflow.MatchesErrorType(err, workflow.ErrorTypeTimeout) {
+		t.Fatalf("err = %v, want timeout", err)
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	cases := map[codes.Code]string{
+		codes.DeadlineExceeded:  workflow.ErrorTypeTimeout,
+		codes.InvalidArgument:   workflow.ErrorTypeFatal,
+		codes.Unimplemented:     workflow.ErrorTypeFatal,
+		codes.Unavailable:       "grpc.UNAVAILABLE",
+		codes.ResourceExhausted: "grpc.RESOURCE_EXHAUSTED",
+	}
+	for code, want := range cases {
+		if got := grpcx.ErrorType(code); got != want {
+			t.Errorf("ErrorType(%s) = %q, want %q", code, got, want)
+		}
+	}
+	// Retryable codes still match the catch-all patterns.
+	err := workflow.NewWorkflowError(grpcx.ErrorType(codes.Unavailable), "down")
+	if !workflow.MatchesErrorType(err, workflow.ErrorTypeActivityFailed) {
+		t.Error("UNAVAILABLE should match activity_failed")
+	}
+}
@@ -0,0 +1,107 @@
+package grpcx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/deepnoodle-ai/workflow"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// reflectFiles asks the server reflection service for the file that
+// declares symbol and every file it depends on. Dependencies already
+// linked into the binary (well-known types, for example) are taken from
+// protoregistry.GlobalFiles when the server does not send them.
+func reflectFiles(ctx context.Context, conn *grpc.ClientConn, symbol string) (*protoregistry.Files, error) {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, reflectionError(err)
+	}
+	defer stream.CloseSend()
+
+	protos := map[string]*descriptorpb.FileDescriptorProto{}
+	request := func(req *reflectionpb.ServerReflectionRequest) error {
+		if err := stream.Send(req); err != nil {
+			return reflectionError(err)
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return reflectionError(err)
+		}
+		if e := resp.GetErrorResponse(); e != nil {
+			return &workflow.WorkflowError{
+				Type:  ErrorType(codes.Code(e.GetErrorCode())),
+				Cause: fmt.Sprintf("grpc reflection: %s", e.GetErrorMessage()),
+			}
+		}
+		for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fd := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(raw, fd); err != nil {
+				return fmt.Errorf("grpc reflection: invalid file descriptor: %w", err)
+			}
+			protos[fd.GetName()] = fd
+		}
+		return nil
+	}
+
+	if err := request(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+	}); err != nil {
+		return nil, err
+	}
+
+	// Fetch dependencies the server did not include in its first answer.
+	for {
+		missing := ""
+		for _, fd := range protos {
+			for _, dep := range fd.GetDependency() {
+				if _, ok := protos[dep]; ok {
+					continue
+				}
+				if linked, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+					protos[dep] = protodesc.ToFileDescriptorProto(linked)
+					continue
+				}
+				missing = dep
+			}
+		}
+		if missing == "" {
+			break
+		}
+		if err := request(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: missing},
+		}); err != nil {
+			return nil, err
+		}
+		if _, ok := protos[missing]; !ok {
+			return nil, fmt.Errorf("grpc reflection: server did not return %q", missing)
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range protos {
+		set.File = append(set.File, fd)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("grpc reflection: %w", err)
+	}
+	return files, nil
+}
+
+// reflectionError explains a missing reflection service, which is the
+// usual reason descriptor lookup fails.
+func reflectionError(err error) error {
+	if status.Code(err) == codes.Unimplemented {
+		return workflow.NewWorkflowError(workflow.ErrorTypeFatal,
+			"grpc server reflection is not available; register descriptors with GRPCOptions.Files")
+	}
+	return statusError(err)
+}
@@ -0,0 +1,11 @@
+// Package metrics provides Prometheus instrumentation for workflow
+// executions.
+//
+// PrometheusCallbacks implements workflow.ExecutionCallbacks and
+// records workflow and activity counters and an activity duration
+// histogram, plus a per-step duration histogram with WithStepLabels.
+// Attach it with workflow.WithExecutionCallbacks, or add it
+// to a workflow.CallbackChain alongside other callbacks. The collectors
+// are exported so consumers can register them on a registry of their
+// choosing.
+package metrics
@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/deepnoodle-ai/workflow"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ workflow.ExecutionCallbacks = (*PrometheusCallbacks)(nil)
+
+// Status label values recorded on completion metrics.
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+// PrometheusCallbacks records workflow and activity metrics. All
+// collectors are safe for concurrent use, so a single instance can be
+// shared by every execution in a process.
+type PrometheusCallbacks struct {
+	workflow.BaseExecutionCallbacks
+
+	// WorkflowStarted counts workflow executions started, labeled by
+	// workflow name.
+	WorkflowStarted *prometheus.CounterVec
+	// WorkflowCompleted counts workflow executions that finished,
+	// labeled by workflow name and status (success or failure).
+	WorkflowCompleted *prometheus.CounterVec
+	// ActivityStarted counts activity invocations, labeled by
+	// activity name.
+	ActivityStarted *prometheus.CounterVec
+	// ActivityCompleted counts finished activity invocations, labeled
+	// by activity name and status.
+	ActivityCompleted *prometheus.CounterVec
+	// ActivityDuration observes activity durations in seconds, labeled
+	// by activity name and status.
+	ActivityDuration *prometheus.HistogramVec
+	// StepDuration observes the duration of each activity invocation
+	// in seconds, labeled by workflow name, step name, and status. It
+	// is nil unless WithStepLabels is passed.
+	StepDuration *prometheus.HistogramVec
+}
+
+// Option configures PrometheusCallbacks.
+type Option func(*options)
+
+type options struct {
+	stepLabels bool
+}
+
+// WithStepLabels adds the StepDuration histogram. Step names are
+// usually bounded by the workflows a process runs, but every step of
+// every workflow becomes its own series, so it is opt-in.
+func WithStepLabels() Option {
+	return func(o *options) { o.stepLabels = true }
+}
+
+// NewPrometheusCallbacks creates the collectors and, when registry is
+// non-nil, registers them on it. Pass a nil registry to register the
+// collectors yourself via Collectors.
+func NewPrometheusCallbacks(registry *prometheus.Registry, opts ...Option) (*PrometheusCallbacks, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	c := &PrometheusCallbacks{
+		WorkflowStarted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "workflow",
+			Name:      "executions_started_total",
+			Help:      "Number of workflow executions started.",
+		}, []string{"workflow"}),
+		WorkflowCompleted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "workflow",
+			Name:      "executions_completed_total",
+			Help:      "Number of workflow executions completed, by status.",
+		}, []string{"workflow", "status"}),
+		ActivityStarted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "workflow",
+			Name:      "activities_started_total",
+			Help:      "Number of activity invocations started.",
+		}, []string{"activity"}),
+		ActivityCompleted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "workflow",
+			Name:      "activities_completed_total",
+			Help:      "Number of activity invocations completed, by status.",
+		}, []string{"activity", "status"}),
+		ActivityDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "workflow",
+			Name:      "activity_duration_seconds",
+			Help:      "Activity execution duration in seconds.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"activity", "status"}),
+	}
+	if o.stepLabels {
+		c.StepDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "workflow",
+			Name:      "step_duration_seconds",
+			Help:      "Step activity execution duration in seconds, by step.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"workflow", "step", "status"})
+	}
+	if registry != nil {
+		for _, collector := range c.Collectors() {
+			if err := registry.Register(collector); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return c, nil
+}
+
+// Collectors returns every collector owned by the callbacks.
+func (c *PrometheusCallbacks) Collectors() []prometheus.Collector {
+	collectors := []prometheus.Collector{
+		c.WorkflowStarted,
+		c.WorkflowCompleted,
+		c.ActivityStarted,
+		c.ActivityCompleted,
+		c.ActivityDuration,
+	}
+	if c.StepDuration != nil {
+		collectors = append(collectors, c.StepDuration)
+	}
+	return collectors
+}
+
+func (c *PrometheusCallbacks) BeforeWorkflowExecution(ctx context.Context, event *workflow.WorkflowExecutionEvent) {
+	c.WorkflowStarted.WithLabelValues(event.WorkflowName).Inc()
+}
+
+func (c *PrometheusCallbacks) AfterWorkflowExecution(ctx context.Context, event *workflow.WorkflowExecutionEvent) {
+	c.WorkflowCompleted.WithLabelValues(event.WorkflowName, workflowStatus(event)).Inc()
+}
+
+func (c *PrometheusCallbacks) BeforeActivityExecution(ctx context.Context, event *workflow.ActivityExecutionEvent) {
+	c.ActivityStarted.WithLabelValues(event.ActivityName).Inc()
+}
+
+func (c *PrometheusCallbacks) AfterActivityExecution(ctx context.Context, event *workflow.ActivityExecutionEvent) {
+	status := StatusSuccess
+	if event.Error != nil {
+		status = StatusFailure
+	}
+	c.ActivityCompleted.WithLabelValues(event.ActivityName, status).Inc()
+	c.ActivityDuration.WithLabelValues(event.ActivityName, status).Observe(event.Duration.Seconds())
+	if c.StepDuration != nil {
+		c.StepDuration.WithLabelValues(event.WorkflowName, event.StepName, status).Observe(event.Duration.Seconds())
+	}
+}
+
+// workflowStatus maps a finished execution to a status label. Suspended
+// and paused executions are not failures; they count as successes of
+// this particular run.
+func workflowStatus(event *workflow.WorkflowExecutionEvent) string {
+	if event.Error != nil || event.Status == workflow.ExecutionStatusFailed {
+		return StatusFailure
+	}
+	return StatusSuccess
+}
@@ -0,0 +1,155 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow"
+	"github.com/deepnoodle-ai/workflow/experimental/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestPrometheusCallbacks_RecordsExecution(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	callbacks, err := metrics.NewPrometheusCallbacks(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wf, err := workflow.New(workflow.Options{
+		Name: "metrics-test",
+		Steps: []*workflow.Step{
+			{Name: "ok", Activity: "ok", Next: []*workflow.Edge{{Step: "boom"}}},
+			{Name: "boom", Activity: "boom"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := workflow.NewActivityRegistry()
+	reg.MustRegister(workflow.ActivityFunc("ok", func(ctx workflow.Context, params map[string]any) (any, error) {
+		return "fine", nil
+	}))
+	reg.MustRegister(workflow.ActivityFunc("boom", func(ctx workflow.Context, params map[string]any) (any, error) {
+		return nil, errors.New("boom")
+	}))
+
+	exec, err := workflow.NewExecution(wf, reg, workflow.WithExecutionCallbacks(callbacks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := exec.Execute(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != workflow.ExecutionStatusFailed {
+		t.Fatalf("status = %s, want failed", result.Status)
+	}
+
+	checks := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"workflow started", testutil.ToFloat64(callbacks.WorkflowStarted.WithLabelValues("metrics-test")), 1},
+		{"workflow failed", testutil.ToFloat64(callbacks.WorkflowCompleted.WithLabelValues("metrics-test", metrics.StatusFailure)), 1},
+		{"ok started", testutil.ToFloat64(callbacks.ActivityStarted.WithLabelValues("ok")), 1},
+		{"ok succeeded", testutil.ToFloat64(callbacks.ActivityCompleted.WithLabelValues("ok", metrics.StatusSuccess)), 1},
+		{"boom failed", testutil.ToFloat64(callbacks.ActivityCompleted.WithLabelValues("boom", metrics.StatusFailure)), 1},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if n := testutil.CollectAndCount(callbacks.ActivityDuration); n != 2 {
+		t.Errorf("activity duration series = %d, want 2", n)
+	}
+}
+
+func TestPrometheusCallbacks_NilRegistry(t *testing.T) {
+	callbacks, err := metrics.NewPrometheusCallbacks(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewRegistry()
+	for _, c := range callbacks.Collectors() {
+		if err := registry.Register(c); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	}
+	// Registering the same collectors twice must fail rather than
+	// silently double count.
+	if _, err := metrics.NewPrometheusCallbacks(registry); err == nil {
+		t.Fatal("expected duplicate registration error")
+	}
+}
+
+func TestPrometheusCallbacks_StepLabels(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	callbacks, err := metrics.NewPrometheusCallbacks(registry, metrics.WithStepLabels())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same activity runs at two steps; each gets its own series.
+	wf, err := workflow.New(workflow.Options{
+		Name: "steps-test",
+		Steps: []*workflow.Step{
+			{Name: "first", Activity: "ok", Next: []*workflow.Edge{{Step: "second"}}},
+			{Name: "second", Activity: "ok"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := workflow.NewActivityRegistry()
+	reg.MustRegister(workflow.ActivityFunc("ok", func(ctx workflow.Context, params map[string]any) (any, error) {
+		return "fine", nil
+	}))
+	exec, err := workflow.NewExecution(wf, reg, workflow.WithExecutionCallbacks(callbacks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := exec.Execute(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := testutil.CollectAndCount(callbacks.StepDuration, "workflow_step_duration_seconds"); n != 2 {
+		t.Errorf("step duration series = %d, want 2", n)
+	}
+	for _, step := range []string{"first", "second"} {
+		observer := callbacks.StepDuration.WithLabelValues("steps-test", step, metrics.StatusSuccess)
+		if got := histogramCount(t, observer.(prometheus.Histogram)); got != 1 {
+			t.Errorf("step %q observations = %d, want 1", step, got)
+		}
+	}
+	if n := testutil.CollectAndCount(callbacks.ActivityDuration); n != 1 {
+		t.Errorf("activity duration series = %d, want 1", n)
+	}
+}
+
+func TestPrometheusCallbacks_StepLabelsOptIn(t *testing.T) {
+	callbacks, err := metrics.NewPrometheusCallbacks(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if callbacks.StepDuration != nil {
+		t.Fatal("StepDuration should be nil without WithStepLabels")
+	}
+	if n := len(callbacks.Collectors()); n != 5 {
+		t.Errorf("collectors = %d, want 5", n)
+	}
+}
+
+func histogramCount(t *testing.T, h prometheus.Histogram) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := h.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
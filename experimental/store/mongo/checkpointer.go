@@ -0,0 +1,367 @@
+package mongo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow"
+)
+
+// ErrNotFound is returned by Collection.FindOne when no document has
+// the given _id. Adapters translate their driver's no-documents error
+// (mongo.ErrNoDocuments in the official driver) to it.
+var ErrNotFound = errors.New("mongo: document not found")
+
+// Collection is the subset of a MongoDB collection the Checkpointer
+// needs. Filters are equality matches on top-level fields, so an
+// adapter can pass them to the driver as bson.M unchanged.
+// Implementations must be safe for concurrent use.
+type Collection interface {
+	// ReplaceOne replaces the document whose _id is id with doc,
+	// inserting doc when there is none (ReplaceOne with upsert).
+	ReplaceOne(ctx context.Context, id string, doc map[string]any) error
+
+	// FindOne returns the document whose _id is id, or ErrNotFound.
+	FindOne(ctx context.Context, id string) (map[string]any, error)
+
+	// Find returns every document matching filter. With a non-empty
+	// projection only those fields (and _id) are returned.
+	Find(ctx context.Context, filter map[string]any, projection []string) ([]map[string]any, error)
+
+	// DeleteMany removes every document matching filter.
+	DeleteMany(ctx context.Context, filter map[string]any) error
+}
+
+// Document field names.
+const (
+	fieldExecutionID  = "execution_id"
+	fieldCheckpointID = "checkpoint_id"
+	fieldWorkflowName = "workflow_name"
+	fieldStatus       = "status"
+	fieldStartTime    = "start_time"
+	fieldEndTime      = "end_time"
+	fieldCheckpointAt = "checkpoint_at"
+	fieldError        = "error"
+	fieldLabels       = "labels"
+	fieldCheckpoint   = "checkpoint"
+)
+
+// summaryFields are the fields ListExecutions reads.
+var summaryFields = []string{
+	fieldExecutionID, fieldWorkflowName, fieldStatus,
+	fieldStartTime, fieldEndTime, fieldCheckpointAt, fieldError, fieldLabels,
+}
+
+// Option configures a Checkpointer.
+type Option func(*Checkpointer)
+
+// WithHistory keeps every checkpoint in history as well as the latest
+// one, so executions can be rewound with Execution.ResumeFromCheckpoint.
+// history must be a different collection from the one given to
+// NewCheckpointer.
+func WithHistory(history Collection) Option {
+	return func(c *Checkpointer) { c.history = history }
+}
+
+// Checkpointer implements workflow.Checkpointer on top of a Collection.
+type Checkpointer struct {
+	latest  Collection
+	history Collection // nil without WithHistory
+}
+
+var (
+	_ workflow.Checkpointer          = (*Checkpointer)(nil)
+	_ workflow.VersionedCheckpointer = (*Checkpointer)(nil)
+)
+
+// NewCheckpointer returns a Checkpointer that keeps the latest
+// checkpoint of each execution in collection.
+func NewCheckpointer(collection Collection, opts ...Option) (*Checkpointer, error) {
+	if collection == nil {
+		return nil, fmt.Errorf("mongo: collection is required")
+	}
+	c := &Checkpointer{latest: collection}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// SaveCheckpoint writes the checkpoint to the history collection, when
+// there is one, and then replaces the execution's latest document.
+// Saving the same checkpoint ID again replaces the same documents, so
+// retries are idempotent.
+func (c *Checkpointer) SaveCheckpoint(ctx context.Context, checkpoint *workflow.Checkpoint) error {
+	if checkpoint == nil {
+		return fmt.Errorf("mongo: nil checkpoint")
+	}
+	if checkpoint.ExecutionID == "" || strings.Contains(checkpoint.ExecutionID, "/") {
+		return fmt.Errorf("mongo: invalid execution ID %q", checkpoint.ExecutionID)
+	}
+	body, err := toDocument(checkpoint)
+	if err != nil {
+		return fmt.Errorf("mongo: encode checkpoint: %w", err)
+	}
+	doc := map[string]any{
+		fieldExecutionID:  checkpoint.ExecutionID,
+		fieldCheckpointID: checkpoint.ID,
+		fieldWorkflowName: checkpoint.WorkflowName,
+		fieldStatus:       string(checkpoint.Status),
+		fieldStartTime:    checkpoint.StartTime.UTC(),
+		fieldEndTime:      checkpoint.EndTime.UTC(),
+		fieldCheckpointAt: checkpoint.CheckpointAt.UTC(),
+		fieldError:        checkpoint.Error,
+		fieldLabels:       labelsDocument(checkpoint.Labels),
+		fieldCheckpoint:   body,
+	}
+	if c.history != nil {
+		id := historyID(checkpoint.ExecutionID, checkpoint.ID)
+		if err := c.history.ReplaceOne(ctx, id, doc); err != nil {
+			return fmt.Errorf("mongo: save checkpoint %s: %w", id, err)
+		}
+	}
+	if err := c.latest.ReplaceOne(ctx, checkpoint.ExecutionID, doc); err != nil {
+		return fmt.Errorf("mongo: save latest checkpoint of %s: %w", checkpoint.ExecutionID, err)
+	}
+	return nil
+}
+
+// LoadCheckpoint returns the latest checkpoint of the execution, or
+// workflow.ErrNoCheckpoint when it has none.
+func (c *Checkpointer) LoadCheckpoint(ctx context.Context, executionID string) (*workflow.Checkpoint, error) {
+	doc, err := c.latest.FindOne(ctx, executionID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, workflow.ErrNoCheckpoint
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mongo: load checkpoint of %s: %w", executionID, err)
+	}
+	return fromDocument(doc)
+}
+
+// LoadCheckpointByID returns a checkpoint kept by WithHistory, or nil
+// if it does not exist.
+func (c *Checkpointer) LoadCheckpointByID(ctx context.Context, executionID, checkpointID string) (*workflow.Checkpoint, error) {
+	if c.history == nil {
+		return nil, fmt.Errorf("mongo: checkpoint history is not enabled (see WithHistory)")
+	}
+	id := historyID(executionID, checkpointID)
+	doc, err := c.history.FindOne(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mongo: load checkpoint %s: %w", id, err)
+	}
+	return fromDocument(doc)
+}
+
+// ListCheckpoints returns the IDs of the checkpoints kept by
+// WithHistory for an execution, oldest first. The engine numbers
+// checkpoints from 1, so numeric IDs are ordered by value; any others
+// sort after them by name.
+func (c *Checkpointer) ListCheckpoints(ctx context.Context, executionID string) ([]string, error) {
+	if c.history == nil {
+		return nil, fmt.Errorf("mongo: checkpoint history is not enabled (see WithHistory)")
+	}
+	docs, err := c.history.Find(ctx, map[string]any{fieldExecutionID: executionID}, []string{fieldCheckpointID})
+	if err != nil {
+		return nil, fmt.Errorf("mongo: list checkpoints of %s: %w", executionID, err)
+	}
+	ids := []string{}
+	for _, doc := range docs {
+		if id, ok := doc[fieldCheckpointID].(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, errA := strconv.Atoi(ids[i])
+		b, errB := strconv.Atoi(ids[j])
+		switch {
+		case errA == nil && errB == nil:
+			return a < b
+		case errA == nil || errB == nil:
+			return errA == nil
+		default:
+			return ids[i] < ids[j]
+		}
+	})
+	return ids, nil
+}
+
+// DeleteCheckpoint removes the execution's latest document and any
+// history kept for it.
+func (c *Checkpointer) DeleteCheckpoint(ctx context.Context, executionID string) error {
+	filter := map[string]any{fieldExecutionID: executionID}
+	if c.history != nil {
+		if err := c.history.DeleteMany(ctx, filter); err != nil {
+			return fmt.Errorf("mongo: delete checkpoint history of %s: %w", executionID, err)
+		}
+	}
+	if err := c.latest.DeleteMany(ctx, filter); err != nil {
+		return fmt.Errorf("mongo: delete checkpoint of %s: %w", executionID, err)
+	}
+	return nil
+}
+
+// ListExecutions summarizes every execution from the summary fields of
+// its latest document, without loading the checkpoints, newest first.
+func (c *Checkpointer) ListExecutions(ctx context.Context) ([]*workflow.ExecutionSummary, error) {
+	docs, err := c.latest.Find(ctx, map[string]any{}, summaryFields)
+	if err != nil {
+		return nil, fmt.Errorf("mongo: list executions: %w", err)
+	}
+	summaries := make([]*workflow.ExecutionSummary, 0, len(docs))
+	for _, doc := range docs {
+		summary := &workflow.ExecutionSummary{
+			StartTime: timeField(doc, fieldStartTime),
+			EndTime:   timeField(doc, fieldEndTime),
+		}
+		summary.ExecutionID, _ = doc[fieldExecutionID].(string)
+		summary.WorkflowName, _ = doc[fieldWorkflowName].(string)
+		summary.Status, _ = doc[fieldStatus].(string)
+		summary.Error, _ = doc[fieldError].(string)
+		summary.Labels = labelsField(doc)
+		end := summary.EndTime
+		if end.IsZero() {
+			end = timeField(doc, fieldCheckpointAt)
+		}
+		if !summary.StartTime.IsZero() && !end.IsZero() {
+			summary.Duration = end.Sub(summary.StartTime)
+		}
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].StartTime.After(summaries[j].StartTime)
+	})
+	return summaries, nil
+}
+
+func historyID(executionID, checkpointID string) string {
+	return executionID + "/" + checkpointID
+}
+
+// timeField reads a timestamp stored by SaveCheckpoint. Drivers decode
+// BSON dates into their own types, so besides time.Time it accepts any
+// value with a Time method (primitive.DateTime and bson.DateTime
+// have one). The zero time is stored as year 1 and read back as zero.
+func timeField(doc map[string]any, field string) time.Time {
+	var t time.Time
+	switch v := doc[field].(type) {
+	case time.Time:
+		t = v
+	case interface{ Time() time.Time }:
+		t = v.Time()
+	}
+	if t.Year() <= 1 {
+		return time.Time{}
+	}
+	return t
+}
+
+// labelsDocument returns labels as a document, so that executions can
+// be queried by label (for example {"labels.tenant": "acme"}).
+func labelsDocument(labels map[string]string) map[string]any {
+	doc := make(map[string]any, len(labels))
+	for k, v := range labels {
+		doc[k] = v
+	}
+	return doc
+}
+
+// labelsField reads the labels stored by SaveCheckpoint. Drivers may
+// decode embedded documents into their own map types, so anything other
+// than a map[string]any is read through its JSON encoding.
+func labelsField(doc map[string]any) map[string]string {
+	var raw map[string]any
+	switch v := doc[fieldLabels].(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		raw = v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil || json.Unmarshal(data, &raw) != nil {
+			return nil
+		}
+	}
+	if len(raw) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if s, ok := v.(string); ok {
+			labels[k] = s
+		}
+	}
+	return labels
+}
+
+// toDocument converts a checkpoint to a BSON-ready document through
+// its JSON encoding; see the package documentation.
+func toDocument(checkpoint *workflow.Checkpoint) (map[string]any, error) {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return normalizeNumbers(doc).(map[string]any), nil
+}
+
+// normalizeNumbers replaces each json.Number in v with an int64 when
+// it is a whole number that fits and a float64 otherwise.
+func normalizeNumbers(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = normalizeNumbers(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeNumbers(item)
+		}
+		return v
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	default:
+		return v
+	}
+}
+
+// fromDocument decodes the checkpoint stored in doc.
+func fromDocument(doc map[string]any) (*workflow.Checkpoint, error) {
+	body, ok := doc[fieldCheckpoint]
+	if !ok {
+		return nil, fmt.Errorf("mongo: document %v has no checkpoint", doc["_id"])
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("mongo: decode checkpoint %v: %w", doc["_id"], err)
+	}
+	var checkpoint workflow.Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("mongo: decode checkpoint %v: %w", doc["_id"], err)
+	}
+	if checkpoint.SchemaVersion < 1 || checkpoint.SchemaVersion > workflow.CheckpointSchemaVersion {
+		return nil, fmt.Errorf("mongo: checkpoint schema version %d is not supported (supported: 1..%d)",
+			checkpoint.SchemaVersion, workflow.CheckpointSchemaVersion)
+	}
+	return &checkpoint, nil
+}
@@ -0,0 +1,377 @@
+package mongo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow"
+	"github.com/deepnoodle-ai/workflow/experimental/store/mongo"
+)
+
+// dateTime stands in for the driver's BSON date type: millisecond
+// precision, converted back with a Time method.
+type dateTime int64
+
+func (d dateTime) Time() time.Time { return time.UnixMilli(int64(d)).UTC() }
+
+// memCollection is an in-memory Collection. Like the driver, it refuses
+// values BSON cannot encode natively and hands back copies.
+type memCollection struct {
+	mu   sync.Mutex
+	docs map[string]map[string]any
+}
+
+func newMemCollection() *memCollection {
+	return &memCollection{docs: map[string]map[string]any{}}
+}
+
+func (m *memCollection) ReplaceOne(ctx context.Context, id string, doc map[string]any) error {
+	stored, err := toBSON(doc, "")
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored.(map[string]any)["_id"] = id
+	m.docs[id] = stored.(map[string]any)
+	return nil
+}
+
+func (m *memCollection) FindOne(ctx context.Context, id string) (map[string]any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	doc, ok := m.docs[id]
+	if !ok {
+		return nil, mongo.ErrNotFound
+	}
+	return copyDoc(doc, nil), nil
+}
+
+func (m *memCollection) Find(ctx context.Context, filter map[string]any, projection []string) ([]map[string]any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []map[string]any
+	for _, doc := range m.docs {
+		if matches(doc, filter) {
+			out = append(out, copyDoc(doc, projection))
+		}
+	}
+	return out, nil
+}
+
+func (m *memCollection) DeleteMany(ctx context.Context, filter map[string]any) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, doc := range m.docs {
+		if matches(doc, filter) {
+			delete(m.docs, id)
+		}
+	}
+	return nil
+}
+
+func matches(doc, filter map[string]any) bool {
+	for k, v := range filter {
+		if doc[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func copyDoc(doc map[string]any, projection []string) map[string]any {
+	out := map[string]any{"_id": doc["_id"]}
+	if len(projection) == 0 {
+		for k, v := range doc {
+			out[k] = v
+		}
+		return out
+	}
+	for _, k := range projection {
+		if v, ok := doc[k]; ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// toBSON deep-copies v, failing on any value that would not round-trip
+// through BSON as the same Go type.
+func toBSON(v any, path string) (any, error) {
+	switch v := v.(type) {
+	case nil, bool, int64, float64, string:
+		return v, nil
+	case time.Time:
+		return dateTime(v.UnixMilli()), nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			c, err := toBSON(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = c
+		}
+		return out, nil
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			c, err := toBSON(item, path+"."+k)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = c
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported BSON value %T at %s", v, path)
+	}
+}
+
+func checkpoint(executionID, id string, start time.Time) *workflow.Checkpoint {
+	return &workflow.Checkpoint{
+		SchemaVersion: workflow.CheckpointSchemaVersion,
+		ID:            id,
+		ExecutionID:   executionID,
+		WorkflowName:  "wf",
+		Status:        workflow.ExecutionStatusRunning,
+		StartTime:     start,
+		CheckpointAt:  start.Add(time.Second),
+	}
+}
+
+func TestCheckpointerRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	cp, err := mongo.NewCheckpointer(newMemCollection())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cp.LoadCheckpoint(ctx, "exec-1"); !errors.Is(err, workflow.ErrNoCheckpoint) {
+		t.Fatalf("load before save: got %v, want ErrNoCheckpoint", err)
+	}
+
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	saved := checkpoint("exec-1", "3", start)
+	saved.Inputs = map[string]any{"city": "Oslo", "days": 3}
+	saved.Variables = map[string]any{
+		"count": 42,
+		"ratio": 0.5,
+		"big":   int64(1) << 60,
+		"tags":  []string{"a", "b"},
+		"nested": map[string]any{
+			"ok":    true,
+			"none":  nil,
+			"items": []any{1, "two", map[string]any{"three": 3.25}},
+		},
+	}
+	saved.BranchCounter = 2
+	if err := cp.SaveCheckpoint(ctx, saved); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := cp.LoadCheckpoint(ctx, "exec-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Variables come back with the types JSON decoding gives them, as
+	// they would from the FileCheckpointer.
+	wantVariables := map[string]any{
+		"count": float64(42),
+		"ratio": 0.5,
+		"big":   float64(int64(1) << 60),
+		"tags":  []any{"a", "b"},
+		"nested": map[string]any{
+			"ok":    true,
+			"none":  nil,
+			"items": []any{float64(1), "two", map[string]any{"three": 3.25}},
+		},
+	}
+	if !reflect.DeepEqual(loaded.Variables, wantVariables) {
+		t.Errorf("variables = %#v, want %#v", loaded.Variables, wantVariables)
+	}
+	if loaded.ID != "3" || loaded.BranchCounter != 2 || loaded.Inputs["city"] != "Oslo" {
+		t.Errorf("unexpected checkpoint: %+v", loaded)
+	}
+	if !loaded.StartTime.Equal(start) || !loaded.CheckpointAt.Equal(start.Add(time.Second)) {
+		t.Errorf("times = %v, %v", loaded.StartTime, loaded.CheckpointAt)
+	}
+}
+
+func TestCheckpointerListExecutions(t *testing.T) {
+	ctx := context.Background()
+	coll := newMemCollection()
+	cp, err := mongo.NewCheckpointer(coll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	running := checkpoint("exec-1", "1", start)
+	done := checkpoint("exec-2", "4", start.Add(time.Hour))
+	done.Status = workflow.ExecutionStatusFailed
+	done.Error = "boom"
+	done.EndTime = done.StartTime.Add(3 * time.Second)
+	done.Labels = map[string]string{"tenant": "acme"}
+	for _, c := range []*workflow.Checkpoint{running, done} {
+		if err := cp.SaveCheckpoint(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	summaries, err := cp.ListExecutions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 2 || summaries[0].ExecutionID != "exec-2" || summaries[1].ExecutionID != "exec-1" {
+		t.Fatalf("unexpected summaries: %+v", summaries)
+	}
+	got := *summaries[0]
+	want := workflow.ExecutionSummary{
+		ExecutionID:  "exec-2",
+		WorkflowName: "wf",
+		Status:       "failed",
+		StartTime:    done.StartTime,
+		EndTime:      done.EndTime,
+		Duration:     3 * time.Second,
+		Error:        "boom",
+		Labels:       map[string]string{"tenant": "acme"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("summary = %+v, want %+v", got, want)
+	}
+	if !summaries[1].EndTime.IsZero() || summaries[1].Duration != time.Second || summaries[1].Labels != nil {
+		t.Errorf("running summary = %+v", summaries[1])
+	}
+
+	if err := cp.DeleteCheckpoint(ctx, "exec-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cp.LoadCheckpoint(ctx, "exec-1"); !errors.Is(err, workflow.ErrNoCheckpoint) {
+		t.Fatalf("load after delete: got %v, want ErrNoCheckpoint", err)
+	}
+	if len(coll.docs) != 1 {
+		t.Errorf("got %d documents after delete, want 1", len(coll.docs))
+	}
+}
+
+func TestCheckpointerHistory(t *testing.T) {
+	ctx := context.Background()
+	latest, history := newMemCollection(), newMemCollection()
+	cp, err := mongo.NewCheckpointer(latest, mongo.WithHistory(history))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for _, id := range []string{"1", "2", "10", "2"} {
+		if err := cp.SaveCheckpoint(ctx, checkpoint("exec-1", id, start)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cp.SaveCheckpoint(ctx, checkpoint("exec-2", "1", start)); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := cp.ListCheckpoints(ctx, "exec-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(ids, ","); got != "1,2,10" {
+		t.Errorf("checkpoint IDs = %s, want 1,2,10", got)
+	}
+	first, err := cp.LoadCheckpointByID(ctx, "exec-1", "1")
+	if err != nil || first == nil || first.ID != "1" {
+		t.Fatalf("load checkpoint 1: %v, %v", first, err)
+	}
+	if missing, err := cp.LoadCheckpointByID(ctx, "exec-1", "7"); err != nil || missing != nil {
+		t.Fatalf("load missing checkpoint: %v, %v", missing, err)
+	}
+	loaded, err := cp.LoadCheckpoint(ctx, "exec-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ID != "2" {
+		t.Errorf("latest checkpoint %q, want 2 (the last one saved)", loaded.ID)
+	}
+
+	if err := cp.DeleteCheckpoint(ctx, "exec-1"); err != nil {
+		t.Fatal(err)
+	}
+	if ids, err := cp.ListCheckpoints(ctx, "exec-1"); err != nil || len(ids) != 0 {
+		t.Fatalf("checkpoints after delete: %v, %v", ids, err)
+	}
+	if len(history.docs) != 1 {
+		t.Errorf("got %d history documents after delete, want 1", len(history.docs))
+	}
+}
+
+func TestCheckpointerWithoutHistory(t *testing.T) {
+	cp, err := mongo.NewCheckpointer(newMemCollection())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cp.ListCheckpoints(context.Background(), "exec-1"); err == nil {
+		t.Fatal("ListCheckpoints without history: want an error")
+	}
+}
+
+func TestCheckpointerRejectsUnsupportedSchema(t *testing.T) {
+	ctx := context.Background()
+	cp, err := mongo.NewCheckpointer(newMemCollection())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := checkpoint("exec-1", "1", time.Now())
+	c.SchemaVersion = workflow.CheckpointSchemaVersion + 1
+	if err := cp.SaveCheckpoint(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cp.LoadCheckpoint(ctx, "exec-1"); err == nil || errors.Is(err, workflow.ErrNoCheckpoint) {
+		t.Fatalf("got %v, want a schema version error", err)
+	}
+}
+
+func TestCheckpointerWithExecution(t *testing.T) {
+	wf, err := workflow.New(workflow.Options{
+		Name:  "mongo-checkpoint",
+		Steps: []*workflow.Step{{Name: "hello", Activity: "hello", Store: "greeting"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := workflow.NewActivityRegistry()
+	reg.MustRegister(workflow.ActivityFunc("hello", func(ctx workflow.Context, params map[string]any) (any, error) {
+		return map[string]any{"text": "hi", "count": 1}, nil
+	}))
+
+	cp, err := mongo.NewCheckpointer(newMemCollection(), mongo.WithHistory(newMemCollection()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec, err := workflow.NewExecution(wf, reg, workflow.WithCheckpointer(cp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := exec.Execute(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != workflow.ExecutionStatusCompleted {
+		t.Fatalf("status = %s", result.Status)
+	}
+
+	loaded, err := cp.LoadCheckpoint(context.Background(), exec.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Status != workflow.ExecutionStatusCompleted {
+		t.Errorf("checkpoint status = %s, want completed", loaded.Status)
+	}
+}
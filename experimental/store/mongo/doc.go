@@ -0,0 +1,26 @@
+// Package mongo provides a Checkpointer that stores execution
+// checkpoints in MongoDB.
+//
+// The package does not depend on a MongoDB driver. The consumer adapts
+// a collection of the official driver (or any other client) to the
+// small [Collection] interface and passes it to [NewCheckpointer].
+//
+// Each execution has one document in the collection, keyed by its
+// execution ID and replaced on every save. It carries the summary
+// fields ListExecutions projects (execution_id, workflow_name, status,
+// start_time, end_time, checkpoint_at, error, labels) next to the
+// checkpoint itself under "checkpoint". With [WithHistory], every checkpoint is
+// also kept as its own document in a second collection, keyed by
+// "<execution ID>/<checkpoint ID>", which makes the Checkpointer a
+// workflow.VersionedCheckpointer. A separate collection is used rather
+// than an array on the execution's document so that long executions do
+// not run into MongoDB's 16 MB document limit.
+//
+// Checkpoints are converted to documents through their JSON encoding,
+// so documents hold only string keys and values of the types nil,
+// bool, int64, float64, string, time.Time, []any, and map[string]any,
+// all of which BSON encodes natively. Whole numbers are stored as int64
+// and other numbers as float64. A loaded checkpoint is decoded from
+// JSON again, so its variables have the same types as one loaded by the
+// FileCheckpointer.
+package mongo
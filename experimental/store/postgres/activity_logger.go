@@ -15,6 +15,14 @@ func (s *Store) LogActivity(ctx context.Context, entry *workflow.ActivityLogEntr
 	if entry == nil {
 		return fmt.Errorf("postgres: nil activity log entry")
 	}
+	id := entry.ID
+	if id == "" {
+		var err error
+		id, err = generateID("act_")
+		if err != nil {
+			return fmt.Errorf("postgres: %w", err)
+		}
+	}
 	params, err := json.Marshal(entry.Parameters)
 	if err != nil {
 		return fmt.Errorf("postgres: marshal activity parameters: %w", err)
@@ -35,7 +43,7 @@ func (s *Store) LogActivity(ctx context.Context, entry *workflow.ActivityLogEntr
 		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
 	`, s.t("workflow_activity_log"))
 	_, err = s.pool.Exec(ctx, query,
-		entry.ID,
+		id,
 		entry.ExecutionID,
 		entry.Activity,
 		entry.StepName,
@@ -47,7 +55,7 @@ func (s *Store) LogActivity(ctx context.Context, entry *workflow.ActivityLogEntr
 		entry.Duration,
 	)
 	if err != nil {
-		return fmt.Errorf("postgres: insert activity log %s: %w", entry.ID, err)
+		return fmt.Errorf("postgres: insert activity log %s: %w", id, err)
 	}
 	return nil
 }
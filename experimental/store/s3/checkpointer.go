@@ -0,0 +1,209 @@
+package s3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow"
+)
+
+// ErrNotFound is returned by Client.GetObject when the key does not
+// exist. Adapters translate their SDK's not-found error to it.
+var ErrNotFound = errors.New("s3: object not found")
+
+// Client is the subset of an object store API the Checkpointer needs.
+// Implementations must be safe for concurrent use.
+type Client interface {
+	// PutObject writes body to key, replacing any existing object.
+	PutObject(ctx context.Context, bucket, key string, body []byte) error
+
+	// GetObject returns the contents of key, or ErrNotFound.
+	GetObject(ctx context.Context, bucket, key string) ([]byte, error)
+
+	// DeleteObjects removes the given keys. Missing keys are not an
+	// error.
+	DeleteObjects(ctx context.Context, bucket string, keys []string) error
+
+	// ListObjects lists keys under prefix, following pagination until
+	// the listing is complete. With a non-empty delimiter, keys that
+	// contain delimiter after prefix are rolled up into
+	// commonPrefixes, as in S3's ListObjectsV2.
+	ListObjects(ctx context.Context, bucket, prefix, delimiter string) (keys, commonPrefixes []string, err error)
+}
+
+// latestPointer is the body of the latest.json object.
+type latestPointer struct {
+	Key          string    `json:"key"`
+	CheckpointID string    `json:"checkpoint_id"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Checkpointer implements workflow.Checkpointer on top of a Client.
+type Checkpointer struct {
+	client Client
+	bucket string
+	prefix string
+}
+
+var _ workflow.Checkpointer = (*Checkpointer)(nil)
+
+// NewCheckpointer returns a Checkpointer that stores checkpoints in
+// bucket under prefix. An empty prefix stores them at the bucket root.
+func NewCheckpointer(client Client, bucket, prefix string) (*Checkpointer, error) {
+	if client == nil {
+		return nil, fmt.Errorf("s3: client is required")
+	}
+	if bucket == "" {
+		return nil, fmt.Errorf("s3: bucket is required")
+	}
+	return &Checkpointer{
+		client: client,
+		bucket: bucket,
+		prefix: strings.Trim(prefix, "/"),
+	}, nil
+}
+
+// executionPrefix returns the key prefix, ending in "/", for an
+// execution's objects.
+func (c *Checkpointer) executionPrefix(executionID string) string {
+	return path.Join(c.prefix, executionID) + "/"
+}
+
+func (c *Checkpointer) checkpointKey(executionID, checkpointID string) string {
+	return c.executionPrefix(executionID) + "checkpoint-" + checkpointID + ".json"
+}
+
+func (c *Checkpointer) latestKey(executionID string) string {
+	return c.executionPrefix(executionID) + "latest.json"
+}
+
+// SaveCheckpoint writes the checkpoint object and then the latest.json
+// pointer. Saving the same checkpoint ID again overwrites the same
+// object, so retries are idempotent.
+func (c *Checkpointer) SaveCheckpoint(ctx context.Context, checkpoint *workflow.Checkpoint) error {
+	if checkpoint == nil {
+		return fmt.Errorf("s3: nil checkpoint")
+	}
+	if checkpoint.ExecutionID == "" || strings.Contains(checkpoint.ExecutionID, "/") {
+		return fmt.Errorf("s3: invalid execution ID %q", checkpoint.ExecutionID)
+	}
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("s3: marshal checkpoint: %w", err)
+	}
+	key := c.checkpointKey(checkpoint.ExecutionID, checkpoint.ID)
+	if err := c.client.PutObject(ctx, c.bucket, key, data); err != nil {
+		return fmt.Errorf("s3: put checkpoint %s: %w", key, err)
+	}
+
+	pointer, err := json.Marshal(latestPointer{
+		Key:          key,
+		CheckpointID: checkpoint.ID,
+		UpdatedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("s3: marshal latest pointer: %w", err)
+	}
+	latest := c.latestKey(checkpoint.ExecutionID)
+	if err := c.client.PutObject(ctx, c.bucket, latest, pointer); err != nil {
+		return fmt.Errorf("s3: put latest pointer %s: %w", latest, err)
+	}
+	return nil
+}
+
+// LoadCheckpoint follows the latest.json pointer to the most recent
+// checkpoint. Returns workflow.ErrNoCheckpoint when the execution has
+// no pointer.
+func (c *Checkpointer) LoadCheckpoint(ctx context.Context, executionID string) (*workflow.Checkpoint, error) {
+	latest := c.latestKey(executionID)
+	data, err := c.client.GetObject(ctx, c.bucket, latest)
+	if errors.Is(err, ErrNotFound) {
+		return nil, workflow.ErrNoCheckpoint
+	}
+	if err != nil {
+		return nil, fmt.Errorf("s3: get latest pointer %s: %w", latest, err)
+	}
+	var pointer latestPointer
+	if err := json.Unmarshal(data, &pointer); err != nil {
+		return nil, fmt.Errorf("s3: unmarshal latest pointer %s: %w", latest, err)
+	}
+
+	data, err = c.client.GetObject(ctx, c.bucket, pointer.Key)
+	if err != nil {
+		return nil, fmt.Errorf("s3: get checkpoint %s: %w", pointer.Key, err)
+	}
+	var checkpoint workflow.Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("s3: unmarshal checkpoint %s: %w", pointer.Key, err)
+	}
+	if checkpoint.SchemaVersion < 1 || checkpoint.SchemaVersion > workflow.CheckpointSchemaVersion {
+		return nil, fmt.Errorf("s3: checkpoint schema version %d is not supported (supported: 1..%d)",
+			checkpoint.SchemaVersion, workflow.CheckpointSchemaVersion)
+	}
+	return &checkpoint, nil
+}
+
+// DeleteCheckpoint removes every object stored for the execution.
+func (c *Checkpointer) DeleteCheckpoint(ctx context.Context, executionID string) error {
+	keys, _, err := c.client.ListObjects(ctx, c.bucket, c.executionPrefix(executionID), "")
+	if err != nil {
+		return fmt.Errorf("s3: list execution %s: %w", executionID, err)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := c.client.DeleteObjects(ctx, c.bucket, keys); err != nil {
+		return fmt.Errorf("s3: delete execution %s: %w", executionID, err)
+	}
+	return nil
+}
+
+// ListExecutions lists the execution prefixes under the checkpointer's
+// prefix and summarizes each from its latest checkpoint, newest first.
+// Executions whose checkpoint cannot be read are skipped.
+func (c *Checkpointer) ListExecutions(ctx context.Context) ([]*workflow.ExecutionSummary, error) {
+	root := ""
+	if c.prefix != "" {
+		root = c.prefix + "/"
+	}
+	_, prefixes, err := c.client.ListObjects(ctx, c.bucket, root, "/")
+	if err != nil {
+		return nil, fmt.Errorf("s3: list executions: %w", err)
+	}
+
+	summaries := []*workflow.ExecutionSummary{}
+	for _, p := range prefixes {
+		executionID := strings.TrimSuffix(strings.TrimPrefix(p, root), "/")
+		if executionID == "" {
+			continue
+		}
+		checkpoint, err := c.LoadCheckpoint(ctx, executionID)
+		if err != nil {
+			continue
+		}
+		end := checkpoint.EndTime
+		if end.IsZero() {
+			end = checkpoint.CheckpointAt
+		}
+		summaries = append(summaries, &workflow.ExecutionSummary{
+			ExecutionID:  checkpoint.ExecutionID,
+			WorkflowName: checkpoint.WorkflowName,
+			Status:       string(checkpoint.Status),
+			StartTime:    checkpoint.StartTime,
+			EndTime:      checkpoint.EndTime,
+			Duration:     end.Sub(checkpoint.StartTime),
+			Error:        checkpoint.Error,
+			Labels:       checkpoint.Labels,
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].StartTime.After(summaries[j].StartTime)
+	})
+	return summaries, nil
+}
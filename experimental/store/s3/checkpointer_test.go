@@ -0,0 +1,222 @@
+package s3_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow"
+	"github.com/deepnoodle-ai/workflow/experimental/store/s3"
+)
+
+// memClient is an in-memory Client that records the order of writes.
+type memClient struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	puts    []string
+}
+
+func newMemClient() *memClient {
+	return &memClient{objects: map[string][]byte{}}
+}
+
+func (m *memClient) PutObject(ctx context.Context, bucket, key string, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[bucket+"/"+key] = append([]byte(nil), body...)
+	m.puts = append(m.puts, key)
+	return nil
+}
+
+func (m *memClient) GetObject(ctx context.Context, bucket, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[bucket+"/"+key]
+	if !ok {
+		return nil, s3.ErrNotFound
+	}
+	return data, nil
+}
+
+func (m *memClient) DeleteObjects(ctx context.Context, bucket string, keys []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.objects, bucket+"/"+key)
+	}
+	return nil
+}
+
+func (m *memClient) ListObjects(ctx context.Context, bucket, prefix, delimiter string) ([]string, []string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	seen := map[string]bool{}
+	var prefixes []string
+	for full := range m.objects {
+		key, ok := strings.CutPrefix(full, bucket+"/")
+		if !ok || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				p := key[:len(prefix)+i+len(delimiter)]
+				if !seen[p] {
+					seen[p] = true
+					prefixes = append(prefixes, p)
+				}
+				continue
+			}
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	sort.Strings(prefixes)
+	return keys, prefixes, nil
+}
+
+func checkpoint(executionID, id string, start time.Time) *workflow.Checkpoint {
+	return &workflow.Checkpoint{
+		SchemaVersion: workflow.CheckpointSchemaVersion,
+		ID:            id,
+		ExecutionID:   executionID,
+		WorkflowName:  "wf",
+		Status:        workflow.ExecutionStatusRunning,
+		StartTime:     start,
+		CheckpointAt:  start.Add(time.Second),
+	}
+}
+
+func TestCheckpointer(t *testing.T) {
+	ctx := context.Background()
+	client := newMemClient()
+	cp, err := s3.NewCheckpointer(client, "bucket", "/runs/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cp.LoadCheckpoint(ctx, "exec-1"); !errors.Is(err, workflow.ErrNoCheckpoint) {
+		t.Fatalf("load before save: got %v, want ErrNoCheckpoint", err)
+	}
+
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, c := range []*workflow.Checkpoint{
+		checkpoint("exec-1", "1", start),
+		checkpoint("exec-1", "2", start),
+		// Retrying a save with the same checkpoint ID rewrites the same object.
+		checkpoint("exec-1", "2", start),
+		checkpoint("exec-2", "1", start.Add(time.Hour)),
+	} {
+		if err := cp.SaveCheckpoint(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wantPuts := []string{
+		"runs/exec-1/checkpoint-1.json", "runs/exec-1/latest.json",
+		"runs/exec-1/checkpoint-2.json", "runs/exec-1/latest.json",
+		"runs/exec-1/checkpoint-2.json", "runs/exec-1/latest.json",
+		"runs/exec-2/checkpoint-1.json", "runs/exec-2/latest.json",
+	}
+	if got := strings.Join(client.puts, ","); got != strings.Join(wantPuts, ",") {
+		t.Errorf("puts = %v, want %v", client.puts, wantPuts)
+	}
+	keys, _, err := client.ListObjects(ctx, "bucket", "runs/exec-1/", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(keys, ","); got != "runs/exec-1/checkpoint-1.json,runs/exec-1/checkpoint-2.json,runs/exec-1/latest.json" {
+		t.Errorf("exec-1 keys = %v", keys)
+	}
+
+	loaded, err := cp.LoadCheckpoint(ctx, "exec-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ID != "2" {
+		t.Errorf("loaded checkpoint %q, want 2", loaded.ID)
+	}
+
+	summaries, err := cp.ListExecutions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 2 || summaries[0].ExecutionID != "exec-2" || summaries[1].ExecutionID != "exec-1" {
+		t.Fatalf("unexpected summaries: %+v", summaries)
+	}
+	if summaries[1].Duration != time.Second {
+		t.Errorf("duration = %v, want 1s", summaries[1].Duration)
+	}
+
+	if err := cp.DeleteCheckpoint(ctx, "exec-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cp.LoadCheckpoint(ctx, "exec-1"); !errors.Is(err, workflow.ErrNoCheckpoint) {
+		t.Fatalf("load after delete: got %v, want ErrNoCheckpoint", err)
+	}
+	summaries, err = cp.ListExecutions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 1 {
+		t.Errorf("got %d executions after delete, want 1", len(summaries))
+	}
+}
+
+func TestCheckpointerRejectsUnsupportedSchema(t *testing.T) {
+	ctx := context.Background()
+	cp, err := s3.NewCheckpointer(newMemClient(), "bucket", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := checkpoint("exec-1", "1", time.Now())
+	c.SchemaVersion = workflow.CheckpointSchemaVersion + 1
+	if err := cp.SaveCheckpoint(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cp.LoadCheckpoint(ctx, "exec-1"); err == nil || errors.Is(err, workflow.ErrNoCheckpoint) {
+		t.Fatalf("got %v, want a schema version error", err)
+	}
+}
+
+func TestCheckpointerWithExecution(t *testing.T) {
+	wf, err := workflow.New(workflow.Options{
+		Name:  "s3-checkpoint",
+		Steps: []*workflow.Step{{Name: "hello", Activity: "hello", Store: "greeting"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := workflow.NewActivityRegistry()
+	reg.MustRegister(workflow.ActivityFunc("hello", func(ctx workflow.Context, params map[string]any) (any, error) {
+		return "hi", nil
+	}))
+
+	cp, err := s3.NewCheckpointer(newMemClient(), "bucket", "checkpoints")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec, err := workflow.NewExecution(wf, reg, workflow.WithCheckpointer(cp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := exec.Execute(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != workflow.ExecutionStatusCompleted {
+		t.Fatalf("status = %s", result.Status)
+	}
+
+	loaded, err := cp.LoadCheckpoint(context.Background(), exec.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Status != workflow.ExecutionStatusCompleted {
+		t.Errorf("checkpoint status = %s, want completed", loaded.Status)
+	}
+}
@@ -0,0 +1,13 @@
+// Package s3 provides a Checkpointer that stores execution checkpoints
+// in S3 or any S3-compatible object store, for workers without durable
+// local disk.
+//
+// The package does not depend on an AWS SDK. The consumer adapts their
+// client (aws-sdk-go-v2, minio-go, ...) to the small [Client] interface
+// and passes it to [NewCheckpointer].
+//
+// Each checkpoint is written to prefix/<executionID>/checkpoint-<ID>.json,
+// followed by a prefix/<executionID>/latest.json pointer naming that
+// object. The pointer is always written after the checkpoint it names,
+// so a reader that sees the pointer can fetch the checkpoint.
+package s3
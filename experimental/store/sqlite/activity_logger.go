@@ -14,6 +14,14 @@ func (s *Store) LogActivity(ctx context.Context, entry *workflow.ActivityLogEntr
 	if entry == nil {
 		return fmt.Errorf("sqlite: nil activity log entry")
 	}
+	id := entry.ID
+	if id == "" {
+		var err error
+		id, err = generateID("act_")
+		if err != nil {
+			return fmt.Errorf("sqlite: %w", err)
+		}
+	}
 	params, err := json.Marshal(entry.Parameters)
 	if err != nil {
 		return fmt.Errorf("sqlite: marshal activity parameters: %w", err)
@@ -33,7 +41,7 @@ func (s *Store) LogActivity(ctx context.Context, entry *workflow.ActivityLogEntr
 			parameters, result, error, start_time, duration
 		) VALUES (?,?,?,?,?,?,?,?,?,?)
 	`,
-		entry.ID,
+		id,
 		entry.ExecutionID,
 		entry.Activity,
 		entry.StepName,
@@ -45,7 +53,7 @@ func (s *Store) LogActivity(ctx context.Context, entry *workflow.ActivityLogEntr
 		entry.Duration,
 	)
 	if err != nil {
-		return fmt.Errorf("sqlite: insert activity log %s: %w", entry.ID, err)
+		return fmt.Errorf("sqlite: insert activity log %s: %w", id, err)
 	}
 	return nil
 }
@@ -11,4 +11,8 @@
 // transactions rather than FOR UPDATE SKIP LOCKED. This makes the
 // store suitable for development, testing, and single-process
 // deployments — not for distributed worker fleets.
+//
+// ObservabilityStore covers executions run outside the worker queue: it
+// records activity logs and checkpoints in the same database so they
+// can be queried together after the run.
 package sqlite
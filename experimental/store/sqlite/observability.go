@@ -0,0 +1,134 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow"
+)
+
+// ObservabilityStore keeps activity logs and checkpoints for standalone
+// executions in one database, so post-run analysis can join them by
+// execution ID. It implements both workflow.ActivityLogger and
+// workflow.Checkpointer; pass it to WithActivityLogger and
+// WithCheckpointer on the same execution.
+//
+// Activity entries go to workflow_activity_log, shared with Store.
+// Checkpoints go to workflow_checkpoints, one row per execution holding
+// the latest snapshot with its workflow name and status broken out:
+//
+//	SELECT a.step_name, a.activity, a.error
+//	FROM workflow_activity_log a
+//	JOIN workflow_checkpoints c ON c.execution_id = a.execution_id
+//	WHERE c.status = 'failed'
+//	ORDER BY a.execution_id, a.start_time
+//
+// Unlike Store.NewCheckpointer, checkpoints are not fenced by a worker
+// claim. Use it for executions run directly rather than through the
+// worker queue.
+type ObservabilityStore struct {
+	*Store
+}
+
+var (
+	_ workflow.ActivityLogger = (*ObservabilityStore)(nil)
+	_ workflow.Checkpointer   = (*ObservabilityStore)(nil)
+)
+
+// NewObservabilityStore constructs an ObservabilityStore bound to the
+// given *sql.DB. Call Migrate before first use. Panics if db is nil.
+func NewObservabilityStore(db *sql.DB, opts ...Option) *ObservabilityStore {
+	return &ObservabilityStore{Store: New(db, opts...)}
+}
+
+// SaveCheckpoint implements workflow.Checkpointer, replacing the
+// execution's previous checkpoint.
+func (s *ObservabilityStore) SaveCheckpoint(ctx context.Context, checkpoint *workflow.Checkpoint) error {
+	if checkpoint == nil {
+		return fmt.Errorf("sqlite: nil checkpoint")
+	}
+	blob, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("sqlite: marshal checkpoint: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO workflow_checkpoints (
+			execution_id, workflow_name, status, checkpoint, updated_at
+		) VALUES (?,?,?,?,?)
+		ON CONFLICT (execution_id) DO UPDATE SET
+			workflow_name = excluded.workflow_name,
+			status        = excluded.status,
+			checkpoint    = excluded.checkpoint,
+			updated_at    = excluded.updated_at
+	`,
+		checkpoint.ExecutionID,
+		checkpoint.WorkflowName,
+		string(checkpoint.Status),
+		blob,
+		formatTime(time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("sqlite: save checkpoint %s: %w", checkpoint.ExecutionID, err)
+	}
+	return nil
+}
+
+// LoadCheckpoint implements workflow.Checkpointer.
+func (s *ObservabilityStore) LoadCheckpoint(ctx context.Context, executionID string) (*workflow.Checkpoint, error) {
+	var blob []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT checkpoint FROM workflow_checkpoints WHERE execution_id = ?
+	`, executionID).Scan(&blob)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, workflow.ErrNoCheckpoint
+		}
+		return nil, fmt.Errorf("sqlite: load checkpoint %s: %w", executionID, err)
+	}
+	var cp workflow.Checkpoint
+	if err := json.Unmarshal(blob, &cp); err != nil {
+		return nil, fmt.Errorf("sqlite: unmarshal checkpoint %s: %w", executionID, err)
+	}
+	if cp.SchemaVersion < 1 || cp.SchemaVersion > workflow.CheckpointSchemaVersion {
+		return nil, fmt.Errorf("sqlite: checkpoint schema version %d is not supported (supported: 1..%d)",
+			cp.SchemaVersion, workflow.CheckpointSchemaVersion)
+	}
+	return &cp, nil
+}
+
+// DeleteCheckpoint implements workflow.Checkpointer. Activity log
+// entries for the execution are kept.
+func (s *ObservabilityStore) DeleteCheckpoint(ctx context.Context, executionID string) error {
+	if _, err := s.db.ExecContext(ctx, `
+		DELETE FROM workflow_checkpoints WHERE execution_id = ?
+	`, executionID); err != nil {
+		return fmt.Errorf("sqlite: delete checkpoint %s: %w", executionID, err)
+	}
+	return nil
+}
+
+// ListExecutions returns the IDs of executions whose latest checkpoint
+// has the given status, oldest update first.
+func (s *ObservabilityStore) ListExecutions(ctx context.Context, status workflow.ExecutionStatus) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT execution_id FROM workflow_checkpoints
+		WHERE status = ?
+		ORDER BY updated_at ASC
+	`, string(status))
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: list executions: %w", err)
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("sqlite: scan execution id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,119 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/deepnoodle-ai/workflow"
+	"github.com/deepnoodle-ai/workflow/experimental/store/sqlite"
+)
+
+// openTestDB opens a migrated database in a temporary directory.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "workflow.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := sqlite.New(db).Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestObservabilityStoreFailedExecutionActivities(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	store := sqlite.NewObservabilityStore(db)
+
+	wf, err := workflow.New(workflow.Options{
+		Name: "charge",
+		Steps: []*workflow.Step{
+			{Name: "lookup", Activity: "lookup", Next: []*workflow.Edge{{Step: "bill"}}},
+			{Name: "bill", Activity: "bill"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	activities := workflow.NewActivityRegistry()
+	activities.MustRegister(workflow.ActivityFunc("lookup", func(ctx workflow.Context, p map[string]any) (any, error) {
+		return "acct-1", nil
+	}))
+	activities.MustRegister(workflow.ActivityFunc("bill", func(ctx workflow.Context, p map[string]any) (any, error) {
+		return nil, errors.New("card declined")
+	}))
+
+	exec, err := workflow.NewExecution(wf, activities,
+		workflow.WithActivityLogger(store),
+		workflow.WithCheckpointer(store),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := exec.Execute(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != workflow.ExecutionStatusFailed {
+		t.Fatalf("status = %q, want failed", result.Status)
+	}
+
+	failed, err := store.ListExecutions(ctx, workflow.ExecutionStatusFailed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 || failed[0] != exec.ID() {
+		t.Fatalf("failed executions = %v, want [%s]", failed, exec.ID())
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT a.execution_id, c.workflow_name, a.step_name, a.activity, a.error
+		FROM workflow_activity_log a
+		JOIN workflow_checkpoints c ON c.execution_id = a.execution_id
+		WHERE c.status = 'failed'
+		ORDER BY a.execution_id, a.start_time
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	type activityRow struct{ executionID, workflowName, step, activity, err string }
+	var got []activityRow
+	for rows.Next() {
+		var r activityRow
+		if err := rows.Scan(&r.executionID, &r.workflowName, &r.step, &r.activity, &r.err); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []activityRow{
+		{exec.ID(), "charge", "lookup", "lookup", ""},
+		{exec.ID(), "charge", "bill", "bill", "card declined"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("activities = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("activity %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	checkpoint, err := store.LoadCheckpoint(ctx, exec.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint.Status != workflow.ExecutionStatusFailed {
+		t.Fatalf("checkpoint status = %q, want failed", checkpoint.Status)
+	}
+}
@@ -125,3 +125,14 @@ CREATE TABLE IF NOT EXISTS workflow_webhooks (
 
 CREATE INDEX IF NOT EXISTS workflow_webhooks_status
     ON workflow_webhooks (status, created_at);
+
+CREATE TABLE IF NOT EXISTS workflow_checkpoints (
+    execution_id  TEXT PRIMARY KEY,
+    workflow_name TEXT NOT NULL DEFAULT '',
+    status        TEXT NOT NULL DEFAULT '',
+    checkpoint    BLOB NOT NULL,
+    updated_at    TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS workflow_checkpoints_status
+    ON workflow_checkpoints (status);
@@ -0,0 +1,400 @@
+// Package workflowserver serves the workflows of a WorkflowRegistry over
+// HTTP, so a program that defines workflows can be deployed as a
+// service that starts, inspects, and resumes executions.
+//
+// Endpoints:
+//
+//	GET  /workflows                   list workflow summaries
+//	GET  /workflows/{name}            one workflow summary
+//	POST /workflows/{name}/executions start an execution
+//	GET  /executions/{id}             execution status
+//	POST /executions/{id}/resume      resume a stopped execution
+//
+// Executions run in the background on the server's context; the start
+// and resume endpoints respond with 202 Accepted once the execution is
+// launched. Status is read from the running execution while it is in
+// this process and from its latest checkpoint otherwise, so the
+// Checkpointer should be durable when executions must survive restarts.
+// Every response body is JSON; errors are {"error": "..."}.
+package workflowserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow"
+)
+
+// maxRequestBody bounds the size of a start request body.
+const maxRequestBody = 1 << 20
+
+// Options configures a Server.
+type Options struct {
+	// Activities resolves the activities of every served workflow.
+	// Required.
+	Activities *workflow.ActivityRegistry
+
+	// Checkpointer stores execution state. Status and resume read
+	// from it, so it is required.
+	Checkpointer workflow.Checkpointer
+
+	// ExecutionOptions are applied to every execution the server
+	// creates, before the server's own inputs, checkpointer, and
+	// execution ID options.
+	ExecutionOptions []workflow.ExecutionOption
+
+	// Logger receives server-level logs. Defaults to a discard logger.
+	Logger *slog.Logger
+}
+
+// Server is an http.Handler exposing a WorkflowRegistry. Create it with
+// NewServer and stop its background executions with Close.
+type Server struct {
+	registry     workflow.WorkflowRegistry
+	activities   *workflow.ActivityRegistry
+	checkpointer workflow.Checkpointer
+	execOptions  []workflow.ExecutionOption
+	logger       *slog.Logger
+	mux          *http.ServeMux
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	running map[string]*runningExecution // execution ID -> running execution
+}
+
+// runningExecution is an execution running in the background.
+type runningExecution struct {
+	exec         *workflow.Execution
+	workflowName string
+}
+
+// NewServer returns a Server for the workflows in registry.
+func NewServer(registry workflow.WorkflowRegistry, opts Options) (*Server, error) {
+	if registry == nil {
+		return nil, errors.New("workflowserver: workflow registry is required")
+	}
+	if opts.Activities == nil {
+		return nil, errors.New("workflowserver: activity registry is required")
+	}
+	if opts.Checkpointer == nil {
+		return nil, errors.New("workflowserver: checkpointer is required")
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		registry:     registry,
+		activities:   opts.Activities,
+		checkpointer: opts.Checkpointer,
+		execOptions:  slices.Clone(opts.ExecutionOptions),
+		logger:       logger,
+		mux:          http.NewServeMux(),
+		ctx:          ctx,
+		cancel:       cancel,
+		running:      map[string]*runningExecution{},
+	}
+	s.mux.HandleFunc("GET /workflows", s.listWorkflows)
+	s.mux.HandleFunc("GET /workflows/{name}", s.getWorkflow)
+	s.mux.HandleFunc("POST /workflows/{name}/executions", s.startExecution)
+	s.mux.HandleFunc("GET /executions/{id}", s.getExecution)
+	s.mux.HandleFunc("POST /executions/{id}/resume", s.resumeExecution)
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close cancels the executions running in the background and waits for
+// them to stop. Their last checkpoints remain, so they can be resumed
+// later.
+func (s *Server) Close() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+// StartRequest is the body of POST /workflows/{name}/executions. Every
+// field is optional. An ExecutionID that already has a checkpoint is
+// rejected with 409 Conflict.
+type StartRequest struct {
+	// ExecutionID names the execution. Generated when empty.
+	ExecutionID string `json:"execution_id,omitempty"`
+	// Inputs are the workflow inputs.
+	Inputs map[string]any `json:"inputs,omitempty"`
+	// Labels are attached to the execution with workflow.WithLabels.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// ResumeRequest is the optional body of POST /executions/{id}/resume.
+// Checkpoints hold Sensitive inputs as workflow.RedactedValue, so a
+// Sensitive input without a Default or FromEnv must be given again in
+// Inputs; the request is rejected with 400 Bad Request otherwise. Other
+// inputs are taken from the checkpoint, and any given here replace them.
+type ResumeRequest struct {
+	Inputs map[string]any `json:"inputs,omitempty"`
+}
+
+// ExecutionStatus is the response of the execution endpoints.
+type ExecutionStatus struct {
+	ExecutionID  string                   `json:"execution_id"`
+	WorkflowName string                   `json:"workflow_name"`
+	Status       workflow.ExecutionStatus `json:"status"`
+	StartTime    time.Time                `json:"start_time,omitzero"`
+	EndTime      time.Time                `json:"end_time,omitzero"`
+	Outputs      map[string]any           `json:"outputs,omitempty"`
+	Labels       map[string]string        `json:"labels,omitempty"`
+	Error        string                   `json:"error,omitempty"`
+}
+
+func (s *Server) listWorkflows(w http.ResponseWriter, r *http.Request) {
+	names := s.registry.List()
+	slices.Sort(names)
+	summaries := make([]*workflow.WorkflowSummary, 0, len(names))
+	for _, name := range names {
+		if wf, ok := s.registry.Get(name); ok {
+			summaries = append(summaries, wf.Summary())
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"workflows": summaries})
+}
+
+func (s *Server) getWorkflow(w http.ResponseWriter, r *http.Request) {
+	wf, ok := s.registry.Get(r.PathValue("name"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("workflow %q not found", r.PathValue("name")))
+		return
+	}
+	writeJSON(w, http.StatusOK, wf.Summary())
+}
+
+func (s *Server) startExecution(w http.ResponseWriter, r *http.Request) {
+	wf, ok := s.registry.Get(r.PathValue("name"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("workflow %q not found", r.PathValue("name")))
+		return
+	}
+	var req StartRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBody)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if req.ExecutionID == "" {
+		req.ExecutionID = workflow.NewExecutionID()
+	}
+	if s.isRunning(req.ExecutionID) {
+		writeError(w, http.StatusConflict, fmt.Errorf("execution %q is already running", req.ExecutionID))
+		return
+	}
+	if _, err := s.loadCheckpoint(r.Context(), req.ExecutionID); err == nil {
+		writeError(w, http.StatusConflict, fmt.Errorf("execution %q already exists; resume it instead", req.ExecutionID))
+		return
+	}
+
+	opts := append(slices.Clone(s.execOptions),
+		workflow.WithInputs(req.Inputs),
+		workflow.WithCheckpointer(s.checkpointer),
+		workflow.WithExecutionID(req.ExecutionID))
+	if len(req.Labels) > 0 {
+		opts = append(opts, workflow.WithLabels(req.Labels))
+	}
+	exec, err := workflow.NewExecution(wf, s.activities, opts...)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !s.launch(exec, wf.Name()) {
+		writeError(w, http.StatusConflict, fmt.Errorf("execution %q is already running", req.ExecutionID))
+		return
+	}
+	writeJSON(w, http.StatusAccepted, &ExecutionStatus{
+		ExecutionID:  exec.ID(),
+		WorkflowName: wf.Name(),
+		Status:       workflow.ExecutionStatusRunning,
+	})
+}
+
+func (s *Server) getExecution(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	s.mu.Lock()
+	run, running := s.running[id]
+	s.mu.Unlock()
+	if running {
+		writeJSON(w, http.StatusOK, &ExecutionStatus{
+			ExecutionID:  id,
+			WorkflowName: run.workflowName,
+			Status:       run.exec.Status(),
+		})
+		return
+	}
+	checkpoint, err := s.loadCheckpoint(r.Context(), id)
+	if err != nil {
+		writeCheckpointError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &ExecutionStatus{
+		ExecutionID:  checkpoint.ExecutionID,
+		WorkflowName: checkpoint.WorkflowName,
+		Status:       checkpoint.Status,
+		StartTime:    checkpoint.StartTime,
+		EndTime:      checkpoint.EndTime,
+		Outputs:      checkpoint.Outputs,
+		Labels:       checkpoint.Labels,
+		Error:        checkpoint.Error,
+	})
+}
+
+func (s *Server) resumeExecution(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if s.isRunning(id) {
+		writeError(w, http.StatusConflict, fmt.Errorf("execution %q is already running", id))
+		return
+	}
+	checkpoint, err := s.loadCheckpoint(r.Context(), id)
+	if err != nil {
+		writeCheckpointError(w, err)
+		return
+	}
+	if checkpoint.Status == workflow.ExecutionStatusCompleted {
+		writeError(w, http.StatusConflict, fmt.Errorf("execution %q already completed", id))
+		return
+	}
+	wf, ok := s.registry.Get(checkpoint.WorkflowName)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("workflow %q not found", checkpoint.WorkflowName))
+		return
+	}
+	var req ResumeRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBody)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	inputs, err := resumeInputs(wf, checkpoint.Inputs, req.Inputs)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	opts := append(slices.Clone(s.execOptions),
+		workflow.WithInputs(inputs),
+		workflow.WithCheckpointer(s.checkpointer),
+		workflow.WithExecutionID(id))
+	exec, err := workflow.NewExecution(wf, s.activities, opts...)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !s.launch(exec, wf.Name(), workflow.ResumeFrom(id)) {
+		writeError(w, http.StatusConflict, fmt.Errorf("execution %q is already running", id))
+		return
+	}
+	writeJSON(w, http.StatusAccepted, &ExecutionStatus{
+		ExecutionID:  id,
+		WorkflowName: wf.Name(),
+		Status:       workflow.ExecutionStatusRunning,
+	})
+}
+
+// launch runs exec in the background, tracking it as running until it
+// stops. It reports false if an execution with the same ID is already
+// running.
+func (s *Server) launch(exec *workflow.Execution, workflowName string, opts ...workflow.ExecuteOption) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.running[exec.ID()]; exists {
+		return false
+	}
+	s.running[exec.ID()] = &runningExecution{exec: exec, workflowName: workflowName}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.running, exec.ID())
+			s.mu.Unlock()
+		}()
+		result, err := exec.Execute(s.ctx, opts...)
+		if err != nil {
+			s.logger.Error("execution could not run", "execution_id", exec.ID(), "error", err)
+			return
+		}
+		s.logger.Info("execution stopped", "execution_id", exec.ID(), "status", result.Status)
+	}()
+	return true
+}
+
+// resumeInputs returns the inputs of a resumed execution: the
+// checkpointed inputs without the redacted Sensitive ones, with given
+// layered over them. It fails when a Sensitive input that has no
+// Default or FromEnv is not given again.
+func resumeInputs(wf *workflow.Workflow, checkpointed, given map[string]any) (map[string]any, error) {
+	sensitive := map[string]bool{}
+	for _, input := range wf.Inputs() {
+		sensitive[input.Name] = input.Sensitive
+	}
+	inputs := make(map[string]any, len(checkpointed)+len(given))
+	for name, value := range checkpointed {
+		if sensitive[name] && value == workflow.RedactedValue {
+			continue
+		}
+		inputs[name] = value
+	}
+	for name, value := range given {
+		inputs[name] = value
+	}
+	for _, input := range wf.Inputs() {
+		if _, ok := inputs[input.Name]; !ok && input.Sensitive && input.Default == nil && input.FromEnv == "" {
+			return nil, fmt.Errorf("sensitive input %q is not stored in the checkpoint and must be given again in the resume request", input.Name)
+		}
+	}
+	return inputs, nil
+}
+
+func (s *Server) isRunning(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, exists := s.running[id]
+	return exists
+}
+
+func (s *Server) loadCheckpoint(ctx context.Context, id string) (*workflow.Checkpoint, error) {
+	checkpoint, err := s.checkpointer.LoadCheckpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint == nil {
+		return nil, fmt.Errorf("%w: execution %q", workflow.ErrNoCheckpoint, id)
+	}
+	return checkpoint, nil
+}
+
+func writeCheckpointError(w http.ResponseWriter, err error) {
+	if errors.Is(err, workflow.ErrNoCheckpoint) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
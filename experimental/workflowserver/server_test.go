@@ -0,0 +1,258 @@
+package workflowserver_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow"
+	"github.com/deepnoodle-ai/workflow/experimental/workflowserver"
+	"github.com/deepnoodle-ai/workflow/workflowtest"
+)
+
+// serve starts a test server for wf with the given activities.
+func serve(t *testing.T, wf *workflow.Workflow, activities *workflow.ActivityRegistry) *httptest.Server {
+	t.Helper()
+	workflows := workflow.NewMemoryWorkflowRegistry()
+	if err := workflows.Register(wf); err != nil {
+		t.Fatal(err)
+	}
+	server, err := workflowserver.NewServer(workflows, workflowserver.Options{
+		Activities:   activities,
+		Checkpointer: workflowtest.NewMemoryCheckpointer(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(server)
+	t.Cleanup(func() {
+		ts.Close()
+		server.Close()
+	})
+	return ts
+}
+
+// newTestServer serves a "greet" workflow whose activity fails while
+// failing is set.
+func newTestServer(t *testing.T, failing *atomic.Bool) *httptest.Server {
+	t.Helper()
+	wf, err := workflow.New(workflow.Options{
+		Name:   "greet",
+		Inputs: []*workflow.Input{{Name: "name", Type: "string"}},
+		Steps: []*workflow.Step{{
+			Name:       "hello",
+			Activity:   "hello",
+			Parameters: map[string]any{"name": "${inputs.name}"},
+			Store:      "greeting",
+		}},
+		Outputs: []*workflow.Output{{Name: "greeting"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	activities := workflow.NewActivityRegistry()
+	activities.MustRegister(workflow.ActivityFunc("hello", func(ctx workflow.Context, p map[string]any) (any, error) {
+		if failing.Load() {
+			return nil, errors.New("unavailable")
+		}
+		return "hello " + p["name"].(string), nil
+	}))
+	return serve(t, wf, activities)
+}
+
+// do sends a request with body encoded as JSON, decodes the response
+// into out when it is non-nil, and returns the status code.
+func do(t *testing.T, method, url string, body any, out any) int {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, url, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// expectCode fails the test when got is not want.
+func expectCode(t *testing.T, got, want int) {
+	t.Helper()
+	if got != want {
+		t.Fatalf("status code = %d, want %d", got, want)
+	}
+}
+
+// waitForStatus polls the execution until it reaches status.
+func waitForStatus(t *testing.T, ts *httptest.Server, id string, status workflow.ExecutionStatus) workflowserver.ExecutionStatus {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var got workflowserver.ExecutionStatus
+		do(t, http.MethodGet, ts.URL+"/executions/"+id, nil, &got)
+		if got.Status == status {
+			return got
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("execution %s status = %q, want %q", id, got.Status, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServerStartAndQuery(t *testing.T) {
+	ts := newTestServer(t, &atomic.Bool{})
+
+	var list struct {
+		Workflows []*workflow.WorkflowSummary `json:"workflows"`
+	}
+	expectCode(t, do(t, http.MethodGet, ts.URL+"/workflows", nil, &list), http.StatusOK)
+	if len(list.Workflows) != 1 || list.Workflows[0].Name != "greet" {
+		t.Fatalf("workflows = %+v, want only greet", list.Workflows)
+	}
+
+	var started workflowserver.ExecutionStatus
+	code := do(t, http.MethodPost, ts.URL+"/workflows/greet/executions", workflowserver.StartRequest{
+		Inputs: map[string]any{"name": "ada"},
+		Labels: map[string]string{"tenant": "acme"},
+	}, &started)
+	expectCode(t, code, http.StatusAccepted)
+	if started.WorkflowName != "greet" {
+		t.Fatalf("workflow name = %q, want greet", started.WorkflowName)
+	}
+
+	done := waitForStatus(t, ts, started.ExecutionID, workflow.ExecutionStatusCompleted)
+	if done.Outputs["greeting"] != "hello ada" {
+		t.Fatalf("greeting = %v, want hello ada", done.Outputs["greeting"])
+	}
+	if done.Labels["tenant"] != "acme" {
+		t.Fatalf("labels = %v, want tenant=acme", done.Labels)
+	}
+
+	code = do(t, http.MethodPost, ts.URL+"/workflows/greet/executions", workflowserver.StartRequest{
+		ExecutionID: started.ExecutionID,
+		Inputs:      map[string]any{"name": "ada"},
+	}, nil)
+	expectCode(t, code, http.StatusConflict)
+}
+
+func TestServerResume(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	ts := newTestServer(t, &failing)
+
+	var started workflowserver.ExecutionStatus
+	do(t, http.MethodPost, ts.URL+"/workflows/greet/executions", workflowserver.StartRequest{
+		Inputs: map[string]any{"name": "bob"},
+	}, &started)
+	failed := waitForStatus(t, ts, started.ExecutionID, workflow.ExecutionStatusFailed)
+	if !strings.Contains(failed.Error, "unavailable") {
+		t.Fatalf("error = %q, want it to mention unavailable", failed.Error)
+	}
+
+	failing.Store(false)
+	resumeURL := ts.URL + "/executions/" + started.ExecutionID + "/resume"
+	expectCode(t, do(t, http.MethodPost, resumeURL, nil, nil), http.StatusAccepted)
+	done := waitForStatus(t, ts, started.ExecutionID, workflow.ExecutionStatusCompleted)
+	if done.Outputs["greeting"] != "hello bob" {
+		t.Fatalf("greeting = %v, want hello bob", done.Outputs["greeting"])
+	}
+
+	expectCode(t, do(t, http.MethodPost, resumeURL, nil, nil), http.StatusConflict)
+}
+
+func TestServerResumeSensitiveInput(t *testing.T) {
+	wf, err := workflow.New(workflow.Options{
+		Name: "call-api",
+		Inputs: []*workflow.Input{
+			{Name: "api_key", Type: "string", Sensitive: true},
+			{Name: "region", Type: "string"},
+		},
+		Steps: []*workflow.Step{{
+			Name:       "call",
+			Activity:   "call",
+			Parameters: map[string]any{"key": "${inputs.api_key}", "region": "${inputs.region}"},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var failing atomic.Bool
+	failing.Store(true)
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	activities := workflow.NewActivityRegistry()
+	activities.MustRegister(workflow.ActivityFunc("call", func(ctx workflow.Context, p map[string]any) (any, error) {
+		mu.Lock()
+		calls = append(calls, p["key"].(string)+"/"+p["region"].(string))
+		mu.Unlock()
+		if failing.Load() {
+			return nil, errors.New("unavailable")
+		}
+		return nil, nil
+	}))
+	ts := serve(t, wf, activities)
+
+	var started workflowserver.ExecutionStatus
+	do(t, http.MethodPost, ts.URL+"/workflows/call-api/executions", workflowserver.StartRequest{
+		Inputs: map[string]any{"api_key": "secret", "region": "eu"},
+	}, &started)
+	waitForStatus(t, ts, started.ExecutionID, workflow.ExecutionStatusFailed)
+	resumeURL := ts.URL + "/executions/" + started.ExecutionID + "/resume"
+
+	// The checkpoint holds the key redacted, so it must be given again.
+	var body map[string]string
+	expectCode(t, do(t, http.MethodPost, resumeURL, nil, &body), http.StatusBadRequest)
+	if !strings.Contains(body["error"], "api_key") {
+		t.Fatalf("error = %q, want it to name api_key", body["error"])
+	}
+
+	failing.Store(false)
+	code := do(t, http.MethodPost, resumeURL, workflowserver.ResumeRequest{
+		Inputs: map[string]any{"api_key": "rotated"},
+	}, nil)
+	expectCode(t, code, http.StatusAccepted)
+	waitForStatus(t, ts, started.ExecutionID, workflow.ExecutionStatusCompleted)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"secret/eu", "rotated/eu"}; !slices.Equal(calls, want) {
+		t.Fatalf("activity calls = %v, want %v", calls, want)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	ts := newTestServer(t, &atomic.Bool{})
+
+	var body map[string]string
+	expectCode(t, do(t, http.MethodPost, ts.URL+"/workflows/missing/executions", nil, &body), http.StatusNotFound)
+	if !strings.Contains(body["error"], "missing") {
+		t.Fatalf("error = %q, want it to name the workflow", body["error"])
+	}
+	expectCode(t, do(t, http.MethodGet, ts.URL+"/executions/unknown", nil, nil), http.StatusNotFound)
+	expectCode(t, do(t, http.MethodPost, ts.URL+"/executions/unknown/resume", nil, nil), http.StatusNotFound)
+	expectCode(t, do(t, http.MethodPost, ts.URL+"/workflows/greet/executions", nil, nil), http.StatusBadRequest)
+}
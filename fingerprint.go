@@ -0,0 +1,82 @@
+package workflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+)
+
+// fingerprintDefinition is the normalized form of a workflow that
+// Fingerprint hashes. Steps are ordered by name and descriptions are
+// dropped, so reordering the step list or editing prose does not change
+// the fingerprint. encoding/json sorts map keys, which makes parameter
+// and policy maps order-insensitive.
+type fingerprintDefinition struct {
+	Name          string                  `json:"name"`
+	Start         string                  `json:"start"`
+	Inputs        []*Input                `json:"inputs,omitempty"`
+	Outputs       []*Output               `json:"outputs,omitempty"`
+	State         map[string]any          `json:"state,omitempty"`
+	Steps         []*Step                 `json:"steps"`
+	ErrorPolicies map[string]*RetryConfig `json:"error_policies,omitempty"`
+	CatchPolicies map[string]*CatchConfig `json:"catch_policies,omitempty"`
+	OnError       string                  `json:"on_error,omitempty"`
+}
+
+// Fingerprint returns a stable hex-encoded SHA-256 hash of the workflow
+// definition: its steps, edges, conditions, activities, parameters,
+// inputs, outputs, initial state, and policies. Two workflows built from
+// equivalent options hash equally regardless of map ordering or the
+// order steps are listed in; descriptions are ignored.
+//
+// The fingerprint is recorded in every checkpoint so a resume against a
+// changed definition can be detected. It is empty if the definition
+// holds values that cannot be encoded as JSON.
+func (w *Workflow) Fingerprint() string {
+	return w.fingerprint
+}
+
+func computeFingerprint(w *Workflow) string {
+	def := fingerprintDefinition{
+		Name:          w.name,
+		Start:         w.start.Name,
+		State:         w.initialState,
+		ErrorPolicies: w.errorPolicies,
+		CatchPolicies: w.catchPolicies,
+		OnError:       w.onError,
+	}
+	for _, input := range w.inputs {
+		normalized := *input
+		normalized.Description = ""
+		def.Inputs = append(def.Inputs, &normalized)
+	}
+	for _, output := range w.outputs {
+		normalized := *output
+		normalized.Description = ""
+		def.Outputs = append(def.Outputs, &normalized)
+	}
+	for _, step := range w.steps {
+		normalized := *step
+		normalized.Description = ""
+		def.Steps = append(def.Steps, &normalized)
+	}
+	sort.SliceStable(def.Steps, func(i, j int) bool {
+		return def.Steps[i].Name < def.Steps[j].Name
+	})
+
+	data, err := json.Marshal(def)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// shortFingerprint abbreviates a fingerprint for error messages.
+func shortFingerprint(fp string) string {
+	if len(fp) > 12 {
+		return fp[:12]
+	}
+	return fp
+}
@@ -0,0 +1,70 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func fingerprintOptions(condition string) Options {
+	return Options{
+		Name:  "fingerprint",
+		State: map[string]any{"a": 1, "b": 2, "c": 3},
+		Steps: []*Step{
+			{
+				Name:       "fetch",
+				Activity:   "http",
+				Store:      "response",
+				Parameters: map[string]any{"url": "https://example.com", "method": "GET", "retries": 3},
+				Next: []*Edge{
+					{Step: "ok", Condition: condition},
+					{Step: "retry"},
+				},
+				EdgeMatchingStrategy: EdgeMatchingFirst,
+			},
+			{Name: "ok", Activity: "print"},
+			{Name: "retry", Activity: "print"},
+		},
+	}
+}
+
+func TestWorkflowFingerprint(t *testing.T) {
+	a, err := New(fingerprintOptions("state.response.status == 200"))
+	require.NoError(t, err)
+	b, err := New(fingerprintOptions("state.response.status == 200"))
+	require.NoError(t, err)
+
+	require.Len(t, a.Fingerprint(), 64)
+	require.Equal(t, a.Fingerprint(), b.Fingerprint())
+
+	// Map iteration order varies between runs; the hash must not.
+	for i := 0; i < 20; i++ {
+		c, err := New(fingerprintOptions("state.response.status == 200"))
+		require.NoError(t, err)
+		require.Equal(t, a.Fingerprint(), c.Fingerprint())
+	}
+
+	changed, err := New(fingerprintOptions("state.response.status == 201"))
+	require.NoError(t, err)
+	require.NotEqual(t, a.Fingerprint(), changed.Fingerprint())
+}
+
+func TestWorkflowFingerprintIgnoresDescriptionsAndStepOrder(t *testing.T) {
+	base, err := New(fingerprintOptions("true"))
+	require.NoError(t, err)
+
+	opts := fingerprintOptions("true")
+	opts.Description = "documented"
+	opts.Steps[1].Description = "happy path"
+	// Keep "fetch" first so the start step is unchanged.
+	opts.Steps[1], opts.Steps[2] = opts.Steps[2], opts.Steps[1]
+	reordered, err := New(opts)
+	require.NoError(t, err)
+	require.Equal(t, base.Fingerprint(), reordered.Fingerprint())
+
+	opts = fingerprintOptions("true")
+	opts.Steps[1].Activity = "log"
+	renamed, err := New(opts)
+	require.NoError(t, err)
+	require.NotEqual(t, base.Fingerprint(), renamed.Fingerprint())
+}
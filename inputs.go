@@ -0,0 +1,350 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Input types checked by NewExecution. A provided value that does not
+// match its input's declared Type is rejected before the execution
+// starts. Inputs with an empty Type, InputTypeAny, or a type name not
+// listed here are passed through unchecked.
+const (
+	InputTypeString = "string"
+	InputTypeBool   = "bool"
+	InputTypeAny    = "any"
+
+	// InputTypeInt accepts any Go integer, or a whole-valued float64 or
+	// json.Number as produced by JSON decoding, and converts it to int.
+	InputTypeInt = "int"
+
+	// InputTypeFloat accepts any Go number or json.Number and converts
+	// it to float64.
+	InputTypeFloat = "float"
+
+	// InputTypeObject accepts a map with string keys.
+	InputTypeObject = "object"
+
+	// InputTypeArray accepts a slice or array.
+	InputTypeArray = "array"
+)
+
+// Input types with structured values. Inputs declared with these types
+// are converted by NewExecution, so activities receive a time.Duration
+// or time.Time instead of the string the caller supplied.
+const (
+	// InputTypeDuration accepts a time.ParseDuration string such as
+	// "1m30s", a time.Duration, or an integer count of nanoseconds.
+	InputTypeDuration = "duration"
+
+	// InputTypeTimestamp accepts an RFC 3339 string or a time.Time.
+	InputTypeTimestamp = "timestamp"
+)
+
+// RedactedValue replaces the value of a Sensitive input in callback
+// events and checkpoints, and of a parameter named with
+// WithRedactedParameters in activity events and activity log entries.
+const RedactedValue = "[REDACTED]"
+
+// sensitiveInputs returns the names of the inputs marked Sensitive, or
+// nil when there are none.
+func sensitiveInputs(inputs []*Input) map[string]bool {
+	var names map[string]bool
+	for _, input := range inputs {
+		if input.Sensitive {
+			if names == nil {
+				names = map[string]bool{}
+			}
+			names[input.Name] = true
+		}
+	}
+	return names
+}
+
+// redactInputs returns a copy of inputs with the values named in
+// sensitive replaced by RedactedValue.
+func redactInputs(inputs map[string]any, sensitive map[string]bool) map[string]any {
+	redacted := copyMap(inputs)
+	for name := range sensitive {
+		if _, ok := redacted[name]; ok {
+			redacted[name] = RedactedValue
+		}
+	}
+	return redacted
+}
+
+// redactParameters returns a copy of params with the values at the
+// given names replaced by RedactedValue. A dotted name descends into
+// nested maps, which are copied rather than modified. Names that are
+// not present are ignored.
+func redactParameters(params map[string]any, names []string) map[string]any {
+	redacted := copyMap(params)
+	for _, name := range names {
+		redactPath(redacted, strings.Split(name, "."))
+	}
+	return redacted
+}
+
+func redactPath(m map[string]any, path []string) {
+	value, ok := m[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		m[path[0]] = RedactedValue
+		return
+	}
+	switch nested := value.(type) {
+	case map[string]any:
+		nested = copyMap(nested)
+		redactPath(nested, path[1:])
+		m[path[0]] = nested
+	case map[string]string:
+		if _, ok := nested[path[1]]; ok && len(path) == 2 {
+			nested = maps.Clone(nested)
+			nested[path[1]] = RedactedValue
+			m[path[0]] = nested
+		}
+	}
+}
+
+// envInputValue reads the environment variable named by input.FromEnv
+// and parses it according to the input's Type: numbers and bools from
+// their text, objects and arrays as JSON, and everything else as the
+// string itself, which coerceInput then converts. It reports false
+// when the input has no FromEnv or the variable is unset or empty.
+// Errors do not include the value, which may be a secret.
+func envInputValue(input *Input) (any, bool, error) {
+	if input.FromEnv == "" {
+		return nil, false, nil
+	}
+	raw := os.Getenv(input.FromEnv)
+	if raw == "" {
+		return nil, false, nil
+	}
+	var value any = raw
+	var err error
+	switch input.Type {
+	case InputTypeBool:
+		value, err = strconv.ParseBool(raw)
+	case InputTypeInt:
+		value, err = strconv.Atoi(raw)
+	case InputTypeFloat:
+		value, err = strconv.ParseFloat(raw, 64)
+	case InputTypeObject, InputTypeArray:
+		err = json.Unmarshal([]byte(raw), &value)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("input %q: environment variable %s is not a valid %s", input.Name, input.FromEnv, input.Type)
+	}
+	return value, true, nil
+}
+
+// coerceInput checks value against the input's declared Type and
+// converts it to the corresponding Go type. Nil values and values of
+// unchecked types are returned unchanged.
+func coerceInput(input *Input, value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch input.Type {
+	case InputTypeString:
+		if _, ok := value.(string); !ok {
+			return nil, fmt.Errorf("expected a string, got %T", value)
+		}
+	case InputTypeBool:
+		if _, ok := value.(bool); !ok {
+			return nil, fmt.Errorf("expected a bool, got %T", value)
+		}
+	case InputTypeInt:
+		return coerceInt(value)
+	case InputTypeFloat:
+		return coerceFloat(value)
+	case InputTypeObject:
+		if rv := reflect.ValueOf(value); rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("expected an object, got %T", value)
+		}
+	case InputTypeArray:
+		if kind := reflect.ValueOf(value).Kind(); kind != reflect.Slice && kind != reflect.Array {
+			return nil, fmt.Errorf("expected an array, got %T", value)
+		}
+	case InputTypeDuration:
+		return coerceDuration(value)
+	case InputTypeTimestamp:
+		return coerceTimestamp(value)
+	}
+	return value, nil
+}
+
+func coerceInt(value any) (int, error) {
+	switch v := value.(type) {
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			return 0, fmt.Errorf("invalid int %q", v.String())
+		}
+		return int(n), nil
+	case float32, float64:
+		f := reflect.ValueOf(v).Float()
+		if f != math.Trunc(f) || math.IsInf(f, 0) {
+			return 0, fmt.Errorf("invalid int %v: not a whole number", v)
+		}
+		return int(f), nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(rv.Uint()), nil
+	}
+	return 0, fmt.Errorf("expected an int, got %T", value)
+}
+
+func coerceFloat(value any) (float64, error) {
+	if n, ok := value.(json.Number); ok {
+		f, err := n.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("invalid float %q", n.String())
+		}
+		return f, nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	}
+	return 0, fmt.Errorf("expected a float, got %T", value)
+}
+
+func coerceDuration(value any) (time.Duration, error) {
+	switch v := value.(type) {
+	case time.Duration:
+		return v, nil
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", v, err)
+		}
+		return d, nil
+	case int:
+		return time.Duration(v), nil
+	case int64:
+		return time.Duration(v), nil
+	case float64:
+		// JSON numbers decode as float64; a checkpointed time.Duration
+		// round-trips as whole nanoseconds.
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("invalid duration %v: not a whole number of nanoseconds", v)
+		}
+		return time.Duration(v), nil
+	}
+	return 0, fmt.Errorf("invalid duration: expected a string, got %T", value)
+}
+
+func coerceTimestamp(value any) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q: expected RFC 3339: %w", v, err)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp: expected an RFC 3339 string, got %T", value)
+}
+
+// coerceInputs applies coerceInput to every declared input present in
+// values and returns the converted copy.
+func coerceInputs(inputs []*Input, values map[string]any) (map[string]any, error) {
+	out := copyMap(values)
+	for _, input := range inputs {
+		v, ok := out[input.Name]
+		if !ok {
+			continue
+		}
+		coerced, err := coerceInput(input, v)
+		if err == nil {
+			err = checkInputConstraints(input, coerced)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("input %q: %w", input.Name, err)
+		}
+		out[input.Name] = coerced
+	}
+	return out, nil
+}
+
+// checkInputConstraints reports a value that is not one of the input's
+// Enum values or does not match its Pattern.
+func checkInputConstraints(input *Input, value any) error {
+	if len(input.Enum) > 0 && !enumContains(input.Enum, value) {
+		allowed, _ := json.Marshal(input.Enum)
+		return fmt.Errorf("value %v is not one of %s", formatInputValue(value), allowed)
+	}
+	if input.Pattern != "" {
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("pattern %q requires a string, got %T", input.Pattern, value)
+		}
+		re, err := regexp.Compile(input.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", input.Pattern, err)
+		}
+		if !re.MatchString(s) {
+			return fmt.Errorf("value %q does not match pattern %q", s, input.Pattern)
+		}
+	}
+	return nil
+}
+
+func enumContains(enum []any, value any) bool {
+	for _, candidate := range enum {
+		if a, ok := numericValue(candidate); ok {
+			if b, ok := numericValue(value); ok && a == b {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// numericValue returns v as a float64 when it is a plain Go number.
+func numericValue(v any) (float64, bool) {
+	if v == nil {
+		return 0, false
+	}
+	switch reflect.TypeOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		f, err := coerceFloat(v)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func formatInputValue(value any) string {
+	if data, err := json.Marshal(value); err == nil {
+		return string(data)
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,251 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestInputCoercion(t *testing.T) {
+	wf, err := New(Options{
+		Name: "coerce-inputs",
+		Inputs: []*Input{
+			{Name: "delay", Type: InputTypeDuration},
+			{Name: "deadline", Type: InputTypeTimestamp},
+			{Name: "grace", Type: InputTypeDuration, Default: "5m"},
+		},
+		Steps: []*Step{
+			{Name: "capture", Activity: "capture", Parameters: map[string]any{"delay": "${inputs.delay}"}},
+		},
+	})
+	require.NoError(t, err)
+
+	newExecution := func(inputs map[string]any) (*Execution, map[string]any, error) {
+		got := map[string]any{}
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("capture", func(ctx Context, params map[string]any) (any, error) {
+			inputs := ctx.Inputs()
+			for _, k := range inputs.Keys() {
+				got[k], _ = inputs.Get(k)
+			}
+			got["param"] = params["delay"]
+			return nil, nil
+		}))
+		exec, err := NewExecution(wf, reg, WithInputs(inputs), WithScriptCompiler(newTestCompiler()))
+		return exec, got, err
+	}
+
+	t.Run("valid strings", func(t *testing.T) {
+		exec, got, err := newExecution(map[string]any{
+			"delay":    "1m30s",
+			"deadline": "2026-01-02T15:04:05Z",
+		})
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+
+		require.Equal(t, 90*time.Second, got["delay"])
+		require.Equal(t, 90*time.Second, got["param"])
+		require.Equal(t, 5*time.Minute, got["grace"])
+		require.Equal(t, time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC), got["deadline"])
+	})
+
+	t.Run("typed values pass through", func(t *testing.T) {
+		deadline := time.Now()
+		exec, _, err := newExecution(map[string]any{"delay": time.Second, "deadline": deadline})
+		require.NoError(t, err)
+		require.Equal(t, time.Second, exec.state.GetInputs()["delay"])
+		require.Equal(t, deadline, exec.state.GetInputs()["deadline"])
+	})
+
+	t.Run("invalid duration", func(t *testing.T) {
+		_, _, err := newExecution(map[string]any{"delay": "soon", "deadline": "2026-01-02T15:04:05Z"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `input "delay": invalid duration "soon"`)
+
+		_, _, err = newExecution(map[string]any{"delay": true, "deadline": "2026-01-02T15:04:05Z"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid duration")
+	})
+
+	t.Run("invalid timestamp", func(t *testing.T) {
+		_, _, err := newExecution(map[string]any{"delay": "1s", "deadline": "2026-01-02 15:04"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `input "deadline": invalid timestamp "2026-01-02 15:04"`)
+	})
+}
+
+func TestInputTypeValidation(t *testing.T) {
+	wf, err := New(Options{
+		Name: "typed-inputs",
+		Inputs: []*Input{
+			{Name: "name", Type: InputTypeString},
+			{Name: "count", Type: InputTypeInt},
+			{Name: "ratio", Type: InputTypeFloat, Default: 1},
+			{Name: "enabled", Type: InputTypeBool, Default: false},
+			{Name: "config", Type: InputTypeObject, Default: map[string]any{}},
+			{Name: "tags", Type: InputTypeArray, Default: []any{}},
+			{Name: "extra", Type: InputTypeAny, Default: 42},
+		},
+		Steps: []*Step{{Name: "noop", Activity: "noop"}},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("noop", func(ctx Context, params map[string]any) (any, error) {
+		return nil, nil
+	}))
+	newExecution := func(inputs map[string]any) (*Execution, error) {
+		return NewExecution(wf, reg, WithInputs(inputs))
+	}
+
+	t.Run("coerces JSON values", func(t *testing.T) {
+		var decoded map[string]any
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"name": "job", "count": 3, "ratio": 2, "enabled": true,
+			"config": {"a": 1}, "tags": ["x"], "extra": "anything"
+		}`), &decoded))
+		exec, err := newExecution(decoded)
+		require.NoError(t, err)
+		inputs := exec.state.GetInputs()
+		require.Equal(t, 3, inputs["count"])
+		require.Equal(t, 2.0, inputs["ratio"])
+		require.Equal(t, map[string]any{"a": 1.0}, inputs["config"])
+		require.Equal(t, []any{"x"}, inputs["tags"])
+		require.Equal(t, "anything", inputs["extra"])
+	})
+
+	t.Run("coerces Go values and defaults", func(t *testing.T) {
+		exec, err := newExecution(map[string]any{
+			"name":   "job",
+			"count":  int64(7),
+			"config": map[string]string{"a": "b"},
+			"tags":   []string{"x", "y"},
+		})
+		require.NoError(t, err)
+		inputs := exec.state.GetInputs()
+		require.Equal(t, 7, inputs["count"])
+		require.Equal(t, 1.0, inputs["ratio"])
+		require.Equal(t, false, inputs["enabled"])
+		require.Equal(t, map[string]string{"a": "b"}, inputs["config"])
+	})
+
+	for name, tc := range map[string]struct {
+		inputs map[string]any
+		want   string
+	}{
+		"string for int":     {map[string]any{"name": "job", "count": "3"}, `input "count": expected an int, got string`},
+		"fractional int":     {map[string]any{"name": "job", "count": 1.5}, `input "count": invalid int 1.5`},
+		"int for string":     {map[string]any{"name": 1, "count": 1}, `input "name": expected a string, got int`},
+		"string for float":   {map[string]any{"name": "job", "count": 1, "ratio": "0.5"}, `input "ratio": expected a float`},
+		"string for bool":    {map[string]any{"name": "job", "count": 1, "enabled": "true"}, `input "enabled": expected a bool`},
+		"array for object":   {map[string]any{"name": "job", "count": 1, "config": []any{}}, `input "config": expected an object`},
+		"object for array":   {map[string]any{"name": "job", "count": 1, "tags": map[string]any{}}, `input "tags": expected an array`},
+		"int keys on object": {map[string]any{"name": "job", "count": 1, "config": map[int]any{}}, `input "config": expected an object`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := newExecution(tc.inputs)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestInputConstraints(t *testing.T) {
+	wf, err := New(Options{
+		Name: "constrained-inputs",
+		Inputs: []*Input{
+			{Name: "env", Type: InputTypeString, Enum: []any{"dev", "prod"}},
+			{Name: "replicas", Type: InputTypeInt, Enum: []any{1.0, 3.0}, Default: 1},
+			{Name: "ticket", Type: InputTypeString, Pattern: `^[A-Z]+-[0-9]+$`, Default: "OPS-1"},
+		},
+		Steps: []*Step{{Name: "noop", Activity: "noop"}},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("noop", func(ctx Context, params map[string]any) (any, error) {
+		return nil, nil
+	}))
+	newExecution := func(inputs map[string]any) error {
+		_, err := NewExecution(wf, reg, WithInputs(inputs))
+		return err
+	}
+
+	require.NoError(t, newExecution(map[string]any{"env": "prod", "replicas": 3, "ticket": "ENG-42"}))
+
+	err = newExecution(map[string]any{"env": "staging"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `input "env": value "staging" is not one of ["dev","prod"]`)
+
+	err = newExecution(map[string]any{"env": "dev", "replicas": 2})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `input "replicas": value 2 is not one of [1,3]`)
+
+	err = newExecution(map[string]any{"env": "dev", "ticket": "eng-42"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `input "ticket": value "eng-42" does not match pattern`)
+
+	// Type checking runs first.
+	err = newExecution(map[string]any{"env": 1})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `input "env": expected a string`)
+
+	_, err = New(Options{
+		Name:   "bad-pattern",
+		Inputs: []*Input{{Name: "x", Type: InputTypeString, Pattern: "("}},
+		Steps:  []*Step{{Name: "noop", Activity: "noop"}},
+	})
+	require.ErrorIs(t, err, ErrInvalidInputConfig)
+}
+
+func TestInputFromEnv(t *testing.T) {
+	wf, err := New(Options{
+		Name: "env-inputs",
+		Inputs: []*Input{
+			{Name: "region", Type: InputTypeString, FromEnv: "WF_TEST_REGION", Default: "us-east-1"},
+			{Name: "workers", Type: InputTypeInt, FromEnv: "WF_TEST_WORKERS", Default: 1},
+			{Name: "timeout", Type: InputTypeDuration, FromEnv: "WF_TEST_TIMEOUT"},
+			{Name: "labels", Type: InputTypeObject, FromEnv: "WF_TEST_LABELS", Default: map[string]any{}},
+		},
+		Steps: []*Step{{Name: "noop", Activity: "noop"}},
+	})
+	require.NoError(t, err)
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("noop", func(ctx Context, params map[string]any) (any, error) {
+		return nil, nil
+	}))
+
+	t.Run("required without env or default", func(t *testing.T) {
+		_, err := NewExecution(wf, reg)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `input "timeout" is required`)
+	})
+
+	t.Run("explicit input over env over default", func(t *testing.T) {
+		t.Setenv("WF_TEST_WORKERS", "8")
+		t.Setenv("WF_TEST_TIMEOUT", "30s")
+		t.Setenv("WF_TEST_LABELS", `{"team": "infra"}`)
+		t.Setenv("WF_TEST_REGION", "")
+		exec, err := NewExecution(wf, reg, WithInputs(map[string]any{"labels": map[string]any{"team": "web"}}))
+		require.NoError(t, err)
+		inputs := exec.state.GetInputs()
+		require.Equal(t, "us-east-1", inputs["region"])
+		require.Equal(t, 8, inputs["workers"])
+		require.Equal(t, 30*time.Second, inputs["timeout"])
+		require.Equal(t, map[string]any{"team": "web"}, inputs["labels"])
+	})
+
+	t.Run("unparseable env value", func(t *testing.T) {
+		t.Setenv("WF_TEST_TIMEOUT", "30s")
+		t.Setenv("WF_TEST_WORKERS", "many")
+		_, err := NewExecution(wf, reg)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `input "workers": environment variable WF_TEST_WORKERS is not a valid int`)
+		require.NotContains(t, err.Error(), "many")
+	})
+}
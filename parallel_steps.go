@@ -0,0 +1,223 @@
+package workflow
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// stateWordPattern matches any use of the state global, including the
+// forms stateRefPattern cannot attribute to a single variable, such as
+// state["name"] or state passed as a whole.
+var stateWordPattern = regexp.MustCompile(`\bstate\b`)
+
+// stepsWordPattern matches uses of the steps global, which exposes the
+// results of earlier steps.
+var stepsWordPattern = regexp.MustCompile(`\bsteps\b`)
+
+// stepsRefPattern matches a reference into the steps global, such as
+// steps.fetch.body or steps["fetch"], but not the word in prose or a
+// field named steps, as in state.steps.count.
+var stepsRefPattern = regexp.MustCompile(`(^|[^.\w])steps\s*(\.\s*[A-Za-z_]|\[)`)
+
+// stepReadingStepResults returns the name of the first step whose
+// parameters, store expression, scripts, skip, each items, terminate
+// condition or message, or edge conditions reference the steps global,
+// or "" if none does.
+func (w *Workflow) stepReadingStepResults() string {
+	var found bool
+	var walk func(value any)
+	walk = func(value any) {
+		switch v := value.(type) {
+		case map[string]any:
+			for _, inner := range v {
+				walk(inner)
+			}
+		case []any:
+			for _, inner := range v {
+				walk(inner)
+			}
+		case string:
+			found = found || stepsRefPattern.MatchString(v)
+		}
+	}
+	for _, step := range w.steps {
+		found = false
+		walk(step.Parameters)
+		walk(step.StoreExpression)
+		walk(step.Skip)
+		walk(step.Before)
+		walk(step.After)
+		if step.Each != nil {
+			walk(step.Each.Items)
+		}
+		if step.Terminate != nil {
+			walk(step.Terminate.Condition)
+			walk(step.Terminate.Message)
+		}
+		for _, edge := range step.Next {
+			walk(edge.Condition)
+		}
+		if found {
+			return step.Name
+		}
+	}
+	return ""
+}
+
+// stepAccess summarizes the branch variables a step reads and writes,
+// as far as they can be seen in its definition.
+type stepAccess struct {
+	reads   map[string]bool
+	readAll bool
+	write   string
+
+	// readsSteps is set when the step reads earlier step results, so
+	// it must wait for the steps before it.
+	readsSteps bool
+}
+
+// conflicts reports whether running a and b in either order could give
+// different results: one writes a variable the other reads or writes.
+func (a stepAccess) conflicts(b stepAccess) bool {
+	touches := func(s stepAccess, name string) bool {
+		return name != "" && (s.readAll || s.reads[name] || s.write == name)
+	}
+	return touches(b, a.write) || touches(a, b.write)
+}
+
+// analyzeStepAccess collects the state references in the parameter
+// templates and store expression of step. A reference that cannot be
+// attributed to a named variable marks the step as reading everything.
+func analyzeStepAccess(step *Step) stepAccess {
+	access := stepAccess{reads: map[string]bool{}}
+	if store := step.Store + step.StoreAppend; store != "" {
+		access.write, _, _ = strings.Cut(strings.TrimPrefix(store, "state."), ".")
+	}
+	scan := func(code string) {
+		refs := stateRefPattern.FindAllStringSubmatch(code, -1)
+		if len(stateWordPattern.FindAllStringIndex(code, -1)) > len(refs) {
+			access.readAll = true
+		}
+		for _, m := range refs {
+			access.reads[m[1]] = true
+		}
+		if stepsWordPattern.MatchString(code) {
+			access.readsSteps = true
+		}
+	}
+	var walk func(value any)
+	walk = func(value any) {
+		switch v := value.(type) {
+		case map[string]any:
+			for _, inner := range v {
+				walk(inner)
+			}
+		case string:
+			scan(v)
+		}
+	}
+	walk(step.Parameters)
+	scan(step.StoreExpression)
+	return access
+}
+
+// parallelizable reports whether step is a plain activity step that can
+// run alongside its neighbours: no loop, no control-flow modifier, no
+// Before or After script, and no catch handler that could redirect the
+// branch mid-run.
+func parallelizable(step *Step) bool {
+	return step.Activity != "" && step.Each == nil && step.Join == nil &&
+		step.WaitSignal == nil && step.Sleep == nil && step.Pause == nil &&
+		step.Skip == "" && step.Before == "" && step.After == "" &&
+		len(step.Catch) == 0
+}
+
+// parallelStepGroups plans the runs of consecutive steps that
+// WithAutoParallelSteps executes concurrently. The result maps the name
+// of each step that starts a run of two or more to the run, in
+// workflow order. A run follows single unconditional edges and stops
+// at the first step that is not parallelizable, was already included,
+// or conflicts with a step already in the run.
+//
+// Workflow-level catch fallbacks can redirect any failing step, so a
+// workflow that declares them is never parallelized.
+func (w *Workflow) parallelStepGroups() map[string][]*Step {
+	groups := map[string][]*Step{}
+	if len(w.catchFallbacks) > 0 {
+		return groups
+	}
+	for _, head := range w.steps {
+		if !parallelizable(head) {
+			continue
+		}
+		group := []*Step{head}
+		accesses := []stepAccess{analyzeStepAccess(head)}
+		included := map[string]bool{head.Name: true}
+		for {
+			last := group[len(group)-1]
+			if len(last.Next) != 1 {
+				break
+			}
+			edge := last.Next[0]
+			if edge.conditional() || edge.BranchName != "" || edge.Else {
+				break
+			}
+			next, ok := w.stepsByName[edge.Step]
+			if !ok || included[next.Name] || !parallelizable(next) {
+				break
+			}
+			access := analyzeStepAccess(next)
+			independent := !access.readsSteps
+			for _, prior := range accesses {
+				if prior.conflicts(access) {
+					independent = false
+					break
+				}
+			}
+			if !independent {
+				break
+			}
+			group = append(group, next)
+			accesses = append(accesses, access)
+			included[next.Name] = true
+		}
+		if len(group) > 1 {
+			groups[head.Name] = group
+		}
+	}
+	return groups
+}
+
+// prefetchedStep holds the outcome of a step that ran ahead of its turn
+// as part of a parallel group.
+type prefetchedStep struct {
+	result any
+	err    error
+}
+
+// executeStepGroup runs the activities of every step in group at once
+// and returns the outcome of the first. The outcomes of the rest are
+// held until the branch reaches each of them, so results are stored,
+// recorded, and checkpointed in workflow order as if the steps had run
+// one after another.
+func (p *branch) executeStepGroup(ctx context.Context, group []*Step) (any, error) {
+	outcomes := make([]prefetchedStep, len(group))
+	var wg sync.WaitGroup
+	for i, step := range group {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := p.executeStepActivity(ctx, step)
+			outcomes[i] = prefetchedStep{result: result, err: err}
+		}()
+	}
+	wg.Wait()
+
+	p.prefetched = make(map[string]prefetchedStep, len(group)-1)
+	for i, step := range group[1:] {
+		p.prefetched[step.Name] = outcomes[i+1]
+	}
+	return outcomes[0].result, outcomes[0].err
+}
@@ -0,0 +1,161 @@
+package workflow
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestAutoParallelSteps(t *testing.T) {
+	wf, err := New(Options{
+		Name:  "independent-fetches",
+		State: map[string]any{"id": 7},
+		Steps: []*Step{
+			{
+				Name:       "fetch-user",
+				Activity:   "fetch",
+				Parameters: map[string]any{"what": "user-${state.id}"},
+				Store:      "user",
+				Next:       []*Edge{{Step: "fetch-orders"}},
+			},
+			{
+				Name:       "fetch-orders",
+				Activity:   "fetch",
+				Parameters: map[string]any{"what": "orders-${state.id}"},
+				Store:      "orders",
+				Next:       []*Edge{{Step: "summarize"}},
+			},
+			{
+				Name:       "summarize",
+				Activity:   "fetch",
+				Parameters: map[string]any{"what": "${state.user}+${state.orders}"},
+				Store:      "summary",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	groups := wf.parallelStepGroups()
+	require.Len(t, groups, 1)
+	require.Len(t, groups["fetch-user"], 2)
+
+	// Each activity waits until the other independent one has started,
+	// so the execution only completes if they run concurrently.
+	var running, peak atomic.Int32
+	both := make(chan struct{})
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("fetch", func(ctx Context, params map[string]any) (any, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		if n > peak.Load() {
+			peak.Store(n)
+		}
+		if ctx.StepName() != "summarize" {
+			if n == 2 {
+				close(both)
+			}
+			select {
+			case <-both:
+			case <-time.After(2 * time.Second):
+			}
+		}
+		return params["what"], nil
+	}))
+
+	exec, err := NewExecution(wf, reg, WithAutoParallelSteps(true))
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.Equal(t, int32(2), peak.Load())
+
+	state := exec.state.GetBranchStates()["main"]
+	require.Equal(t, "user-7+orders-7", state.Variables["summary"])
+	require.Len(t, state.StepOutputs, 3)
+}
+
+func TestParallelStepGroupsRespectDependencies(t *testing.T) {
+	wf, err := New(Options{
+		Name: "dependent-chain",
+		Steps: []*Step{
+			{Name: "a", Activity: "work", Store: "x", Next: []*Edge{{Step: "b"}}},
+			{Name: "b", Activity: "work", Parameters: map[string]any{"in": "${state.x}"}, Next: []*Edge{{Step: "c"}}},
+			{Name: "c", Activity: "work", Parameters: map[string]any{"in": `${state["x"]}`}, Next: []*Edge{{Step: "d"}}},
+			{Name: "d", Activity: "work", Store: "x", Next: []*Edge{{Step: "e", Condition: "true"}}},
+			{Name: "e", Activity: "work"},
+		},
+	})
+	require.NoError(t, err)
+	groups := wf.parallelStepGroups()
+	// a/b conflict on x; b and c only read; c reads everything, so d
+	// cannot join; d's only edge is conditional.
+	require.Len(t, groups, 1)
+	require.Len(t, groups["b"], 2)
+	require.Equal(t, "c", groups["b"][1].Name)
+}
+
+func TestParallelStepGroupsWaitForStepOutputs(t *testing.T) {
+	wf, err := New(Options{
+		Name: "step-output-chain",
+		Steps: []*Step{
+			{Name: "a", Activity: "work", Next: []*Edge{{Step: "b"}}},
+			{Name: "b", Activity: "work", Next: []*Edge{{Step: "c"}}},
+			{Name: "c", Activity: "work", Parameters: map[string]any{"in": "${steps.a}"}},
+		},
+	})
+	require.NoError(t, err)
+	groups := wf.parallelStepGroups()
+	// c reads an earlier result, so it cannot run alongside a and b.
+	require.Len(t, groups, 1)
+	require.Len(t, groups["a"], 2)
+}
+
+func TestParallelStepGroupHeadFailure(t *testing.T) {
+	wf, err := New(Options{
+		Name: "failing-head",
+		Steps: []*Step{
+			{Name: "a", Activity: "work", Store: "a", Next: []*Edge{{Step: "b"}}},
+			{Name: "b", Activity: "work", Store: "b", Next: []*Edge{{Step: "c"}}},
+			{Name: "c", Activity: "work", Store: "c"},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, wf.parallelStepGroups()["a"], 3)
+
+	// The head fails only once the later steps' activities have run.
+	var calls atomic.Int32
+	others := make(chan struct{})
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("work", func(ctx Context, params map[string]any) (any, error) {
+		if calls.Add(1) == 3 {
+			close(others)
+		}
+		if ctx.StepName() != "a" {
+			return ctx.StepName(), nil
+		}
+		select {
+		case <-others:
+		case <-time.After(2 * time.Second):
+		}
+		return nil, NewWorkflowError(ErrorTypeFatal, "a failed")
+	}))
+
+	exec, err := NewExecution(wf, reg, WithAutoParallelSteps(true))
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusFailed, result.Status)
+	require.Contains(t, result.Error.Error(), "a failed")
+	require.Equal(t, int32(3), calls.Load())
+
+	state := exec.state.GetBranchStates()["main"]
+	for _, name := range []string{"a", "b", "c"} {
+		_, stored := state.Variables[name]
+		require.False(t, stored, "variable %q", name)
+		_, recorded := state.StepOutputs[name]
+		require.False(t, recorded, "step output %q", name)
+	}
+}
@@ -0,0 +1,180 @@
+package workflow
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Recording is the sequence of activity results captured from one
+// execution by a Recorder. Activities are the engine's only source of
+// non-determinism — the clock and random numbers are reached through
+// activities such as time and random — so replaying these results
+// reproduces the execution's path through the workflow. A Recording
+// encodes to JSON for storage; note that numbers in results decode as
+// float64.
+type Recording struct {
+	WorkflowName string              `json:"workflow_name"`
+	ExecutionID  string              `json:"execution_id"`
+	Inputs       map[string]any      `json:"inputs,omitempty"`
+	Activities   []*RecordedActivity `json:"activities"`
+}
+
+// RecordedActivity is one activity invocation. Sequence counts earlier
+// invocations of the same step on the same branch, which covers
+// retries and Each iterations.
+type RecordedActivity struct {
+	BranchID     string         `json:"branch_id"`
+	StepName     string         `json:"step_name"`
+	Activity     string         `json:"activity"`
+	Sequence     int            `json:"sequence"`
+	Parameters   map[string]any `json:"parameters,omitempty"`
+	Result       any            `json:"result,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	ErrorType    string         `json:"error_type,omitempty"`
+	ErrorDetails any            `json:"error_details,omitempty"`
+}
+
+// replayKey identifies an activity invocation within an execution.
+type replayKey struct {
+	branchID string
+	stepName string
+	sequence int
+}
+
+// Recorder captures every activity invocation of an execution. Pass it
+// with WithRecorder and read the result with Recording once the
+// execution returns. A Recorder records a single execution and is safe
+// for concurrent use.
+type Recorder struct {
+	mu        sync.Mutex
+	recording Recording
+	counts    map[[2]string]int
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{counts: map[[2]string]int{}}
+}
+
+// Recording returns a copy of everything recorded so far.
+func (r *Recorder) Recording() *Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec := r.recording
+	rec.Inputs = copyMap(r.recording.Inputs)
+	rec.Activities = append([]*RecordedActivity(nil), r.recording.Activities...)
+	return &rec
+}
+
+func (r *Recorder) start(workflowName, executionID string, inputs map[string]any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recording.WorkflowName = workflowName
+	r.recording.ExecutionID = executionID
+	r.recording.Inputs = copyMap(inputs)
+}
+
+func (r *Recorder) record(branchID, stepName, activity string, params map[string]any, result any, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := [2]string{branchID, stepName}
+	entry := &RecordedActivity{
+		BranchID:   branchID,
+		StepName:   stepName,
+		Activity:   activity,
+		Sequence:   r.counts[k],
+		Parameters: copyMap(params),
+		Result:     result,
+	}
+	r.counts[k]++
+	if err != nil {
+		wErr := ClassifyError(err)
+		entry.Error = err.Error()
+		entry.ErrorType = wErr.Type
+		entry.ErrorDetails = wErr.Details
+	}
+	r.recording.Activities = append(r.recording.Activities, entry)
+}
+
+// replayer serves recorded activity results to a replayed execution.
+type replayer struct {
+	mu      sync.Mutex
+	entries map[replayKey]*RecordedActivity
+	counts  map[[2]string]int
+}
+
+func newReplayer(rec *Recording) *replayer {
+	p := &replayer{
+		entries: make(map[replayKey]*RecordedActivity, len(rec.Activities)),
+		counts:  map[[2]string]int{},
+	}
+	for _, a := range rec.Activities {
+		p.entries[replayKey{a.BranchID, a.StepName, a.Sequence}] = a
+	}
+	return p
+}
+
+// next returns the recorded result for the next invocation of stepName
+// on branchID.
+func (p *replayer) next(branchID, stepName, activity string) (any, error) {
+	p.mu.Lock()
+	k := [2]string{branchID, stepName}
+	seq := p.counts[k]
+	p.counts[k]++
+	entry, ok := p.entries[replayKey{branchID, stepName, seq}]
+	p.mu.Unlock()
+
+	if !ok {
+		return nil, replayMismatch(fmt.Sprintf("no recorded call %d of step %q on branch %q",
+			seq, stepName, branchID))
+	}
+	if entry.Activity != activity {
+		return nil, replayMismatch(fmt.Sprintf("step %q on branch %q called activity %q, recording has %q",
+			stepName, branchID, activity, entry.Activity))
+	}
+	if entry.Error != "" {
+		return nil, &replayedError{
+			msg: entry.Error,
+			cause: &WorkflowError{
+				Type:    entry.ErrorType,
+				Cause:   entry.Error,
+				Details: entry.ErrorDetails,
+			},
+		}
+	}
+	return entry.Result, nil
+}
+
+// replayMismatch is fatal so that retry and catch handlers do not mask
+// a divergence from the recording.
+func replayMismatch(msg string) error {
+	return &WorkflowError{
+		Type:    ErrorTypeFatal,
+		Cause:   msg,
+		Wrapped: ErrReplayMismatch,
+	}
+}
+
+// replayedError reproduces a recorded activity error: the original
+// message, with the original classification available to errors.As so
+// retry and catch matching behave as they did when recorded.
+type replayedError struct {
+	msg   string
+	cause *WorkflowError
+}
+
+func (e *replayedError) Error() string { return e.msg }
+func (e *replayedError) Unwrap() error { return e.cause }
+
+// replayActivity stands in for activities missing from the registry
+// of a replayed execution. Replay serves results from the recording,
+// so Execute is never called.
+type replayActivity struct {
+	name string
+}
+
+func (a replayActivity) Name() string { return a.name }
+
+func (a replayActivity) Execute(ctx Context, params map[string]any) (any, error) {
+	return nil, fmt.Errorf("activity %q is only available for replay", a.name)
+}
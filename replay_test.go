@@ -0,0 +1,114 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+// TestRecordReplay records a run whose path depends on activity results
+// (a retried flaky call, a conditional route, and parallel branches),
+// then replays it with no activities registered and gets the same
+// outputs.
+func TestRecordReplay(t *testing.T) {
+	wf, err := New(Options{
+		Name:   "record-replay",
+		Inputs: []*Input{{Name: "threshold", Type: "number"}},
+		Steps: []*Step{
+			{
+				Name:     "roll",
+				Activity: "roll",
+				Store:    "roll",
+				Retry:    []*RetryConfig{{ErrorEquals: []string{ErrorTypeAll}, MaxRetries: 2, BaseDelay: time.Millisecond}},
+				Next: []*Edge{
+					{Step: "high", Condition: "state.roll >= inputs.threshold"},
+					{Step: "low", Condition: "state.roll < inputs.threshold"},
+				},
+			},
+			{
+				Name:     "high",
+				Activity: "stamp",
+				Store:    "route",
+				Next:     []*Edge{{Step: "left", BranchName: "left"}, {Step: "right", BranchName: "right"}},
+			},
+			{Name: "low", Activity: "stamp", Store: "route"},
+			{Name: "left", Activity: "stamp", Store: "left"},
+			{Name: "right", Activity: "stamp", Store: "right"},
+		},
+		Outputs: []*Output{
+			{Name: "roll", Variable: "roll"},
+			{Name: "route", Variable: "route"},
+			{Name: "left", Variable: "left", Branch: "left"},
+			{Name: "right", Variable: "right", Branch: "right"},
+		},
+	})
+	require.NoError(t, err)
+
+	var calls atomic.Int64
+	reg := NewActivityRegistry()
+	require.NoError(t, reg.Register(ActivityFunc("roll", func(ctx Context, params map[string]any) (any, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("flaky")
+		}
+		return 7, nil
+	})))
+	require.NoError(t, reg.Register(ActivityFunc("stamp", func(ctx Context, params map[string]any) (any, error) {
+		calls.Add(1)
+		return ctx.StepName() + "-" + ctx.BranchID(), nil
+	})))
+
+	ctx := context.Background()
+	recorder := NewRecorder()
+	exec1, err := NewExecution(wf, reg,
+		WithInputs(map[string]any{"threshold": 5}),
+		WithRecorder(recorder),
+	)
+	require.NoError(t, err)
+	res1, err := exec1.Execute(ctx)
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, res1.Status)
+	require.Equal(t, "high-main", res1.Outputs["route"])
+	require.Equal(t, "left-left", res1.Outputs["left"])
+	require.Equal(t, "right-right", res1.Outputs["right"])
+
+	rec := recorder.Recording()
+	require.Equal(t, "record-replay", rec.WorkflowName)
+	require.Equal(t, exec1.ID(), rec.ExecutionID)
+	require.Len(t, rec.Activities, 5)
+	require.Equal(t, "flaky", rec.Activities[0].Error)
+	require.Equal(t, 1, rec.Activities[1].Sequence)
+
+	// Round-trip through JSON as a stored recording would.
+	data, err := json.Marshal(rec)
+	require.NoError(t, err)
+	var stored Recording
+	require.NoError(t, json.Unmarshal(data, &stored))
+
+	recordedCalls := calls.Load()
+	exec2, err := NewExecution(wf, NewActivityRegistry(), WithReplay(&stored))
+	require.NoError(t, err)
+	res2, err := exec2.Execute(ctx)
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, res2.Status)
+	require.Equal(t, recordedCalls, calls.Load())
+	require.Equal(t, float64(7), res2.Outputs["roll"])
+	for _, name := range []string{"route", "left", "right"} {
+		require.Equal(t, res1.Outputs[name], res2.Outputs[name])
+	}
+
+	t.Run("mismatch fails the execution", func(t *testing.T) {
+		short := stored
+		short.Activities = stored.Activities[:2]
+		exec, err := NewExecution(wf, NewActivityRegistry(), WithReplay(&short))
+		require.NoError(t, err)
+		res, err := exec.Execute(ctx)
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusFailed, res.Status)
+		require.ErrorIs(t, res.Error, ErrReplayMismatch)
+	})
+}
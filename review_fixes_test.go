@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -327,3 +328,50 @@ func (f *failingCheckpointer) LoadCheckpoint(ctx context.Context, executionID st
 func (f *failingCheckpointer) DeleteCheckpoint(ctx context.Context, executionID string) error {
 	return nil
 }
+
+// TestFinalCheckpointSavedAfterFailure verifies the final checkpoint of
+// a failed execution is saved even though the failure cancelled the
+// run context, for checkpointers that honor ctx.
+func TestFinalCheckpointSavedAfterFailure(t *testing.T) {
+	wf, err := New(Options{
+		Name:  "fails",
+		Steps: []*Step{{Name: "boom", Activity: "boom"}},
+	})
+	require.NoError(t, err)
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("boom", func(ctx Context, params map[string]any) (any, error) {
+		return nil, errors.New("boom")
+	}))
+	checkpointer := &ctxCheckpointer{}
+	exec, err := NewExecution(wf, reg, WithCheckpointer(checkpointer))
+	require.NoError(t, err)
+
+	res, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.True(t, res.Failed())
+	require.NotNil(t, checkpointer.last)
+	require.Equal(t, ExecutionStatusFailed, checkpointer.last.Status)
+}
+
+// ctxCheckpointer keeps the last checkpoint, refusing saves whose
+// context is done.
+type ctxCheckpointer struct {
+	mu   sync.Mutex
+	last *Checkpoint
+}
+
+func (c *ctxCheckpointer) SaveCheckpoint(ctx context.Context, cp *Checkpoint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.last = cp
+	return nil
+}
+func (c *ctxCheckpointer) LoadCheckpoint(ctx context.Context, executionID string) (*Checkpoint, error) {
+	return nil, nil
+}
+func (c *ctxCheckpointer) DeleteCheckpoint(ctx context.Context, executionID string) error {
+	return nil
+}
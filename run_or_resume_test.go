@@ -4,9 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/deepnoodle-ai/workflow/internal/require"
+	"github.com/deepnoodle-ai/workflow/script"
 )
 
 func TestRunOrResumeFallsBackOnMissingCheckpoint(t *testing.T) {
@@ -72,3 +77,244 @@ type errorCheckpointer struct {
 func (e *errorCheckpointer) LoadCheckpoint(ctx context.Context, executionID string) (*Checkpoint, error) {
 	return nil, e.err
 }
+
+func TestResumeFromCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	checkpointer, err := NewFileCheckpointer(t.TempDir())
+	require.NoError(t, err)
+
+	wf, err := New(Options{
+		Name: "rewind",
+		Steps: []*Step{
+			{Name: "load", Activity: "load", Store: "record", Next: []*Edge{{Step: "process"}}},
+			{Name: "process", Activity: "process"},
+		},
+	})
+	require.NoError(t, err)
+
+	loads, fixed := 0, false
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("load", func(ctx Context, params map[string]any) (any, error) {
+		loads++
+		return "record-1", nil
+	}))
+	reg.MustRegister(ActivityFunc("process", func(ctx Context, params map[string]any) (any, error) {
+		if !fixed {
+			return nil, errors.New("bad record")
+		}
+		return "ok", nil
+	}))
+	newExec := func() *Execution {
+		exec, err := NewExecution(wf, reg,
+			WithScriptCompiler(newTestCompiler()),
+			WithCheckpointer(checkpointer),
+			WithExecutionID("exec-1"))
+		require.NoError(t, err)
+		return exec
+	}
+
+	result, err := newExec().Execute(ctx)
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusFailed, result.Status)
+
+	ids, err := checkpointer.ListCheckpoints(ctx, "exec-1")
+	require.NoError(t, err)
+	require.Greater(t, len(ids), 1)
+	first, err := checkpointer.LoadCheckpointByID(ctx, "exec-1", ids[0])
+	require.NoError(t, err)
+	require.Equal(t, ids[0], first.ID)
+	require.Equal(t, ExecutionStatusRunning, first.Status)
+
+	// Rewind past the failure to the first checkpoint and run again
+	// with the problem fixed.
+	fixed = true
+	result, err = newExec().ResumeFromCheckpoint(ctx, "exec-1", ids[0])
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.Equal(t, 2, loads)
+
+	// New checkpoints are numbered on from the one resumed.
+	latest, err := checkpointer.LoadCheckpoint(ctx, "exec-1")
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, latest.Status)
+	firstN, _ := strconv.Atoi(first.ID)
+	latestN, _ := strconv.Atoi(latest.ID)
+	require.Greater(t, latestN, firstN)
+
+	_, err = newExec().ResumeFromCheckpoint(ctx, "exec-1", "999")
+	require.ErrorIs(t, err, ErrNoCheckpoint)
+
+	exec, err := NewExecution(wf, reg, WithCheckpointer(NewNullCheckpointer()))
+	require.NoError(t, err)
+	_, err = exec.ResumeFromCheckpoint(ctx, "exec-1", ids[0])
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not support loading checkpoints by ID")
+}
+
+// unavailableCompiler compiles like the test compiler but fails every
+// evaluation, standing in for a script engine outage.
+type unavailableCompiler struct{ testCompiler }
+
+func (c unavailableCompiler) Compile(ctx context.Context, code string) (script.Script, error) {
+	if _, err := c.testCompiler.Compile(ctx, code); err != nil {
+		return nil, err
+	}
+	return unavailableScript{}, nil
+}
+
+type unavailableScript struct{}
+
+func (unavailableScript) Evaluate(context.Context, map[string]any) (script.Value, error) {
+	return nil, errors.New("script engine unavailable")
+}
+
+func TestResumeReusesIdempotentStepResult(t *testing.T) {
+	for _, idempotent := range []bool{true, false} {
+		t.Run(fmt.Sprintf("idempotent %v", idempotent), func(t *testing.T) {
+			ctx := context.Background()
+			checkpointer, err := NewFileCheckpointer(t.TempDir())
+			require.NoError(t, err)
+
+			wf, err := New(Options{
+				Name: "send-then-route",
+				Steps: []*Step{
+					{
+						Name:       "send",
+						Activity:   "send",
+						Idempotent: idempotent,
+						Store:      "receipt",
+						Next:       []*Edge{{Step: "done", Condition: `state.receipt != ""`}},
+					},
+					{Name: "done", Activity: "done"},
+				},
+			})
+			require.NoError(t, err)
+
+			sent := 0
+			reg := NewActivityRegistry()
+			reg.MustRegister(ActivityFunc("send", func(ctx Context, params map[string]any) (any, error) {
+				sent++
+				return fmt.Sprintf("message-%d", sent), nil
+			}))
+			reg.MustRegister(ActivityFunc("done", func(ctx Context, params map[string]any) (any, error) {
+				return nil, nil
+			}))
+			newExec := func(compiler script.Compiler) *Execution {
+				exec, err := NewExecution(wf, reg,
+					WithScriptCompiler(compiler),
+					WithCheckpointer(checkpointer),
+					WithExecutionID("send-1"))
+				require.NoError(t, err)
+				return exec
+			}
+
+			// The email is sent, then the edge condition fails.
+			result, err := newExec(unavailableCompiler{}).Execute(ctx)
+			require.NoError(t, err)
+			require.Equal(t, ExecutionStatusFailed, result.Status)
+			require.Equal(t, 1, sent)
+			checkpoint, err := checkpointer.LoadCheckpoint(ctx, "send-1")
+			require.NoError(t, err)
+			require.Equal(t, idempotent, checkpoint.BranchStates["main"].CompletedActivity != nil)
+
+			exec := newExec(newTestCompiler())
+			result, err = exec.Execute(ctx, ResumeFrom("send-1"))
+			require.NoError(t, err)
+			require.Equal(t, ExecutionStatusCompleted, result.Status, "%v", result.Error)
+
+			state := exec.state.GetBranchStates()["main"]
+			if idempotent {
+				require.Equal(t, 1, sent, "the email must not be sent twice")
+				require.Equal(t, "message-1", state.Variables["receipt"])
+			} else {
+				require.Equal(t, 2, sent)
+				require.Equal(t, "message-2", state.Variables["receipt"])
+			}
+			require.Nil(t, state.CompletedActivity)
+		})
+	}
+
+	_, err := New(Options{
+		Name:  "idempotent-sleep",
+		Steps: []*Step{{Name: "nap", Sleep: &SleepConfig{Duration: time.Second}, Idempotent: true}},
+	})
+	require.ErrorIs(t, err, ErrInvalidModifier)
+}
+
+func TestResumeContinuesEachLoop(t *testing.T) {
+	for _, concurrency := range []int{0, 3} {
+		t.Run(fmt.Sprintf("max concurrency %d", concurrency), func(t *testing.T) {
+			ctx := context.Background()
+			checkpointer, err := NewFileCheckpointer(t.TempDir())
+			require.NoError(t, err)
+
+			items := make([]any, 10)
+			for i := range items {
+				items[i] = i
+			}
+			wf, err := New(Options{
+				Name: "crash-mid-loop",
+				Steps: []*Step{{
+					Name:       "square",
+					Activity:   "square",
+					Each:       &Each{Items: items, As: "n", MaxConcurrency: concurrency},
+					Parameters: map[string]any{"n": "${state.n}"},
+					Store:      "squares",
+				}},
+			})
+			require.NoError(t, err)
+
+			var mu sync.Mutex
+			var processed []int
+			crashed := false
+			reg := NewActivityRegistry()
+			reg.MustRegister(ActivityFunc("square", func(ctx Context, params map[string]any) (any, error) {
+				n := params["n"].(int)
+				mu.Lock()
+				defer mu.Unlock()
+				if n == 7 && !crashed {
+					crashed = true
+					return nil, errors.New("worker lost")
+				}
+				processed = append(processed, n)
+				return n * n, nil
+			}))
+			newExec := func() *Execution {
+				exec, err := NewExecution(wf, reg,
+					WithScriptCompiler(newTestCompiler()),
+					WithCheckpointer(checkpointer),
+					WithExecutionID("loop-1"))
+				require.NoError(t, err)
+				return exec
+			}
+
+			result, err := newExec().Execute(ctx)
+			require.NoError(t, err)
+			require.Equal(t, ExecutionStatusFailed, result.Status)
+			firstRun := slices.Clone(processed)
+			processed = nil
+
+			exec := newExec()
+			result, err = exec.Execute(ctx, ResumeFrom("loop-1"))
+			require.NoError(t, err)
+			require.Equal(t, ExecutionStatusCompleted, result.Status)
+
+			// The resumed run only squares what the first run did not.
+			require.Contains(t, processed, 7)
+			for _, n := range processed {
+				require.False(t, slices.Contains(firstRun, n), "item %d ran twice", n)
+			}
+			require.Len(t, processed, 10-len(firstRun))
+
+			state := exec.state.GetBranchStates()["main"]
+			squares := state.Variables["squares"].([]any)
+			require.Len(t, squares, 10)
+			for i, square := range squares {
+				f, ok := toFloat(square)
+				require.True(t, ok)
+				require.Equal(t, float64(i*i), f)
+			}
+			require.Nil(t, state.EachProgress)
+		})
+	}
+}
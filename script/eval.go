@@ -3,7 +3,6 @@ package script
 import (
 	"context"
 	"fmt"
-	"regexp"
 	"strings"
 )
 
@@ -23,64 +22,115 @@ import (
 // whole value as a single ${...} token; callers building a string
 // (URLs, messages, topics) interpolate tokens inside surrounding text
 // and get a string back.
+//
+// A "$" immediately before the opening delimiter escapes it: "$${x}"
+// is the literal text "${x}". The delimiters themselves can be changed
+// with NewTemplateWithDelimiters.
 type Template struct {
-	raw        string
+	text       string   // literal text with escapes resolved, when there are no scripts
 	parts      []string // literal segments, interleaved with placeholders ("")
 	scripts    []Script // compiled scripts, one per placeholder
 	singleExpr bool     // raw (trimmed) is exactly one ${...} token
 }
 
-var templateExprRE = regexp.MustCompile(`\${([^}]+)}`)
+// Delimiters are the opening and closing markers of a template
+// expression. The expression is the text between Left and the first
+// Right that follows it, so it cannot itself contain Right.
+type Delimiters struct {
+	Left  string
+	Right string
+}
+
+// DefaultDelimiters are the standard ${...} delimiters.
+var DefaultDelimiters = Delimiters{Left: "${", Right: "}"}
+
+// Validate reports whether the delimiters are usable: both must be
+// non-empty.
+func (d Delimiters) Validate() error {
+	if d.Left == "" || d.Right == "" {
+		return fmt.Errorf("template delimiters must be non-empty, got %q and %q", d.Left, d.Right)
+	}
+	return nil
+}
+
+// IsTemplate reports whether s contains an opening delimiter and so
+// must be parsed as a template rather than used as a literal. Escaped
+// delimiters count, since parsing is what resolves them.
+func (d Delimiters) IsTemplate(s string) bool {
+	return strings.Contains(s, d.Left)
+}
 
 // NewTemplate parses raw as a ${...} template and compiles every
 // expression it contains against engine. Returns an error if any
 // expression is syntactically malformed (unclosed brace) or fails to
 // compile.
 func NewTemplate(engine Compiler, raw string) (*Template, error) {
-	if strings.Count(raw, "${") > strings.Count(raw, "}") {
-		return nil, fmt.Errorf("unclosed template expression in string: %q", raw)
-	}
+	return NewTemplateWithDelimiters(engine, raw, DefaultDelimiters)
+}
 
-	matches := templateExprRE.FindAllStringSubmatchIndex(raw, -1)
-	if len(matches) == 0 {
-		// If the string contains "${" but no valid ${...} matches were
-		// found, it has a malformed template expression.
-		if strings.Contains(raw, "${") {
-			return nil, fmt.Errorf("malformed template expression in string: %q", raw)
-		}
-		return &Template{raw: raw}, nil
+// NewTemplateWithDelimiters is NewTemplate with custom delimiters, for
+// workflows whose parameters contain literal ${...} text such as shell
+// scripts. "$" followed by delims.Left is still the escape for a
+// literal opening delimiter.
+func NewTemplateWithDelimiters(engine Compiler, raw string, delims Delimiters) (*Template, error) {
+	if err := delims.Validate(); err != nil {
+		return nil, err
 	}
+	escape := "$" + delims.Left
 
 	var (
 		parts   []string
 		scripts []Script
-		lastEnd int
+		literal strings.Builder
 	)
-	for _, match := range matches {
-		if match[0] > lastEnd {
-			parts = append(parts, raw[lastEnd:match[0]])
+	flush := func() {
+		if literal.Len() > 0 {
+			parts = append(parts, literal.String())
+			literal.Reset()
 		}
-		expr := raw[match[2]:match[3]]
-		compiled, err := engine.Compile(context.Background(), expr)
-		if err != nil {
-			return nil, fmt.Errorf("failed to compile template expression %q: %w", expr, err)
+	}
+	for i := 0; i < len(raw); {
+		rest := raw[i:]
+		switch {
+		case strings.HasPrefix(rest, escape):
+			literal.WriteString(delims.Left)
+			i += len(escape)
+		case strings.HasPrefix(rest, delims.Left):
+			end := strings.Index(rest[len(delims.Left):], delims.Right)
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed template expression in string: %q", raw)
+			}
+			expr := rest[len(delims.Left) : len(delims.Left)+end]
+			if expr == "" {
+				return nil, fmt.Errorf("malformed template expression in string: %q", raw)
+			}
+			compiled, err := engine.Compile(context.Background(), expr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compile template expression %q: %w", expr, err)
+			}
+			flush()
+			scripts = append(scripts, compiled)
+			parts = append(parts, "") // placeholder
+			i += len(delims.Left) + end + len(delims.Right)
+		default:
+			literal.WriteByte(raw[i])
+			i++
 		}
-		scripts = append(scripts, compiled)
-		parts = append(parts, "") // placeholder
-		lastEnd = match[1]
 	}
-	if lastEnd < len(raw) {
-		parts = append(parts, raw[lastEnd:])
+	flush()
+
+	if len(scripts) == 0 {
+		return &Template{text: strings.Join(parts, "")}, nil
 	}
 
-	trimmed := strings.TrimSpace(raw)
-	singleExpr := len(matches) == 1 &&
-		strings.HasPrefix(trimmed, "${") &&
-		strings.HasSuffix(trimmed, "}") &&
-		templateExprRE.FindString(trimmed) == trimmed
+	singleExpr := len(scripts) == 1
+	for _, part := range parts {
+		if part != "" && strings.TrimSpace(part) != "" {
+			singleExpr = false
+		}
+	}
 
 	return &Template{
-		raw:        raw,
 		parts:      parts,
 		scripts:    scripts,
 		singleExpr: singleExpr,
@@ -93,7 +143,7 @@ func NewTemplate(engine Compiler, raw string) (*Template, error) {
 // the concatenated string with each expression stringified.
 func (e *Template) Eval(ctx context.Context, globals map[string]any) (any, error) {
 	if len(e.scripts) == 0 {
-		return e.raw, nil
+		return e.text, nil
 	}
 
 	if e.singleExpr {
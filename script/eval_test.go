@@ -134,6 +134,58 @@ func TestTemplate(t *testing.T) {
 		require.Contains(t, err.Error(), "unclosed template expression")
 	})
 
+	t.Run("escaped delimiter is literal", func(t *testing.T) {
+		tmpl, err := NewTemplate(engine, "echo $${HOME} for ${state.name}")
+		require.NoError(t, err)
+		got, err := tmpl.Eval(context.Background(), map[string]any{
+			"state": map[string]any{"name": "Alice"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "echo ${HOME} for Alice", got)
+	})
+
+	t.Run("only escapes yields literal", func(t *testing.T) {
+		tmpl, err := NewTemplate(engine, "$${state.name}")
+		require.NoError(t, err)
+		got, err := tmpl.Eval(context.Background(), nil)
+		require.NoError(t, err)
+		require.Equal(t, "${state.name}", got)
+	})
+
+	t.Run("empty expression is rejected", func(t *testing.T) {
+		_, err := NewTemplate(engine, "x ${} y")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "malformed template expression")
+	})
+
+	t.Run("custom delimiters", func(t *testing.T) {
+		delims := Delimiters{Left: "<<", Right: ">>"}
+		tmpl, err := NewTemplateWithDelimiters(engine, `echo "${HOME}" <<state.name>> $<<x>>`, delims)
+		require.NoError(t, err)
+		got, err := tmpl.Eval(context.Background(), map[string]any{
+			"state": map[string]any{"name": "Alice"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, `echo "${HOME}" Alice <<x>>`, got)
+
+		tmpl, err = NewTemplateWithDelimiters(engine, " <<state.count>> ", delims)
+		require.NoError(t, err)
+		got, err = tmpl.Eval(context.Background(), map[string]any{
+			"state": map[string]any{"count": 3},
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, got)
+
+		_, err = NewTemplateWithDelimiters(engine, "<<state.name", delims)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unclosed template expression")
+	})
+
+	t.Run("empty delimiters are rejected", func(t *testing.T) {
+		_, err := NewTemplateWithDelimiters(engine, "x", Delimiters{Left: "<<"})
+		require.Error(t, err)
+	})
+
 }
 
 func TestIsTruthyValue(t *testing.T) {
@@ -207,3 +259,25 @@ func TestEachValue(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestNormalizeValue(t *testing.T) {
+	// require.Equal treats int and int64 as equal, so compare types too.
+	typed := func(v any) string { return fmt.Sprintf("%T(%v)", v, v) }
+
+	require.Equal(t, "int(7)", typed(NormalizeValue(int64(7))))
+	require.Equal(t, "int(7)", typed(NormalizeValue(uint8(7))))
+	require.Equal(t, "float64(1.5)", typed(NormalizeValue(float32(1.5))))
+	require.Equal(t, "float64(2)", typed(NormalizeValue(2.0)))
+	require.Equal(t, "uint64(18446744073709551615)", typed(NormalizeValue(uint64(1<<64-1))))
+	require.Equal(t, "string(x)", typed(NormalizeValue("x")))
+
+	nested := NormalizeMap(map[string]any{
+		"n":    int32(1),
+		"list": []any{int64(2), map[string]any{"m": int16(3)}},
+	})
+	require.Equal(t, "int(1)", typed(nested["n"]))
+	list := nested["list"].([]any)
+	require.Equal(t, "int(2)", typed(list[0]))
+	require.Equal(t, "int(3)", typed(list[1].(map[string]any)["m"]))
+	require.Nil(t, NormalizeMap(nil))
+}
@@ -0,0 +1,66 @@
+package script
+
+import "math"
+
+// NormalizeValue returns value with its numbers converted to the
+// canonical numeric types the workflow engine exposes to scripts and
+// conditions: every Go integer type becomes int and float32 becomes
+// float64. Maps with string keys and slices of any are normalized
+// recursively; other values are returned unchanged.
+//
+// Activities and script engines produce integers of different widths
+// (Risor returns int64, Go activities usually return int), and engines
+// that compare numbers by type would otherwise treat int(1) and
+// int64(1) as different values. Unsigned values too large for an int
+// are left as they are. Whole float64 values are not converted, so a
+// number that round-tripped through JSON stays a float64.
+func NormalizeValue(value any) any {
+	switch v := value.(type) {
+	case int8:
+		return int(v)
+	case int16:
+		return int(v)
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case uint:
+		if v <= math.MaxInt {
+			return int(v)
+		}
+	case uint8:
+		return int(v)
+	case uint16:
+		return int(v)
+	case uint32:
+		return int(v)
+	case uint64:
+		if v <= math.MaxInt {
+			return int(v)
+		}
+	case float32:
+		return float64(v)
+	case map[string]any:
+		return NormalizeMap(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = NormalizeValue(item)
+		}
+		return out
+	}
+	return value
+}
+
+// NormalizeMap returns a copy of m with NormalizeValue applied to each
+// value. A nil map is returned as nil.
+func NormalizeMap(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = NormalizeValue(v)
+	}
+	return out
+}
@@ -2,9 +2,11 @@ package workflow
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/deepnoodle-ai/workflow/internal/require"
+	"github.com/deepnoodle-ai/workflow/script"
 )
 
 func TestDefaultScriptCompiler(t *testing.T) {
@@ -113,3 +115,307 @@ func TestDefaultCompilerWiring(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "world", captured)
 }
+
+// typeRecordingCompiler wraps a compiler and records the Go type of
+// state.counter each time a script is evaluated.
+type typeRecordingCompiler struct {
+	script.Compiler
+	types *[]string
+}
+
+func (c typeRecordingCompiler) Compile(ctx context.Context, code string) (script.Script, error) {
+	s, err := c.Compiler.Compile(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return typeRecordingScript{Script: s, types: c.types}, nil
+}
+
+type typeRecordingScript struct {
+	script.Script
+	types *[]string
+}
+
+func (s typeRecordingScript) Evaluate(ctx context.Context, globals map[string]any) (script.Value, error) {
+	if state, ok := globals["state"].(map[string]any); ok {
+		if v, ok := state["counter"]; ok {
+			*s.types = append(*s.types, fmt.Sprintf("%T", v))
+		}
+	}
+	return s.Script.Evaluate(ctx, globals)
+}
+
+func TestScriptGlobalsNormalizeNumbers(t *testing.T) {
+	w, err := New(Options{
+		Name: "normalize-numbers",
+		Steps: []*Step{
+			{
+				Name:     "count",
+				Activity: "count",
+				Store:    "counter",
+				Next: []*Edge{
+					{Step: "low", Condition: "state.counter < 2"},
+					{Step: "high", Else: true},
+				},
+			},
+			{Name: "low", Activity: "mark", Store: "route"},
+			{Name: "high", Activity: "mark", Store: "route"},
+		},
+		Outputs: []*Output{{Name: "route", Variable: "route"}},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("count", func(ctx Context, params map[string]any) (any, error) {
+		// Engines such as Risor hand back int64.
+		return int64(1), nil
+	}))
+	reg.MustRegister(ActivityFunc("mark", func(ctx Context, params map[string]any) (any, error) {
+		return ctx.StepName(), nil
+	}))
+
+	var types []string
+	exec, err := NewExecution(w, reg,
+		WithScriptCompiler(typeRecordingCompiler{Compiler: DefaultScriptCompiler(), types: &types}),
+	)
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.Equal(t, "low", result.Outputs["route"])
+	require.Equal(t, []string{"int"}, types)
+}
+
+func TestStoreExpression(t *testing.T) {
+	w, err := New(Options{
+		Name: "store-expression",
+		Steps: []*Step{
+			{
+				Name:            "fetch",
+				Activity:        "fetch",
+				Store:           "items",
+				StoreExpression: "result.data.items",
+			},
+		},
+		Outputs: []*Output{{Name: "items", Variable: "items"}},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("fetch", func(ctx Context, params map[string]any) (any, error) {
+		return map[string]any{
+			"status": 200,
+			"data":   map[string]any{"items": []any{"a", "b"}, "total": 2},
+		}, nil
+	}))
+
+	exec, err := NewExecution(w, reg)
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.Equal(t, []any{"a", "b"}, result.Outputs["items"])
+
+	t.Run("requires store", func(t *testing.T) {
+		_, err := New(Options{
+			Name:  "store-expression-no-store",
+			Steps: []*Step{{Name: "fetch", Activity: "fetch", StoreExpression: "result.data"}},
+		})
+		require.ErrorIs(t, err, ErrInvalidModifier)
+	})
+}
+
+func TestStoreAppend(t *testing.T) {
+	w, err := New(Options{
+		Name:  "store-append",
+		State: map[string]any{"n": 0},
+		Steps: []*Step{
+			{
+				Name:            "tick",
+				Activity:        "tick",
+				StoreAppend:     "squares",
+				StoreExpression: "result * result",
+				Next: []*Edge{
+					{Step: "tick", Condition: "state.n < 4"},
+					{Step: "done", Condition: "state.n >= 4"},
+				},
+			},
+			{Name: "done", Activity: "tick", StoreAppend: "squares"},
+		},
+		Outputs: []*Output{{Name: "squares", Variable: "squares"}},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("tick", func(ctx Context, params map[string]any) (any, error) {
+		n, _ := ctx.Get("n")
+		next := n.(int) + 1
+		ctx.Set("n", next)
+		return next, nil
+	}))
+
+	exec, err := NewExecution(w, reg)
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	// Four visits of tick append their squares (int64, as computed by
+	// the expression), then done appends its raw result to the same list.
+	require.Equal(t, []any{int64(1), int64(4), int64(9), int64(16), 5}, result.Outputs["squares"])
+
+	t.Run("existing non-list fails the step", func(t *testing.T) {
+		w, err := New(Options{
+			Name:  "store-append-scalar",
+			State: map[string]any{"n": 0, "squares": "oops"},
+			Steps: []*Step{{Name: "tick", Activity: "tick", StoreAppend: "squares"}},
+		})
+		require.NoError(t, err)
+		exec, err := NewExecution(w, reg)
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusFailed, result.Status)
+		require.Contains(t, result.Error.Error(), "not a list")
+	})
+
+	t.Run("cannot be combined with store", func(t *testing.T) {
+		_, err := New(Options{
+			Name:  "store-append-and-store",
+			Steps: []*Step{{Name: "tick", Activity: "tick", Store: "a", StoreAppend: "b"}},
+		})
+		require.ErrorIs(t, err, ErrInvalidModifier)
+	})
+}
+
+func TestStoreNilResult(t *testing.T) {
+	w, err := New(Options{
+		Name: "store-nil",
+		Steps: []*Step{
+			{
+				Name:     "lookup",
+				Activity: "lookup",
+				Store:    "found",
+				Next: []*Edge{
+					{Step: "missing", Condition: "state.found == nil"},
+					{Step: "present", Condition: "state.found != nil"},
+				},
+			},
+			{Name: "missing", Activity: "route", Store: "route", Parameters: map[string]any{"to": "missing"}},
+			{Name: "present", Activity: "route", Store: "route", Parameters: map[string]any{"to": "present"}},
+		},
+		Outputs: []*Output{
+			{Name: "found", Variable: "found"},
+			{Name: "route", Variable: "route"},
+		},
+	})
+	require.NoError(t, err)
+
+	type record struct{ ID string }
+	for name, lookup := range map[string]func() any{
+		"untyped nil":     func() any { return nil },
+		"typed nil":       func() any { return (*record)(nil) },
+		"non-nil pointer": func() any { return &record{ID: "r1"} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			reg := NewActivityRegistry()
+			reg.MustRegister(ActivityFunc("lookup", func(ctx Context, params map[string]any) (any, error) {
+				return lookup(), nil
+			}))
+			reg.MustRegister(ActivityFunc("route", func(ctx Context, params map[string]any) (any, error) {
+				return params["to"], nil
+			}))
+
+			exec, err := NewExecution(w, reg)
+			require.NoError(t, err)
+			result, err := exec.Execute(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, ExecutionStatusCompleted, result.Status)
+
+			found, ok := result.Outputs["found"]
+			require.True(t, ok)
+			if name == "non-nil pointer" {
+				require.Equal(t, "present", result.Outputs["route"])
+				return
+			}
+			require.True(t, found == nil)
+			require.Equal(t, "missing", result.Outputs["route"])
+		})
+	}
+
+	t.Run("absent variable is an error", func(t *testing.T) {
+		w, err := New(Options{
+			Name: "store-nil-absent",
+			Steps: []*Step{
+				{Name: "start", Activity: "route", Next: []*Edge{{Step: "end", Condition: "state.unset == nil"}}},
+				{Name: "end", Activity: "route"},
+			},
+		})
+		require.NoError(t, err)
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("route", func(ctx Context, params map[string]any) (any, error) {
+			return nil, nil
+		}))
+		exec, err := NewExecution(w, reg)
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusFailed, result.Status)
+	})
+}
+
+func TestTemplateDelimiters(t *testing.T) {
+	w, err := New(Options{
+		Name:   "template-delimiters",
+		Inputs: []*Input{{Name: "name", Type: InputTypeString}},
+		Steps: []*Step{
+			{
+				Name:     "run",
+				Activity: "capture",
+				Parameters: map[string]any{
+					"script": `echo "${HOME}" [[inputs.name]]`,
+					"count":  "[[len(inputs.name)]]",
+				},
+				Store: "params",
+			},
+		},
+		Outputs: []*Output{{Name: "params", Variable: "params"}},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("capture", func(ctx Context, params map[string]any) (any, error) {
+		return params, nil
+	}))
+
+	exec, err := NewExecution(w, reg,
+		WithInputs(map[string]any{"name": "ada"}),
+		WithTemplateDelimiters("[[", "]]"),
+	)
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	params := result.Outputs["params"].(map[string]any)
+	require.Equal(t, `echo "${HOME}" ada`, params["script"])
+	require.Equal(t, 3, params["count"])
+
+	t.Run("escape with default delimiters", func(t *testing.T) {
+		w, err := New(Options{
+			Name:    "template-escape",
+			Inputs:  []*Input{{Name: "name", Type: InputTypeString}},
+			Steps:   []*Step{{Name: "run", Activity: "capture", Parameters: map[string]any{"script": "echo $${HOME} ${inputs.name}"}, Store: "params"}},
+			Outputs: []*Output{{Name: "params", Variable: "params"}},
+		})
+		require.NoError(t, err)
+		exec, err := NewExecution(w, reg, WithInputs(map[string]any{"name": "ada"}))
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "echo ${HOME} ada", result.Outputs["params"].(map[string]any)["script"])
+	})
+
+	t.Run("empty delimiters are rejected", func(t *testing.T) {
+		_, err := NewExecution(w, reg, WithTemplateDelimiters("", "]]"))
+		require.Error(t, err)
+	})
+}
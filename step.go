@@ -1,6 +1,7 @@
 package workflow
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -22,12 +23,91 @@ type Edge struct {
 	// BranchName optionally names the branch created when this edge
 	// is followed. Empty means "continue on the current branch".
 	BranchName string `json:"branch,omitempty"`
+	// Else marks a default edge, followed only when no edge with a
+	// Condition matched. Unconditional edges do not count as matches
+	// under EdgeMatchingAll; under EdgeMatchingFirst the first
+	// matching edge of any kind wins, and the first Else edge is used
+	// only if none matched. Else edges cannot have a Condition.
+	Else bool `json:"else,omitempty"`
+	// Predicate is a Go alternative to Condition for workflows built in
+	// code. When set it decides whether the edge matches and Condition
+	// is not evaluated, though it may still describe the edge (as in
+	// ToDOT). It sees the branch's inputs and variables through ctx and
+	// should not modify them. Predicates are not serialized, so they
+	// do not survive a JSON round trip and do not affect Fingerprint.
+	Predicate func(ctx Context) (bool, error) `json:"-"`
+}
+
+// conditional reports whether the edge has a Condition or Predicate.
+func (e *Edge) conditional() bool {
+	return e.Condition != "" || e.Predicate != nil
+}
+
+// describeCondition names what decides the edge, for error messages.
+func (e *Edge) describeCondition() string {
+	if e.Predicate != nil {
+		return fmt.Sprintf("predicate of edge to %q", e.Step)
+	}
+	return fmt.Sprintf("condition %q", e.Condition)
 }
 
 // Each is used to configure a step to loop over a list of items.
+//
+// Iterations run one at a time by default. MaxConcurrency above 1 runs
+// up to that many iterations at once, each activity seeing its own
+// copy of the branch variables with As set to its item. Variables an
+// activity writes are merged back into the branch when it finishes,
+// so when iterations write the same variable the last to finish wins.
+// Results are stored in item order either way.
+//
+// Parallel fans the iterations out like separate paths: they all run
+// at once (or up to MaxConcurrency, when set), and each activity sees
+// its own copy of the branch variables with As set to its item. Writes
+// an activity makes to its copy are discarded when it finishes; only
+// the results, collected in item order into Store once every
+// iteration is done, flow back into the branch.
 type Each struct {
-	Items any    `json:"items"`
-	As    string `json:"as,omitempty"`
+	Items          any    `json:"items"`
+	As             string `json:"as,omitempty"`
+	MaxConcurrency int    `json:"max_concurrency,omitempty"`
+	Parallel       bool   `json:"parallel,omitempty"`
+}
+
+// MapReduceConfig configures a step that maps an activity over a list
+// and reduces the results to one value. It is shorthand for an
+// activity step with a Parallel Each and a StoreExpression, and
+// workflow.New expands it into exactly that: every item runs Activity
+// with Parameters, concurrently (up to MaxConcurrency, when set), and
+// Reduce is then evaluated with `result` bound to the list of results
+// in item order. Its value is stored under the step's Store or
+// appended to StoreAppend, one of which is required.
+//
+//	{Name: "check", Store: "healthy", MapReduce: &MapReduceConfig{
+//		Items:      "state.urls",
+//		As:         "url",
+//		Activity:   "http",
+//		Parameters: map[string]any{"url": "${state.url}"},
+//		Reduce:     "count(result, it.status_code == 200)",
+//	}}
+//
+// A MapReduce step cannot also set Activity, Parameters, Each, or
+// StoreExpression. Other activity-step modifiers, such as Retry,
+// Catch, and Cacheable, apply to every item's activity call.
+type MapReduceConfig struct {
+	// Items is the list to map over: a literal list or an expression
+	// evaluated against the branch state, as for Each.Items. Required.
+	Items any `json:"items"`
+	// As names the variable holding the current item, as for Each.As.
+	As string `json:"as,omitempty"`
+	// Activity is called once per item. Required.
+	Activity string `json:"activity"`
+	// Parameters are evaluated per item and passed to Activity.
+	Parameters map[string]any `json:"parameters,omitempty"`
+	// MaxConcurrency caps how many items run at once. Zero means all.
+	MaxConcurrency int `json:"max_concurrency,omitempty"`
+	// Reduce is a script expression evaluated with the ordered list of
+	// results bound as `result`. Required.
+	Reduce string `json:"reduce"`
 }
 
 // WaitSignalConfig configures a step to park a path until an external
@@ -86,6 +166,21 @@ type SleepConfig struct {
 	Duration time.Duration `json:"duration"`
 }
 
+// JoinMode selects how many branches a join waits for.
+type JoinMode string
+
+const (
+	// JoinModeAll waits for every branch listed in JoinConfig.Branches.
+	JoinModeAll JoinMode = "all"
+	// JoinModeAny proceeds as soon as one listed branch completes and
+	// cancels the listed branches that are still running.
+	JoinModeAny JoinMode = "any"
+	// JoinModeCount proceeds once JoinConfig.Count branches complete.
+	// When Branches is set, only listed branches count and the rest
+	// are canceled once the join proceeds.
+	JoinModeCount JoinMode = "count"
+)
+
 // JoinConfig configures a step to wait for multiple branches to converge.
 type JoinConfig struct {
 	// Branches specifies which named branches to wait for. If empty,
@@ -96,6 +191,13 @@ type JoinConfig struct {
 	// for all specified branches.
 	Count int `json:"count,omitempty"`
 
+	// Mode makes the join semantics explicit: "all", "any", or "count".
+	// When empty, the join waits for all listed Branches, or for Count
+	// completed branches when no Branches are listed. In "any" and
+	// "count" modes, listed branches that have not completed when the
+	// join proceeds are canceled and marked ExecutionStatusCanceled.
+	Mode JoinMode `json:"mode,omitempty"`
+
 	// BranchMappings specifies where to store branch data. Supports two
 	// syntaxes:
 	//  1. Store entire branch state: "branchID": "destination"
@@ -126,48 +228,108 @@ type JoinConfig struct {
 //     Survives process restarts.
 //   - Pause — declarative counterpart to PauseBranch; parks the
 //     branch until an operator unpauses it.
+//   - Terminate — ends the whole execution early with a completed or
+//     failed status, canceling every other branch; see TerminateConfig.
 //
 // workflow.New rejects any step that sets more than one kind field
 // with ErrInvalidStepKind, and any step that sets none with the
 // implicit "activity" default — Activity may be empty only if a
-// Sleep, Pause, Join, or WaitSignal is set.
+// Sleep, Pause, Terminate, Join, or WaitSignal is set.
 //
 // # Modifier fields
 //
 //   - Store — name of the variable to write the step result into.
 //     Activity-kind only.
+//   - StoreAppend — name of a list variable the step result is appended
+//     to, created when absent, so a step in a loop accumulates one
+//     element per visit. Activity-kind only; exclusive with Store.
+//   - StoreExpression — script expression evaluated with the activity
+//     result bound as `result`; its value is stored under Store (or
+//     appended to StoreAppend) instead of the raw result. Requires
+//     Store or StoreAppend.
+//   - Skip — script expression evaluated before the step runs. When
+//     it is truthy the step's work is bypassed, Store is left
+//     untouched, and Next is followed as if the step had returned nil.
+//     Rejected on Join steps.
+//   - SkipValue — stored under Store (or appended to StoreAppend) when
+//     Skip bypasses the step, so later references to the variable
+//     resolve. StoreExpression is not applied. Use Skipped to mark the
+//     value as a skip. Requires Skip and Store or StoreAppend.
+//   - Before / After — scripts evaluated with the script compiler just
+//     before the activity runs and just after its result is stored.
+//     They see inputs and state (After also sees result), and state
+//     keys they add, change, or remove are written back to the branch.
+//     A Before script that fails aborts the step with its error, which
+//     Catch handlers match like an activity failure. Activity-kind only.
 //   - Parameters — typed input passed to the activity (Activity-kind
 //     only). Values may use ${...} templates.
 //   - Each — fan-out loop over a list. The step is executed once
 //     per item in a fresh sub-branch.
+//   - MapReduce — shorthand for an activity step with a Parallel Each
+//     and a StoreExpression that reduces the results; see
+//     MapReduceConfig. Expanded by workflow.New.
 //   - Next — outgoing edges, evaluated against EdgeMatchingStrategy.
 //   - EdgeMatchingStrategy — "all" (default; follow every matching
 //     edge, branching the path) or "first" (follow only the first
 //     match, single branch continues).
 //   - Retry — per-error-class retry policy with backoff. Activity-kind
-//     only; rejected on Sleep/Pause/Join/WaitSignal at workflow.New.
+//     only; rejected on Sleep/Pause/Terminate/Join/WaitSignal at
+//     workflow.New.
 //   - Catch — per-error-class fallback routing. Activity-kind only;
 //     same restriction as Retry.
+//   - Idempotent — once the activity has succeeded, a resumed
+//     execution that restarts the branch at this step reuses the
+//     recorded result instead of running the activity again, so a
+//     side effect such as sending an email happens once even if a
+//     later part of the step (Store, After, or edge evaluation) failed
+//     or the process stopped. Steps without it are always re-run from
+//     the activity on resume. Activity-kind only; not valid with Each,
+//     whose finished iterations are always kept.
+//   - Cacheable — with an execution Cache (WithStepCache), the
+//     activity's result is stored under a hash of the activity name and
+//     resolved parameters, and a later call with the same hash, in this
+//     or any execution sharing the cache, skips the activity and uses
+//     the stored result. Only for deterministic activities. With Each,
+//     every item is cached on its own. Activity-kind only.
+//   - CacheTTL — how long a cached result stays valid; zero keeps it
+//     until the cache evicts it. Requires Cacheable.
 //
 // Mixing a modifier with an incompatible kind is rejected at
 // validation time with ErrInvalidModifier.
 type Step struct {
 	Name                 string               `json:"name"`
 	Description          string               `json:"description,omitempty"`
+	Skip                 string               `json:"skip,omitempty"`
+	SkipValue            any                  `json:"skip_value,omitempty"`
 	Store                string               `json:"store,omitempty"`
+	StoreAppend          string               `json:"store_append,omitempty"`
+	StoreExpression      string               `json:"store_expression,omitempty"`
+	Before               string               `json:"before,omitempty"`
+	After                string               `json:"after,omitempty"`
 	Activity             string               `json:"activity,omitempty"`
 	Parameters           map[string]any       `json:"parameters,omitempty"`
 	Each                 *Each                `json:"each,omitempty"`
+	MapReduce            *MapReduceConfig     `json:"map_reduce,omitempty"`
 	Join                 *JoinConfig          `json:"join,omitempty"`
 	WaitSignal           *WaitSignalConfig    `json:"wait_signal,omitempty"`
 	Sleep                *SleepConfig         `json:"sleep,omitempty"`
 	Pause                *PauseConfig         `json:"pause,omitempty"`
+	Terminate            *TerminateConfig     `json:"terminate,omitempty"`
 	Next                 []*Edge              `json:"next,omitempty"`
 	EdgeMatchingStrategy EdgeMatchingStrategy `json:"edge_matching_strategy,omitempty"`
 	Retry                []*RetryConfig       `json:"retry,omitempty"`
 	Catch                []*CatchConfig       `json:"catch,omitempty"`
+	Idempotent           bool                 `json:"idempotent,omitempty"`
+	Cacheable            bool                 `json:"cacheable,omitempty"`
+	CacheTTL             time.Duration        `json:"cache_ttl,omitempty"`
 }
 
+// Skipped is a sentinel for Step.SkipValue. A condition tells a skipped
+// step's variable from a real result by comparing against it:
+//
+//	state.profile == "__skipped__"
+const Skipped = "__skipped__"
+
 // GetEdgeMatchingStrategy returns the edge matching strategy for this step,
 // defaulting to "all" if not specified
 func (s *Step) GetEdgeMatchingStrategy() EdgeMatchingStrategy {
@@ -185,10 +347,21 @@ const (
 	JitterFull JitterStrategy = "FULL"
 )
 
+// UnlimitedRetries as RetryConfig.MaxRetries retries until MaxElapsed
+// runs out instead of stopping after a fixed count.
+const UnlimitedRetries = -1
+
 // RetryConfig configures retry behavior for a step.
+//
+// MaxElapsed bounds the total time spent on a step across all attempts
+// and backoff delays: a retry whose delay would end past the budget is
+// not attempted. It is required when MaxRetries is UnlimitedRetries and
+// optional otherwise, in which case whichever limit is reached first
+// stops retrying.
 type RetryConfig struct {
 	ErrorEquals    []string       `json:"error_equals,omitempty"`
 	MaxRetries     int            `json:"max_retries,omitempty"`
+	MaxElapsed     time.Duration  `json:"max_elapsed,omitempty"`
 	BaseDelay      time.Duration  `json:"base_delay,omitempty"`
 	MaxDelay       time.Duration  `json:"max_delay,omitempty"`
 	BackoffRate    float64        `json:"backoff_rate,omitempty"`
@@ -196,6 +369,52 @@ type RetryConfig struct {
 	Timeout        time.Duration  `json:"timeout,omitempty"`
 }
 
+// Defaults used by ExponentialRetry.
+const (
+	DefaultRetryBaseDelay   = time.Second
+	DefaultRetryBackoffRate = 2.0
+	DefaultRetryMaxDelay    = 30 * time.Second
+)
+
+// ExponentialRetry returns a config that retries errors of errorType
+// (ErrorTypeAll, or "" for the same, matches any non-fatal error) up to
+// maxRetries times. The delay starts at DefaultRetryBaseDelay, doubles
+// on each retry up to DefaultRetryMaxDelay, and is fully jittered so
+// that many failing branches do not retry in lockstep. Adjust the
+// returned config to tune it further.
+func ExponentialRetry(errorType string, maxRetries int) *RetryConfig {
+	return &RetryConfig{
+		ErrorEquals:    retryErrorEquals(errorType),
+		MaxRetries:     maxRetries,
+		BaseDelay:      DefaultRetryBaseDelay,
+		BackoffRate:    DefaultRetryBackoffRate,
+		MaxDelay:       DefaultRetryMaxDelay,
+		JitterStrategy: JitterFull,
+	}
+}
+
+// LinearRetry returns a config that retries errors of errorType up to
+// maxRetries times, waiting delay before every retry, so the time spent
+// grows linearly with the number of attempts. It suits polling a
+// dependency that recovers on its own schedule. There is no jitter.
+func LinearRetry(errorType string, maxRetries int, delay time.Duration) *RetryConfig {
+	return &RetryConfig{
+		ErrorEquals:    retryErrorEquals(errorType),
+		MaxRetries:     maxRetries,
+		BaseDelay:      delay,
+		BackoffRate:    1,
+		MaxDelay:       delay,
+		JitterStrategy: JitterNone,
+	}
+}
+
+func retryErrorEquals(errorType string) []string {
+	if errorType == "" {
+		return nil
+	}
+	return []string{errorType}
+}
+
 // CatchConfig configures fallback behavior when errors occur
 type CatchConfig struct {
 	ErrorEquals []string `json:"error_equals"`
@@ -0,0 +1,128 @@
+package workflow
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Cache stores the results of Cacheable steps across executions. Keys
+// are hex-encoded hashes of a step's activity name and resolved
+// parameters; see Step.Cacheable. Implementations must be safe for
+// concurrent use. A durable store (Redis, a database table) lets
+// executions in different processes share results; values then need to
+// survive whatever encoding the store applies, so activities whose
+// results are cached should return JSON-friendly values.
+type Cache interface {
+	// Get returns the value stored under key and whether one was
+	// found. An expired entry is reported as not found.
+	Get(ctx context.Context, key string) (value any, found bool, err error)
+
+	// Set stores value under key. A ttl of zero keeps the entry until
+	// it is evicted by the store.
+	Set(ctx context.Context, key string, value any, ttl time.Duration) error
+}
+
+// MemoryCache is an in-memory implementation of Cache suitable for tests
+// and single-process use. Expired entries are dropped when they are
+// read.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	value     any
+	expiresAt time.Time // zero when the entry does not expire
+}
+
+// NewMemoryCache returns a new empty in-memory cache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: map[string]memoryCacheEntry{}}
+}
+
+// Get implements Cache.
+func (m *MemoryCache) Get(ctx context.Context, key string) (any, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !entry.expiresAt.IsZero() && !time.Now().Before(entry.expiresAt) {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+// Set implements Cache.
+func (m *MemoryCache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	entry := memoryCacheEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = entry
+	return nil
+}
+
+// stepCacheKey hashes an activity name and its resolved parameters.
+// encoding/json sorts map keys, so equal parameter maps hash equally.
+// It returns false when the parameters cannot be encoded as JSON, in
+// which case the step is not cached.
+func stepCacheKey(activityName string, params map[string]any) (string, bool) {
+	data, err := json.Marshal(struct {
+		Activity   string         `json:"activity"`
+		Parameters map[string]any `json:"parameters"`
+	}{activityName, params})
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), true
+}
+
+// cachedResult looks a Cacheable step up in the step cache. It returns
+// the key to store a fresh result under, or "" when the step is not
+// cached, along with the cached result on a hit. Cache errors are
+// logged and treated as misses: the cache only saves work, so it never
+// fails a step.
+func (e *Execution) cachedResult(ctx context.Context, stepName, activityName string, params map[string]any) (key string, result any, hit bool) {
+	if e.stepCache == nil || e.dryRun || e.replayer != nil {
+		return "", nil, false
+	}
+	step, ok := e.workflow.GetStep(stepName)
+	if !ok || !step.Cacheable {
+		return "", nil, false
+	}
+	key, ok = stepCacheKey(activityName, params)
+	if !ok {
+		e.logger.Warn("step parameters cannot be hashed; not caching", "step_name", stepName)
+		return "", nil, false
+	}
+	result, hit, err := e.stepCache.Get(ctx, key)
+	if err != nil {
+		e.logger.Warn("step cache lookup failed", "step_name", stepName, "error", err)
+		return key, nil, false
+	}
+	return key, result, hit
+}
+
+// storeCachedResult saves a Cacheable step's result under key.
+func (e *Execution) storeCachedResult(ctx context.Context, stepName, key string, result any) {
+	step, _ := e.workflow.GetStep(stepName)
+	if err := e.stepCache.Set(ctx, key, result, step.CacheTTL); err != nil {
+		e.logger.Warn("failed to store step result in cache", "step_name", stepName, "error", err)
+	}
+}
@@ -0,0 +1,79 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestStepCacheSkipsActivityAcrossExecutions(t *testing.T) {
+	wf, err := New(Options{
+		Name:   "forecast",
+		Inputs: []*Input{{Name: "city", Type: InputTypeString}},
+		Steps: []*Step{{
+			Name:       "lookup",
+			Activity:   "lookup",
+			Parameters: map[string]any{"city": "${inputs.city}"},
+			Store:      "forecast",
+			Cacheable:  true,
+			CacheTTL:   time.Hour,
+		}},
+		Outputs: []*Output{{Name: "forecast", Variable: "forecast"}},
+	})
+	require.NoError(t, err)
+
+	calls := 0
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("lookup", func(ctx Context, params map[string]any) (any, error) {
+		calls++
+		return "sunny in " + params["city"].(string), nil
+	}))
+	cache := NewMemoryCache()
+	run := func(city string) *ExecutionResult {
+		exec, err := NewExecution(wf, reg,
+			WithScriptCompiler(newTestCompiler()),
+			WithInputs(map[string]any{"city": city}),
+			WithStepCache(cache),
+		)
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		return result
+	}
+
+	require.Equal(t, "sunny in Oslo", run("Oslo").Outputs["forecast"])
+	require.Equal(t, 1, calls)
+	require.Equal(t, "sunny in Oslo", run("Oslo").Outputs["forecast"])
+	require.Equal(t, 1, calls, "the second execution must take the cached result")
+	require.Equal(t, "sunny in Lima", run("Lima").Outputs["forecast"])
+	require.Equal(t, 2, calls, "different parameters must miss the cache")
+}
+
+func TestMemoryCacheExpiresEntries(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache()
+	require.NoError(t, cache.Set(ctx, "short", 1, time.Millisecond))
+	require.NoError(t, cache.Set(ctx, "forever", 2, 0))
+	time.Sleep(5 * time.Millisecond)
+
+	_, found, err := cache.Get(ctx, "short")
+	require.NoError(t, err)
+	require.False(t, found)
+	value, found, err := cache.Get(ctx, "forever")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, 2, value)
+}
+
+func TestCacheTTLRequiresCacheable(t *testing.T) {
+	_, err := New(Options{
+		Name:  "bad-cache",
+		Steps: []*Step{{Name: "a", Activity: "noop", CacheTTL: time.Minute}},
+	})
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrInvalidModifier))
+}
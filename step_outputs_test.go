@@ -0,0 +1,104 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestMaxStepOutputs(t *testing.T) {
+	const length = 50
+	steps := make([]*Step, length)
+	for i := range steps {
+		steps[i] = &Step{Name: fmt.Sprintf("step-%02d", i), Activity: "echo"}
+		if i+1 < length {
+			steps[i].Next = []*Edge{{Step: fmt.Sprintf("step-%02d", i+1)}}
+		}
+	}
+	wf, err := New(Options{Name: "long-linear", Steps: steps})
+	require.NoError(t, err)
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("echo", func(ctx Context, params map[string]any) (any, error) {
+		return ctx.StepName(), nil
+	}))
+
+	run := func(t *testing.T, opts ...ExecutionOption) *BranchState {
+		t.Helper()
+		checkpointer, err := NewFileCheckpointer(t.TempDir())
+		require.NoError(t, err)
+		exec, err := NewExecution(wf, reg, append(opts, WithCheckpointer(checkpointer))...)
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		checkpoint, err := checkpointer.LoadCheckpoint(context.Background(), exec.ID())
+		require.NoError(t, err)
+		return checkpoint.BranchStates["main"]
+	}
+
+	t.Run("unlimited", func(t *testing.T) {
+		state := run(t)
+		require.Len(t, state.StepOutputs, length)
+		require.Nil(t, state.StepOutputOrder)
+	})
+
+	t.Run("last three", func(t *testing.T) {
+		state := run(t, WithMaxStepOutputs(3))
+		require.Equal(t, map[string]any{
+			"step-47": "step-47",
+			"step-48": "step-48",
+			"step-49": "step-49",
+		}, state.StepOutputs)
+		require.Equal(t, []string{"step-47", "step-48", "step-49"}, state.StepOutputOrder)
+	})
+}
+
+func TestRetainStepOutputRepeatedStep(t *testing.T) {
+	outputs := map[string]any{}
+	var order []string
+	for _, step := range []string{"a", "b", "a", "c", "a"} {
+		order = retainStepOutput(outputs, order, step, step+"-out", 2)
+	}
+	require.Equal(t, []string{"c", "a"}, order)
+	require.Equal(t, map[string]any{"c": "c-out", "a": "a-out"}, outputs)
+}
+
+func TestMaxStepOutputsRejectsStepsReads(t *testing.T) {
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("work", func(ctx Context, params map[string]any) (any, error) {
+		return 1, nil
+	}))
+
+	t.Run("condition", func(t *testing.T) {
+		wf, err := New(Options{Name: "cond", Steps: []*Step{
+			{Name: "check", Activity: "work", Next: []*Edge{{Step: "done", Condition: `steps.check == 1`}}},
+			{Name: "done", Activity: "work"},
+		}})
+		require.NoError(t, err)
+		_, err = NewExecution(wf, reg, WithMaxStepOutputs(1))
+		require.ErrorIs(t, err, ErrStepOutputsPruned)
+		require.Contains(t, err.Error(), `"check"`)
+	})
+
+	t.Run("parameter", func(t *testing.T) {
+		wf, err := New(Options{Name: "param", Steps: []*Step{
+			{Name: "a", Activity: "work", Next: []*Edge{{Step: "b"}}},
+			{Name: "b", Activity: "work", Parameters: map[string]any{"in": []any{`${steps["a"]}`}}},
+		}})
+		require.NoError(t, err)
+		_, err = NewExecution(wf, reg, WithMaxStepOutputs(1))
+		require.ErrorIs(t, err, ErrStepOutputsPruned)
+	})
+
+	t.Run("no steps reads", func(t *testing.T) {
+		wf, err := New(Options{Name: "plain", Steps: []*Step{
+			{Name: "a", Activity: "work", Store: "steps", Next: []*Edge{{Step: "b", Condition: `state.steps.count == 1`}}},
+			{Name: "b", Activity: "work"},
+		}})
+		require.NoError(t, err)
+		_, err = NewExecution(wf, reg, WithMaxStepOutputs(1))
+		require.NoError(t, err)
+	})
+}
@@ -106,7 +106,7 @@ func (t *stepProgressTracker) BeforeActivityExecution(ctx context.Context, event
 	key := stepKey{stepName: event.StepName, branchID: event.BranchID}
 	existing := t.steps[key]
 	attempt := 1
-	if existing != nil && existing.Status != StepStatusCompleted {
+	if existing != nil && existing.Status != StepStatusCompleted && existing.Status != StepStatusSkipped {
 		attempt = existing.Attempt + 1
 	}
 
@@ -143,6 +143,22 @@ func (t *stepProgressTracker) AfterActivityExecution(ctx context.Context, event
 	t.dispatch(ctx, *existing)
 }
 
+// skipStep records a step bypassed by its Skip condition.
+func (t *stepProgressTracker) skipStep(ctx context.Context, stepName, branchID, activityName string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	progress := StepProgress{
+		StepName:     stepName,
+		BranchID:     branchID,
+		Status:       StepStatusSkipped,
+		ActivityName: activityName,
+		FinishedAt:   time.Now(),
+	}
+	t.steps[stepKey{stepName: stepName, branchID: branchID}] = &progress
+	t.dispatch(ctx, progress)
+}
+
 // reportProgress is called from the execution context to report intra-activity progress.
 func (t *stepProgressTracker) reportProgress(ctx context.Context, stepName, branchID string, detail ProgressDetail) {
 	t.mu.Lock()
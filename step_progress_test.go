@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -151,3 +152,132 @@ func TestReportProgressNoopWithoutStore(t *testing.T) {
 	_, err = exec.Execute(context.Background())
 	require.NoError(t, err)
 }
+
+func TestStepSkip(t *testing.T) {
+	wf, err := New(Options{
+		Name:   "skip-test",
+		Inputs: []*Input{{Name: "fast", Type: InputTypeBool}},
+		State:  map[string]any{"enriched": "unset"},
+		Steps: []*Step{
+			{
+				Name:     "enrich",
+				Activity: "work",
+				Skip:     "inputs.fast",
+				Store:    "enriched",
+				Next:     []*Edge{{Step: "finish"}},
+			},
+			{Name: "finish", Activity: "work", Store: "finished"},
+		},
+		Outputs: []*Output{
+			{Name: "enriched", Variable: "enriched"},
+			{Name: "finished", Variable: "finished"},
+		},
+	})
+	require.NoError(t, err)
+
+	run := func(fast bool) (*ExecutionResult, []string, []StepProgress) {
+		var calls []string
+		reg := NewActivityRegistry()
+		reg.MustRegister(ActivityFunc("work", func(ctx Context, params map[string]any) (any, error) {
+			calls = append(calls, ctx.StepName())
+			return "done", nil
+		}))
+		store := &captureStore{}
+		exec, err := NewExecution(wf, reg,
+			WithInputs(map[string]any{"fast": fast}),
+			WithStepProgressStore(store))
+		require.NoError(t, err)
+		result, err := exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ExecutionStatusCompleted, result.Status)
+		var updates []StepProgress
+		require.Eventually(t, func() bool {
+			updates = store.getUpdates()
+			return len(updates) >= 3
+		}, 500*time.Millisecond, 10*time.Millisecond)
+		return result, calls, updates
+	}
+
+	t.Run("truthy skip bypasses the step and follows next", func(t *testing.T) {
+		result, calls, updates := run(true)
+		require.Equal(t, []string{"finish"}, calls)
+		require.Equal(t, "unset", result.Outputs["enriched"])
+		require.Equal(t, "done", result.Outputs["finished"])
+
+		var skipped []StepProgress
+		for _, u := range updates {
+			if u.Status == StepStatusSkipped {
+				skipped = append(skipped, u)
+			}
+		}
+		require.Len(t, skipped, 1)
+		require.Equal(t, "enrich", skipped[0].StepName)
+		require.Equal(t, "work", skipped[0].ActivityName)
+	})
+
+	t.Run("falsy skip runs the step", func(t *testing.T) {
+		result, calls, _ := run(false)
+		require.Equal(t, []string{"enrich", "finish"}, calls)
+		require.Equal(t, "done", result.Outputs["enriched"])
+	})
+
+	t.Run("skip is rejected on join steps", func(t *testing.T) {
+		_, err := New(Options{
+			Name:  "skip-join",
+			Steps: []*Step{{Name: "join", Join: &JoinConfig{}, Skip: "true"}},
+		})
+		require.ErrorIs(t, err, ErrInvalidModifier)
+	})
+}
+
+func TestStepSkipValue(t *testing.T) {
+	wf, err := New(Options{
+		Name: "skip-value",
+		Steps: []*Step{
+			{
+				Name:      "enrich",
+				Activity:  "work",
+				Skip:      "true",
+				Store:     "profile",
+				SkipValue: Skipped,
+				Next: []*Edge{
+					{Step: "fallback", Condition: `state.profile == "__skipped__"`},
+					{Step: "score", Else: true},
+				},
+			},
+			{
+				Name:      "fallback",
+				Activity:  "work",
+				Skip:      "true",
+				Store:     "limits",
+				SkipValue: map[string]any{"max": 10},
+				Next:      []*Edge{{Step: "score"}},
+			},
+			{
+				Name:       "score",
+				Activity:   "work",
+				Parameters: map[string]any{"profile": "${state.profile}", "max": "${state.limits.max}"},
+				Store:      "score",
+			},
+		},
+		Outputs: []*Output{{Name: "score", Variable: "score"}},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("work", func(ctx Context, params map[string]any) (any, error) {
+		return fmt.Sprintf("%v/%v", params["profile"], params["max"]), nil
+	}))
+	exec, err := NewExecution(wf, reg)
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.Equal(t, "__skipped__/10", result.Outputs["score"])
+
+	_, err = New(Options{
+		Name:  "skip-value-without-store",
+		Steps: []*Step{{Name: "a", Activity: "work", Skip: "true", SkipValue: Skipped}},
+	})
+	require.ErrorIs(t, err, ErrInvalidModifier)
+}
@@ -0,0 +1,146 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTerminated is returned by an execution ended by a Terminate step
+// with Status failed. The error message includes the step name and the
+// step's Message.
+var ErrTerminated = errors.New("workflow: execution terminated")
+
+// TerminateConfig configures a Terminate step, which ends the whole
+// execution early. When a branch reaches a Terminate step whose
+// Condition is empty or truthy, every other branch is canceled and the
+// execution finishes with Status. When the Condition is false the step
+// does nothing and the branch follows its Next edges, so a Terminate
+// step can sit in the middle of a path as a guard.
+//
+// A completed termination extracts workflow outputs as usual, from the
+// variables the branches held when they stopped. A failed termination
+// reports ErrTerminated; resuming the execution re-evaluates the
+// Terminate step.
+type TerminateConfig struct {
+	// Condition is an optional expression evaluated against the
+	// branch's state. The step terminates only when it is truthy.
+	Condition string `json:"condition,omitempty"`
+
+	// Status is the final execution status: ExecutionStatusCompleted
+	// (the default when empty) or ExecutionStatusFailed.
+	Status ExecutionStatus `json:"status,omitempty"`
+
+	// Message describes why the execution ended. It may use ${...}
+	// templates. For a failed termination it becomes part of the
+	// execution error and the branch's ErrorMessage.
+	Message string `json:"message,omitempty"`
+}
+
+// terminateRequest is emitted by branch.Run when a Terminate step
+// triggers. The orchestrator records the branch's final status, cancels
+// every other branch, and ends the run loop.
+type terminateRequest struct {
+	StepName string
+	Status   ExecutionStatus
+	Message  string
+}
+
+// handleTerminateStep executes a Terminate step. A false Condition
+// returns a nil result so the branch continues to Next; otherwise the
+// result is a *terminateRequest that branch.Run turns into the
+// branch's final snapshot.
+func (p *branch) handleTerminateStep(ctx context.Context, step *Step) (any, error) {
+	cfg := step.Terminate
+	if cfg.Condition != "" {
+		ok, err := p.evaluateCondition(ctx, cfg.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("terminate condition on step %q: %w", step.Name, err)
+		}
+		if !ok {
+			return nil, nil
+		}
+	}
+	message, err := p.evaluateTemplateString(ctx, cfg.Message)
+	if err != nil {
+		return nil, fmt.Errorf("terminate message on step %q: %w", step.Name, err)
+	}
+	status := cfg.Status
+	if status == "" {
+		status = ExecutionStatusCompleted
+	}
+	return &terminateRequest{StepName: step.Name, Status: status, Message: message}, nil
+}
+
+// processTerminateRequest finishes the branch that reached a Terminate
+// step and cancels all others. For a failed termination it returns an
+// error wrapping ErrTerminated, which ends the run loop with the
+// execution Failed; otherwise the loop ends once the canceled branches
+// are gone and the execution completes.
+func (e *Execution) processTerminateRequest(ctx context.Context, snapshot branchSnapshot) error {
+	req := snapshot.terminateRequest
+	var err error
+	if req.Status == ExecutionStatusFailed {
+		err = fmt.Errorf("%w by step %q", ErrTerminated, req.StepName)
+		if req.Message != "" {
+			err = fmt.Errorf("%w: %s", err, req.Message)
+		}
+	}
+	e.state.UpdateBranchState(snapshot.BranchID, func(state *BranchState) {
+		state.Status = req.Status
+		state.CurrentStep = req.StepName
+		state.EndTime = snapshot.EndTime
+		state.Wait = nil
+		if err != nil {
+			state.ErrorMessage = err.Error()
+		}
+		if activeBranch, exists := e.getActiveBranch(snapshot.BranchID); exists {
+			state.Variables = activeBranch.Variables()
+		}
+	})
+	e.removeActiveBranch(snapshot.BranchID)
+
+	reason := fmt.Sprintf("terminated by step %q", req.StepName)
+	for branchID := range e.state.GetBranchStates() {
+		if branchID != snapshot.BranchID {
+			e.cancelBranch(ctx, branchID, reason)
+		}
+	}
+	e.logger.Info("execution terminated",
+		"step_name", req.StepName,
+		"branch_id", snapshot.BranchID,
+		"status", req.Status,
+		"message", req.Message)
+
+	branchState := e.state.GetBranchStates()[snapshot.BranchID]
+	e.executionCallbacks.AfterBranchExecution(ctx, &BranchExecutionEvent{
+		ExecutionID:  e.state.ID(),
+		WorkflowName: e.workflow.Name(),
+		BranchID:     snapshot.BranchID,
+		Status:       req.Status,
+		StartTime:    snapshot.StartTime,
+		EndTime:      snapshot.EndTime,
+		Duration:     snapshot.EndTime.Sub(snapshot.StartTime),
+		CurrentStep:  req.StepName,
+		StepOutputs:  copyMap(branchState.StepOutputs),
+		Error:        err,
+	})
+	return err
+}
+
+// terminateSnapshot is the final snapshot of a branch that reached a
+// triggered Terminate step.
+func (p *branch) terminateSnapshot(req *terminateRequest) branchSnapshot {
+	p.status = req.Status
+	p.endTime = time.Now()
+	return branchSnapshot{
+		BranchID:         p.id,
+		Status:           req.Status,
+		StepName:         req.StepName,
+		terminateRequest: req,
+		StartTime:        p.startTime,
+		EndTime:          p.endTime,
+		Timestamp:        time.Now(),
+	}
+}
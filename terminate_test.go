@@ -0,0 +1,147 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestTerminateFailedCancelsSiblings(t *testing.T) {
+	blocking := ActivityFunc("blocking", func(ctx Context, p map[string]any) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	noop := ActivityFunc("noop", func(ctx Context, p map[string]any) (any, error) {
+		return "ok", nil
+	})
+
+	wf, err := New(Options{
+		Name: "terminate-failed",
+		Steps: []*Step{
+			{
+				Name:     "start",
+				Activity: "noop",
+				Next: []*Edge{
+					{Step: "slow", BranchName: "slow"},
+					{Step: "check", BranchName: "check"},
+				},
+			},
+			{Name: "slow", Activity: "blocking"},
+			{Name: "check", Activity: "noop", Store: "verdict", Next: []*Edge{{Step: "stop"}}},
+			{
+				Name: "stop",
+				Terminate: &TerminateConfig{
+					Condition: `state.verdict == "ok"`,
+					Status:    ExecutionStatusFailed,
+					Message:   "verdict was ${state.verdict}",
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(blocking)
+	reg.MustRegister(noop)
+	exec, err := NewExecution(wf, reg)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := exec.Execute(ctx)
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusFailed, result.Status)
+	require.ErrorIs(t, result.Error, ErrTerminated)
+	require.Contains(t, result.Error.Error(), "verdict was ok")
+
+	states := exec.BranchStates()
+	require.Equal(t, ExecutionStatusFailed, states["check"].Status)
+	require.Equal(t, ExecutionStatusCanceled, states["slow"].Status)
+}
+
+func TestTerminateCompleted(t *testing.T) {
+	var ranAfter bool
+	noop := ActivityFunc("noop", func(ctx Context, p map[string]any) (any, error) {
+		return "ok", nil
+	})
+	after := ActivityFunc("after", func(ctx Context, p map[string]any) (any, error) {
+		ranAfter = true
+		return nil, nil
+	})
+
+	wf, err := New(Options{
+		Name: "terminate-completed",
+		Steps: []*Step{
+			{Name: "first", Activity: "noop", Store: "result", Next: []*Edge{{Step: "stop"}}},
+			{Name: "stop", Terminate: &TerminateConfig{Message: "done early"}, Next: []*Edge{{Step: "after"}}},
+			{Name: "after", Activity: "after"},
+		},
+		Outputs: []*Output{{Name: "result"}},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(noop)
+	reg.MustRegister(after)
+	exec, err := NewExecution(wf, reg)
+	require.NoError(t, err)
+
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.Nil(t, result.Error)
+	require.Equal(t, "ok", result.Outputs["result"])
+	require.False(t, ranAfter)
+}
+
+func TestTerminateFalseConditionContinues(t *testing.T) {
+	var ranAfter bool
+	after := ActivityFunc("after", func(ctx Context, p map[string]any) (any, error) {
+		ranAfter = true
+		return nil, nil
+	})
+
+	wf, err := New(Options{
+		Name: "terminate-guard",
+		Steps: []*Step{
+			{
+				Name:      "guard",
+				Terminate: &TerminateConfig{Condition: "false", Status: ExecutionStatusFailed},
+				Next:      []*Edge{{Step: "after"}},
+			},
+			{Name: "after", Activity: "after"},
+		},
+	})
+	require.NoError(t, err)
+
+	reg := NewActivityRegistry()
+	reg.MustRegister(after)
+	exec, err := NewExecution(wf, reg)
+	require.NoError(t, err)
+
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+	require.True(t, ranAfter)
+}
+
+func TestTerminateValidation(t *testing.T) {
+	_, err := New(Options{
+		Name: "terminate-invalid",
+		Steps: []*Step{
+			{Name: "stop", Terminate: &TerminateConfig{Status: ExecutionStatusPaused}},
+		},
+	})
+	require.True(t, errors.Is(err, ErrInvalidTerminateConfig))
+
+	_, err = New(Options{
+		Name: "terminate-conflict",
+		Steps: []*Step{
+			{Name: "stop", Activity: "noop", Terminate: &TerminateConfig{}},
+		},
+	})
+	require.True(t, errors.Is(err, ErrInvalidStepKind))
+}
@@ -0,0 +1,156 @@
+package workflow
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// workflowTagged caches whether a parameter type declares any
+// `workflow` struct tags, directly or in nested fields.
+var workflowTagged sync.Map // reflect.Type -> bool
+
+// remapWorkflowTags rewrites the keys of value so that fields tagged
+// `workflow:"name"` in t receive the parameter called name. The JSON
+// decoder then maps keys using the usual `json` tag rules, which already
+// cover most structs; the workflow tag only matters when a struct's
+// JSON names differ from the workflow's parameter names. Nested structs,
+// pointers, slices, arrays, and maps of structs are followed. value is
+// never modified; maps and slices on the path are copied.
+func remapWorkflowTags(t reflect.Type, value any) any {
+	if !hasWorkflowTags(t) {
+		return value
+	}
+	return remapValue(t, value)
+}
+
+func remapValue(t reflect.Type, value any) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := value.(map[string]any)
+		if !ok {
+			return value
+		}
+		out := make(map[string]any, len(m))
+		for k, v := range m {
+			out[k] = v
+		}
+		remapStruct(t, out)
+		return out
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]any)
+		if !ok {
+			return value
+		}
+		out := make([]any, len(items))
+		for i, item := range items {
+			out[i] = remapValue(t.Elem(), item)
+		}
+		return out
+	case reflect.Map:
+		m, ok := value.(map[string]any)
+		if !ok {
+			return value
+		}
+		out := make(map[string]any, len(m))
+		for k, v := range m {
+			out[k] = remapValue(t.Elem(), v)
+		}
+		return out
+	}
+	return value
+}
+
+// remapStruct renames keys in m, in place, for the fields of struct t.
+// Embedded structs without a JSON name are flattened, as encoding/json
+// does.
+func remapStruct(t reflect.Type, m map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		jsonName, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+		if field.Anonymous && jsonName == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				remapStruct(ft, m)
+				continue
+			}
+		}
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+		if name := workflowTagName(field); name != "" && name != jsonName {
+			if v, ok := m[name]; ok {
+				delete(m, name)
+				m[jsonName] = v
+			}
+		}
+		if v, ok := m[jsonName]; ok {
+			m[jsonName] = remapValue(field.Type, v)
+		}
+	}
+}
+
+// jsonFieldName returns the field's `json` tag name, which is empty when
+// the tag omits it, and whether encoding/json ignores the field.
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	tag, ok := field.Tag.Lookup("json")
+	if !ok {
+		return "", false
+	}
+	if tag == "-" {
+		return "", true
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	return name, false
+}
+
+func workflowTagName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("workflow"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func hasWorkflowTags(t reflect.Type) bool {
+	if cached, ok := workflowTagged.Load(t); ok {
+		return cached.(bool)
+	}
+	found := scanWorkflowTags(t, map[reflect.Type]bool{})
+	workflowTagged.Store(t, found)
+	return found
+}
+
+func scanWorkflowTags(t reflect.Type, seen map[reflect.Type]bool) bool {
+	for {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+			continue
+		}
+		break
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return false
+	}
+	seen[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if workflowTagName(field) != "" || scanWorkflowTags(field.Type, seen) {
+			return true
+		}
+	}
+	return false
+}
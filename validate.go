@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/deepnoodle-ai/workflow/script"
@@ -87,6 +89,11 @@ func (w *Workflow) Validate() error {
 					fmt.Sprintf("edge destination %q not found", edge.Step),
 					ErrUnknownEdgeTarget)
 			}
+			if edge.Else && edge.conditional() {
+				add(step.Name,
+					fmt.Sprintf("else edge to %q cannot have a condition or predicate", edge.Step),
+					ErrInvalidEdge)
+			}
 			if edge.BranchName == "" {
 				continue
 			}
@@ -124,9 +131,12 @@ func (w *Workflow) Validate() error {
 		if step.Pause != nil {
 			kinds = append(kinds, "pause")
 		}
+		if step.Terminate != nil {
+			kinds = append(kinds, "terminate")
+		}
 		if len(kinds) > 1 {
 			add(step.Name,
-				fmt.Sprintf("conflicting step kinds %v — a step is exactly one of: activity, join, wait_signal, sleep, pause", kinds),
+				fmt.Sprintf("conflicting step kinds %v — a step is exactly one of: activity, join, wait_signal, sleep, pause, terminate", kinds),
 				ErrInvalidStepKind)
 		}
 	}
@@ -144,6 +154,30 @@ func (w *Workflow) Validate() error {
 				add(step.Name, "catch is only valid on activity or wait_signal steps", ErrInvalidModifier)
 			}
 		}
+		if step.Skip != "" && step.Join != nil {
+			add(step.Name, "skip is not valid on join steps", ErrInvalidModifier)
+		}
+		if (step.Before != "" || step.After != "") && step.Activity == "" {
+			add(step.Name, "before and after are only valid on activity steps", ErrInvalidModifier)
+		}
+		if step.SkipValue != nil && (step.Skip == "" || (step.Store == "" && step.StoreAppend == "")) {
+			add(step.Name, "skip_value is only valid on steps that set skip and store or store_append", ErrInvalidModifier)
+		}
+		if step.StoreExpression != "" && (step.Activity == "" || (step.Store == "" && step.StoreAppend == "")) {
+			add(step.Name, "store_expression is only valid on activity steps that set store or store_append", ErrInvalidModifier)
+		}
+		if step.StoreAppend != "" && (step.Activity == "" || step.Store != "") {
+			add(step.Name, "store_append is only valid on activity steps and cannot be combined with store", ErrInvalidModifier)
+		}
+		if step.Idempotent && (step.Activity == "" || step.Each != nil) {
+			add(step.Name, "idempotent is only valid on activity steps without each", ErrInvalidModifier)
+		}
+		if step.Cacheable && step.Activity == "" {
+			add(step.Name, "cacheable is only valid on activity steps", ErrInvalidModifier)
+		}
+		if step.CacheTTL != 0 && (!step.Cacheable || step.CacheTTL < 0) {
+			add(step.Name, "cache_ttl requires cacheable and must not be negative", ErrInvalidModifier)
+		}
 	}
 
 	// 4. Join configuration validity.
@@ -158,6 +192,27 @@ func (w *Workflow) Validate() error {
 					ErrUnknownJoinBranch)
 			}
 		}
+		switch step.Join.Mode {
+		case "":
+		case JoinModeAll, JoinModeAny:
+			if len(step.Join.Branches) == 0 {
+				add(step.Name,
+					fmt.Sprintf("join mode %q requires Branches", step.Join.Mode),
+					ErrInvalidJoinConfig)
+			}
+		case JoinModeCount:
+			if step.Join.Count <= 0 {
+				add(step.Name, "join mode \"count\" requires a positive Count", ErrInvalidJoinConfig)
+			} else if len(step.Join.Branches) > 0 && step.Join.Count > len(step.Join.Branches) {
+				add(step.Name,
+					fmt.Sprintf("join count %d exceeds the %d listed branches", step.Join.Count, len(step.Join.Branches)),
+					ErrInvalidJoinConfig)
+			}
+		default:
+			add(step.Name,
+				fmt.Sprintf("unknown join mode %q", step.Join.Mode),
+				ErrInvalidJoinConfig)
+		}
 	}
 
 	// 5. Catch handler next-step validity.
@@ -213,31 +268,105 @@ func (w *Workflow) Validate() error {
 	}
 
 	// 9. Retry configuration sanity.
+	checkRetry := func(step, label string, rc *RetryConfig) {
+		if rc.MaxRetries < UnlimitedRetries {
+			add(step, fmt.Sprintf("%s: MaxRetries must be >= 0, or -1 for unlimited", label), ErrInvalidRetryConfig)
+		}
+		if rc.MaxElapsed < 0 {
+			add(step, fmt.Sprintf("%s: MaxElapsed must be >= 0", label), ErrInvalidRetryConfig)
+		}
+		if rc.MaxRetries == UnlimitedRetries && rc.MaxElapsed <= 0 {
+			add(step, fmt.Sprintf("%s: unlimited MaxRetries requires a MaxElapsed budget", label), ErrInvalidRetryConfig)
+		}
+		if rc.BaseDelay < 0 || rc.MaxDelay < 0 {
+			add(step, fmt.Sprintf("%s: delays must be >= 0", label), ErrInvalidRetryConfig)
+		}
+		if rc.MaxDelay > 0 && rc.BaseDelay > rc.MaxDelay {
+			add(step,
+				fmt.Sprintf("%s: BaseDelay (%s) > MaxDelay (%s)", label, rc.BaseDelay, rc.MaxDelay),
+				ErrInvalidRetryConfig)
+		}
+		if rc.BackoffRate < 0 {
+			add(step, fmt.Sprintf("%s: BackoffRate must be >= 0", label), ErrInvalidRetryConfig)
+		}
+	}
 	for _, step := range w.steps {
 		for i, rc := range step.Retry {
 			if rc == nil {
 				continue
 			}
-			if rc.MaxRetries < 0 {
-				add(step.Name,
-					fmt.Sprintf("retry[%d]: MaxRetries must be >= 0", i),
-					ErrInvalidRetryConfig)
-			}
-			if rc.BaseDelay < 0 || rc.MaxDelay < 0 {
-				add(step.Name,
-					fmt.Sprintf("retry[%d]: delays must be >= 0", i),
-					ErrInvalidRetryConfig)
-			}
-			if rc.MaxDelay > 0 && rc.BaseDelay > rc.MaxDelay {
-				add(step.Name,
-					fmt.Sprintf("retry[%d]: BaseDelay (%s) > MaxDelay (%s)", i, rc.BaseDelay, rc.MaxDelay),
-					ErrInvalidRetryConfig)
-			}
-			if rc.BackoffRate < 0 {
-				add(step.Name,
-					fmt.Sprintf("retry[%d]: BackoffRate must be >= 0", i),
-					ErrInvalidRetryConfig)
-			}
+			checkRetry(step.Name, fmt.Sprintf("retry[%d]", i), rc)
+		}
+	}
+
+	// 10. Workflow-level error policies.
+	for _, errorType := range policyErrorTypes(w.errorPolicies) {
+		if rc := w.errorPolicies[errorType]; rc != nil {
+			checkRetry("", fmt.Sprintf("error policy %q", errorType), rc)
+		}
+	}
+	for _, errorType := range policyErrorTypes(w.catchPolicies) {
+		cc := w.catchPolicies[errorType]
+		if cc == nil {
+			continue
+		}
+		if _, ok := w.stepsByName[cc.Next]; !ok {
+			add("",
+				fmt.Sprintf("catch policy %q references unknown step %q", errorType, cc.Next),
+				ErrUnknownCatchTarget)
+		}
+	}
+
+	if w.onError != "" {
+		if _, ok := w.stepsByName[w.onError]; !ok {
+			add("", fmt.Sprintf("on_error references unknown step %q", w.onError), ErrUnknownCatchTarget)
+		}
+	}
+
+	// 11. Each configuration validity.
+	for _, step := range w.steps {
+		if step.Each != nil && step.Each.MaxConcurrency < 0 {
+			add(step.Name, "each: MaxConcurrency must be >= 0", ErrInvalidEachConfig)
+		}
+	}
+
+	// 12. Input constraint validity.
+	for _, input := range w.inputs {
+		if input.Pattern == "" {
+			continue
+		}
+		if _, err := regexp.Compile(input.Pattern); err != nil {
+			add("", fmt.Sprintf("input %q: invalid pattern: %v", input.Name, err), ErrInvalidInputConfig)
+		}
+	}
+
+	// 13. Output contract validity.
+	for _, out := range w.outputs {
+		if out.Required && out.Default != nil {
+			add("", fmt.Sprintf("output %q: a required output cannot have a default", out.Name), ErrInvalidOutputConfig)
+		}
+		if len(out.Branches) > 0 && (out.Branch != "" || out.Expression != "" || out.Default != nil) {
+			add("", fmt.Sprintf("output %q: branches cannot be combined with branch, expression, or default", out.Name), ErrInvalidOutputConfig)
+		}
+		if out.FromAllPaths && (out.Branch != "" || len(out.Branches) > 0 || out.Expression != "" || out.Default != nil) {
+			add("", fmt.Sprintf("output %q: from_all_paths cannot be combined with branch, branches, expression, or default", out.Name), ErrInvalidOutputConfig)
+		}
+		if out.SkipMissing && len(out.Branches) == 0 {
+			add("", fmt.Sprintf("output %q: skip_missing requires branches", out.Name), ErrInvalidOutputConfig)
+		}
+	}
+
+	// 14. Terminate configuration validity.
+	for _, step := range w.steps {
+		if step.Terminate == nil {
+			continue
+		}
+		switch step.Terminate.Status {
+		case "", ExecutionStatusCompleted, ExecutionStatusFailed:
+		default:
+			add(step.Name,
+				fmt.Sprintf("terminate: status must be %q or %q, got %q", ExecutionStatusCompleted, ExecutionStatusFailed, step.Terminate.Status),
+				ErrInvalidTerminateConfig)
 		}
 	}
 
@@ -265,9 +394,12 @@ func (w *Workflow) branchExists(name string) bool {
 // NewExecution time.
 //
 // Checks performed:
-//  1. Activity references resolve in the registry.
+//  1. Activity references resolve in the registry, and parameters
+//     match the schema of an ActivityWithParamSchema.
 //  2. Parameter templates ("${...}") compile against the given compiler.
-//  3. Edge condition expressions compile.
+//  3. Edge condition, Skip, StoreExpression, Each.Items,
+//     TerminateConfig.Condition, and Output.Expression expressions
+//     compile.
 //  4. WaitSignalConfig.Topic templates compile.
 //  5. Step.Store, WaitSignalConfig.Store, CatchConfig.Store, and
 //     Output.Variable reject any "state." prefix.
@@ -275,7 +407,7 @@ func (w *Workflow) branchExists(name string) bool {
 //     SignalStore is configured.
 //
 // All problems are collected into a single *ValidationError.
-func (w *Workflow) validateBinding(reg *ActivityRegistry, compiler script.Compiler, hasSignalStore bool, logger *slog.Logger) error {
+func (w *Workflow) validateBinding(reg *ActivityRegistry, compiler script.Compiler, delims script.Delimiters, hasSignalStore bool, logger *slog.Logger) error {
 	var problems []ValidationProblem
 	add := func(step, msg string, sentinel error) {
 		problems = append(problems, ValidationProblem{
@@ -285,78 +417,30 @@ func (w *Workflow) validateBinding(reg *ActivityRegistry, compiler script.Compil
 		})
 	}
 
-	ctx := context.Background()
-
-	// Helper: compile a parameter value recursively, flagging any
-	// ${...} template that fails to parse.
-	var checkParamValue func(stepName, paramName string, value any)
-	checkParamValue = func(stepName, paramName string, value any) {
-		switch v := value.(type) {
-		case map[string]any:
-			for k, vv := range v {
-				checkParamValue(stepName, paramName+"."+k, vv)
-			}
-		case []any:
-			for i, vv := range v {
-				checkParamValue(stepName, fmt.Sprintf("%s[%d]", paramName, i), vv)
-			}
-		case string:
-			checkParamString(ctx, compiler, stepName, paramName, v, add)
-		}
-	}
-
-	usesWaitSignal := false
-
 	// 1. Activity references.
 	for _, step := range w.steps {
 		if step.Activity == "" {
 			continue
 		}
-		if _, ok := reg.Get(step.Activity); !ok {
+		activity, ok := reg.Get(step.Activity)
+		if !ok {
 			add(step.Name,
 				fmt.Sprintf("unknown activity %q", step.Activity),
 				ErrUnknownActivity)
+			continue
 		}
-	}
-
-	// 2. Parameter templates.
-	for _, step := range w.steps {
-		for name, value := range step.Parameters {
-			checkParamValue(step.Name, name, value)
+		if a, ok := activity.(ActivityWithParamSchema); ok {
+			checkParamSchema(step, a.ParamSchema(), delims, add)
 		}
 	}
 
-	// 3. Edge condition expressions (raw script expressions).
-	for _, step := range w.steps {
-		for i, edge := range step.Next {
-			if edge.Condition == "" {
-				continue
-			}
-			switch strings.ToLower(strings.TrimSpace(edge.Condition)) {
-			case "true", "false":
-				continue
-			}
-			if _, err := compiler.Compile(ctx, edge.Condition); err != nil {
-				add(step.Name,
-					fmt.Sprintf("edge[%d] condition %q: %v", i, edge.Condition, err),
-					ErrInvalidExpression)
-			}
-		}
-	}
+	// 2-4. Templates and expressions.
+	w.checkExpressions(compiler, delims, add)
 
-	// 4. WaitSignalConfig.Topic template compiles.
+	usesWaitSignal := false
 	for _, step := range w.steps {
-		ws := step.WaitSignal
-		if ws == nil {
-			continue
-		}
-		usesWaitSignal = true
-		if ws.Topic != "" {
-			if _, err := script.NewTemplate(compiler, ws.Topic); err != nil {
-				add(step.Name,
-					fmt.Sprintf("wait_signal topic %q: %v", ws.Topic, err),
-					ErrInvalidTemplate)
-			}
+		if step.WaitSignal != nil {
+			usesWaitSignal = true
 		}
 	}
 
@@ -367,6 +451,11 @@ func (w *Workflow) validateBinding(reg *ActivityRegistry, compiler script.Compil
 				fmt.Sprintf("store %q must be a bare variable name, not a %q path", step.Store, "state."),
 				ErrInvalidStorePath)
 		}
+		if hasStatePrefix(step.StoreAppend) {
+			add(step.Name,
+				fmt.Sprintf("store_append %q must be a bare variable name, not a %q path", step.StoreAppend, "state."),
+				ErrInvalidStorePath)
+		}
 		if step.WaitSignal != nil && hasStatePrefix(step.WaitSignal.Store) {
 			add(step.Name,
 				fmt.Sprintf("wait_signal store %q must be a bare variable name", step.WaitSignal.Store),
@@ -400,6 +489,150 @@ func (w *Workflow) validateBinding(reg *ActivityRegistry, compiler script.Compil
 	return nil
 }
 
+// ValidateExpressions compiles every template and expression in the
+// workflow with compiler: parameter templates, edge conditions, Skip
+// conditions, StoreExpressions, Each.Items expressions, WaitSignal
+// topics, and Output.Expressions. Problems name the step and, for conditions, the
+// edge and its target, and are collected into a *ValidationError.
+//
+// NewExecution performs the same checks against the execution's
+// compiler. Calling ValidateExpressions right after workflow.New
+// reports a malformed condition on a rarely taken edge at construction
+// time, before any execution exists. A nil compiler means
+// DefaultScriptCompiler.
+func (w *Workflow) ValidateExpressions(compiler script.Compiler) error {
+	if compiler == nil {
+		compiler = DefaultScriptCompiler()
+	}
+	var problems []ValidationProblem
+	w.checkExpressions(compiler, script.DefaultDelimiters, func(step, msg string, sentinel error) {
+		problems = append(problems, ValidationProblem{
+			Step:    step,
+			Message: msg,
+			Err:     sentinel,
+		})
+	})
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// checkExpressions reports every template and expression in the
+// workflow that fails to compile with compiler.
+func (w *Workflow) checkExpressions(compiler script.Compiler, delims script.Delimiters, add func(step, msg string, sentinel error)) {
+	ctx := context.Background()
+
+	// Helper: compile a parameter value recursively, flagging any
+	// ${...} template that fails to parse.
+	var checkParamValue func(stepName, paramName string, value any)
+	checkParamValue = func(stepName, paramName string, value any) {
+		switch v := value.(type) {
+		case map[string]any:
+			for k, vv := range v {
+				checkParamValue(stepName, paramName+"."+k, vv)
+			}
+		case []any:
+			for i, vv := range v {
+				checkParamValue(stepName, fmt.Sprintf("%s[%d]", paramName, i), vv)
+			}
+		case string:
+			checkParamString(ctx, compiler, delims, stepName, paramName, v, add)
+		}
+	}
+
+	// 2. Parameter templates.
+	for _, step := range w.steps {
+		for name, value := range step.Parameters {
+			checkParamValue(step.Name, name, value)
+		}
+	}
+
+	// 3. Edge condition, skip, store, and output expressions (raw
+	// script expressions).
+	for _, out := range w.outputs {
+		if out.Expression == "" {
+			continue
+		}
+		if _, err := compiler.Compile(ctx, out.Expression); err != nil {
+			add("",
+				fmt.Sprintf("output %q expression %q: %v", out.Name, out.Expression, err),
+				ErrInvalidExpression)
+		}
+	}
+	for _, step := range w.steps {
+		if step.StoreExpression != "" {
+			if _, err := compiler.Compile(ctx, step.StoreExpression); err != nil {
+				add(step.Name,
+					fmt.Sprintf("store_expression %q: %v", step.StoreExpression, err),
+					ErrInvalidExpression)
+			}
+		}
+		if step.Skip != "" {
+			if _, err := compiler.Compile(ctx, step.Skip); err != nil {
+				add(step.Name,
+					fmt.Sprintf("skip %q: %v", step.Skip, err),
+					ErrInvalidExpression)
+			}
+		}
+		if step.Terminate != nil && step.Terminate.Condition != "" {
+			if _, err := compiler.Compile(ctx, step.Terminate.Condition); err != nil {
+				add(step.Name,
+					fmt.Sprintf("terminate condition %q: %v", step.Terminate.Condition, err),
+					ErrInvalidExpression)
+			}
+		}
+		for _, hook := range []struct{ kind, code string }{{"before", step.Before}, {"after", step.After}} {
+			if hook.code == "" {
+				continue
+			}
+			if _, err := compiler.Compile(ctx, hook.code); err != nil {
+				add(step.Name,
+					fmt.Sprintf("%s script: %v", hook.kind, err),
+					ErrInvalidExpression)
+			}
+		}
+		if step.Each != nil {
+			if items, ok := step.Each.Items.(string); ok && items != "" {
+				if _, err := compiler.Compile(ctx, items); err != nil {
+					add(step.Name,
+						fmt.Sprintf("each items %q: %v", items, err),
+						ErrInvalidExpression)
+				}
+			}
+		}
+		for i, edge := range step.Next {
+			if edge.Condition == "" || edge.Predicate != nil {
+				continue
+			}
+			switch strings.ToLower(strings.TrimSpace(edge.Condition)) {
+			case "true", "false":
+				continue
+			}
+			if _, err := compiler.Compile(ctx, edge.Condition); err != nil {
+				add(step.Name,
+					fmt.Sprintf("edge[%d] to %q condition %q: %v", i, edge.Step, edge.Condition, err),
+					ErrInvalidExpression)
+			}
+		}
+	}
+
+	// 4. WaitSignalConfig.Topic template compiles.
+	for _, step := range w.steps {
+		ws := step.WaitSignal
+		if ws == nil {
+			continue
+		}
+		if ws.Topic != "" {
+			if _, err := script.NewTemplateWithDelimiters(compiler, ws.Topic, delims); err != nil {
+				add(step.Name,
+					fmt.Sprintf("wait_signal topic %q: %v", ws.Topic, err),
+					ErrInvalidTemplate)
+			}
+		}
+	}
+}
+
 // hasStatePrefix reports whether s begins with the reserved "state."
 // prefix that Store fields must not carry.
 func hasStatePrefix(s string) bool {
@@ -408,10 +641,55 @@ func hasStatePrefix(s string) bool {
 
 // checkParamString compiles a single parameter string value, reporting
 // any template parse failure as a ValidationProblem.
-func checkParamString(ctx context.Context, compiler script.Compiler, stepName, paramName, value string, add func(step, msg string, sentinel error)) {
+// checkParamSchema reports parameters of step that do not match schema.
+// A nil schema is not checked.
+func checkParamSchema(step *Step, schema map[string]ParamSpec, delims script.Delimiters, add func(step, msg string, sentinel error)) {
+	if schema == nil {
+		return
+	}
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		spec := schema[name]
+		value, ok := step.Parameters[name]
+		if !ok {
+			if spec.Required {
+				add(step.Name,
+					fmt.Sprintf("activity %q requires parameter %q", step.Activity, name),
+					ErrInvalidParameter)
+			}
+			continue
+		}
+		if s, isString := value.(string); isString && delims.IsTemplate(s) {
+			continue
+		}
+		if _, err := coerceInput(&Input{Name: name, Type: spec.Type}, value); err != nil {
+			add(step.Name,
+				fmt.Sprintf("parameter %q: %v", name, err),
+				ErrInvalidParameter)
+		}
+	}
+	var unknown []string
+	for name := range step.Parameters {
+		if _, ok := schema[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		add(step.Name,
+			fmt.Sprintf("activity %q does not accept parameter %q", step.Activity, name),
+			ErrInvalidParameter)
+	}
+}
+
+func checkParamString(ctx context.Context, compiler script.Compiler, delims script.Delimiters, stepName, paramName, value string, add func(step, msg string, sentinel error)) {
 	_ = ctx
-	if strings.Contains(value, "${") {
-		if _, err := script.NewTemplate(compiler, value); err != nil {
+	if delims.IsTemplate(value) {
+		if _, err := script.NewTemplateWithDelimiters(compiler, value, delims); err != nil {
 			add(stepName,
 				fmt.Sprintf("parameter %q: %v", paramName, err),
 				ErrInvalidTemplate)
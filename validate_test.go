@@ -169,6 +169,40 @@ func TestValidateRejectsInvalidRetryConfig(t *testing.T) {
 	})
 	require.Error(t, err)
 	require.True(t, errors.Is(err, ErrInvalidRetryConfig))
+
+	for name, rc := range map[string]*RetryConfig{
+		"below unlimited":   {MaxRetries: -2, MaxElapsed: time.Minute},
+		"negative elapsed":  {MaxRetries: 3, MaxElapsed: -time.Second},
+		"unlimited no time": {MaxRetries: UnlimitedRetries},
+	} {
+		_, err := New(Options{
+			Name:  "bad-retry",
+			Steps: []*Step{{Name: "a", Activity: "x", Retry: []*RetryConfig{rc}}},
+		})
+		require.ErrorIs(t, err, ErrInvalidRetryConfig, name)
+	}
+
+	_, err = New(Options{
+		Name: "unlimited-retry",
+		Steps: []*Step{{
+			Name:     "a",
+			Activity: "x",
+			Retry:    []*RetryConfig{{MaxRetries: UnlimitedRetries, MaxElapsed: time.Minute}},
+		}},
+	})
+	require.NoError(t, err)
+}
+
+func TestValidateRejectsElseEdgeWithCondition(t *testing.T) {
+	_, err := New(Options{
+		Name: "bad-else",
+		Steps: []*Step{
+			{Name: "a", Activity: "x", Next: []*Edge{{Step: "b", Condition: "true", Else: true}}},
+			{Name: "b", Activity: "x"},
+		},
+	})
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrInvalidEdge))
 }
 
 // --- Phase 2: binding validation ---
@@ -441,3 +475,90 @@ func TestValidateRejectsModifierOnPauseStep(t *testing.T) {
 	require.Error(t, err)
 	require.True(t, errors.Is(err, ErrInvalidModifier))
 }
+
+type schemaActivity struct{}
+
+func (schemaActivity) Name() string { return "schema" }
+
+func (schemaActivity) Execute(ctx Context, params map[string]any) (any, error) {
+	return nil, nil
+}
+
+func (schemaActivity) ParamSchema() map[string]ParamSpec {
+	return map[string]ParamSpec{
+		"count": {Type: InputTypeInt, Required: true},
+		"label": {Type: InputTypeString},
+	}
+}
+
+func TestBindingValidation_ParamSchema(t *testing.T) {
+	reg := NewActivityRegistry()
+	reg.MustRegister(schemaActivity{})
+
+	tests := []struct {
+		name    string
+		params  map[string]any
+		wantErr string
+	}{
+		{name: "valid", params: map[string]any{"count": 3, "label": "x"}},
+		{name: "templated", params: map[string]any{"count": "${inputs.count}"}},
+		{name: "missing required", params: map[string]any{"label": "x"}, wantErr: `requires parameter "count"`},
+		{name: "unknown", params: map[string]any{"count": 1, "colour": "red"}, wantErr: `does not accept parameter "colour"`},
+		{name: "wrong type", params: map[string]any{"count": "three"}, wantErr: `parameter "count": expected an int`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf, err := New(Options{
+				Name:  "schema",
+				Steps: []*Step{{Name: "start", Activity: "schema", Parameters: tt.params}},
+			})
+			require.NoError(t, err)
+			_, err = NewExecution(wf, reg, WithScriptCompiler(DefaultScriptCompiler()))
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, ErrInvalidParameter)
+			require.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidateExpressionsCatchesMalformedCondition(t *testing.T) {
+	wf, err := New(Options{
+		Name:  "rare-edge",
+		State: map[string]any{"count": 0},
+		Steps: []*Step{
+			{
+				Name:     "check",
+				Activity: "a",
+				Next: []*Edge{
+					{Step: "overflow", Condition: "state.count > 1000 &&"},
+					{Step: "done"},
+				},
+			},
+			{Name: "overflow", Activity: "b"},
+			{Name: "done", Activity: "b"},
+		},
+	})
+	require.NoError(t, err)
+
+	err = wf.ValidateExpressions(nil)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrInvalidExpression))
+	var ve *ValidationError
+	require.True(t, errors.As(err, &ve))
+	require.Len(t, ve.Problems, 1)
+	require.Equal(t, "check", ve.Problems[0].Step)
+	require.Contains(t, ve.Problems[0].Message, `to "overflow"`)
+
+	valid, err := New(Options{
+		Name: "valid",
+		Steps: []*Step{
+			{Name: "start", Activity: "a", Next: []*Edge{{Step: "end", Condition: "state.count > 1"}}},
+			{Name: "end", Activity: "b", Each: &Each{Items: "state.items", As: "item"}},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, valid.ValidateExpressions(nil))
+}
@@ -0,0 +1,88 @@
+package workflow
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// PatchRecord is one entry of a branch's variable change log: a change
+// to a branch variable, the step that made it, and when.
+type PatchRecord struct {
+	Step     string    `json:"step"`
+	Variable string    `json:"variable"`
+	OldValue any       `json:"old_value,omitempty"` // nil if the variable was not set
+	NewValue any       `json:"new_value,omitempty"` // nil when Deleted
+	Deleted  bool      `json:"deleted,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// variableHistory records PatchRecords per branch from the
+// OnVariableChanged callback, so it sees every change the callbacks
+// do: Store targets, Context.Set and Context.Delete, script state
+// changes, and stored signals and caught errors.
+type variableHistory struct {
+	BaseExecutionCallbacks
+	mu      sync.Mutex
+	records map[string][]PatchRecord // branch ID -> changes, oldest first
+}
+
+func newVariableHistory() *variableHistory {
+	return &variableHistory{records: map[string][]PatchRecord{}}
+}
+
+func (h *variableHistory) OnVariableChanged(ctx context.Context, event *VariableChangeEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records[event.BranchID] = append(h.records[event.BranchID], PatchRecord{
+		Step:     event.StepName,
+		Variable: event.Variable,
+		OldValue: event.OldValue,
+		NewValue: event.NewValue,
+		Deleted:  event.Deleted,
+		Time:     time.Now(),
+	})
+}
+
+// branch returns a copy of the change log of branchID.
+func (h *variableHistory) branch(branchID string) []PatchRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.records[branchID])
+}
+
+// snapshot returns a copy of every branch's change log, for a
+// checkpoint.
+func (h *variableHistory) snapshot() map[string][]PatchRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make(map[string][]PatchRecord, len(h.records))
+	for id, records := range h.records {
+		out[id] = slices.Clone(records)
+	}
+	return out
+}
+
+// restore replaces the change logs with those saved in a checkpoint.
+func (h *variableHistory) restore(records map[string][]PatchRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = maps.Clone(records)
+	if h.records == nil {
+		h.records = map[string][]PatchRecord{}
+	}
+}
+
+// VariableHistory returns the change log of a branch: every change made
+// to its variables, oldest first, with the step that made it. It is
+// empty unless the execution was created with WithVariableHistory. A
+// branch forked by an edge starts with its own empty log; the
+// variables it inherited appear in its parent's log.
+func (e *Execution) VariableHistory(branchID string) []PatchRecord {
+	if e.variableHistory == nil {
+		return nil
+	}
+	return e.variableHistory.branch(branchID)
+}
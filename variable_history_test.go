@@ -0,0 +1,79 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestVariableHistory(t *testing.T) {
+	wf, err := New(Options{
+		Name:  "history",
+		State: map[string]any{"count": 0},
+		Steps: []*Step{
+			{Name: "fetch", Activity: "fetch", Store: "doc", Next: []*Edge{{Step: "bump"}}},
+			{Name: "bump", Activity: "bump", Next: []*Edge{{Step: "side", BranchName: "side"}}},
+			{Name: "side", Activity: "fetch", Store: "copy"},
+		},
+	})
+	require.NoError(t, err)
+	reg := NewActivityRegistry()
+	reg.MustRegister(ActivityFunc("fetch", func(ctx Context, params map[string]any) (any, error) {
+		return "v1", nil
+	}))
+	reg.MustRegister(ActivityFunc("bump", func(ctx Context, params map[string]any) (any, error) {
+		ctx.Set("count", 1)
+		ctx.Delete("doc")
+		return nil, nil
+	}))
+	checkpointer, err := NewFileCheckpointer(t.TempDir())
+	require.NoError(t, err)
+
+	exec, err := NewExecution(wf, reg,
+		WithScriptCompiler(newTestCompiler()),
+		WithCheckpointer(checkpointer),
+		WithVariableHistoryInCheckpoints(true))
+	require.NoError(t, err)
+	result, err := exec.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, result.Status)
+
+	type change struct {
+		step, variable string
+		old, new       any
+		deleted        bool
+	}
+	summarize := func(records []PatchRecord) []change {
+		var out []change
+		for _, r := range records {
+			require.False(t, r.Time.IsZero())
+			out = append(out, change{r.Step, r.Variable, r.OldValue, r.NewValue, r.Deleted})
+		}
+		return out
+	}
+	require.Equal(t, []change{
+		{"fetch", "doc", nil, "v1", false},
+		{"bump", "count", 0, 1, false},
+		{"bump", "doc", "v1", nil, true},
+	}, summarize(exec.VariableHistory("main")))
+	require.Equal(t, []change{
+		{"side", "copy", nil, "v1", false},
+	}, summarize(exec.VariableHistory("side")))
+
+	checkpoint, err := checkpointer.LoadCheckpoint(context.Background(), exec.ID())
+	require.NoError(t, err)
+	require.Len(t, checkpoint.VariableHistory["main"], 3)
+	require.Equal(t, "copy", checkpoint.VariableHistory["side"][0].Variable)
+
+	t.Run("off by default", func(t *testing.T) {
+		exec, err := NewExecution(wf, reg, WithScriptCompiler(newTestCompiler()), WithCheckpointer(checkpointer))
+		require.NoError(t, err)
+		_, err = exec.Execute(context.Background())
+		require.NoError(t, err)
+		require.Len(t, exec.VariableHistory("main"), 0)
+		checkpoint, err := checkpointer.LoadCheckpoint(context.Background(), exec.ID())
+		require.NoError(t, err)
+		require.Nil(t, checkpoint.VariableHistory)
+	})
+}
@@ -395,6 +395,51 @@ func TestWaitSignalDeclarativeStep(t *testing.T) {
 	require.Equal(t, map[string]any{"ok": true}, res2.Outputs["reply"])
 }
 
+// TestExecutionSignal: Execution.Signal delivers through the configured
+// SignalStore, so the resuming execution can deliver its own signal.
+func TestExecutionSignal(t *testing.T) {
+	wf, err := New(Options{
+		Name: "execution-signal",
+		Steps: []*Step{
+			{
+				Name:       "wait",
+				WaitSignal: &WaitSignalConfig{Topic: "approval", Timeout: time.Minute, Store: "decision"},
+			},
+		},
+		Outputs: []*Output{{Name: "decision", Variable: "decision"}},
+	})
+	require.NoError(t, err)
+
+	signals := NewMemorySignalStore()
+	cp := newSpikeMemoryCheckpointer()
+	reg := NewActivityRegistry()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	exec1, err := NewExecution(wf, reg, WithCheckpointer(cp), WithSignalStore(signals))
+	require.NoError(t, err)
+	res1, err := exec1.Execute(ctx)
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusSuspended, res1.Status)
+
+	exec2, err := NewExecution(wf, reg,
+		WithCheckpointer(cp),
+		WithSignalStore(signals),
+		WithExecutionID(exec1.ID()),
+	)
+	require.NoError(t, err)
+	require.NoError(t, exec2.Signal(ctx, "approval", "approved"))
+	res2, err := exec2.Execute(ctx, ResumeFrom(exec1.ID()))
+	require.NoError(t, err)
+	require.Equal(t, ExecutionStatusCompleted, res2.Status)
+	require.Equal(t, "approved", res2.Outputs["decision"])
+
+	exec3, err := NewExecution(wf, reg)
+	require.NoError(t, err)
+	require.ErrorIs(t, exec3.Signal(ctx, "approval", nil), ErrNoSignalStore)
+}
+
 // TestWaitSignalDeclarativeOnTimeoutRouting: a declarative WaitSignal
 // step with OnTimeout routes to the timeout successor when the deadline
 // passes, instead of failing the step.
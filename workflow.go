@@ -3,7 +3,9 @@ package workflow
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"sort"
+	"strings"
 )
 
 // Input defines a workflow input parameter
@@ -12,6 +14,26 @@ type Input struct {
 	Type        string      `json:"type" yaml:"type"`
 	Description string      `json:"description,omitempty" yaml:"description,omitempty"`
 	Default     interface{} `json:"default,omitempty" yaml:"default,omitempty"`
+
+	// FromEnv names an environment variable that supplies the input
+	// when the execution is not given a value for it. The variable's
+	// text is parsed according to Type; an unset or empty variable
+	// falls back to Default.
+	FromEnv string `json:"from_env,omitempty" yaml:"from_env,omitempty"`
+
+	// Enum, when non-empty, lists the values the input may take.
+	// Numbers compare by value, so 3 matches 3.0.
+	Enum []any `json:"enum,omitempty" yaml:"enum,omitempty"`
+
+	// Pattern is a regular expression a string input must match. It is
+	// not anchored implicitly; use ^ and $ to match the whole value.
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+
+	// Sensitive marks a secret such as an API key. Its value is
+	// replaced by RedactedValue in the inputs passed to execution
+	// callbacks and in checkpoints, so a resumed execution must be
+	// given the value again (directly, through FromEnv, or by Default).
+	Sensitive bool `json:"sensitive,omitempty" yaml:"sensitive,omitempty"`
 }
 
 func (i *Input) IsRequired() bool {
@@ -26,6 +48,37 @@ type Output struct {
 	// Defaults to "main" when empty.
 	Branch      string `json:"branch,omitempty" yaml:"branch,omitempty"`
 	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+
+	// Branches, when set, collects Variable from each listed branch
+	// into a slice in the listed order, instead of reading one Branch.
+	// A listed branch that is missing the variable fails the execution
+	// unless SkipMissing is set, in which case it is left out. Branches
+	// cannot be combined with Branch, Expression, or Default.
+	Branches    []string `json:"branches,omitempty" yaml:"branches,omitempty"`
+	SkipMissing bool     `json:"skip_missing,omitempty" yaml:"skip_missing,omitempty"`
+
+	// FromAllPaths collects Variable from every branch that completed
+	// into a slice, without naming the branches. Branches are ordered
+	// by start time, then ID; those that did not set the variable are
+	// left out. It cannot be combined with Branch, Branches, Expression,
+	// or Default.
+	FromAllPaths bool `json:"from_all_paths,omitempty" yaml:"from_all_paths,omitempty"`
+
+	// Expression, when set, computes the output with the script compiler
+	// instead of copying Variable. It is a raw expression such as
+	// "state.a + state.b", evaluated against the branch's final
+	// variables (state) and the execution inputs (inputs).
+	Expression string `json:"expression,omitempty" yaml:"expression,omitempty"`
+
+	// Default is used when Variable is not set in the branch. Without a
+	// default, a missing variable fails the execution.
+	Default any `json:"default,omitempty" yaml:"default,omitempty"`
+
+	// Required makes the output part of the workflow's contract: an
+	// execution whose paths all complete still fails with
+	// ErrRequiredOutputMissing unless the output has a non-nil value.
+	// A required output cannot declare a Default.
+	Required bool `json:"required,omitempty" yaml:"required,omitempty"`
 }
 
 // Options are used to configure a workflow.
@@ -40,8 +93,33 @@ type Options struct {
 	// When empty, the first step in Steps is the start step. Validated
 	// at New() time to reference an existing step.
 	StartAt string `json:"start_at,omitempty" yaml:"start_at,omitempty"`
+	// ErrorPolicies declares retry policies keyed by error type that
+	// apply to every activity step. A step's own Retry entries are
+	// consulted first; a policy is used only when none of them match
+	// the error. The map key replaces the policy's ErrorEquals.
+	ErrorPolicies map[string]*RetryConfig `json:"error_policies,omitempty" yaml:"error_policies,omitempty"`
+	// CatchPolicies declares catch handlers keyed by error type that
+	// apply to every activity step, layered under each step's own
+	// Catch entries in the same way as ErrorPolicies.
+	CatchPolicies map[string]*CatchConfig `json:"catch_policies,omitempty" yaml:"catch_policies,omitempty"`
+	// OnError names a step that a path moves to when it fails with an
+	// error that no step-level catch or catch policy handled, instead
+	// of failing. The error is stored in the ErrorVariable and
+	// ErrorStepVariable state variables. Errors that no catch can
+	// match (fatal errors, exhausted activity budgets, fence
+	// violations) and failures on the OnError path itself still fail
+	// the path.
+	OnError string `json:"on_error,omitempty" yaml:"on_error,omitempty"`
 }
 
+// State variables written when a path is routed to Options.OnError.
+const (
+	// ErrorVariable holds the ErrorOutput of the unhandled error.
+	ErrorVariable = "error"
+	// ErrorStepVariable holds the name of the step that failed.
+	ErrorStepVariable = "error_step"
+)
+
 // Workflow defines a repeatable process as a graph of steps to be executed.
 type Workflow struct {
 	name         string
@@ -52,6 +130,16 @@ type Workflow struct {
 	stepsByName  map[string]*Step
 	start        *Step
 	initialState map[string]any
+
+	errorPolicies map[string]*RetryConfig
+	catchPolicies map[string]*CatchConfig
+	// retryPolicies and catchFallbacks are the policy maps flattened
+	// into the order they are matched in.
+	retryPolicies  []*RetryConfig
+	catchFallbacks []*CatchConfig
+	onError        string
+
+	fingerprint string
 }
 
 // New returns a new Workflow configured with the given options.
@@ -69,18 +157,18 @@ func New(opts Options) (*Workflow, error) {
 		return nil, fmt.Errorf("workflow: steps required")
 	}
 
-	stepsByName := make(map[string]*Step, len(opts.Steps))
-	var dupes []ValidationProblem
-	for _, step := range opts.Steps {
+	steps, problems := expandMapReduce(opts.Steps)
+	stepsByName := make(map[string]*Step, len(steps))
+	for _, step := range steps {
 		if step.Name == "" {
-			dupes = append(dupes, ValidationProblem{
+			problems = append(problems, ValidationProblem{
 				Message: "empty step name",
 				Err:     ErrEmptyStepName,
 			})
 			continue
 		}
 		if _, exists := stepsByName[step.Name]; exists {
-			dupes = append(dupes, ValidationProblem{
+			problems = append(problems, ValidationProblem{
 				Step:    step.Name,
 				Message: fmt.Sprintf("duplicate step name %q", step.Name),
 				Err:     ErrDuplicateStepName,
@@ -90,12 +178,12 @@ func New(opts Options) (*Workflow, error) {
 		stepsByName[step.Name] = step
 	}
 
-	start := opts.Steps[0]
+	start := steps[0]
 	if opts.StartAt != "" {
 		if s, ok := stepsByName[opts.StartAt]; ok {
 			start = s
 		} else {
-			dupes = append(dupes, ValidationProblem{
+			problems = append(problems, ValidationProblem{
 				Message: fmt.Sprintf("start step %q not found", opts.StartAt),
 				Err:     ErrUnknownStartStep,
 			})
@@ -107,27 +195,97 @@ func New(opts Options) (*Workflow, error) {
 		description:  opts.Description,
 		inputs:       opts.Inputs,
 		outputs:      opts.Outputs,
-		steps:        opts.Steps,
+		steps:        steps,
 		stepsByName:  stepsByName,
 		start:        start,
 		initialState: opts.State,
+
+		errorPolicies:  opts.ErrorPolicies,
+		catchPolicies:  opts.CatchPolicies,
+		retryPolicies:  flattenRetryPolicies(opts.ErrorPolicies),
+		catchFallbacks: flattenCatchPolicies(opts.CatchPolicies),
+		onError:        opts.OnError,
 	}
 
 	if err := wf.Validate(); err != nil {
-		// Merge duplicate-name problems found while building stepsByName.
+		// Merge the problems found while expanding steps and building
+		// stepsByName.
 		var ve *ValidationError
-		if errors.As(err, &ve) && len(dupes) > 0 {
-			ve.Problems = append(dupes, ve.Problems...)
+		if errors.As(err, &ve) && len(problems) > 0 {
+			ve.Problems = append(problems, ve.Problems...)
 			return nil, ve
 		}
 		return nil, err
 	}
-	if len(dupes) > 0 {
-		return nil, &ValidationError{Problems: dupes}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
 	}
+	wf.fingerprint = computeFingerprint(wf)
 	return wf, nil
 }
 
+// expandMapReduce returns steps with each MapReduce step replaced by a
+// copy expanded into the equivalent activity step: a Parallel Each
+// over the items and a StoreExpression that reduces the results. The
+// caller's steps are not modified. Invalid MapReduce configs are
+// reported as problems and left unexpanded.
+func expandMapReduce(steps []*Step) ([]*Step, []ValidationProblem) {
+	var problems []ValidationProblem
+	expanded, cloned := steps, false
+	for i, step := range steps {
+		mr := step.MapReduce
+		if mr == nil {
+			continue
+		}
+		problem := func(msg string, err error) {
+			problems = append(problems, ValidationProblem{Step: step.Name, Message: msg, Err: err})
+		}
+		var conflicts []string
+		if step.Activity != "" {
+			conflicts = append(conflicts, "activity")
+		}
+		if step.Parameters != nil {
+			conflicts = append(conflicts, "parameters")
+		}
+		if step.Each != nil {
+			conflicts = append(conflicts, "each")
+		}
+		if step.StoreExpression != "" {
+			conflicts = append(conflicts, "store_expression")
+		}
+		if step.Join != nil || step.WaitSignal != nil || step.Sleep != nil || step.Pause != nil || step.Terminate != nil {
+			conflicts = append(conflicts, "a non-activity step kind")
+		}
+		if len(conflicts) > 0 {
+			problem(fmt.Sprintf("map_reduce cannot be combined with %s", strings.Join(conflicts, ", ")), ErrInvalidStepKind)
+			continue
+		}
+		if mr.Items == nil || mr.Items == "" || mr.Activity == "" || mr.Reduce == "" {
+			problem("map_reduce: items, activity, and reduce are required", ErrInvalidMapReduceConfig)
+			continue
+		}
+		if mr.MaxConcurrency < 0 {
+			problem("map_reduce: max_concurrency must be >= 0", ErrInvalidMapReduceConfig)
+			continue
+		}
+		if step.Store == "" && step.StoreAppend == "" {
+			problem("map_reduce requires store or store_append for the reduced value", ErrInvalidMapReduceConfig)
+			continue
+		}
+		if !cloned {
+			expanded, cloned = slices.Clone(steps), true
+		}
+		s := *step
+		s.MapReduce = nil
+		s.Activity = mr.Activity
+		s.Parameters = mr.Parameters
+		s.Each = &Each{Items: mr.Items, As: mr.As, MaxConcurrency: mr.MaxConcurrency, Parallel: true}
+		s.StoreExpression = mr.Reduce
+		expanded[i] = &s
+	}
+	return expanded, problems
+}
+
 // Name returns the workflow name
 func (w *Workflow) Name() string {
 	return w.name
@@ -178,3 +336,29 @@ func (w *Workflow) StepNames() []string {
 	sort.Strings(names)
 	return names
 }
+
+// RequiredActivities returns the sorted, distinct names of the
+// activities the workflow's steps reference. NewExecution fails with
+// ErrUnknownActivity when any of them is missing from the registry and
+// the ActivityResolver; other registered activities are ignored, so a
+// large shared registry can serve many workflows.
+func (w *Workflow) RequiredActivities() []string {
+	var names []string
+	for _, step := range w.steps {
+		if step.Activity != "" && !slices.Contains(names, step.Activity) {
+			names = append(names, step.Activity)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ErrorPolicies returns the workflow-level retry policies keyed by error type
+func (w *Workflow) ErrorPolicies() map[string]*RetryConfig {
+	return w.errorPolicies
+}
+
+// CatchPolicies returns the workflow-level catch handlers keyed by error type
+func (w *Workflow) CatchPolicies() map[string]*CatchConfig {
+	return w.catchPolicies
+}
@@ -0,0 +1,187 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// WorkflowSummary is a structured description of a workflow for
+// catalogs and other tooling. It is built by Workflow.Summary and
+// encodes to JSON directly.
+type WorkflowSummary struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Start       string           `json:"start"`
+	Inputs      []*InputSummary  `json:"inputs,omitempty"`
+	Steps       []*StepSummary   `json:"steps"`
+	Outputs     []*OutputSummary `json:"outputs,omitempty"`
+}
+
+// InputSummary describes a workflow input. An input is required when it
+// has no default.
+type InputSummary struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+	Default     any    `json:"default,omitempty"`
+	Enum        []any  `json:"enum,omitempty"`
+	Pattern     string `json:"pattern,omitempty"`
+}
+
+// StepSummary describes a step. Kind is "activity", "join",
+// "wait_signal", "sleep", "pause", or "terminate". Next lists the target step names
+// of the step's outgoing edges.
+type StepSummary struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Kind        string   `json:"kind"`
+	Activity    string   `json:"activity,omitempty"`
+	Each        bool     `json:"each,omitempty"`
+	Store       string   `json:"store,omitempty"`
+	StoreAppend string   `json:"store_append,omitempty"`
+	Next        []string `json:"next,omitempty"`
+}
+
+// OutputSummary describes a workflow output.
+type OutputSummary struct {
+	Name        string `json:"name"`
+	Variable    string `json:"variable,omitempty"`
+	Expression  string `json:"expression,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Summary returns a structured description of the workflow: its name
+// and description, inputs, steps in declaration order, and outputs.
+func (w *Workflow) Summary() *WorkflowSummary {
+	s := &WorkflowSummary{
+		Name:        w.name,
+		Description: w.description,
+		Start:       w.start.Name,
+	}
+	for _, input := range w.inputs {
+		s.Inputs = append(s.Inputs, &InputSummary{
+			Name:        input.Name,
+			Type:        input.Type,
+			Description: input.Description,
+			Required:    input.Default == nil,
+			Default:     input.Default,
+			Enum:        input.Enum,
+			Pattern:     input.Pattern,
+		})
+	}
+	for _, step := range w.steps {
+		kind := stepKind(step)
+		if step.Join == nil && step.WaitSignal == nil && step.Sleep == nil && step.Pause == nil && step.Terminate == nil {
+			kind = "activity"
+		}
+		ss := &StepSummary{
+			Name:        step.Name,
+			Description: step.Description,
+			Kind:        kind,
+			Activity:    step.Activity,
+			Each:        step.Each != nil,
+			Store:       step.Store,
+			StoreAppend: step.StoreAppend,
+		}
+		for _, edge := range step.Next {
+			ss.Next = append(ss.Next, edge.Step)
+		}
+		s.Steps = append(s.Steps, ss)
+	}
+	for _, output := range w.outputs {
+		s.Outputs = append(s.Outputs, &OutputSummary{
+			Name:        output.Name,
+			Variable:    output.Variable,
+			Expression:  output.Expression,
+			Branch:      output.Branch,
+			Description: output.Description,
+		})
+	}
+	return s
+}
+
+// Markdown renders the summary as a Markdown document with a heading
+// for the workflow and tables for inputs, steps, and outputs.
+func (s *WorkflowSummary) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", s.Name)
+	if s.Description != "" {
+		fmt.Fprintf(&b, "\n%s\n", s.Description)
+	}
+
+	if len(s.Inputs) > 0 {
+		b.WriteString("\n## Inputs\n\n")
+		b.WriteString("| Name | Type | Required | Default | Description |\n")
+		b.WriteString("|------|------|----------|---------|-------------|\n")
+		for _, in := range s.Inputs {
+			required := "no"
+			if in.Required {
+				required = "yes"
+			}
+			def := ""
+			if in.Default != nil {
+				def = markdownCode(markdownValue(in.Default))
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+				markdownCode(in.Name), markdownCell(in.Type), required, def, markdownCell(in.Description))
+		}
+	}
+
+	b.WriteString("\n## Steps\n\n")
+	fmt.Fprintf(&b, "Starts at %s.\n\n", markdownCode(s.Start))
+	b.WriteString("| Step | Kind | Activity | Next | Description |\n")
+	b.WriteString("|------|------|----------|------|-------------|\n")
+	for _, step := range s.Steps {
+		activity := ""
+		if step.Activity != "" {
+			activity = markdownCode(step.Activity)
+			if step.Each {
+				activity += " (each)"
+			}
+		}
+		next := make([]string, len(step.Next))
+		for i, n := range step.Next {
+			next[i] = markdownCode(n)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+			markdownCode(step.Name), step.Kind, activity, strings.Join(next, ", "), markdownCell(step.Description))
+	}
+
+	if len(s.Outputs) > 0 {
+		b.WriteString("\n## Outputs\n\n")
+		b.WriteString("| Name | Source | Description |\n")
+		b.WriteString("|------|--------|-------------|\n")
+		for _, out := range s.Outputs {
+			source := markdownCode(out.Variable)
+			if out.Expression != "" {
+				source = markdownCode(out.Expression)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s |\n",
+				markdownCode(out.Name), source, markdownCell(out.Description))
+		}
+	}
+	return b.String()
+}
+
+// markdownCell escapes text for a single Markdown table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + strings.ReplaceAll(markdownCell(s), "`", "'") + "`"
+}
+
+func markdownValue(v any) string {
+	if data, err := json.Marshal(v); err == nil {
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
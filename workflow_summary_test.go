@@ -0,0 +1,79 @@
+package workflow
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/deepnoodle-ai/workflow/internal/require"
+)
+
+func TestWorkflowSummary(t *testing.T) {
+	wf, err := New(Options{
+		Name:        "onboarding",
+		Description: "Provision a new customer account.",
+		Inputs: []*Input{
+			{Name: "email", Type: InputTypeString, Description: "Customer email"},
+			{Name: "plan", Type: InputTypeString, Default: "free", Enum: []any{"free", "pro"}},
+		},
+		Steps: []*Step{
+			{
+				Name:        "create",
+				Description: "Create the account | user",
+				Activity:    "accounts.create",
+				Store:       "account",
+				Next:        []*Edge{{Step: "notify"}},
+			},
+			{
+				Name:        "notify",
+				Description: "Send the welcome email",
+				Activity:    "email.send",
+				Each:        &Each{Items: "state.account.contacts", As: "contact"},
+				Next:        []*Edge{{Step: "done"}},
+			},
+			{Name: "done", Pause: &PauseConfig{}, Next: []*Edge{{Step: "activate"}}},
+			{Name: "activate", Activity: "accounts.activate"},
+		},
+		Outputs: []*Output{{Name: "account_id", Variable: "account", Description: "New account"}},
+	})
+	require.NoError(t, err)
+
+	s := wf.Summary()
+	require.Equal(t, "onboarding", s.Name)
+	require.Equal(t, "Provision a new customer account.", s.Description)
+	require.Equal(t, "create", s.Start)
+
+	require.Len(t, s.Inputs, 2)
+	require.True(t, s.Inputs[0].Required)
+	require.Equal(t, "Customer email", s.Inputs[0].Description)
+	require.False(t, s.Inputs[1].Required)
+	require.Equal(t, "free", s.Inputs[1].Default)
+
+	require.Len(t, s.Steps, 4)
+	for i, want := range []struct{ description, activity, kind string }{
+		{"Create the account | user", "accounts.create", "activity"},
+		{"Send the welcome email", "email.send", "activity"},
+		{"", "", "pause"},
+		{"", "accounts.activate", "activity"},
+	} {
+		require.Equal(t, want.description, s.Steps[i].Description)
+		require.Equal(t, want.activity, s.Steps[i].Activity)
+		require.Equal(t, want.kind, s.Steps[i].Kind)
+	}
+	require.True(t, s.Steps[1].Each)
+	require.Equal(t, []string{"notify"}, s.Steps[0].Next)
+
+	require.Len(t, s.Outputs, 1)
+	require.Equal(t, "New account", s.Outputs[0].Description)
+
+	_, err = json.Marshal(s)
+	require.NoError(t, err)
+
+	md := s.Markdown()
+	require.Contains(t, md, "# onboarding\n\nProvision a new customer account.\n")
+	require.Contains(t, md, "| `email` | string | yes |  | Customer email |")
+	require.Contains(t, md, "| `plan` | string | no | `\"free\"` |  |")
+	require.Contains(t, md, "| `create` | activity | `accounts.create` | `notify` | Create the account \\| user |")
+	require.Contains(t, md, "| `notify` | activity | `email.send` (each) | `done` | Send the welcome email |")
+	require.Contains(t, md, "| `done` | pause |  | `activate` |  |")
+	require.Contains(t, md, "| `account_id` | `account` | New account |")
+}